@@ -353,6 +353,16 @@ func (s *containerRouter) postContainersWait(ctx context.Context, w http.Respons
 			case container.WaitConditionRemoved:
 				waitCondition = containerpkg.WaitConditionRemoved
 				legacyRemovalWaitPre134 = versions.LessThan(version, "1.34")
+			case container.WaitConditionHealthy:
+				if versions.LessThan(version, "1.43") {
+					return errdefs.InvalidParameter(errors.Errorf("invalid condition: %q", v))
+				}
+				waitCondition = containerpkg.WaitConditionHealthy
+			case container.WaitConditionReady:
+				if versions.LessThan(version, "1.43") {
+					return errdefs.InvalidParameter(errors.Errorf("invalid condition: %q", v))
+				}
+				waitCondition = containerpkg.WaitConditionReady
 			default:
 				return errdefs.InvalidParameter(errors.Errorf("invalid condition: %q", v))
 			}
@@ -450,6 +460,11 @@ func (s *containerRouter) postContainerUpdate(ctx context.Context, w http.Respon
 		updateConfig.KernelMemory = 0
 	}
 
+	if versions.LessThan(httputils.VersionFromContext(ctx), "1.43") {
+		// Ignore restart backoff and budget options added in API 1.43.
+		updateConfig.RestartPolicy = legacyRestartPolicy(updateConfig.RestartPolicy)
+	}
+
 	if updateConfig.PidsLimit != nil && *updateConfig.PidsLimit <= 0 {
 		// Both `0` and `-1` are accepted to set "unlimited" when updating.
 		// Historically, any negative value was accepted, so treat them as
@@ -554,6 +569,39 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 		}
 	}
 
+	if hostConfig != nil && versions.LessThan(version, "1.43") {
+		// Ignore restart backoff and budget options added in API 1.43.
+		hostConfig.RestartPolicy = legacyRestartPolicy(hostConfig.RestartPolicy)
+		// Ignore UnhealthyPolicy added in API 1.43.
+		hostConfig.UnhealthyPolicy = container.UnhealthyPolicy{}
+		// Ignore the idmapped mount options added in API 1.43.
+		for _, m := range hostConfig.Mounts {
+			if bo := m.BindOptions; bo != nil {
+				bo.IDMap = nil
+			}
+			if vo := m.VolumeOptions; vo != nil {
+				vo.IDMap = nil
+			}
+		}
+	}
+
+	if config != nil && config.Healthcheck != nil && versions.LessThan(version, "1.43") {
+		// Ignore StartupProbe, ReadinessProbe, HTTP and TCP added in API 1.43.
+		config.Healthcheck.StartupProbe = nil
+		config.Healthcheck.ReadinessProbe = nil
+		config.Healthcheck.HTTP = nil
+		config.Healthcheck.TCP = nil
+	}
+
+	if networkingConfig != nil && versions.LessThan(version, "1.43") {
+		// Ignore bandwidth limits added in API 1.43.
+		for _, epConfig := range networkingConfig.EndpointsConfig {
+			if epConfig != nil {
+				epConfig.Bandwidth = nil
+			}
+		}
+	}
+
 	if hostConfig != nil && runtime.GOOS == "linux" && versions.LessThan(version, "1.42") {
 		// ConsoleSize is not respected by Linux daemon before API 1.42
 		hostConfig.ConsoleSize = [2]uint{0, 0}
@@ -593,6 +641,15 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 	return httputils.WriteJSON(w, http.StatusCreated, ccr)
 }
 
+// legacyRestartPolicy strips the fields of a RestartPolicy that are not
+// supported by API versions before 1.43.
+func legacyRestartPolicy(policy container.RestartPolicy) container.RestartPolicy {
+	return container.RestartPolicy{
+		Name:              policy.Name,
+		MaximumRetryCount: policy.MaximumRetryCount,
+	}
+}
+
 func (s *containerRouter) deleteContainers(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -112,7 +112,11 @@ func (ir *imageRouter) postImagesPush(ctx context.Context, w http.ResponseWriter
 
 	img := vars["name"]
 	tag := r.Form.Get("tag")
-	if err := ir.backend.PushImage(ctx, img, tag, metaHeaders, authConfig, output); err != nil {
+	var compression string
+	if versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.43") {
+		compression = r.Form.Get("compression")
+	}
+	if err := ir.backend.PushImage(ctx, img, tag, metaHeaders, authConfig, compression, output); err != nil {
 		if !output.Flushed() {
 			return err
 		}
@@ -136,8 +140,12 @@ func (ir *imageRouter) getImagesGet(ctx context.Context, w http.ResponseWriter,
 	} else {
 		names = r.Form["names"]
 	}
+	var compression string
+	if versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.43") {
+		compression = r.Form.Get("compression")
+	}
 
-	if err := ir.backend.ExportImage(ctx, names, output); err != nil {
+	if err := ir.backend.ExportImage(ctx, names, compression, output); err != nil {
 		if !output.Flushed() {
 			return err
 		}
@@ -212,6 +212,19 @@ func (n *networkRouter) postNetworkCreate(ctx context.Context, w http.ResponseWr
 		return err
 	}
 
+	if versions.LessThan(httputils.VersionFromContext(ctx), "1.43") {
+		// DNS records and forwarding rules, policies, and address
+		// reservations were added in API 1.43.
+		create.DNS = nil
+		create.Policy = nil
+		if create.IPAM != nil {
+			for i := range create.IPAM.Config {
+				create.IPAM.Config[i].Reservations = nil
+				create.IPAM.Config[i].Options = nil
+			}
+		}
+	}
+
 	if nws, err := n.cluster.GetNetworksByName(create.Name); err == nil && len(nws) > 0 {
 		return nameConflict(create.Name)
 	}
@@ -231,6 +244,9 @@ func (n *networkRouter) postNetworkCreate(ctx context.Context, w http.ResponseWr
 		if _, ok := err.(libnetwork.ManagerRedirectError); !ok {
 			return err
 		}
+		if create.DNS != nil {
+			return errdefs.InvalidParameter(errors.New("DNS records and forwarding rules are not supported on swarm-scoped networks"))
+		}
 		id, err := n.cluster.CreateNetwork(create)
 		if err != nil {
 			return err
@@ -254,6 +270,11 @@ func (n *networkRouter) postNetworkConnect(ctx context.Context, w http.ResponseW
 		return err
 	}
 
+	if connect.EndpointConfig != nil && versions.LessThan(httputils.VersionFromContext(ctx), "1.43") {
+		// Bandwidth limits were added in API 1.43.
+		connect.EndpointConfig.Bandwidth = nil
+	}
+
 	// Unlike other operations, we does not check ambiguity of the name/ID here.
 	// The reason is that, In case of attachable network in swarm scope, the actual local network
 	// may not be available at the time. At the same time, inside daemon `ConnectContainerToNetwork`
@@ -274,6 +295,26 @@ func (n *networkRouter) postNetworkDisconnect(ctx context.Context, w http.Respon
 	return n.backend.DisconnectContainerFromNetwork(disconnect.Container, vars["id"], disconnect.Force)
 }
 
+func (n *networkRouter) postNetworkUpdate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	var update types.NetworkUpdate
+	if err := httputils.ReadJSON(r, &update); err != nil {
+		return err
+	}
+
+	nw, err := n.findUniqueNetwork(vars["id"])
+	if err != nil {
+		return err
+	}
+	if nw.Scope == "swarm" {
+		return errdefs.InvalidParameter(errors.New("swarm-scoped networks cannot be updated"))
+	}
+	return n.backend.UpdateNetwork(nw.ID, update)
+}
+
 func (n *networkRouter) deleteNetwork(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
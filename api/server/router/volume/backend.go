@@ -2,6 +2,7 @@ package volume // import "github.com/docker/docker/api/server/router/volume"
 
 import (
 	"context"
+	"io"
 
 	"github.com/docker/docker/volume/service/opts"
 	// TODO return types need to be refactored into pkg
@@ -17,7 +18,14 @@ type Backend interface {
 	Get(ctx context.Context, name string, opts ...opts.GetOption) (*volume.Volume, error)
 	Create(ctx context.Context, name, driverName string, opts ...opts.CreateOption) (*volume.Volume, error)
 	Remove(ctx context.Context, name string, opts ...opts.RemoveOption) error
+	Update(ctx context.Context, name string, options map[string]string) error
 	Prune(ctx context.Context, pruneFilters filters.Args) (*types.VolumesPruneReport, error)
+	CreateSnapshot(ctx context.Context, name, snapshot string) (*volume.Snapshot, error)
+	ListSnapshots(ctx context.Context, name string) ([]*volume.Snapshot, error)
+	RemoveSnapshot(ctx context.Context, name, snapshot string) error
+	RestoreSnapshot(ctx context.Context, name, snapshot string) error
+	Export(ctx context.Context, name, compression string, out io.Writer) error
+	Import(ctx context.Context, name string, in io.Reader) error
 }
 
 // ClusterBackend is the backend used for Swarm Cluster Volumes. Regular
@@ -28,13 +28,19 @@ func (r *volumeRouter) initRoutes() {
 	r.routes = []router.Route{
 		// GET
 		router.NewGetRoute("/volumes", r.getVolumesList),
+		router.NewGetRoute("/volumes/{name:.*}/snapshots", r.getVolumeSnapshots),
+		router.NewGetRoute("/volumes/{name:.*}/export", r.getVolumeExport),
 		router.NewGetRoute("/volumes/{name:.*}", r.getVolumeByName),
 		// POST
 		router.NewPostRoute("/volumes/create", r.postVolumesCreate),
 		router.NewPostRoute("/volumes/prune", r.postVolumesPrune),
+		router.NewPostRoute("/volumes/{name:.*}/snapshots", r.postVolumeSnapshotsCreate),
+		router.NewPostRoute("/volumes/{name:.*}/snapshots/{snapshot:.*}/restore", r.postVolumeSnapshotRestore),
+		router.NewPostRoute("/volumes/{name:.*}/import", r.postVolumeImport),
 		// PUT
 		router.NewPutRoute("/volumes/{name:.*}", r.putVolumesUpdate),
 		// DELETE
+		router.NewDeleteRoute("/volumes/{name:.*}/snapshots/{snapshot:.*}", r.deleteVolumeSnapshot),
 		router.NewDeleteRoute("/volumes/{name:.*}", r.deleteVolumes),
 	}
 }
@@ -20,6 +20,18 @@ const (
 	// clusterVolumesVersion defines the API version that swarm cluster volume
 	// functionality was introduced. avoids the use of magic numbers.
 	clusterVolumesVersion = "1.42"
+
+	// snapshotsVersion defines the API version that volume snapshots were
+	// introduced.
+	snapshotsVersion = "1.43"
+
+	// localUpdateVersion defines the API version that updating volumes
+	// which are not cluster volumes was introduced.
+	localUpdateVersion = "1.43"
+
+	// accessModesVersion defines the API version that access modes of
+	// volumes were introduced.
+	accessModesVersion = "1.43"
 )
 
 func (v *volumeRouter) getVolumesList(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -103,6 +115,13 @@ func (v *volumeRouter) postVolumesCreate(ctx context.Context, w http.ResponseWri
 		version = httputils.VersionFromContext(ctx)
 	)
 
+	if versions.LessThan(version, snapshotsVersion) {
+		req.Snapshot = nil
+	}
+	if versions.LessThan(version, accessModesVersion) {
+		req.AccessMode = ""
+	}
+
 	// if the ClusterVolumeSpec is filled in, then this is a cluster volume
 	// and is created through the swarm cluster volume backend.
 	//
@@ -116,11 +135,24 @@ func (v *volumeRouter) postVolumesCreate(ctx context.Context, w http.ResponseWri
 	// Instead, we will allow creating a volume with a duplicate name, which
 	// should not break anything.
 	if req.ClusterVolumeSpec != nil && versions.GreaterThanOrEqualTo(version, clusterVolumesVersion) {
+		if req.Snapshot != nil {
+			return errdefs.InvalidParameter(errors.New("cluster volumes cannot be created from a snapshot"))
+		}
+		if req.AccessMode != "" {
+			return errdefs.InvalidParameter(errors.New("the access mode of cluster volumes is set in the ClusterVolumeSpec"))
+		}
 		logrus.Debug("using cluster volume")
 		vol, err = v.cluster.CreateVolume(req)
 	} else {
 		logrus.Debug("using regular volume")
-		vol, err = v.backend.Create(ctx, req.Name, req.Driver, opts.WithCreateOptions(req.DriverOpts), opts.WithCreateLabels(req.Labels))
+		createOpts := []opts.CreateOption{opts.WithCreateOptions(req.DriverOpts), opts.WithCreateLabels(req.Labels), opts.WithCreateAccessMode(req.AccessMode)}
+		if req.Snapshot != nil {
+			if req.Snapshot.Volume == "" || req.Snapshot.Name == "" {
+				return errdefs.InvalidParameter(errors.New("the volume and the name of the snapshot to create the volume from are required"))
+			}
+			createOpts = append(createOpts, opts.WithCreateFromSnapshot(req.Snapshot.Volume, req.Snapshot.Name))
+		}
+		vol, err = v.backend.Create(ctx, req.Name, req.Driver, createOpts...)
 	}
 
 	if err != nil {
@@ -130,14 +162,30 @@ func (v *volumeRouter) postVolumesCreate(ctx context.Context, w http.ResponseWri
 }
 
 func (v *volumeRouter) putVolumesUpdate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-	if !v.cluster.IsManager() {
-		return errdefs.Unavailable(errors.New("volume update only valid for cluster volumes, but swarm is unavailable"))
+	if err := httputils.ParseForm(r); err != nil {
+		return err
 	}
 
-	if err := httputils.ParseForm(r); err != nil {
+	var req volume.UpdateOptions
+	if err := httputils.ReadJSON(r, &req); err != nil {
 		return err
 	}
 
+	// Volumes which are not cluster volumes are updated by their driver,
+	// with the given options.
+	if versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), localUpdateVersion) {
+		if req.Spec == nil {
+			return v.backend.Update(ctx, vars["name"], req.Options)
+		}
+		if len(req.Options) > 0 {
+			return errdefs.InvalidParameter(errors.New("the options of cluster volumes cannot be updated"))
+		}
+	}
+
+	if !v.cluster.IsManager() {
+		return errdefs.Unavailable(errors.New("volume update only valid for cluster volumes, but swarm is unavailable"))
+	}
+
 	rawVersion := r.URL.Query().Get("version")
 	version, err := strconv.ParseUint(rawVersion, 10, 64)
 	if err != nil {
@@ -145,11 +193,6 @@ func (v *volumeRouter) putVolumesUpdate(ctx context.Context, w http.ResponseWrit
 		return errdefs.InvalidParameter(err)
 	}
 
-	var req volume.UpdateOptions
-	if err := httputils.ReadJSON(r, &req); err != nil {
-		return err
-	}
-
 	return v.cluster.UpdateVolume(vars["name"], version, req)
 }
 
@@ -199,3 +242,64 @@ func (v *volumeRouter) postVolumesPrune(ctx context.Context, w http.ResponseWrit
 	}
 	return httputils.WriteJSON(w, http.StatusOK, pruneReport)
 }
+
+func (v *volumeRouter) getVolumeSnapshots(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	snapshots, err := v.backend.ListSnapshots(ctx, vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, snapshots)
+}
+
+func (v *volumeRouter) postVolumeSnapshotsCreate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	var req volume.SnapshotCreateOptions
+	if err := httputils.ReadJSON(r, &req); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		return errdefs.InvalidParameter(errors.New("snapshot name is required"))
+	}
+
+	snapshot, err := v.backend.CreateSnapshot(ctx, vars["name"], req.Name)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, snapshot)
+}
+
+func (v *volumeRouter) postVolumeSnapshotRestore(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := v.backend.RestoreSnapshot(ctx, vars["name"], vars["snapshot"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (v *volumeRouter) deleteVolumeSnapshot(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := v.backend.RemoveSnapshot(ctx, vars["name"], vars["snapshot"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (v *volumeRouter) getVolumeExport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	return v.backend.Export(ctx, vars["name"], r.Form.Get("compression"), w)
+}
+
+func (v *volumeRouter) postVolumeImport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := v.backend.Import(ctx, vars["name"], r.Body); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
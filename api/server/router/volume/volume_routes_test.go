@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -213,6 +215,159 @@ func TestCreateRegularVolume(t *testing.T) {
 	assert.Equal(t, 0, len(c.volumes))
 }
 
+func TestCreateVolumeFromSnapshot(t *testing.T) {
+	b := &fakeVolumeBackend{}
+	v := &volumeRouter{
+		backend: b,
+		cluster: &fakeClusterBackend{},
+	}
+
+	create := func(version, name string) error {
+		volumeCreate := volume.CreateOptions{
+			Name:     name,
+			Snapshot: &volume.SnapshotSource{Volume: "vol1", Name: "snap1"},
+		}
+		buf := bytes.Buffer{}
+		assert.NilError(t, json.NewEncoder(&buf).Encode(volumeCreate))
+
+		ctx := context.WithValue(context.Background(), httputils.APIVersionKey{}, version)
+		req := httptest.NewRequest("POST", "/volumes/create", &buf)
+		req.Header.Add("Content-Type", "application/json")
+		return v.postVolumesCreate(ctx, httptest.NewRecorder(), req, nil)
+	}
+
+	assert.NilError(t, create(snapshotsVersion, "clone1"))
+	assert.DeepEqual(t, b.sources, map[string]volume.SnapshotSource{
+		"clone1": {Volume: "vol1", Name: "snap1"},
+	})
+
+	// The snapshot is ignored on older API versions.
+	assert.NilError(t, create(clusterVolumesVersion, "clone2"))
+	assert.Equal(t, len(b.sources), 1)
+}
+
+func TestCreateVolumeWithAccessMode(t *testing.T) {
+	b := &fakeVolumeBackend{}
+	v := &volumeRouter{
+		backend: b,
+		cluster: &fakeClusterBackend{},
+	}
+
+	create := func(version, name string) error {
+		volumeCreate := volume.CreateOptions{
+			Name:       name,
+			AccessMode: volume.AccessModeSingleWriter,
+		}
+		buf := bytes.Buffer{}
+		assert.NilError(t, json.NewEncoder(&buf).Encode(volumeCreate))
+
+		ctx := context.WithValue(context.Background(), httputils.APIVersionKey{}, version)
+		req := httptest.NewRequest("POST", "/volumes/create", &buf)
+		req.Header.Add("Content-Type", "application/json")
+		return v.postVolumesCreate(ctx, httptest.NewRecorder(), req, nil)
+	}
+
+	assert.NilError(t, create(accessModesVersion, "vol1"))
+	assert.Equal(t, b.volumes["vol1"].AccessMode, volume.AccessModeSingleWriter)
+
+	// The access mode is ignored on older API versions.
+	assert.NilError(t, create(clusterVolumesVersion, "vol2"))
+	assert.Equal(t, b.volumes["vol2"].AccessMode, "")
+}
+
+func TestVolumeSnapshots(t *testing.T) {
+	b := &fakeVolumeBackend{
+		volumes: map[string]*volume.Volume{
+			"vol1": {Name: "vol1"},
+		},
+	}
+	v := &volumeRouter{
+		backend: b,
+		cluster: &fakeClusterBackend{},
+	}
+	ctx := context.WithValue(context.Background(), httputils.APIVersionKey{}, snapshotsVersion)
+
+	createSnapshot := func(name string) (*httptest.ResponseRecorder, error) {
+		buf := bytes.Buffer{}
+		assert.NilError(t, json.NewEncoder(&buf).Encode(volume.SnapshotCreateOptions{Name: name}))
+		req := httptest.NewRequest("POST", "/volumes/vol1/snapshots", &buf)
+		req.Header.Add("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		err := v.postVolumeSnapshotsCreate(ctx, resp, req, map[string]string{"name": "vol1"})
+		return resp, err
+	}
+
+	resp, err := createSnapshot("snap1")
+	assert.NilError(t, err)
+	assert.Equal(t, resp.Code, 201)
+	var snapshot volume.Snapshot
+	assert.NilError(t, json.NewDecoder(resp.Result().Body).Decode(&snapshot))
+	assert.Equal(t, snapshot, volume.Snapshot{Name: "snap1", Volume: "vol1"})
+
+	_, err = createSnapshot("")
+	assert.Assert(t, errdefs.IsInvalidParameter(err))
+
+	req := httptest.NewRequest("GET", "/volumes/vol1/snapshots", nil)
+	resp = httptest.NewRecorder()
+	assert.NilError(t, v.getVolumeSnapshots(ctx, resp, req, map[string]string{"name": "vol1"}))
+	var snapshots []volume.Snapshot
+	assert.NilError(t, json.NewDecoder(resp.Result().Body).Decode(&snapshots))
+	assert.DeepEqual(t, snapshots, []volume.Snapshot{{Name: "snap1", Volume: "vol1"}})
+
+	req = httptest.NewRequest("GET", "/volumes/vol2/snapshots", nil)
+	err = v.getVolumeSnapshots(ctx, httptest.NewRecorder(), req, map[string]string{"name": "vol2"})
+	assert.Assert(t, errdefs.IsNotFound(err))
+
+	req = httptest.NewRequest("POST", "/volumes/vol1/snapshots/snap1/restore", nil)
+	resp = httptest.NewRecorder()
+	assert.NilError(t, v.postVolumeSnapshotRestore(ctx, resp, req, map[string]string{"name": "vol1", "snapshot": "snap1"}))
+	assert.Equal(t, resp.Code, 204)
+	assert.Equal(t, b.restored["vol1"], "snap1")
+
+	req = httptest.NewRequest("DELETE", "/volumes/vol1/snapshots/snap1", nil)
+	resp = httptest.NewRecorder()
+	assert.NilError(t, v.deleteVolumeSnapshot(ctx, resp, req, map[string]string{"name": "vol1", "snapshot": "snap1"}))
+	assert.Equal(t, resp.Code, 204)
+	assert.Equal(t, len(b.snapshots["vol1"]), 0)
+
+	req = httptest.NewRequest("DELETE", "/volumes/vol1/snapshots/snap1", nil)
+	err = v.deleteVolumeSnapshot(ctx, httptest.NewRecorder(), req, map[string]string{"name": "vol1", "snapshot": "snap1"})
+	assert.Assert(t, errdefs.IsNotFound(err))
+}
+
+func TestVolumeExportImport(t *testing.T) {
+	b := &fakeVolumeBackend{
+		volumes: map[string]*volume.Volume{
+			"vol1": {Name: "vol1"},
+		},
+	}
+	v := &volumeRouter{
+		backend: b,
+		cluster: &fakeClusterBackend{},
+	}
+	ctx := context.Background()
+
+	req := httptest.NewRequest("POST", "/volumes/vol1/import", strings.NewReader("archive"))
+	resp := httptest.NewRecorder()
+	assert.NilError(t, v.postVolumeImport(ctx, resp, req, map[string]string{"name": "vol1"}))
+	assert.Equal(t, resp.Code, 204)
+
+	req = httptest.NewRequest("POST", "/volumes/vol2/import", strings.NewReader("archive"))
+	err := v.postVolumeImport(ctx, httptest.NewRecorder(), req, map[string]string{"name": "vol2"})
+	assert.Assert(t, errdefs.IsNotFound(err))
+
+	req = httptest.NewRequest("GET", "/volumes/vol1/export?compression=none", nil)
+	resp = httptest.NewRecorder()
+	assert.NilError(t, v.getVolumeExport(ctx, resp, req, map[string]string{"name": "vol1"}))
+	assert.Equal(t, resp.Code, 200)
+	assert.Equal(t, resp.Header().Get("Content-Type"), "application/x-tar")
+	assert.Equal(t, resp.Body.String(), "archive")
+
+	req = httptest.NewRequest("GET", "/volumes/vol1/export?compression=lz4", nil)
+	err = v.getVolumeExport(ctx, httptest.NewRecorder(), req, map[string]string{"name": "vol1"})
+	assert.Assert(t, errdefs.IsInvalidParameter(err))
+}
+
 func TestCreateSwarmVolumeNoSwarm(t *testing.T) {
 	b := &fakeVolumeBackend{}
 	c := &fakeClusterBackend{}
@@ -376,6 +531,45 @@ func TestUpdateVolumeNoSwarm(t *testing.T) {
 	assert.Assert(t, errdefs.IsUnavailable(err))
 }
 
+func TestUpdateLocalVolume(t *testing.T) {
+	b := &fakeVolumeBackend{
+		volumes: map[string]*volume.Volume{
+			"vol1": {Name: "vol1"},
+		},
+	}
+	c := &fakeClusterBackend{}
+
+	v := &volumeRouter{
+		backend: b,
+		cluster: c,
+	}
+
+	callUpdate := func(apiVersion string, update volume.UpdateOptions) error {
+		buf := bytes.Buffer{}
+		assert.NilError(t, json.NewEncoder(&buf).Encode(update))
+		ctx := context.WithValue(context.Background(), httputils.APIVersionKey{}, apiVersion)
+		req := httptest.NewRequest("PUT", "/volumes/vol1", &buf)
+		req.Header.Add("Content-Type", "application/json")
+		return v.putVolumesUpdate(ctx, httptest.NewRecorder(), req, map[string]string{"name": "vol1"})
+	}
+
+	err := callUpdate(localUpdateVersion, volume.UpdateOptions{Options: map[string]string{"size": "1G"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, b.volumes["vol1"].Options, map[string]string{"size": "1G"})
+
+	// Options cannot be combined with a cluster volume spec.
+	err = callUpdate(localUpdateVersion, volume.UpdateOptions{
+		Spec:    &volume.ClusterVolumeSpec{},
+		Options: map[string]string{"size": "1G"},
+	})
+	assert.Assert(t, errdefs.IsInvalidParameter(err))
+
+	// Older API versions only update cluster volumes.
+	err = callUpdate(clusterVolumesVersion, volume.UpdateOptions{Options: map[string]string{"size": "2G"}})
+	assert.Assert(t, errdefs.IsUnavailable(err))
+	assert.DeepEqual(t, b.volumes["vol1"].Options, map[string]string{"size": "1G"})
+}
+
 func TestUpdateVolumeNotFound(t *testing.T) {
 	b := &fakeVolumeBackend{}
 	c := &fakeClusterBackend{
@@ -577,7 +771,11 @@ func TestVolumeRemoveSwarmForce(t *testing.T) {
 }
 
 type fakeVolumeBackend struct {
-	volumes map[string]*volume.Volume
+	volumes   map[string]*volume.Volume
+	sources   map[string]volume.SnapshotSource
+	snapshots map[string][]*volume.Snapshot
+	restored  map[string]string
+	data      map[string]string
 }
 
 func (b *fakeVolumeBackend) List(_ context.Context, _ filters.Args) ([]*volume.Volume, []string, error) {
@@ -595,15 +793,27 @@ func (b *fakeVolumeBackend) Get(_ context.Context, name string, _ ...opts.GetOpt
 	return nil, errdefs.NotFound(fmt.Errorf("volume %s not found", name))
 }
 
-func (b *fakeVolumeBackend) Create(_ context.Context, name, driverName string, _ ...opts.CreateOption) (*volume.Volume, error) {
+func (b *fakeVolumeBackend) Create(_ context.Context, name, driverName string, createOpts ...opts.CreateOption) (*volume.Volume, error) {
 	if _, ok := b.volumes[name]; ok {
 		// TODO(dperny): return appropriate error type
 		return nil, fmt.Errorf("already exists")
 	}
 
+	var cfg opts.CreateConfig
+	for _, o := range createOpts {
+		o(&cfg)
+	}
+	if cfg.Snapshot != "" {
+		if b.sources == nil {
+			b.sources = map[string]volume.SnapshotSource{}
+		}
+		b.sources[name] = volume.SnapshotSource{Volume: cfg.Source, Name: cfg.Snapshot}
+	}
+
 	v := &volume.Volume{
-		Name:   name,
-		Driver: driverName,
+		Name:       name,
+		Driver:     driverName,
+		AccessMode: cfg.AccessMode,
 	}
 	if b.volumes == nil {
 		b.volumes = map[string]*volume.Volume{
@@ -632,6 +842,88 @@ func (b *fakeVolumeBackend) Prune(_ context.Context, _ filters.Args) (*types.Vol
 	return nil, nil
 }
 
+func (b *fakeVolumeBackend) CreateSnapshot(_ context.Context, name, snapshot string) (*volume.Snapshot, error) {
+	if _, ok := b.volumes[name]; !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("volume %s not found", name))
+	}
+	if b.snapshots == nil {
+		b.snapshots = map[string][]*volume.Snapshot{}
+	}
+	s := &volume.Snapshot{Name: snapshot, Volume: name}
+	b.snapshots[name] = append(b.snapshots[name], s)
+	return s, nil
+}
+
+func (b *fakeVolumeBackend) ListSnapshots(_ context.Context, name string) ([]*volume.Snapshot, error) {
+	if _, ok := b.volumes[name]; !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("volume %s not found", name))
+	}
+	return b.snapshots[name], nil
+}
+
+func (b *fakeVolumeBackend) RemoveSnapshot(_ context.Context, name, snapshot string) error {
+	for i, s := range b.snapshots[name] {
+		if s.Name == snapshot {
+			b.snapshots[name] = append(b.snapshots[name][:i], b.snapshots[name][i+1:]...)
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("snapshot %s of volume %s not found", snapshot, name))
+}
+
+func (b *fakeVolumeBackend) RestoreSnapshot(_ context.Context, name, snapshot string) error {
+	for _, s := range b.snapshots[name] {
+		if s.Name == snapshot {
+			if b.restored == nil {
+				b.restored = map[string]string{}
+			}
+			b.restored[name] = snapshot
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("snapshot %s of volume %s not found", snapshot, name))
+}
+
+func (b *fakeVolumeBackend) Update(_ context.Context, name string, options map[string]string) error {
+	v, ok := b.volumes[name]
+	if !ok {
+		return errdefs.NotFound(fmt.Errorf("volume %s not found", name))
+	}
+	if v.Options == nil {
+		v.Options = map[string]string{}
+	}
+	for k, val := range options {
+		v.Options[k] = val
+	}
+	return nil
+}
+
+func (b *fakeVolumeBackend) Export(_ context.Context, name, compression string, out io.Writer) error {
+	if _, ok := b.volumes[name]; !ok {
+		return errdefs.NotFound(fmt.Errorf("volume %s not found", name))
+	}
+	if compression != "" && compression != "none" {
+		return errdefs.InvalidParameter(fmt.Errorf("invalid compression %q", compression))
+	}
+	_, err := io.WriteString(out, b.data[name])
+	return err
+}
+
+func (b *fakeVolumeBackend) Import(_ context.Context, name string, in io.Reader) error {
+	if _, ok := b.volumes[name]; !ok {
+		return errdefs.NotFound(fmt.Errorf("volume %s not found", name))
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if b.data == nil {
+		b.data = map[string]string{}
+	}
+	b.data[name] = string(data)
+	return nil
+}
+
 type fakeClusterBackend struct {
 	swarm   bool
 	manager bool
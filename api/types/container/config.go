@@ -41,6 +41,8 @@ type HealthConfig struct {
 	// {"NONE"} : disable healthcheck
 	// {"CMD", args...} : exec arguments directly
 	// {"CMD-SHELL", command} : run command with system's default shell
+	// {"HTTP"} : send an HTTP GET request, as configured by HTTP
+	// {"TCP"} : open a TCP connection, as configured by TCP
 	Test []string `json:",omitempty"`
 
 	// Zero means to inherit. Durations are expressed as integer nanoseconds.
@@ -51,6 +53,48 @@ type HealthConfig struct {
 	// Retries is the number of consecutive failures needed to consider a container as unhealthy.
 	// Zero means inherit.
 	Retries int `json:",omitempty"`
+
+	// StartupProbe is run until it succeeds once, before the healthcheck and
+	// the readiness probe start. The container is unhealthy if it fails
+	// Retries consecutive times.
+	StartupProbe *HealthConfig `json:",omitempty"`
+
+	// ReadinessProbe is run alongside the healthcheck to tell whether the
+	// container is ready to receive traffic. Its result does not affect the
+	// health status of the container.
+	ReadinessProbe *HealthConfig `json:",omitempty"`
+
+	// HTTP configures the "HTTP" test.
+	HTTP *HTTPProbe `json:",omitempty"`
+	// TCP configures the "TCP" test.
+	TCP *TCPProbe `json:",omitempty"`
+}
+
+// HTTPProbe configures a healthcheck which sends an HTTP GET request to the
+// container. The request is sent by the daemon from inside the container's
+// network namespace, so the container does not need to ship an HTTP client.
+type HTTPProbe struct {
+	// Port is the port to send the request to on the container's loopback
+	// interface. The request is sent to 127.0.0.1, or to ::1 if no
+	// connection can be made to 127.0.0.1.
+	Port int
+	// Path is the path to request. Defaults to "/".
+	Path string `json:",omitempty"`
+	// Headers are added to the request.
+	Headers map[string]string `json:",omitempty"`
+	// ExpectedStatus is the list of response status codes which are
+	// considered healthy. Defaults to any status from 200 to 399.
+	ExpectedStatus []int `json:",omitempty"`
+}
+
+// TCPProbe configures a healthcheck which opens a TCP connection to the
+// container. The connection is made by the daemon from inside the
+// container's network namespace.
+type TCPProbe struct {
+	// Port is the port to connect to on the container's loopback interface.
+	// The connection is made to 127.0.0.1, or to ::1 if no connection can be
+	// made to 127.0.0.1.
+	Port int
 }
 
 // ExecStartOptions holds the options to start container's exec.
@@ -2,6 +2,7 @@ package container // import "github.com/docker/docker/api/types/container"
 
 import (
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/blkiodev"
 	"github.com/docker/docker/api/types/mount"
@@ -303,6 +304,17 @@ type DeviceMapping struct {
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+
+	// Zero means to inherit the daemon default. Durations are expressed as integer nanoseconds.
+	InitialDelay time.Duration `json:",omitempty"` // InitialDelay is the delay before the first restart.
+	MaxDelay     time.Duration `json:",omitempty"` // MaxDelay is the upper bound of the delay between restarts.
+	Multiplier   float64       `json:",omitempty"` // Multiplier is applied to the delay after each restart.
+	ResetAfter   time.Duration `json:",omitempty"` // ResetAfter is how long the container must run for the delay to be reset.
+
+	// Restart budget. MaximumRestarts restarts are allowed within RestartWindow;
+	// once exhausted, the container is no longer restarted. 0 means no budget.
+	MaximumRestarts int           `json:",omitempty"`
+	RestartWindow   time.Duration `json:",omitempty"`
 }
 
 // IsNone indicates whether the container has the "no" restart policy.
@@ -332,7 +344,34 @@ func (rp *RestartPolicy) IsUnlessStopped() bool {
 
 // IsSame compares two RestartPolicy to see if they are the same
 func (rp *RestartPolicy) IsSame(tp *RestartPolicy) bool {
-	return rp.Name == tp.Name && rp.MaximumRetryCount == tp.MaximumRetryCount
+	return *rp == *tp
+}
+
+// UnhealthyAction is the action taken by the daemon on a container which
+// keeps failing its healthcheck.
+type UnhealthyAction string
+
+// Available actions for unhealthy containers
+const (
+	UnhealthyActionNone    UnhealthyAction = "none"
+	UnhealthyActionRestart UnhealthyAction = "restart"
+	UnhealthyActionKill    UnhealthyAction = "kill"
+)
+
+// UnhealthyPolicy represents the action to take when a container's
+// healthcheck keeps failing.
+type UnhealthyPolicy struct {
+	Action UnhealthyAction `json:",omitempty"`
+
+	// Threshold is the number of consecutive failed probes after which
+	// Action is taken. Zero means to act as soon as the container is
+	// unhealthy; values lower than the healthcheck's Retries are ignored.
+	Threshold int `json:",omitempty"`
+}
+
+// IsNone indicates whether no action is taken on unhealthy containers.
+func (up *UnhealthyPolicy) IsNone() bool {
+	return up.Action == "" || up.Action == UnhealthyActionNone
 }
 
 // LogMode is a type to define the available modes for logging
@@ -419,6 +458,9 @@ type HostConfig struct {
 	VolumesFrom     []string      // List of volumes to take from other container
 	ConsoleSize     [2]uint       // Initial console size (height,width)
 
+	// Action to take when the container keeps failing its healthcheck
+	UnhealthyPolicy UnhealthyPolicy
+
 	// Applicable to UNIX platforms
 	CapAdd          strslice.StrSlice // List of kernel capabilities to add to the container
 	CapDrop         strslice.StrSlice // List of kernel capabilities to remove from the container
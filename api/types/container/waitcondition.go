@@ -15,8 +15,16 @@ type WaitCondition string
 // or is removed.
 //
 // WaitConditionRemoved is used to wait for the container to be removed.
+//
+// WaitConditionHealthy is used to wait for the container to be running and
+// healthy.
+//
+// WaitConditionReady is used to wait for the container to be running and
+// ready, as reported by its readiness probe, or healthy if it has none.
 const (
 	WaitConditionNotRunning WaitCondition = "not-running"
 	WaitConditionNextExit   WaitCondition = "next-exit"
 	WaitConditionRemoved    WaitCondition = "removed"
+	WaitConditionHealthy    WaitCondition = "healthy"
+	WaitConditionReady      WaitCondition = "ready"
 )
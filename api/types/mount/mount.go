@@ -82,9 +82,10 @@ const (
 
 // BindOptions defines options specific to mounts of type "bind".
 type BindOptions struct {
-	Propagation      Propagation `json:",omitempty"`
-	NonRecursive     bool        `json:",omitempty"`
-	CreateMountpoint bool        `json:",omitempty"`
+	Propagation      Propagation   `json:",omitempty"`
+	NonRecursive     bool          `json:",omitempty"`
+	CreateMountpoint bool          `json:",omitempty"`
+	IDMap            *IDMapOptions `json:",omitempty"`
 }
 
 // VolumeOptions represents the options for a mount of type volume.
@@ -92,6 +93,28 @@ type VolumeOptions struct {
 	NoCopy       bool              `json:",omitempty"`
 	Labels       map[string]string `json:",omitempty"`
 	DriverConfig *Driver           `json:",omitempty"`
+	IDMap        *IDMapOptions     `json:",omitempty"`
+}
+
+// IDMapOptions defines the options of a Linux idmapped mount, which changes
+// the owners of the files of the mount source as seen through the mount,
+// without changing the files.
+//
+// The mappings are those of a user namespace: the files owned by ContainerID
+// in the source are owned by HostID through the mount, which the container
+// sees as owned by ContainerID if the mappings are the ones of its user
+// namespace. If no mappings are specified, the mappings of the user namespace
+// of the container are used.
+type IDMapOptions struct {
+	UIDMappings []IDMapping `json:",omitempty"`
+	GIDMappings []IDMapping `json:",omitempty"`
+}
+
+// IDMapping is a range of IDs of an idmapped mount.
+type IDMapping struct {
+	ContainerID uint32
+	HostID      uint32
+	Size        uint32
 }
 
 // Driver represents a volume driver.
@@ -22,6 +22,12 @@ type IPAMConfig struct {
 	IPRange    string            `json:",omitempty"`
 	Gateway    string            `json:",omitempty"`
 	AuxAddress map[string]string `json:"AuxiliaryAddresses,omitempty"`
+	// Reservations maps container names to the address of the subnet
+	// reserved for them.
+	Reservations map[string]string `json:",omitempty"`
+	// Options are IPAM driver options of the pool, which override the
+	// options of the network.
+	Options map[string]string `json:",omitempty"`
 }
 
 // EndpointIPAMConfig represents IPAM configurations for the endpoint
@@ -62,6 +68,23 @@ type EndpointSettings struct {
 	GlobalIPv6PrefixLen int
 	MacAddress          string
 	DriverOpts          map[string]string
+	// Bandwidth holds the bandwidth limits of the endpoint
+	Bandwidth *EndpointBandwidth `json:",omitempty"`
+}
+
+// EndpointBandwidth represents the bandwidth limits of an endpoint. The rates
+// are in bytes per second and the bursts in bytes; a zero rate means no limit,
+// and a zero burst a default burst for the rate.
+type EndpointBandwidth struct {
+	// IngressRate limits the traffic received by the container
+	IngressRate  uint64 `json:",omitempty"`
+	IngressBurst uint64 `json:",omitempty"`
+	// EgressRate limits the traffic sent by the container
+	EgressRate  uint64 `json:",omitempty"`
+	EgressBurst uint64 `json:",omitempty"`
+	// Priority is the priority given to the packets sent by the container,
+	// used by the queueing disciplines of the host interfaces to order them
+	Priority uint32 `json:",omitempty"`
 }
 
 // Task carries the information about one backend task
@@ -96,6 +119,11 @@ func (es *EndpointSettings) Copy() *EndpointSettings {
 		aliases := make([]string, 0, len(es.Aliases))
 		epCopy.Aliases = append(aliases, es.Aliases...)
 	}
+
+	if es.Bandwidth != nil {
+		bw := *es.Bandwidth
+		epCopy.Bandwidth = &bw
+	}
 	return &epCopy
 }
 
@@ -110,6 +138,47 @@ type ConfigReference struct {
 	Network string
 }
 
+// DNSConfig holds the static records and conditional forwarding rules
+// served by the embedded DNS server to the containers connected to a network.
+type DNSConfig struct {
+	Records    []DNSRecord    `json:",omitempty"`
+	Forwarders []DNSForwarder `json:",omitempty"`
+}
+
+// DNSRecord is a static DNS record
+type DNSRecord struct {
+	Name  string // Name is the domain name of the record, e.g. "db.corp.internal"
+	Type  string // Type is one of "A", "AAAA", "CNAME", "SRV" or "TXT"
+	Value string // Value is the data of the record in zone-file format
+	TTL   uint32 `json:",omitempty"` // TTL is the time to live of the record in seconds
+}
+
+// DNSForwarder forwards the queries for the names in Domain to Servers
+// instead of the container's nameservers.
+type DNSForwarder struct {
+	Domain  string
+	Servers []string
+}
+
+// Policy controls the communication between the containers connected to a
+// network. The first rule matching a connection decides whether it is
+// allowed, and the connections matching no rule are subject to the default
+// action.
+type Policy struct {
+	Default string       `json:",omitempty"` // Default is the action applied to the connections matching no rule, "allow" or "deny", and "allow" when empty
+	Rules   []PolicyRule `json:",omitempty"`
+}
+
+// PolicyRule allows or denies the connections from the containers having
+// all the From labels to the containers having all the To labels.
+type PolicyRule struct {
+	Action   string            // Action is "allow" or "deny"
+	From     map[string]string `json:",omitempty"` // From selects the containers opening the connections, all of them when empty
+	To       map[string]string `json:",omitempty"` // To selects the containers receiving the connections, all of them when empty
+	Protocol string            `json:",omitempty"` // Protocol is "tcp", "udp" or "sctp", all the protocols when empty
+	Ports    []string          `json:",omitempty"` // Ports are the destination ports or port ranges, e.g. "5432" or "8000-8080", all the ports when empty
+}
+
 var acceptedFilters = map[string]bool{
 	"dangling": true,
 	"driver":   true,
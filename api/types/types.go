@@ -408,11 +408,33 @@ const (
 	Unhealthy     = "unhealthy" // Unhealthy indicates that the container has a problem
 )
 
+// Readiness states
+const (
+	Ready    = "ready"     // Ready indicates that the container can receive traffic
+	NotReady = "not-ready" // NotReady indicates that the container cannot receive traffic
+)
+
 // Health stores information about the container's healthcheck results
 type Health struct {
 	Status        string               // Status is one of Starting, Healthy or Unhealthy
 	FailingStreak int                  // FailingStreak is the number of consecutive failures
 	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
+	LastAction    *HealthAction        `json:",omitempty"` // LastAction is the last action taken because the container was unhealthy
+	Readiness     *Readiness           `json:",omitempty"` // Readiness holds the results of the readiness probe, if any
+}
+
+// Readiness stores information about the container's readiness probe results
+type Readiness struct {
+	Status        string               // Status is one of Ready or NotReady
+	FailingStreak int                  // FailingStreak is the number of consecutive failures
+	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
+}
+
+// HealthAction stores an action taken by the daemon on an unhealthy container
+type HealthAction struct {
+	Action        string    // Action is the action that was taken, either "restart" or "kill"
+	Time          time.Time // Time is the time the action was taken
+	FailingStreak int       // FailingStreak is the number of consecutive failures that triggered the action
 }
 
 // ContainerState stores container's running state
@@ -583,6 +605,8 @@ type NetworkResource struct {
 	Labels     map[string]string              // Labels holds metadata specific to the network being created
 	Peers      []network.PeerInfo             `json:",omitempty"` // List of peer nodes for an overlay network
 	Services   map[string]network.ServiceInfo `json:",omitempty"`
+	DNS        *network.DNSConfig             `json:",omitempty"` // DNS holds the static records and forwarding rules of the embedded DNS server
+	Policy     *network.Policy                `json:",omitempty"` // Policy controls the communication between the containers of the network
 }
 
 // EndpointResource contains network resources allocated and used for a container in a network
@@ -592,6 +616,7 @@ type EndpointResource struct {
 	MacAddress  string
 	IPv4Address string
 	IPv6Address string
+	Bandwidth   *network.EndpointBandwidth `json:",omitempty"`
 }
 
 // NetworkCreate is the expected body of the "create network" http request message
@@ -615,6 +640,8 @@ type NetworkCreate struct {
 	ConfigFrom     *network.ConfigReference
 	Options        map[string]string
 	Labels         map[string]string
+	DNS            *network.DNSConfig `json:",omitempty"`
+	Policy         *network.Policy    `json:",omitempty"`
 }
 
 // NetworkCreateRequest is the request message sent to the server for network create call.
@@ -629,6 +656,31 @@ type NetworkCreateResponse struct {
 	Warning string
 }
 
+// NetworkUpdate is the expected body of the "update network" http request
+// message. The settings which are not set are left unchanged.
+type NetworkUpdate struct {
+	// Options sets driver options of the network. Drivers only support
+	// updating some of their options, such as the MTU, inter-container
+	// communication and IP masquerading of bridge networks.
+	Options map[string]string
+	// Labels replaces the labels of the network.
+	Labels map[string]string
+	// Internal sets whether the network is internal. It can only be
+	// changed on networks without containers.
+	Internal *bool `json:",omitempty"`
+	// Attachable sets whether the network is attachable.
+	Attachable *bool `json:",omitempty"`
+	// IPAM sets the IP range of the existing pools with the same subnet,
+	// and adds the other pools to the network.
+	IPAM *network.IPAM `json:",omitempty"`
+	// DNS replaces the static records and forwarding rules of the embedded
+	// DNS server. An empty DNS removes them.
+	DNS *network.DNSConfig `json:",omitempty"`
+	// Policy replaces the policy of the network. An empty Policy removes
+	// it.
+	Policy *network.Policy `json:",omitempty"`
+}
+
 // NetworkConnect represents the data to be used to connect a container to the network
 type NetworkConnect struct {
 	Container      string
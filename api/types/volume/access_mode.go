@@ -0,0 +1,18 @@
+package volume // import "github.com/docker/docker/api/types/volume"
+
+// Access modes of a volume, which restrict how containers can mount the
+// volume concurrently on a host. A volume without an access mode can be
+// mounted by any number of containers.
+const (
+	// AccessModeSingleWriter allows any number of containers to mount the
+	// volume read-only, but only one container to mount it read-write.
+	AccessModeSingleWriter = "single-writer"
+
+	// AccessModeSingleContainer allows only one container at a time to
+	// mount the volume.
+	AccessModeSingleContainer = "single-container"
+
+	// AccessModeReadOnlyMany allows any number of containers to mount the
+	// volume, but only read-only.
+	AccessModeReadOnlyMany = "read-only-many"
+)
@@ -9,6 +9,14 @@ package volume
 // swagger:model CreateOptions
 type CreateOptions struct {
 
+	// The access mode of the volume, which restricts how containers can
+	// mount it concurrently on this host. Either `single-writer`,
+	// `single-container` or `read-only-many`. If not specified, the volume
+	// can be mounted by any number of containers.
+	//
+	// Enum: [single-writer single-container read-only-many]
+	AccessMode string `json:"AccessMode,omitempty"`
+
 	// cluster volume spec
 	ClusterVolumeSpec *ClusterVolumeSpec `json:"ClusterVolumeSpec,omitempty"`
 
@@ -26,4 +34,7 @@ type CreateOptions struct {
 	// The new volume's name. If not specified, Docker generates a name.
 	//
 	Name string `json:"Name,omitempty"`
+
+	// snapshot
+	Snapshot *SnapshotSource `json:"Snapshot,omitempty"`
 }
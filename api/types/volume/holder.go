@@ -0,0 +1,19 @@
+package volume
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+// Holder VolumeHolder
+//
+// A container which has the volume mounted.
+// swagger:model Holder
+type Holder struct {
+
+	// The ID of the container.
+	// Required: true
+	Container string `json:"Container"`
+
+	// Whether the container has the volume mounted read-write.
+	// Required: true
+	ReadWrite bool `json:"ReadWrite"`
+}
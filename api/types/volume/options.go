@@ -6,3 +6,15 @@ import "github.com/docker/docker/api/types/filters"
 type ListOptions struct {
 	Filters filters.Args
 }
+
+// SnapshotCreateOptions holds parameters to take a snapshot of a volume.
+type SnapshotCreateOptions struct {
+	Name string
+}
+
+// ExportOptions holds parameters to export the contents of a volume.
+type ExportOptions struct {
+	// Compression is the compression of the exported archive, "none"
+	// (default), "gzip" or "zstd".
+	Compression string
+}
@@ -0,0 +1,22 @@
+package volume
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+// Snapshot VolumeSnapshot
+//
+// A point-in-time copy of the data of a volume.
+// swagger:model Snapshot
+type Snapshot struct {
+
+	// Date/Time the snapshot was taken.
+	CreatedAt string `json:"CreatedAt,omitempty"`
+
+	// Name of the snapshot.
+	// Required: true
+	Name string `json:"Name"`
+
+	// Name of the volume the snapshot was taken of.
+	// Required: true
+	Volume string `json:"Volume"`
+}
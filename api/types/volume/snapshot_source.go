@@ -0,0 +1,21 @@
+package volume
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+// SnapshotSource VolumeSnapshotSource
+//
+// The snapshot of a volume to create a volume from. The volume is created
+// with the driver of the snapshot, which must support snapshots.
+//
+// swagger:model SnapshotSource
+type SnapshotSource struct {
+
+	// Name of the snapshot.
+	// Required: true
+	Name string `json:"Name"`
+
+	// Name of the volume the snapshot was taken of.
+	// Required: true
+	Volume string `json:"Volume"`
+}
@@ -7,6 +7,13 @@ package volume
 // swagger:model Volume
 type Volume struct {
 
+	// The access mode of the volume, which restricts how containers can
+	// mount it concurrently on this host. Omitted if the volume has no
+	// access mode.
+	//
+	// Enum: [single-writer single-container read-only-many]
+	AccessMode string `json:"AccessMode,omitempty"`
+
 	// cluster volume
 	ClusterVolume *ClusterVolume `json:"ClusterVolume,omitempty"`
 
@@ -17,6 +24,11 @@ type Volume struct {
 	// Required: true
 	Driver string `json:"Driver"`
 
+	// The containers which currently have the volume mounted. This field
+	// is only returned by `GET /volumes/{name}`.
+	//
+	Holders []*Holder `json:"Holders,omitempty"`
+
 	// User-defined key/value metadata.
 	// Required: true
 	Labels map[string]string `json:"Labels"`
@@ -54,7 +66,9 @@ type Volume struct {
 }
 
 // UsageData Usage details about the volume. This information is used by the
-// `GET /system/df` endpoint, and omitted in other endpoints.
+// `GET /system/df` endpoint. It is also returned by `GET /volumes/{name}`
+// for the volumes which can report their usage cheaply, such as the
+// volumes of the `"local"` driver with a quota, and omitted otherwise.
 //
 // swagger:model UsageData
 type UsageData struct {
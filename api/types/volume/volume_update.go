@@ -4,4 +4,9 @@ package volume // import "github.com/docker/docker/api/types/volume"
 type UpdateOptions struct {
 	// Spec is the ClusterVolumeSpec to update the volume to.
 	Spec *ClusterVolumeSpec `json:"Spec,omitempty"`
+
+	// Options are the driver specific options to update a volume which is
+	// not a cluster volume with. Options which are not given are left
+	// unchanged.
+	Options map[string]string `json:"Options,omitempty"`
 }
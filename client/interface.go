@@ -113,6 +113,7 @@ type NetworkAPIClient interface {
 	NetworkInspectWithRaw(ctx context.Context, network string, options types.NetworkInspectOptions) (types.NetworkResource, []byte, error)
 	NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error)
 	NetworkRemove(ctx context.Context, network string) error
+	NetworkUpdate(ctx context.Context, network string, options types.NetworkUpdate) error
 	NetworksPrune(ctx context.Context, pruneFilter filters.Args) (types.NetworksPruneReport, error)
 }
 
@@ -180,6 +181,12 @@ type VolumeAPIClient interface {
 	VolumeRemove(ctx context.Context, volumeID string, force bool) error
 	VolumesPrune(ctx context.Context, pruneFilter filters.Args) (types.VolumesPruneReport, error)
 	VolumeUpdate(ctx context.Context, volumeID string, version swarm.Version, options volume.UpdateOptions) error
+	VolumeSnapshotCreate(ctx context.Context, volumeID string, options volume.SnapshotCreateOptions) (volume.Snapshot, error)
+	VolumeSnapshotList(ctx context.Context, volumeID string) ([]volume.Snapshot, error)
+	VolumeSnapshotRemove(ctx context.Context, volumeID, snapshot string) error
+	VolumeSnapshotRestore(ctx context.Context, volumeID, snapshot string) error
+	VolumeExport(ctx context.Context, volumeID string, options volume.ExportOptions) (io.ReadCloser, error)
+	VolumeImport(ctx context.Context, volumeID string, content io.Reader) error
 }
 
 // SecretAPIClient defines API client methods for secrets
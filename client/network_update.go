@@ -0,0 +1,14 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+)
+
+// NetworkUpdate replaces the static DNS records and forwarding rules of a network.
+func (cli *Client) NetworkUpdate(ctx context.Context, networkID string, options types.NetworkUpdate) error {
+	resp, err := cli.post(ctx, "/networks/"+networkID+"/update", nil, options, nil)
+	ensureReaderClosed(resp)
+	return err
+}
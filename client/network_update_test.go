@@ -0,0 +1,69 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+func TestNetworkUpdateError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	err := client.NetworkUpdate(context.Background(), "network_id", types.NetworkUpdate{})
+	if !errdefs.IsSystem(err) {
+		t.Fatalf("expected a Server Error, got %[1]T: %[1]v", err)
+	}
+}
+
+func TestNetworkUpdate(t *testing.T) {
+	expectedURL := "/networks/network_id/update"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+
+			if req.Method != http.MethodPost {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+
+			var update types.NetworkUpdate
+			if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+				return nil, err
+			}
+
+			if update.DNS == nil || len(update.DNS.Records) != 1 {
+				return nil, fmt.Errorf("expected 1 DNS record, got %v", update.DNS)
+			}
+			if r := update.DNS.Records[0]; r.Name != "db.corp.internal" || r.Type != "A" || r.Value != "10.0.0.5" {
+				return nil, fmt.Errorf("unexpected DNS record %+v", r)
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+
+	err := client.NetworkUpdate(context.Background(), "network_id", types.NetworkUpdate{
+		DNS: &network.DNSConfig{
+			Records: []network.DNSRecord{{Name: "db.corp.internal", Type: "A", Value: "10.0.0.5"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestVolumeExportError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeExport(context.Background(), "volume_id", volume.ExportOptions{})
+	assert.Check(t, is.ErrorType(err, errdefs.IsSystem))
+}
+
+func TestVolumeExport(t *testing.T) {
+	expectedURL := "/volumes/volume_id/export"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != http.MethodGet {
+				return nil, fmt.Errorf("expected GET method, got %s", req.Method)
+			}
+			if compression := req.URL.Query().Get("compression"); compression != "gzip" {
+				return nil, fmt.Errorf("compression not set in URL query properly. Expected 'gzip', got %s", compression)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte("response"))),
+			}, nil
+		}),
+	}
+
+	body, err := client.VolumeExport(context.Background(), "volume_id", volume.ExportOptions{Compression: "gzip"})
+	assert.NilError(t, err)
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "response"))
+}
@@ -0,0 +1,20 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"io"
+)
+
+// VolumeImport extracts the tar archive read from content, which may be
+// compressed, into a volume. The volume must not be in use.
+func (cli *Client) VolumeImport(ctx context.Context, volumeID string, content io.Reader) error {
+	if err := cli.NewVersionError("1.43", "volume import"); err != nil {
+		return err
+	}
+	headers := map[string][]string{
+		"Content-Type": {"application/x-tar"},
+	}
+	resp, err := cli.postRaw(ctx, "/volumes/"+volumeID+"/import", nil, content, headers)
+	defer ensureReaderClosed(resp)
+	return err
+}
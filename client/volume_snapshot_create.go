@@ -0,0 +1,23 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types/volume"
+)
+
+// VolumeSnapshotCreate takes a snapshot of a volume in the docker host.
+func (cli *Client) VolumeSnapshotCreate(ctx context.Context, volumeID string, options volume.SnapshotCreateOptions) (volume.Snapshot, error) {
+	var snapshot volume.Snapshot
+	if err := cli.NewVersionError("1.43", "volume snapshot create"); err != nil {
+		return snapshot, err
+	}
+	resp, err := cli.post(ctx, "/volumes/"+volumeID+"/snapshots", nil, options, nil)
+	defer ensureReaderClosed(resp)
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.NewDecoder(resp.body).Decode(&snapshot)
+	return snapshot, err
+}
@@ -0,0 +1,70 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestVolumeSnapshotCreateError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeSnapshotCreate(context.Background(), "volume_id", volume.SnapshotCreateOptions{Name: "snapshot"})
+	assert.Check(t, is.ErrorType(err, errdefs.IsSystem))
+}
+
+func TestVolumeSnapshotCreateOldAPIVersion(t *testing.T) {
+	client := &Client{
+		version: "1.42",
+		client:  newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeSnapshotCreate(context.Background(), "volume_id", volume.SnapshotCreateOptions{Name: "snapshot"})
+	assert.Check(t, is.Error(err, `"volume snapshot create" requires API version 1.43, but the Docker daemon API version is 1.42`))
+}
+
+func TestVolumeSnapshotCreate(t *testing.T) {
+	expectedURL := "/volumes/volume_id/snapshots"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != http.MethodPost {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+
+			var options volume.SnapshotCreateOptions
+			if err := json.NewDecoder(req.Body).Decode(&options); err != nil {
+				return nil, err
+			}
+			content, err := json.Marshal(volume.Snapshot{
+				Name:   options.Name,
+				Volume: "volume_id",
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       io.NopCloser(bytes.NewReader(content)),
+			}, nil
+		}),
+	}
+
+	snapshot, err := client.VolumeSnapshotCreate(context.Background(), "volume_id", volume.SnapshotCreateOptions{Name: "snapshot"})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(snapshot, volume.Snapshot{Name: "snapshot", Volume: "volume_id"}))
+}
@@ -0,0 +1,23 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types/volume"
+)
+
+// VolumeSnapshotList returns the snapshots of a volume in the docker host.
+func (cli *Client) VolumeSnapshotList(ctx context.Context, volumeID string) ([]volume.Snapshot, error) {
+	var snapshots []volume.Snapshot
+	if err := cli.NewVersionError("1.43", "volume snapshot list"); err != nil {
+		return snapshots, err
+	}
+	resp, err := cli.get(ctx, "/volumes/"+volumeID+"/snapshots", nil, nil)
+	defer ensureReaderClosed(resp)
+	if err != nil {
+		return snapshots, err
+	}
+	err = json.NewDecoder(resp.body).Decode(&snapshots)
+	return snapshots, err
+}
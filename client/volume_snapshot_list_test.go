@@ -0,0 +1,55 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestVolumeSnapshotListError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeSnapshotList(context.Background(), "volume_id")
+	assert.Check(t, is.ErrorType(err, errdefs.IsSystem))
+}
+
+func TestVolumeSnapshotList(t *testing.T) {
+	expectedURL := "/volumes/volume_id/snapshots"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != http.MethodGet {
+				return nil, fmt.Errorf("expected GET method, got %s", req.Method)
+			}
+			content, err := json.Marshal([]volume.Snapshot{
+				{Name: "snapshot1", Volume: "volume_id"},
+				{Name: "snapshot2", Volume: "volume_id"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(content)),
+			}, nil
+		}),
+	}
+
+	snapshots, err := client.VolumeSnapshotList(context.Background(), "volume_id")
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(snapshots, 2))
+}
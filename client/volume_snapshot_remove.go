@@ -0,0 +1,13 @@
+package client // import "github.com/docker/docker/client"
+
+import "context"
+
+// VolumeSnapshotRemove removes a snapshot of a volume from the docker host.
+func (cli *Client) VolumeSnapshotRemove(ctx context.Context, volumeID, snapshot string) error {
+	if err := cli.NewVersionError("1.43", "volume snapshot remove"); err != nil {
+		return err
+	}
+	resp, err := cli.delete(ctx, "/volumes/"+volumeID+"/snapshots/"+snapshot, nil, nil)
+	defer ensureReaderClosed(resp)
+	return err
+}
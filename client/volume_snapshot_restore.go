@@ -0,0 +1,14 @@
+package client // import "github.com/docker/docker/client"
+
+import "context"
+
+// VolumeSnapshotRestore replaces the data of a volume with the data of its
+// snapshot.
+func (cli *Client) VolumeSnapshotRestore(ctx context.Context, volumeID, snapshot string) error {
+	if err := cli.NewVersionError("1.43", "volume snapshot restore"); err != nil {
+		return err
+	}
+	resp, err := cli.post(ctx, "/volumes/"+volumeID+"/snapshots/"+snapshot+"/restore", nil, nil, nil)
+	defer ensureReaderClosed(resp)
+	return err
+}
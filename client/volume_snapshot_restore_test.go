@@ -0,0 +1,45 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestVolumeSnapshotRestoreError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusConflict, "volume has active mounts")),
+	}
+
+	err := client.VolumeSnapshotRestore(context.Background(), "volume_id", "snapshot")
+	assert.Check(t, is.ErrorType(err, errdefs.IsConflict))
+}
+
+func TestVolumeSnapshotRestore(t *testing.T) {
+	expectedURL := "/volumes/volume_id/snapshots/snapshot/restore"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != http.MethodPost {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       io.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.VolumeSnapshotRestore(context.Background(), "volume_id", "snapshot")
+	assert.NilError(t, err)
+}
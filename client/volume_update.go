@@ -8,12 +8,19 @@ import (
 	"github.com/docker/docker/api/types/volume"
 )
 
-// VolumeUpdate updates a volume. This only works for Cluster Volumes, and
-// only some fields can be updated.
+// VolumeUpdate updates a volume. Cluster Volumes are updated with the given
+// Spec and version, of which only some fields can be updated. Other volumes
+// are updated with the given driver specific Options, and the version is
+// ignored.
 func (cli *Client) VolumeUpdate(ctx context.Context, volumeID string, version swarm.Version, options volume.UpdateOptions) error {
 	if err := cli.NewVersionError("1.42", "volume update"); err != nil {
 		return err
 	}
+	if options.Spec == nil {
+		if err := cli.NewVersionError("1.43", "volume update without a cluster volume spec"); err != nil {
+			return err
+		}
+	}
 
 	query := url.Values{}
 	query.Set("version", version.String())
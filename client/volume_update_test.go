@@ -53,3 +53,18 @@ func TestVolumeUpdate(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestVolumeUpdateOptionsOldAPIVersion(t *testing.T) {
+	client := &Client{
+		version: "1.42",
+		client:  newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	err := client.VolumeUpdate(context.Background(), "test1", swarm.Version{}, volumetypes.UpdateOptions{
+		Options: map[string]string{"size": "1G"},
+	})
+	expected := `"volume update without a cluster volume spec" requires API version 1.43, but the Docker daemon API version is 1.42`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+}
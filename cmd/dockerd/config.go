@@ -44,10 +44,16 @@ func installCommonConfigFlags(conf *config.Config, flags *pflag.FlagSet) error {
 	flags.StringVar(&conf.LogConfig.Type, "log-driver", "json-file", "Default driver for container logs")
 	flags.Var(opts.NewNamedMapOpts("log-opts", conf.LogConfig.Config, nil), "log-opt", "Default log driver options for containers")
 
+	flags.BoolVar(&conf.EventsJournal, "events-journal", false, "Persist events to disk")
+	flags.Var(&conf.EventsJournalMaxSize, "events-journal-max-size", "Maximum size of the events journal")
+	flags.StringVar(&conf.EventsJournalMaxAge, "events-journal-max-age", "", "Maximum age of events kept in the events journal")
+
 	flags.StringVar(&conf.CorsHeaders, "api-cors-header", "", "Set CORS headers in the Engine API")
 	flags.IntVar(&conf.MaxConcurrentDownloads, "max-concurrent-downloads", conf.MaxConcurrentDownloads, "Set the max concurrent downloads for each pull")
 	flags.IntVar(&conf.MaxConcurrentUploads, "max-concurrent-uploads", conf.MaxConcurrentUploads, "Set the max concurrent uploads for each push")
 	flags.IntVar(&conf.MaxDownloadAttempts, "max-download-attempts", conf.MaxDownloadAttempts, "Set the max download attempts for each pull")
+	flags.StringVar(&conf.LayerCompression, "layer-compression", "", `Set the compression of layers for push ("gzip", "zstd", "zstd:chunked")`)
+	flags.BoolVar(&conf.LazyPull, "lazy-pull", false, "Mount eStargz and zstd:chunked layers from the registry on pull, and download them in the background")
 	flags.IntVar(&conf.ShutdownTimeout, "shutdown-timeout", conf.ShutdownTimeout, "Set the default shutdown timeout")
 
 	flags.StringVar(&conf.SwarmDefaultAdvertiseAddr, "swarm-default-advertise-addr", "", "Set default address or interface for swarm advertised address")
@@ -401,6 +401,13 @@ func (container *Container) StartLogger() (logger.Logger, error) {
 		info.LogPath = filepath.Join(logDir, "container.log")
 	}
 
+	// Parse the rate limit before creating the driver, so that the driver is
+	// not left open if the configuration is invalid.
+	rateLimit, err := logger.ParseRateLimitConfig(cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+
 	l, err := initDriver(info)
 	if err != nil {
 		return nil, err
@@ -417,6 +424,10 @@ func (container *Container) StartLogger() (logger.Logger, error) {
 		l = logger.NewRingLogger(l, info, bufferSize)
 	}
 
+	if rateLimit != nil {
+		l = logger.NewRateLimitedLogger(l, *rateLimit)
+	}
+
 	if _, ok := l.(logger.LogReader); !ok {
 		if cache.ShouldUseCache(cfg.Config) {
 			logPath, err := container.GetRootResourcePath("container-cached.log")
@@ -566,6 +577,18 @@ func (container *Container) UpdateMonitor(restartPolicy containertypes.RestartPo
 	}
 }
 
+// ForceRestart makes the restart manager restart the container on its next
+// exit, regardless of its restart policy.
+func (container *Container) ForceRestart() {
+	type forceRestarter interface {
+		ForceRestart()
+	}
+
+	if rm, ok := container.RestartManager().(forceRestarter); ok {
+		rm.ForceRestart()
+	}
+}
+
 // FullHostname returns hostname and optional domain appended to it.
 func (container *Container) FullHostname() string {
 	fullHostname := container.Config.Hostname
@@ -632,7 +655,16 @@ func (container *Container) startLogging() error {
 		return fmt.Errorf("failed to initialize logging driver: %v", err)
 	}
 
-	copier := logger.NewCopier(map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
+	var opts []logger.CopierOption
+	multiline, err := logger.ParseMultilineConfig(container.HostConfig.LogConfig.Config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logging driver: %v", err)
+	}
+	if multiline != nil {
+		opts = append(opts, logger.WithMultiline(*multiline))
+	}
+
+	copier := logger.NewCopier(map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l, opts...)
 	container.LogCopier = copier
 	copier.Run()
 	container.LogDriver = l
@@ -17,6 +17,7 @@ import (
 	"github.com/docker/docker/volume"
 	volumemounts "github.com/docker/docker/volume/mounts"
 	"github.com/moby/sys/mount"
+	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -254,6 +255,26 @@ func (container *Container) SecretMounts() ([]Mount, error) {
 	return mounts, nil
 }
 
+// IDMappedMountPath returns the path of the idmapped mount of the source of
+// the mount point at destination.
+func (container *Container) IDMappedMountPath(destination string) string {
+	return filepath.Join(container.Root, "idmapped", digest.FromString(destination).Encoded())
+}
+
+// UnmountIDMappedMounts unmounts the idmapped mounts of the sources of the
+// mount points of the container.
+func (container *Container) UnmountIDMappedMounts() error {
+	p := filepath.Join(container.Root, "idmapped")
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return mount.RecursiveUnmount(p)
+}
+
 // UnmountSecrets unmounts the local tmpfs for secrets
 func (container *Container) UnmountSecrets() error {
 	p, err := container.SecretMountPath()
@@ -391,6 +412,15 @@ func (container *Container) DetachAndUnmount(volumeEventLog func(name, action st
 				Warn("Unable to unmount")
 		}
 	}
+
+	// The idmapped mounts of a running container are unmounted when it is
+	// cleaned up, the others were mounted for the volumes mounted above.
+	if !container.Running {
+		if err := container.UnmountIDMappedMounts(); err != nil {
+			logrus.WithError(err).WithField("container", container.ID).
+				Warn("Unable to unmount idmapped mounts")
+		}
+	}
 	return container.UnmountVolumes(volumeEventLog)
 }
 
@@ -37,6 +37,7 @@ type State struct {
 
 	stopWaiters       []chan<- StateStatus
 	removeOnlyWaiters []chan<- StateStatus
+	healthWaiters     []healthWaiter
 
 	// The libcontainerd reference fields are unexported to force consumers
 	// to access them through the getter methods with multi-valued returns
@@ -67,6 +68,16 @@ func (s StateStatus) Err() error {
 	return s.err
 }
 
+// healthWaiter is waiting for the container to become healthy or ready.
+type healthWaiter struct {
+	condition WaitCondition
+	c         chan<- StateStatus
+}
+
+// errStoppedWaitingForHealth is returned to health waiters when the container
+// is not running, or stops before meeting the condition they are waiting for.
+var errStoppedWaitingForHealth = errors.New("container is not running")
+
 // NewState creates a default state object.
 func NewState() *State {
 	return &State{}
@@ -171,10 +182,19 @@ type WaitCondition int
 // or is removed.
 //
 // WaitConditionRemoved is used to wait for the container to be removed.
+//
+// WaitConditionHealthy is used to wait for the container to be running and
+// healthy. If the container stops first, the wait ends with an error.
+//
+// WaitConditionReady is used to wait for the container to be running and
+// ready, as reported by its readiness probe, or healthy if it has none. If
+// the container stops first, the wait ends with an error.
 const (
 	WaitConditionNotRunning WaitCondition = iota
 	WaitConditionNextExit
 	WaitConditionRemoved
+	WaitConditionHealthy
+	WaitConditionReady
 )
 
 // Wait waits until the container is in a certain state indicated by the given
@@ -201,14 +221,26 @@ func (s *State) Wait(ctx context.Context, condition WaitCondition) <-chan StateS
 		return resultC
 	}
 
+	if (condition == WaitConditionHealthy || condition == WaitConditionReady) && !s.Running {
+		resultC <- StateStatus{
+			exitCode: s.ExitCode(),
+			err:      errStoppedWaitingForHealth,
+		}
+
+		return resultC
+	}
+
 	waitC := make(chan StateStatus, 1)
 
 	// Removal wakes up both removeOnlyWaiters and stopWaiters
 	// Container could be removed while still in "created" state
 	// in which case it is never actually stopped
-	if condition == WaitConditionRemoved {
+	switch condition {
+	case WaitConditionRemoved:
 		s.removeOnlyWaiters = append(s.removeOnlyWaiters, waitC)
-	} else {
+	case WaitConditionHealthy, WaitConditionReady:
+		s.healthWaiters = append(s.healthWaiters, healthWaiter{condition: condition, c: waitC})
+	default:
 		s.stopWaiters = append(s.stopWaiters, waitC)
 	}
 
@@ -235,11 +267,48 @@ func (s *State) conditionAlreadyMet(condition WaitCondition) bool {
 		return !s.Running
 	case WaitConditionRemoved:
 		return s.Removed
+	case WaitConditionHealthy, WaitConditionReady:
+		return s.healthConditionMet(condition)
 	}
 
 	return false
 }
 
+func (s *State) healthConditionMet(condition WaitCondition) bool {
+	if !s.Running || s.Restarting || s.Health == nil {
+		return false
+	}
+	if r := s.Health.Readiness; r != nil && condition == WaitConditionReady {
+		return r.Status == types.Ready
+	}
+	return s.Health.Status() == types.Healthy
+}
+
+// NotifyHealthChange wakes up the waiters for the WaitConditionHealthy and
+// WaitConditionReady conditions which are now met. It must be called after
+// updating the container's health or readiness status, with the state
+// locked.
+func (s *State) NotifyHealthChange() {
+	waiters := s.healthWaiters[:0]
+	for _, w := range s.healthWaiters {
+		if s.healthConditionMet(w.condition) {
+			w.c <- StateStatus{exitCode: s.ExitCodeValue}
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+	s.healthWaiters = waiters
+}
+
+// notifyHealthWaitersStopped wakes up all health waiters with an error, as
+// the container stopped before meeting their condition.
+func (s *State) notifyHealthWaitersStopped() {
+	for _, w := range s.healthWaiters {
+		w.c <- StateStatus{exitCode: s.ExitCodeValue, err: errStoppedWaitingForHealth}
+	}
+	s.healthWaiters = nil
+}
+
 // IsRunning returns whether the running flag is set. Used by Container to check whether a container is running.
 func (s *State) IsRunning() bool {
 	s.Lock()
@@ -305,6 +374,7 @@ func (s *State) SetStopped(exitStatus *ExitStatus) {
 	s.ExitCodeValue = exitStatus.ExitCode
 
 	s.notifyAndClear(&s.stopWaiters)
+	s.notifyHealthWaitersStopped()
 }
 
 // SetRestarting sets the container state to "restarting" without locking.
@@ -397,6 +467,7 @@ func (s *State) SetRemovalError(err error) {
 	s.Removed = true
 	s.notifyAndClear(&s.removeOnlyWaiters)
 	s.notifyAndClear(&s.stopWaiters)
+	s.notifyHealthWaitersStopped()
 	s.Unlock()
 }
 
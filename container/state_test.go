@@ -225,3 +225,53 @@ func TestIsValidStateString(t *testing.T) {
 		}
 	}
 }
+
+func TestStateWaitHealthy(t *testing.T) {
+	s := NewState()
+
+	// Waiting for a container which is not running fails immediately.
+	status := <-s.Wait(context.Background(), WaitConditionHealthy)
+	if status.Err() == nil {
+		t.Fatal("expected an error waiting for a stopped container to be healthy")
+	}
+
+	s.Lock()
+	s.SetRunning(nil, nil, true)
+	s.Health = &Health{}
+	s.Health.SetStatus(types.Starting)
+	s.Unlock()
+
+	healthyC := s.Wait(context.Background(), WaitConditionHealthy)
+	readyC := s.Wait(context.Background(), WaitConditionReady)
+
+	s.Lock()
+	s.Health.SetStatus(types.Healthy)
+	s.Health.Readiness = &types.Readiness{Status: types.NotReady}
+	s.NotifyHealthChange()
+	s.Unlock()
+
+	select {
+	case status := <-healthyC:
+		if status.Err() != nil {
+			t.Fatalf("unexpected error: %v", status.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the healthy condition to be met")
+	}
+
+	// The container has a readiness probe, which hasn't passed yet.
+	select {
+	case status := <-readyC:
+		t.Fatalf("unexpected result while not ready: %v", status)
+	default:
+	}
+
+	s.Lock()
+	s.SetStopped(&ExitStatus{ExitCode: 1})
+	s.Unlock()
+
+	status = <-readyC
+	if status.Err() == nil {
+		t.Fatal("expected an error when the container stops before becoming ready")
+	}
+}
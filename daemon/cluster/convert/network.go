@@ -1,12 +1,14 @@
 package convert // import "github.com/docker/docker/daemon/cluster/convert"
 
 import (
+	"encoding/json"
 	"strings"
 
 	basictypes "github.com/docker/docker/api/types"
 	networktypes "github.com/docker/docker/api/types/network"
 	types "github.com/docker/docker/api/types/swarm"
 	netconst "github.com/docker/docker/libnetwork/datastore"
+	"github.com/docker/docker/libnetwork/netlabel"
 	gogotypes "github.com/gogo/protobuf/types"
 	swarmapi "github.com/moby/swarmkit/v2/api"
 )
@@ -177,7 +179,7 @@ func BasicNetworkFromGRPC(n swarmapi.Network) basictypes.NetworkResource {
 
 	if n.DriverState != nil {
 		nr.Driver = n.DriverState.Name
-		nr.Options = n.DriverState.Options
+		nr.Options, nr.Policy = PolicyFromDriverOptions(n.DriverState.Options)
 	}
 
 	return nr
@@ -192,7 +194,7 @@ func BasicNetworkCreateToGRPC(create basictypes.NetworkCreateRequest) swarmapi.N
 		},
 		DriverConfig: &swarmapi.Driver{
 			Name:    create.Driver,
-			Options: create.Options,
+			Options: policyToDriverOptions(create.Options, create.Policy),
 		},
 		Ipv6Enabled: create.EnableIPv6,
 		Internal:    create.Internal,
@@ -228,6 +230,44 @@ func BasicNetworkCreateToGRPC(create basictypes.NetworkCreateRequest) swarmapi.N
 	return ns
 }
 
+// policyToDriverOptions returns the driver options of a network with its
+// policy, which swarm networks carry as a driver option.
+func policyToDriverOptions(options map[string]string, policy *networktypes.Policy) map[string]string {
+	if policy == nil {
+		return options
+	}
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return options
+	}
+	opts := make(map[string]string, len(options)+1)
+	for k, v := range options {
+		opts[k] = v
+	}
+	opts[netlabel.Policy] = string(b)
+	return opts
+}
+
+// PolicyFromDriverOptions returns the driver options of a swarm network
+// without its policy, and the policy.
+func PolicyFromDriverOptions(options map[string]string) (map[string]string, *networktypes.Policy) {
+	v, ok := options[netlabel.Policy]
+	if !ok {
+		return options, nil
+	}
+	opts := make(map[string]string, len(options)-1)
+	for k, v := range options {
+		if k != netlabel.Policy {
+			opts[k] = v
+		}
+	}
+	var policy networktypes.Policy
+	if err := json.Unmarshal([]byte(v), &policy); err != nil {
+		return opts, nil
+	}
+	return opts, &policy
+}
+
 // IsIngressNetwork check if the swarm network is an ingress network
 func IsIngressNetwork(n *swarmapi.Network) bool {
 	if n.Spec.Ingress {
@@ -1,9 +1,12 @@
 package convert // import "github.com/docker/docker/daemon/cluster/convert"
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
+	basictypes "github.com/docker/docker/api/types"
+	networktypes "github.com/docker/docker/api/types/network"
 	gogotypes "github.com/gogo/protobuf/types"
 	swarmapi "github.com/moby/swarmkit/v2/api"
 )
@@ -32,3 +35,35 @@ func TestNetworkConvertBasicNetworkFromGRPCCreatedAt(t *testing.T) {
 		t.Fatalf("expected time %s; received %s", expected, n.Created)
 	}
 }
+
+func TestNetworkConvertPolicy(t *testing.T) {
+	policy := &networktypes.Policy{
+		Default: "deny",
+		Rules: []networktypes.PolicyRule{
+			{Action: "allow", From: map[string]string{"role": "web"}, To: map[string]string{"role": "db"}, Protocol: "tcp", Ports: []string{"5432"}},
+		},
+	}
+	options := map[string]string{"foo": "bar"}
+	spec := BasicNetworkCreateToGRPC(basictypes.NetworkCreateRequest{
+		Name: "net",
+		NetworkCreate: basictypes.NetworkCreate{
+			Driver:  "overlay",
+			Options: options,
+			Policy:  policy,
+		},
+	})
+	if len(options) != 1 {
+		t.Fatalf("expected the options of the request to be left unchanged, got %v", options)
+	}
+
+	n := BasicNetworkFromGRPC(swarmapi.Network{
+		Spec:        spec,
+		DriverState: spec.DriverConfig,
+	})
+	if !reflect.DeepEqual(n.Options, options) {
+		t.Fatalf("expected options %v; received %v", options, n.Options)
+	}
+	if !reflect.DeepEqual(n.Policy, policy) {
+		t.Fatalf("expected policy %+v; received %+v", policy, n.Policy)
+	}
+}
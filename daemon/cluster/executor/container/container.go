@@ -652,7 +652,7 @@ func (c *containerConfig) networkCreateRequest(name string) (clustertypes.Networ
 
 	if na.Network.DriverState != nil {
 		options.Driver = na.Network.DriverState.Name
-		options.Options = na.Network.DriverState.Options
+		options.Options, options.Policy = convert.PolicyFromDriverOptions(na.Network.DriverState.Options)
 	}
 	if na.Network.IPAM != nil {
 		options.IPAM = &network.IPAM{
@@ -271,6 +271,16 @@ func (c *Cluster) CreateNetwork(s apitypes.NetworkCreateRequest) (string, error)
 		err := notAllowedError(fmt.Sprintf("%s is a pre-defined network and cannot be created", s.Name))
 		return "", errors.WithStack(err)
 	}
+	if s.IPAM != nil {
+		for _, cfg := range s.IPAM.Config {
+			if len(cfg.Reservations) > 0 {
+				return "", errdefs.InvalidParameter(errors.New("address reservations are not supported on swarm-scoped networks"))
+			}
+			if len(cfg.Options) > 0 {
+				return "", errdefs.InvalidParameter(errors.New("IPAM options of pools are not supported on swarm-scoped networks"))
+			}
+		}
+	}
 
 	var resp *swarmapi.CreateNetworkResponse
 	if err := c.lockedManagerAction(func(ctx context.Context, state nodeState) error {
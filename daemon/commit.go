@@ -80,6 +80,8 @@ func merge(userConf, imageConf *containertypes.Config) error {
 		} else {
 			if len(userConf.Healthcheck.Test) == 0 {
 				userConf.Healthcheck.Test = imageConf.Healthcheck.Test
+				userConf.Healthcheck.HTTP = imageConf.Healthcheck.HTTP
+				userConf.Healthcheck.TCP = imageConf.Healthcheck.TCP
 			}
 			if userConf.Healthcheck.Interval == 0 {
 				userConf.Healthcheck.Interval = imageConf.Healthcheck.Interval
@@ -93,6 +95,12 @@ func merge(userConf, imageConf *containertypes.Config) error {
 			if userConf.Healthcheck.Retries == 0 {
 				userConf.Healthcheck.Retries = imageConf.Healthcheck.Retries
 			}
+			if userConf.Healthcheck.StartupProbe == nil {
+				userConf.Healthcheck.StartupProbe = imageConf.Healthcheck.StartupProbe
+			}
+			if userConf.Healthcheck.ReadinessProbe == nil {
+				userConf.Healthcheck.ReadinessProbe = imageConf.Healthcheck.ReadinessProbe
+			}
 		}
 	}
 
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/containerd/containerd/runtime/v2/shim"
 	"github.com/docker/docker/opts"
@@ -124,6 +125,18 @@ type NetworkConfig struct {
 	NetworkControlPlaneMTU int `json:"network-control-plane-mtu,omitempty"`
 }
 
+// EventsConfig defines the configuration of the on-disk events journal.
+type EventsConfig struct {
+	// EventsJournal enables persisting events to disk, so that they can be
+	// replayed after a daemon restart and beyond the in-memory buffer.
+	EventsJournal bool `json:"events-journal,omitempty"`
+	// EventsJournalMaxSize is the maximum total size of the journal.
+	EventsJournalMaxSize opts.MemBytes `json:"events-journal-max-size,omitempty"`
+	// EventsJournalMaxAge is the maximum age of events kept in the journal,
+	// expressed as a duration (for example, "72h").
+	EventsJournalMaxAge string `json:"events-journal-max-age,omitempty"`
+}
+
 // CommonTLSOptions defines TLS configuration for the daemon server.
 // It includes json tags to deserialize configuration from a file
 // using the same names that the flags in the command line use.
@@ -190,6 +203,16 @@ type CommonConfig struct {
 	// may take place at a time for each push.
 	MaxDownloadAttempts int `json:"max-download-attempts,omitempty"`
 
+	// LayerCompression is the compression applied to the layers of images
+	// that are pushed: "gzip" (the default), "zstd", or
+	// "zstd:chunked".
+	LayerCompression string `json:"layer-compression,omitempty"`
+
+	// LazyPull mounts the layers of pulled images in the eStargz and
+	// zstd:chunked formats from the registry, fetching files on first
+	// access, and downloads them in the background.
+	LazyPull bool `json:"lazy-pull,omitempty"`
+
 	// ShutdownTimeout is the timeout value (in seconds) the daemon will wait for the container
 	// to stop when daemon is being shutdown
 	ShutdownTimeout int `json:"shutdown-timeout,omitempty"`
@@ -223,6 +246,7 @@ type CommonConfig struct {
 	MetricsAddress string `json:"metrics-addr"`
 
 	DNSConfig
+	EventsConfig
 	LogConfig
 	BridgeConfig // bridgeConfig holds bridge network specific configuration.
 	NetworkConfig
@@ -590,6 +614,16 @@ func Validate(config *Config) error {
 		}
 	}
 
+	// validate events journal
+	if config.EventsJournalMaxSize < 0 {
+		return fmt.Errorf("invalid events journal max size: %d", config.EventsJournalMaxSize)
+	}
+	if config.EventsJournalMaxAge != "" {
+		if d, err := time.ParseDuration(config.EventsJournalMaxAge); err != nil || d < 0 {
+			return fmt.Errorf("invalid events journal max age: %s", config.EventsJournalMaxAge)
+		}
+	}
+
 	// validate Labels
 	for _, label := range config.Labels {
 		if _, err := opts.ValidateLabel(label); err != nil {
@@ -610,6 +644,11 @@ func Validate(config *Config) error {
 	if config.MaxDownloadAttempts < 0 {
 		return fmt.Errorf("invalid max download attempts: %d", config.MaxDownloadAttempts)
 	}
+	switch config.LayerCompression {
+	case "", "gzip", "zstd", "zstd:chunked":
+	default:
+		return fmt.Errorf("invalid layer compression: %s", config.LayerCompression)
+	}
 
 	// validate that "default" runtime is not reset
 	if runtimes := config.GetAllRuntimes(); len(runtimes) > 0 {
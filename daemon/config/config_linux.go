@@ -50,6 +50,7 @@ type BridgeConfig struct {
 	EnableIPMasq        bool   `json:"ip-masq,omitempty"`
 	EnableUserlandProxy bool   `json:"userland-proxy,omitempty"`
 	UserlandProxyPath   string `json:"userland-proxy-path,omitempty"`
+	FirewallBackend     string `json:"firewall-backend,omitempty"`
 	FixedCIDRv6         string `json:"fixed-cidr-v6,omitempty"`
 }
 
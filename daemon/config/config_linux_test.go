@@ -188,3 +188,18 @@ func TestUnixGetInitPath(t *testing.T) {
 		assert.Equal(t, tc.config.GetInitPath(), tc.expectedInitPath)
 	}
 }
+
+func TestIsSwarmCompatible(t *testing.T) {
+	var conf Config
+	assert.Check(t, conf.IsSwarmCompatible())
+
+	conf.BridgeConfig.FirewallBackend = "iptables"
+	assert.Check(t, conf.IsSwarmCompatible())
+
+	conf.BridgeConfig.FirewallBackend = "nftables"
+	assert.Check(t, conf.IsSwarmCompatible())
+
+	conf.BridgeConfig.FirewallBackend = ""
+	conf.LiveRestoreEnabled = true
+	assert.Check(t, is.ErrorContains(conf.IsSwarmCompatible(), "--live-restore"))
+}
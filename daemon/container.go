@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	containertypes "github.com/docker/docker/api/types/container"
@@ -16,6 +17,7 @@ import (
 	"github.com/docker/docker/oci/caps"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/restartmanager"
 	"github.com/docker/docker/runconfig"
 	volumemounts "github.com/docker/docker/volume/mounts"
 	"github.com/docker/go-connections/nat"
@@ -292,6 +294,12 @@ func validateHostConfig(hostConfig *containertypes.HostConfig) error {
 	if err := validateRestartPolicy(hostConfig.RestartPolicy); err != nil {
 		return err
 	}
+	if err := validateUnhealthyPolicy(hostConfig.UnhealthyPolicy); err != nil {
+		return err
+	}
+	if hostConfig.AutoRemove && hostConfig.UnhealthyPolicy.Action == containertypes.UnhealthyActionRestart {
+		return errors.Errorf("can't create 'AutoRemove' container with unhealthy action '%s'", hostConfig.UnhealthyPolicy.Action)
+	}
 	if err := validateCapabilities(hostConfig); err != nil {
 		return err
 	}
@@ -317,17 +325,68 @@ func validateHealthCheck(healthConfig *containertypes.HealthConfig) error {
 	if healthConfig == nil {
 		return nil
 	}
+	if err := validateProbe(healthConfig, "Healthcheck"); err != nil {
+		return err
+	}
+	for name, probe := range map[string]*containertypes.HealthConfig{
+		"StartupProbe":   healthConfig.StartupProbe,
+		"ReadinessProbe": healthConfig.ReadinessProbe,
+	} {
+		if probe == nil {
+			continue
+		}
+		if probe.StartupProbe != nil || probe.ReadinessProbe != nil {
+			return errors.Errorf("%s in Healthcheck cannot have a StartupProbe or ReadinessProbe", name)
+		}
+		if len(probe.Test) == 0 || probe.Test[0] == "NONE" {
+			return errors.Errorf("%s in Healthcheck must have a test", name)
+		}
+		if err := validateProbe(probe, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateProbe(healthConfig *containertypes.HealthConfig, name string) error {
 	if healthConfig.Interval != 0 && healthConfig.Interval < containertypes.MinimumDuration {
-		return errors.Errorf("Interval in Healthcheck cannot be less than %s", containertypes.MinimumDuration)
+		return errors.Errorf("Interval in %s cannot be less than %s", name, containertypes.MinimumDuration)
 	}
 	if healthConfig.Timeout != 0 && healthConfig.Timeout < containertypes.MinimumDuration {
-		return errors.Errorf("Timeout in Healthcheck cannot be less than %s", containertypes.MinimumDuration)
+		return errors.Errorf("Timeout in %s cannot be less than %s", name, containertypes.MinimumDuration)
 	}
 	if healthConfig.Retries < 0 {
-		return errors.Errorf("Retries in Healthcheck cannot be negative")
+		return errors.Errorf("Retries in %s cannot be negative", name)
 	}
 	if healthConfig.StartPeriod != 0 && healthConfig.StartPeriod < containertypes.MinimumDuration {
-		return errors.Errorf("StartPeriod in Healthcheck cannot be less than %s", containertypes.MinimumDuration)
+		return errors.Errorf("StartPeriod in %s cannot be less than %s", name, containertypes.MinimumDuration)
+	}
+
+	var test string
+	if len(healthConfig.Test) > 0 {
+		test = healthConfig.Test[0]
+	}
+	if (test == "HTTP") != (healthConfig.HTTP != nil) {
+		return errors.Errorf("HTTP in %s must be set if and only if the test is HTTP", name)
+	}
+	if (test == "TCP") != (healthConfig.TCP != nil) {
+		return errors.Errorf("TCP in %s must be set if and only if the test is TCP", name)
+	}
+	if p := healthConfig.HTTP; p != nil {
+		if p.Port < 1 || p.Port > 65535 {
+			return errors.Errorf("invalid port in HTTP probe of %s: %d", name, p.Port)
+		}
+		if p.Path != "" && !strings.HasPrefix(p.Path, "/") {
+			return errors.Errorf("path in HTTP probe of %s must start with '/': %q", name, p.Path)
+		}
+		for _, s := range p.ExpectedStatus {
+			if s < 100 || s > 599 {
+				return errors.Errorf("invalid expected status in HTTP probe of %s: %d", name, s)
+			}
+		}
+	}
+	if p := healthConfig.TCP; p != nil && (p.Port < 1 || p.Port > 65535) {
+		return errors.Errorf("invalid port in TCP probe of %s: %d", name, p.Port)
 	}
 	return nil
 }
@@ -364,6 +423,46 @@ func validateRestartPolicy(policy containertypes.RestartPolicy) error {
 	default:
 		return errors.Errorf("invalid restart policy '%s'", policy.Name)
 	}
+	if policy.InitialDelay < 0 {
+		return errors.Errorf("restart initial delay cannot be negative")
+	}
+	if policy.MaxDelay < 0 {
+		return errors.Errorf("restart maximum delay cannot be negative")
+	}
+	maxDelay := policy.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = restartmanager.DefaultMaxDelay
+	}
+	if maxDelay < policy.InitialDelay {
+		return errors.Errorf("restart maximum delay (%s) cannot be less than the initial delay (%s)", maxDelay, policy.InitialDelay)
+	}
+	if policy.Multiplier != 0 && policy.Multiplier < 1 {
+		return errors.Errorf("restart delay multiplier must be at least 1")
+	}
+	if policy.ResetAfter < 0 {
+		return errors.Errorf("restart reset period cannot be negative")
+	}
+	if policy.MaximumRestarts < 0 {
+		return errors.Errorf("maximum restarts cannot be negative")
+	}
+	if policy.RestartWindow < 0 {
+		return errors.Errorf("restart window cannot be negative")
+	}
+	if (policy.MaximumRestarts == 0) != (policy.RestartWindow == 0) {
+		return errors.Errorf("maximum restarts and restart window must be used together")
+	}
+	return nil
+}
+
+func validateUnhealthyPolicy(policy containertypes.UnhealthyPolicy) error {
+	switch policy.Action {
+	case "", containertypes.UnhealthyActionNone, containertypes.UnhealthyActionRestart, containertypes.UnhealthyActionKill:
+	default:
+		return errors.Errorf("invalid unhealthy action '%s'", policy.Action)
+	}
+	if policy.Threshold < 0 {
+		return errors.Errorf("unhealthy threshold cannot be negative")
+	}
 	return nil
 }
 
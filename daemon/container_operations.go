@@ -3,6 +3,7 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"path"
@@ -65,6 +66,12 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container) ([]lib
 		return nil, err
 	}
 
+	// Containers are not ready until their readiness probe passes, which
+	// must hold from the moment they join their networks.
+	if hc := container.Config.Healthcheck; hc != nil && hc.ReadinessProbe != nil {
+		sboxOptions = append(sboxOptions, libnetwork.OptionNotReady())
+	}
+
 	if len(container.HostConfig.DNS) > 0 {
 		dns = container.HostConfig.DNS
 	} else if len(daemon.configStore.DNS) > 0 {
@@ -624,6 +631,9 @@ func validateNetworkingConfig(n libnetwork.Network, epConfig *networktypes.Endpo
 	if n == nil || epConfig == nil {
 		return nil
 	}
+	if err := validateEndpointBandwidth(n.Type(), epConfig.Bandwidth); err != nil {
+		return err
+	}
 	if !containertypes.NetworkMode(n.Name()).IsUserDefined() {
 		if hasUserDefinedIPAddress(epConfig.IPAMConfig) && !enableIPOnPredefinedNetwork() {
 			return runconfig.ErrUnsupportedNetworkAndIP
@@ -667,6 +677,36 @@ func validateNetworkingConfig(n libnetwork.Network, epConfig *networktypes.Endpo
 	return nil
 }
 
+// validateEndpointBandwidth returns an error if the bandwidth limits bw
+// cannot be applied to an endpoint of a network with the given driver.
+func validateEndpointBandwidth(networkType string, bw *networktypes.EndpointBandwidth) error {
+	if bw == nil {
+		return nil
+	}
+	if networkType != "bridge" {
+		return errdefs.InvalidParameter(fmt.Errorf("bandwidth limits are not supported by the %s network driver", networkType))
+	}
+	if (bw.IngressBurst > 0 && bw.IngressRate == 0) || (bw.EgressBurst > 0 && bw.EgressRate == 0) {
+		return errdefs.InvalidParameter(errors.New("a bandwidth burst cannot be set without a rate"))
+	}
+	// The traffic sent by the container is policed by a filter action, of
+	// which the rate is 32 bits.
+	if bw.EgressRate > math.MaxUint32 {
+		return errdefs.InvalidParameter(fmt.Errorf("egress rate %d exceeds the maximum of %d", bw.EgressRate, uint64(math.MaxUint32)))
+	}
+	return nil
+}
+
+// copyEndpointBandwidth returns a copy of the bandwidth limits bw, or nil if
+// it does not set any.
+func copyEndpointBandwidth(bw *networktypes.EndpointBandwidth) *networktypes.EndpointBandwidth {
+	if bw == nil || *bw == (networktypes.EndpointBandwidth{}) {
+		return nil
+	}
+	c := *bw
+	return &c
+}
+
 // cleanOperationalData resets the operational data from the passed endpoint settings
 func cleanOperationalData(es *network.EndpointSettings) {
 	es.EndpointID = ""
@@ -1065,7 +1105,14 @@ func (daemon *Daemon) ConnectToNetwork(container *container.Container, idOrName
 
 		n, err := daemon.FindNetwork(idOrName)
 		if err == nil && n != nil {
-			if err := daemon.updateNetworkConfig(container, n, endpointConfig, true); err != nil {
+			if epSettings, ok := container.NetworkSettings.Networks[n.Name()]; ok && endpointConfig.Bandwidth != nil {
+				// Update the bandwidth limits applied when the container
+				// is started.
+				if err := validateEndpointBandwidth(n.Type(), endpointConfig.Bandwidth); err != nil {
+					return err
+				}
+				epSettings.Bandwidth = copyEndpointBandwidth(endpointConfig.Bandwidth)
+			} else if err := daemon.updateNetworkConfig(container, n, endpointConfig, true); err != nil {
 				return err
 			}
 		} else {
@@ -1074,14 +1121,56 @@ func (daemon *Daemon) ConnectToNetwork(container *container.Container, idOrName
 			}
 		}
 	} else {
-		if err := daemon.connectToNetwork(container, idOrName, endpointConfig, true); err != nil {
+		updated, err := daemon.updateEndpointBandwidth(container, idOrName, endpointConfig)
+		if err != nil {
 			return err
 		}
+		if !updated {
+			if err := daemon.connectToNetwork(container, idOrName, endpointConfig, true); err != nil {
+				return err
+			}
+		}
 	}
 
 	return container.CheckpointTo(daemon.containersReplica)
 }
 
+// updateEndpointBandwidth applies the bandwidth limits of endpointConfig to
+// the endpoint of the running container on network idOrName. It returns
+// false if the container is not connected to the network, or endpointConfig
+// does not set bandwidth limits.
+func (daemon *Daemon) updateEndpointBandwidth(container *container.Container, idOrName string, endpointConfig *networktypes.EndpointSettings) (bool, error) {
+	if endpointConfig.Bandwidth == nil {
+		return false, nil
+	}
+	n, err := daemon.FindNetwork(idOrName)
+	if err != nil {
+		return false, nil
+	}
+	epSettings, ok := container.NetworkSettings.Networks[n.Name()]
+	if !ok || epSettings.EndpointID == "" {
+		return false, nil
+	}
+	if err := validateEndpointBandwidth(n.Type(), endpointConfig.Bandwidth); err != nil {
+		return true, err
+	}
+
+	ep, err := n.EndpointByID(epSettings.EndpointID)
+	if err != nil {
+		return true, err
+	}
+	if err := ep.UpdateBandwidth(endpointBandwidth(endpointConfig.Bandwidth)); err != nil {
+		if _, ok := err.(types.BadRequestError); ok {
+			return true, errdefs.InvalidParameter(err)
+		}
+		return true, err
+	}
+	epSettings.Bandwidth = copyEndpointBandwidth(endpointConfig.Bandwidth)
+
+	daemon.LogNetworkEventWithAttributes(n, "update", map[string]string{"container": container.ID})
+	return true, nil
+}
+
 // DisconnectFromNetwork disconnects container from network n.
 func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, networkName string, force bool) error {
 	n, err := daemon.FindNetwork(networkName)
@@ -0,0 +1,48 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"math"
+	"testing"
+
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestValidateEndpointBandwidth(t *testing.T) {
+	assert.Check(t, validateEndpointBandwidth("overlay", nil))
+	assert.Check(t, validateEndpointBandwidth("bridge", &networktypes.EndpointBandwidth{IngressRate: math.MaxUint64, EgressRate: math.MaxUint32}))
+
+	for _, tc := range []struct {
+		doc         string
+		networkType string
+		bw          networktypes.EndpointBandwidth
+		expectedErr string
+	}{
+		{
+			doc:         "unsupported driver",
+			networkType: "overlay",
+			bw:          networktypes.EndpointBandwidth{EgressRate: 1000},
+			expectedErr: "bandwidth limits are not supported by the overlay network driver",
+		},
+		{
+			doc:         "burst without rate",
+			networkType: "bridge",
+			bw:          networktypes.EndpointBandwidth{IngressBurst: 1000},
+			expectedErr: "a bandwidth burst cannot be set without a rate",
+		},
+		{
+			doc:         "egress rate above 32 bits",
+			networkType: "bridge",
+			bw:          networktypes.EndpointBandwidth{EgressRate: math.MaxUint32 + 1},
+			expectedErr: "egress rate 4294967296 exceeds the maximum of 4294967295",
+		},
+	} {
+		t.Run(tc.doc, func(t *testing.T) {
+			err := validateEndpointBandwidth(tc.networkType, &tc.bw)
+			assert.Check(t, is.Error(err, tc.expectedErr))
+			assert.Check(t, errdefs.IsInvalidParameter(err))
+		})
+	}
+}
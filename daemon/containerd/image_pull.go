@@ -45,6 +45,16 @@ func (i *ImageService) PullImage(ctx context.Context, image, tagOrDigest string,
 	resolver := newResolverFromAuthConfig(authConfig)
 	opts = append(opts, containerd.WithResolver(resolver))
 
+	if i.usesLazyPull() {
+		// Layers are mounted from the registry when unpacked, instead of
+		// being fetched and extracted.
+		opts = append(opts,
+			containerd.WithImageHandlerWrapper(appendLazyPullLabels(ref.String(), lazyPrefetchSize)),
+			containerd.WithPullUnpack,
+			containerd.WithPullSnapshotter(i.snapshotter),
+		)
+	}
+
 	_, err = i.client.Pull(ctx, ref.String(), opts...)
 	return err
 }
@@ -0,0 +1,91 @@
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/labels"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// lazySnapshotter is the name of the snapshotter which mounts layers lazily
+// from the registry, fetching the content of files on first access, and the
+// remaining content in the background.
+const lazySnapshotter = "stargz"
+
+// Labels passed to the lazy snapshotter when preparing a snapshot for a
+// layer, locating the layer in the registry. Labels prefixed with
+// "containerd.io/snapshot/" are inherited by the snapshot from the
+// annotations of the layer descriptor.
+const (
+	lazyRefLabel          = "containerd.io/snapshot/remote/stargz.reference"
+	lazyDigestLabel       = "containerd.io/snapshot/remote/stargz.digest"
+	lazyImageLayersLabel  = "containerd.io/snapshot/remote/stargz.layers"
+	lazyPrefetchSizeLabel = "containerd.io/snapshot/remote/stargz.prefetch"
+
+	// lazyPrefetchSize is the size of the content fetched for each layer
+	// before it is mounted, in addition to the table of contents. Layers
+	// built with a prioritized files landmark prefetch those files instead.
+	lazyPrefetchSize = 10 * 1024 * 1024
+)
+
+// usesLazyPull returns whether images are pulled lazily, which is the case
+// when lazy pull is enabled and the daemon uses the lazy snapshotter.
+func (i *ImageService) usesLazyPull() bool {
+	return i.lazyPull && i.snapshotter == lazySnapshotter
+}
+
+// appendLazyPullLabels returns a handler wrapper which annotates the layers
+// of the manifests pulled for ref with the labels the lazy snapshotter uses
+// to fetch them from the registry. Layers which are not in the eStargz or
+// zstd:chunked formats are fetched and extracted by the snapshotter as usual.
+func appendLazyPullLabels(ref string, prefetchSize int64) func(images.Handler) images.Handler {
+	return func(f images.Handler) images.Handler {
+		return images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+			children, err := f.Handle(ctx, desc)
+			if err != nil {
+				return nil, err
+			}
+			switch desc.MediaType {
+			case ocispec.MediaTypeImageManifest, images.MediaTypeDockerSchema2Manifest:
+			default:
+				return children, nil
+			}
+			for i := range children {
+				c := &children[i]
+				if !images.IsLayerType(c.MediaType) {
+					continue
+				}
+				if c.Annotations == nil {
+					c.Annotations = make(map[string]string)
+				}
+				c.Annotations[lazyRefLabel] = ref
+				c.Annotations[lazyDigestLabel] = c.Digest.String()
+				c.Annotations[lazyImageLayersLabel] = lazyImageLayers(children[i:])
+				c.Annotations[lazyPrefetchSizeLabel] = fmt.Sprintf("%d", prefetchSize)
+			}
+			return children, nil
+		})
+	}
+}
+
+// lazyImageLayers returns the digests of the layers in descs, so that the
+// snapshotter can prepare the layers above the one being mounted. Layers are
+// skipped once the label would exceed its maximum size, which only affects
+// performance.
+func lazyImageLayers(descs []ocispec.Descriptor) string {
+	var layers string
+	for _, l := range descs {
+		if !images.IsLayerType(l.MediaType) {
+			continue
+		}
+		ls := l.Digest.String() + ","
+		if err := labels.Validate(lazyImageLayersLabel, layers+ls); err != nil {
+			break
+		}
+		layers += ls
+	}
+	return strings.TrimSuffix(layers, ",")
+}
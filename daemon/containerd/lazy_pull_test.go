@@ -0,0 +1,120 @@
+package containerd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestAppendLazyPullLabels(t *testing.T) {
+	layers := []ocispec.Descriptor{
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer1"), Size: 6},
+		{MediaType: ocispec.MediaTypeImageLayerZstd, Digest: digest.FromString("layer2"), Size: 6},
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig, Digest: digest.FromString("{}"), Size: 2},
+		Layers:    layers,
+	})
+	assert.NilError(t, err)
+	manifestDigest := digest.FromBytes(manifest)
+
+	// a stand-in registry serving the manifest of the image.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+		case "/v2/test/manifests/latest", "/v2/test/manifests/" + manifestDigest.String():
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(manifest)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	resolver := docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithPlainHTTP(docker.MatchAllHosts)),
+	})
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/test:latest"
+	name, desc, err := resolver.Resolve(ctx, ref)
+	assert.NilError(t, err)
+	fetcher, err := resolver.Fetcher(ctx, name)
+	assert.NilError(t, err)
+
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		rc, err := fetcher.Fetch(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		var m ocispec.Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		return append([]ocispec.Descriptor{m.Config}, m.Layers...), nil
+	})
+
+	children, err := appendLazyPullLabels(name, lazyPrefetchSize)(handler).Handle(ctx, desc)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(children, 3))
+
+	// the config is not a layer, and is not annotated.
+	assert.Check(t, is.Len(children[0].Annotations, 0))
+
+	for i, c := range children[1:] {
+		assert.Check(t, is.Equal(c.Annotations[lazyRefLabel], name))
+		assert.Check(t, is.Equal(c.Annotations[lazyDigestLabel], layers[i].Digest.String()))
+		assert.Check(t, is.Equal(c.Annotations[lazyPrefetchSizeLabel], "10485760"))
+	}
+	// each layer lists itself and the layers above it.
+	assert.Check(t, is.Equal(children[1].Annotations[lazyImageLayersLabel], layers[0].Digest.String()+","+layers[1].Digest.String()))
+	assert.Check(t, is.Equal(children[2].Annotations[lazyImageLayersLabel], layers[1].Digest.String()))
+}
+
+func TestLazyImageLayersLimit(t *testing.T) {
+	var descs []ocispec.Descriptor
+	for i := 0; i < 100; i++ {
+		descs = append(descs, ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString(strconv.Itoa(i))})
+	}
+	layers := lazyImageLayers(descs)
+	assert.Check(t, len(lazyImageLayersLabel)+len(layers) <= 4096)
+	assert.Check(t, strings.HasPrefix(layers, descs[0].Digest.String()+","))
+	assert.Check(t, !strings.HasSuffix(layers, ","))
+}
+
+func TestUsesLazyPull(t *testing.T) {
+	for _, tc := range []struct {
+		snapshotter string
+		lazyPull    bool
+		expected    bool
+	}{
+		{snapshotter: "overlayfs", lazyPull: false, expected: false},
+		{snapshotter: "overlayfs", lazyPull: true, expected: false},
+		{snapshotter: lazySnapshotter, lazyPull: false, expected: false},
+		{snapshotter: lazySnapshotter, lazyPull: true, expected: true},
+	} {
+		i := &ImageService{snapshotter: tc.snapshotter, lazyPull: tc.lazyPull}
+		assert.Check(t, is.Equal(i.usesLazyPull(), tc.expected), "snapshotter %s, lazy pull %t", tc.snapshotter, tc.lazyPull)
+	}
+}
@@ -18,13 +18,16 @@ import (
 type ImageService struct {
 	client      *containerd.Client
 	snapshotter string
+	lazyPull    bool
 }
 
-// NewService creates a new ImageService.
-func NewService(c *containerd.Client, snapshotter string) *ImageService {
+// NewService creates a new ImageService. If lazyPull is set and snapshotter
+// is the lazy snapshotter, layers are mounted from the registry on pull.
+func NewService(c *containerd.Client, snapshotter string, lazyPull bool) *ImageService {
 	return &ImageService{
 		client:      c,
 		snapshotter: snapshotter,
+		lazyPull:    lazyPull,
 	}
 }
 
@@ -71,7 +71,7 @@ func (daemon *Daemon) createContainerOSSpecificSettings(container *container.Con
 			return err
 		}
 
-		container.AddMountPointWithVolume(destination, &volumeWrapper{v: v, s: daemon.volumes}, true)
+		container.AddMountPointWithVolume(destination, &volumeWrapper{v: v, s: daemon.volumes, container: container.ID}, true)
 	}
 	return daemon.populateVolumes(container)
 }
@@ -44,6 +44,7 @@ import (
 	"github.com/docker/docker/libnetwork"
 	"github.com/docker/docker/libnetwork/cluster"
 	nwconfig "github.com/docker/docker/libnetwork/config"
+	"github.com/docker/docker/libnetwork/ipamutils"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/plugingetter"
@@ -402,6 +403,10 @@ func (daemon *Daemon) restore() error {
 					logger(c).Debug("set stopped state")
 				}
 
+				if alive {
+					daemon.restoreVolumeHolders(c)
+				}
+
 				// we call Mount and then Unmount to get BaseFs of the container
 				if err := daemon.Mount(c); err != nil {
 					// The mount is unlikely to fail. However, in case mount fails
@@ -925,7 +930,7 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		return nil, err
 	}
 
-	d.volumes, err = volumesservice.NewVolumeService(config.Root, d.PluginStore, rootIDs, d)
+	d.volumes, err = volumesservice.NewVolumeService(config.Root, d.PluginStore, idMapping, d)
 	if err != nil {
 		return nil, err
 	}
@@ -962,7 +967,21 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	d.execCommands = container.NewExecStore()
 	d.statsCollector = d.newStatsCollector(1 * time.Second)
 
-	d.EventsService = events.New()
+	if config.EventsJournal {
+		// EventsJournalMaxAge was validated when loading the configuration;
+		// an empty value uses the default.
+		maxAge, _ := time.ParseDuration(config.EventsJournalMaxAge)
+		d.EventsService, err = events.NewWithJournal(events.JournalConfig{
+			Dir:     filepath.Join(config.Root, "events"),
+			MaxSize: config.EventsJournalMaxSize.Value(),
+			MaxAge:  maxAge,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		d.EventsService = events.New()
+	}
 	d.root = config.Root
 	d.idMapping = idMapping
 
@@ -992,7 +1011,7 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		if err := configureKernelSecuritySupport(config, driverName); err != nil {
 			return nil, err
 		}
-		d.imageService = ctrd.NewService(d.containerdCli, driverName)
+		d.imageService = ctrd.NewService(d.containerdCli, driverName, config.LazyPull)
 	} else {
 		layerStore, err := layer.NewStoreFromOptions(layer.StoreOptions{
 			Root:                      config.Root,
@@ -1055,6 +1074,8 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 			MaxConcurrentDownloads:    config.MaxConcurrentDownloads,
 			MaxConcurrentUploads:      config.MaxConcurrentUploads,
 			MaxDownloadAttempts:       config.MaxDownloadAttempts,
+			LayerCompression:          config.LayerCompression,
+			LazyPull:                  config.LazyPull,
 			ReferenceStore:            rs,
 			RegistryService:           registryService,
 			ContentNamespace:          config.ContainerdNamespace,
@@ -1258,6 +1279,12 @@ func (daemon *Daemon) Shutdown() error {
 		daemon.mdDB.Close()
 	}
 
+	if daemon.EventsService != nil {
+		if err := daemon.EventsService.Close(); err != nil {
+			logrus.WithError(err).Warn("error closing events journal")
+		}
+	}
+
 	return daemon.cleanupMounts()
 }
 
@@ -1386,9 +1413,7 @@ func (daemon *Daemon) networkOptions(pg plugingetter.PluginGetter, activeSandbox
 		driverOptions(conf),
 	}
 
-	if len(conf.NetworkConfig.DefaultAddressPools.Value()) > 0 {
-		options = append(options, nwconfig.OptionDefaultAddressPoolConfig(conf.NetworkConfig.DefaultAddressPools.Value()))
-	}
+	options = append(options, nwconfig.OptionDefaultAddressPoolConfig(daemon.defaultAddressPools()))
 	if conf.LiveRestoreEnabled && len(activeSandboxes) != 0 {
 		options = append(options, nwconfig.OptionActiveSandboxes(activeSandboxes))
 	}
@@ -1399,6 +1424,23 @@ func (daemon *Daemon) networkOptions(pg plugingetter.PluginGetter, activeSandbox
 	return options, nil
 }
 
+// defaultAddressPools returns the pools the subnets of the local networks
+// are allocated from. Unless IPv6 pools are configured, such as a prefix
+// delegated to the host, the IPv6 subnets are allocated from the unique
+// local prefix of the host.
+func (daemon *Daemon) defaultAddressPools() []*ipamutils.NetworkToSplit {
+	pools := daemon.configStore.NetworkConfig.DefaultAddressPools.Value()
+	if len(pools) == 0 {
+		pools = ipamutils.LocalScopeDefaultPools()
+	}
+	for _, p := range pools {
+		if ip, _, err := net.ParseCIDR(p.Base); err == nil && ip.To4() == nil {
+			return pools
+		}
+	}
+	return append(pools[:len(pools):len(pools)], ipamutils.ULAPool(daemon.id))
+}
+
 // GetCluster returns the cluster
 func (daemon *Daemon) GetCluster() Cluster {
 	return daemon.cluster
@@ -8,8 +8,10 @@ import (
 
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/libnetwork"
+	"github.com/docker/docker/libnetwork/ipamutils"
 	"github.com/docker/docker/pkg/idtools"
 	volumesservice "github.com/docker/docker/volume/service"
 	"github.com/docker/go-connections/nat"
@@ -113,7 +115,7 @@ func initDaemonWithVolumeStore(tmp string) (*Daemon, error) {
 		repository: tmp,
 		root:       tmp,
 	}
-	daemon.volumes, err = volumesservice.NewVolumeService(tmp, nil, idtools.Identity{UID: 0, GID: 0}, daemon)
+	daemon.volumes, err = volumesservice.NewVolumeService(tmp, nil, idtools.IdentityMapping{}, daemon)
 	if err != nil {
 		return nil, err
 	}
@@ -313,3 +315,21 @@ func TestFindNetworkErrorType(t *testing.T) {
 		t.Error("The FindNetwork method MUST always return an error that implements the NotFound interface and is ErrNoSuchNetwork")
 	}
 }
+
+func TestDefaultAddressPools(t *testing.T) {
+	d := &Daemon{id: "engine", configStore: &config.Config{}}
+
+	// The IPv6 subnets are allocated from the unique local prefix of the
+	// host, along with the default IPv4 pools.
+	pools := d.defaultAddressPools()
+	assert.Check(t, is.DeepEqual(pools, append(ipamutils.LocalScopeDefaultPools(), ipamutils.ULAPool("engine"))))
+
+	assert.NilError(t, d.configStore.NetworkConfig.DefaultAddressPools.Set("base=10.10.0.0/16,size=24"))
+	pools = d.defaultAddressPools()
+	assert.Check(t, is.DeepEqual(pools, []*ipamutils.NetworkToSplit{{Base: "10.10.0.0/16", Size: 24}, ipamutils.ULAPool("engine")}))
+
+	// A configured IPv6 pool replaces the unique local prefix.
+	assert.NilError(t, d.configStore.NetworkConfig.DefaultAddressPools.Set("base=2001:db8:1::/56,size=64"))
+	pools = d.defaultAddressPools()
+	assert.Check(t, is.DeepEqual(pools, []*ipamutils.NetworkToSplit{{Base: "10.10.0.0/16", Size: 24}, {Base: "2001:db8:1::/56", Size: 64}}))
+}
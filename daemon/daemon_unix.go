@@ -743,6 +743,11 @@ func verifyDaemonSettings(conf *config.Config) error {
 	if conf.BridgeConfig.EnableIP6Tables && !conf.Experimental {
 		return fmt.Errorf("ip6tables rules are only available if experimental features are enabled")
 	}
+	switch conf.BridgeConfig.FirewallBackend {
+	case "", "iptables", "nftables":
+	default:
+		return fmt.Errorf("invalid firewall backend %q: use \"iptables\" or \"nftables\"", conf.BridgeConfig.FirewallBackend)
+	}
 	if !conf.BridgeConfig.EnableIPTables && conf.BridgeConfig.EnableIPMasq {
 		conf.BridgeConfig.EnableIPMasq = false
 	}
@@ -918,15 +923,24 @@ func setHostGatewayIP(controller libnetwork.NetworkController, config *config.Co
 }
 
 func driverOptions(config *config.Config) nwconfig.Option {
-	return nwconfig.OptionDriverConfig("bridge", options.Generic{
+	bridgeConfig := nwconfig.OptionDriverConfig("bridge", options.Generic{
 		netlabel.GenericData: options.Generic{
 			"EnableIPForwarding":  config.BridgeConfig.EnableIPForward,
 			"EnableIPTables":      config.BridgeConfig.EnableIPTables,
 			"EnableIP6Tables":     config.BridgeConfig.EnableIP6Tables,
 			"EnableUserlandProxy": config.BridgeConfig.EnableUserlandProxy,
 			"UserlandProxyPath":   config.BridgeConfig.UserlandProxyPath,
+			"FirewallBackend":     config.BridgeConfig.FirewallBackend,
 		},
 	})
+	// The overlay driver programs the host with the same firewall backend.
+	overlayConfig := nwconfig.OptionDriverConfig("overlay", options.Generic{
+		netlabel.OverlayFirewallBackend: config.BridgeConfig.FirewallBackend,
+	})
+	return func(c *nwconfig.Config) {
+		bridgeConfig(c)
+		overlayConfig(c)
+	}
 }
 
 func initBridgeDriver(controller libnetwork.NetworkController, config *config.Config) error {
@@ -6,6 +6,7 @@ import (
 
 	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/moby/pubsub"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -15,9 +16,10 @@ const (
 
 // Events is pubsub channel for events generated by the engine.
 type Events struct {
-	mu     sync.Mutex
-	events []eventtypes.Message
-	pub    *pubsub.Publisher
+	mu      sync.Mutex
+	events  []eventtypes.Message
+	pub     *pubsub.Publisher
+	journal *journal
 }
 
 // New returns new *Events instance
@@ -28,6 +30,33 @@ func New() *Events {
 	}
 }
 
+// NewWithJournal returns new *Events instance which persists events to an
+// on-disk journal. Events are replayed from the journal instead of the
+// in-memory buffer, so that they survive daemon restarts and are not limited
+// to the last 256 events.
+func NewWithJournal(cfg JournalConfig) (*Events, error) {
+	j, err := openJournal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	e := New()
+	e.journal = j
+	return e, nil
+}
+
+// Close closes the events journal, if any. Events published after Close
+// are no longer persisted.
+func (e *Events) Close() error {
+	e.mu.Lock()
+	j := e.journal
+	e.journal = nil
+	e.mu.Unlock()
+	if j == nil {
+		return nil
+	}
+	return j.close()
+}
+
 // Subscribe adds new listener to events, returns slice of 256 stored
 // last events, a channel in which you can expect new events (in form
 // of interface{}, so you need type assertion), and a function to call
@@ -58,7 +87,18 @@ func (e *Events) SubscribeTopic(since, until time.Time, ef *Filter) ([]eventtype
 		topic = func(m interface{}) bool { return ef.Include(m.(eventtypes.Message)) }
 	}
 
-	buffered := e.loadBufferedEvents(since, until, topic)
+	var (
+		buffered []eventtypes.Message
+		j        = e.journal
+		pos      journalPosition
+	)
+	if j != nil {
+		// Remember where the journal ends; events published after this
+		// point are delivered through the subscription.
+		pos = j.position()
+	} else {
+		buffered = e.loadBufferedEvents(since, until, topic)
+	}
 
 	var ch chan interface{}
 	if topic != nil {
@@ -69,6 +109,14 @@ func (e *Events) SubscribeTopic(since, until time.Time, ef *Filter) ([]eventtype
 	}
 
 	e.mu.Unlock()
+
+	if j != nil && (!since.IsZero() || !until.IsZero()) {
+		var err error
+		buffered, err = j.read(since, until, topic, pos)
+		if err != nil {
+			logrus.WithError(err).Warn("failed to read events from the events journal")
+		}
+	}
 	return buffered, ch
 }
 
@@ -117,6 +165,11 @@ func (e *Events) PublishMessage(jm eventtypes.Message) {
 	} else {
 		e.events = append(e.events, jm)
 	}
+	if e.journal != nil {
+		if err := e.journal.write(jm); err != nil {
+			logrus.WithError(err).Warn("failed to persist event to the events journal")
+		}
+	}
 	e.mu.Unlock()
 	e.pub.Publish(jm)
 }
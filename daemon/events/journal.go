@@ -0,0 +1,302 @@
+package events // import "github.com/docker/docker/daemon/events"
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultJournalMaxSize is the default maximum size of the events journal.
+	DefaultJournalMaxSize = 100 * 1024 * 1024
+	// DefaultJournalMaxAge is the default maximum age of events in the journal.
+	DefaultJournalMaxAge = 7 * 24 * time.Hour
+
+	// journalSegments is the number of segments the journal's size budget is
+	// divided in. Retention is enforced by removing whole segments.
+	journalSegments    = 8
+	journalSegmentExt  = ".log"
+	journalPrunePeriod = time.Minute
+)
+
+// JournalConfig holds the configuration of the on-disk events journal.
+type JournalConfig struct {
+	// Dir is the directory the journal segments are stored in.
+	Dir string
+	// MaxSize is the maximum total size (in bytes) of the journal.
+	MaxSize int64
+	// MaxAge is the maximum age of events kept in the journal.
+	MaxAge time.Duration
+}
+
+// journalPosition is the end of the journal at a given point in time. It is
+// used to replay events up to the moment a subscriber was added.
+type journalPosition struct {
+	segment int64
+	offset  int64
+}
+
+// journal stores events on disk as newline-delimited JSON, spread over
+// segments named after the TimeNano of their first event.
+type journal struct {
+	mu          sync.Mutex
+	dir         string
+	maxSize     int64
+	maxAge      time.Duration
+	segmentSize int64
+
+	segments  []int64 // sorted start times of all segments, including the current one
+	sizes     map[int64]int64
+	f         *os.File
+	lastPrune time.Time
+}
+
+func openJournal(cfg JournalConfig) (*journal, error) {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultJournalMaxSize
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultJournalMaxAge
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "failed to create events journal directory")
+	}
+	j := &journal{
+		dir:         cfg.Dir,
+		maxSize:     cfg.MaxSize,
+		maxAge:      cfg.MaxAge,
+		segmentSize: cfg.MaxSize / journalSegments,
+		sizes:       make(map[int64]int64),
+	}
+
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read events journal directory")
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, journalSegmentExt) {
+			continue
+		}
+		start, err := strconv.ParseInt(strings.TrimSuffix(name, journalSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		j.segments = append(j.segments, start)
+		j.sizes[start] = info.Size()
+	}
+	sort.Slice(j.segments, func(a, b int) bool { return j.segments[a] < j.segments[b] })
+
+	if n := len(j.segments); n > 0 {
+		last := j.segments[n-1]
+		j.f, err = os.OpenFile(j.segmentPath(last), os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open events journal")
+		}
+	}
+	j.prune(time.Now())
+	return j, nil
+}
+
+func (j *journal) segmentPath(start int64) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%020d%s", start, journalSegmentExt))
+}
+
+// write appends an event to the journal, starting a new segment if the
+// current one is full.
+func (j *journal) write(ev eventtypes.Message) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	n := len(j.segments)
+	if j.f == nil || j.sizes[j.segments[n-1]]+int64(len(b)) > j.segmentSize {
+		if err := j.rotate(ev.TimeNano); err != nil {
+			return err
+		}
+		n = len(j.segments)
+	}
+	written, err := j.f.Write(b)
+	j.sizes[j.segments[n-1]] += int64(written)
+	if err != nil {
+		return errors.Wrap(err, "failed to write to events journal")
+	}
+
+	if now := time.Now(); now.Sub(j.lastPrune) >= journalPrunePeriod {
+		j.prune(now)
+	}
+	return nil
+}
+
+// rotate closes the current segment and starts a new one. It must be called
+// with j.mu held.
+func (j *journal) rotate(start int64) error {
+	if n := len(j.segments); n > 0 && start <= j.segments[n-1] {
+		// keep segment names unique and ordered, even if the clock went back.
+		start = j.segments[n-1] + 1
+	}
+	f, err := os.OpenFile(j.segmentPath(start), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create events journal segment")
+	}
+	if j.f != nil {
+		j.f.Close()
+	}
+	j.f = f
+	j.segments = append(j.segments, start)
+	j.sizes[start] = 0
+	j.prune(time.Now())
+	return nil
+}
+
+// prune removes the oldest segments until the journal is within its size
+// budget, as well as segments that only contain events older than maxAge.
+// The current segment is never removed. It must be called with j.mu held.
+func (j *journal) prune(now time.Time) {
+	j.lastPrune = now
+
+	var total int64
+	for _, s := range j.sizes {
+		total += s
+	}
+	cutoff := now.Add(-j.maxAge).UnixNano()
+	for len(j.segments) > 1 {
+		oldest := j.segments[0]
+		// the next segment starts after the last event of the oldest one.
+		if total <= j.maxSize && j.segments[1] > cutoff {
+			break
+		}
+		if err := os.Remove(j.segmentPath(oldest)); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).Warn("failed to remove events journal segment")
+			break
+		}
+		total -= j.sizes[oldest]
+		delete(j.sizes, oldest)
+		j.segments = j.segments[1:]
+	}
+}
+
+// position returns the current end of the journal.
+func (j *journal) position() journalPosition {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := len(j.segments)
+	if n == 0 {
+		return journalPosition{}
+	}
+	last := j.segments[n-1]
+	return journalPosition{segment: last, offset: j.sizes[last]}
+}
+
+// read returns the events in the journal up to pos that were emitted between
+// since and until, filtered by topic if it is not nil.
+func (j *journal) read(since, until time.Time, topic func(interface{}) bool, pos journalPosition) ([]eventtypes.Message, error) {
+	var sinceNanoUnix, untilNanoUnix int64
+	if !since.IsZero() {
+		sinceNanoUnix = since.UnixNano()
+	}
+	if !until.IsZero() {
+		untilNanoUnix = until.UnixNano()
+	}
+
+	j.mu.Lock()
+	segments := make([]int64, 0, len(j.segments))
+	for i, s := range j.segments {
+		if s > pos.segment {
+			break
+		}
+		// skip segments which ended before since.
+		if i+1 < len(j.segments) && j.segments[i+1] <= sinceNanoUnix {
+			continue
+		}
+		segments = append(segments, s)
+	}
+	j.mu.Unlock()
+
+	var out []eventtypes.Message
+	for _, s := range segments {
+		if untilNanoUnix > 0 && s > untilNanoUnix {
+			break
+		}
+		limit := int64(-1)
+		if s == pos.segment {
+			limit = pos.offset
+		}
+		var err error
+		out, err = j.readSegment(out, s, limit, sinceNanoUnix, untilNanoUnix, topic)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func (j *journal) readSegment(out []eventtypes.Message, start, limit, since, until int64, topic func(interface{}) bool) ([]eventtypes.Message, error) {
+	f, err := os.Open(j.segmentPath(start))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// removed by retention while we were reading.
+			return out, nil
+		}
+		return out, errors.Wrap(err, "failed to open events journal segment")
+	}
+	defer f.Close()
+
+	var offset int64
+	r := bufio.NewReader(f)
+	for limit < 0 || offset < limit {
+		line, err := r.ReadBytes('\n')
+		offset += int64(len(line))
+		if err != nil {
+			// an incomplete trailing line is the result of an interrupted
+			// write; there is nothing more to read.
+			break
+		}
+		var ev eventtypes.Message
+		if err := json.Unmarshal(line, &ev); err != nil {
+			logrus.WithError(err).WithField("segment", start).Debug("skipping malformed events journal entry")
+			continue
+		}
+		if ev.TimeNano < since {
+			continue
+		}
+		if until > 0 && ev.TimeNano > until {
+			break
+		}
+		if topic == nil || topic(ev) {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (j *journal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
@@ -0,0 +1,79 @@
+package events // import "github.com/docker/docker/daemon/events"
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestJournalReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewWithJournal(JournalConfig{Dir: dir})
+	assert.NilError(t, err)
+
+	start := time.Now()
+	for i := 0; i < eventsLimit+10; i++ {
+		e.Log("start", events.ContainerEventType, events.Actor{ID: fmt.Sprintf("cont%d", i)})
+	}
+	e.Log("create", events.VolumeEventType, events.Actor{ID: "vol"})
+	assert.NilError(t, e.Close())
+
+	// events are kept beyond the in-memory limit, and survive a restart.
+	e, err = NewWithJournal(JournalConfig{Dir: dir})
+	assert.NilError(t, err)
+	defer e.Close()
+
+	buffered, l := e.SubscribeTopic(start, time.Time{}, nil)
+	defer e.Evict(l)
+	assert.Check(t, is.Len(buffered, eventsLimit+11))
+	assert.Check(t, is.Equal(buffered[0].Actor.ID, "cont0"))
+
+	ef := NewFilter(filters.NewArgs(filters.Arg("type", "volume")))
+	buffered, l2 := e.SubscribeTopic(start, time.Time{}, ef)
+	defer e.Evict(l2)
+	assert.Assert(t, is.Len(buffered, 1))
+	assert.Check(t, is.Equal(buffered[0].Actor.ID, "vol"))
+}
+
+func TestJournalRetention(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(JournalConfig{Dir: dir, MaxSize: 8 * 1024})
+	assert.NilError(t, err)
+	defer j.close()
+
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		assert.NilError(t, j.write(events.Message{
+			Action:   "start",
+			Type:     events.ContainerEventType,
+			Actor:    events.Actor{ID: fmt.Sprintf("cont%d", i)},
+			TimeNano: now.UnixNano() + int64(i),
+		}))
+	}
+
+	var total int64
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		assert.NilError(t, err)
+		total += info.Size()
+	}
+	assert.Check(t, total <= 8*1024, "journal exceeds its size budget: %d bytes", total)
+
+	msgs, err := j.read(now, time.Time{}, nil, j.position())
+	assert.NilError(t, err)
+	assert.Assert(t, len(msgs) > 0)
+	assert.Check(t, is.Equal(msgs[len(msgs)-1].Actor.ID, "cont999"))
+
+	// segments that only hold expired events are removed.
+	j.maxAge = time.Nanosecond
+	j.prune(now.Add(time.Hour))
+	assert.Check(t, is.Len(j.segments, 1))
+}
@@ -129,6 +129,23 @@ type DiffGetterDriver interface {
 	DiffGetter(id string) (FileGetCloser, error)
 }
 
+// LazyMountFunc mounts the content of a lazily fetched layer at target, and
+// returns the function unmounting it.
+type LazyMountFunc func(target string) (unmount func() error, err error)
+
+// LazyDiffDriver is the interface for layered file system drivers that can
+// use a layer whose content is mounted lazily, until it has been fetched.
+type LazyDiffDriver interface {
+	Driver
+	// ApplyLazyDiff sets up the layer with id on top of parent, with the
+	// content mounted by mount. It returns ErrNotSupported if the driver
+	// is not configured in a way it can use lazy content.
+	ApplyLazyDiff(id, parent string, mount LazyMountFunc) error
+	// BackfillDiff replaces the lazily mounted content of the layer with id
+	// by the extracted diff, and returns the size of the layer.
+	BackfillDiff(id, parent string, diff io.Reader) (int64, error)
+}
+
 // FileGetCloser extends the storage.FileGetter interface with a Close method
 // for cleaning up.
 type FileGetCloser interface {
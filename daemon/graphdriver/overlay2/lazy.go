@@ -0,0 +1,102 @@
+//go:build linux
+// +build linux
+
+package overlay2 // import "github.com/docker/docker/daemon/graphdriver/overlay2"
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const backfillDirName = "diff-backfill"
+
+// ApplyLazyDiff mounts the lazily fetched content of the layer on its diff
+// directory, which is then used as a lower directory like any other.
+func (d *Driver) ApplyLazyDiff(id, parent string, mount graphdriver.LazyMountFunc) error {
+	// The lazy content is served as is: it can neither be applied through
+	// a naive diff, nor be remapped, nor carry the user namespace variant
+	// of the overlay extended attributes.
+	if useNaiveDiff(d.home) || !d.isParent(id, parent) || userxattr != "" || !d.idMap.Empty() {
+		return graphdriver.ErrNotSupported
+	}
+
+	d.locker.Lock(id)
+	defer d.locker.Unlock(id)
+
+	unmount, err := mount(d.getDiffPath(id))
+	if err != nil {
+		return errors.Wrapf(err, "failed to mount lazy content of layer %s", id)
+	}
+
+	d.lazyMu.Lock()
+	if d.lazy == nil {
+		d.lazy = make(map[string]func() error)
+	}
+	d.lazy[id] = unmount
+	d.lazyMu.Unlock()
+	return nil
+}
+
+// BackfillDiff extracts diff next to the lazily mounted content of the layer,
+// and then replaces that content by it.
+func (d *Driver) BackfillDiff(id, parent string, diff io.Reader) (int64, error) {
+	backfillDir := path.Join(d.dir(id), backfillDirName)
+	if err := os.RemoveAll(backfillDir); err != nil {
+		return 0, err
+	}
+	if err := idtools.MkdirAndChown(backfillDir, 0755, d.idMap.RootPair()); err != nil {
+		return 0, err
+	}
+	if err := untar(diff, backfillDir, &archive.TarOptions{
+		IDMap:          d.idMap,
+		WhiteoutFormat: archive.OverlayWhiteoutFormat,
+	}); err != nil {
+		os.RemoveAll(backfillDir)
+		return 0, err
+	}
+	// Read diff to its end, so that a content not matching the expected
+	// one fails before it replaces the lazily mounted one.
+	if _, err := io.Copy(io.Discard, diff); err != nil {
+		os.RemoveAll(backfillDir)
+		return 0, err
+	}
+
+	d.locker.Lock(id)
+	defer d.locker.Unlock(id)
+
+	if err := d.unmountLazy(id); err != nil {
+		return 0, err
+	}
+	// Unlike os.Rename, rename(2) replaces the now empty diff directory.
+	diffDir := d.getDiffPath(id)
+	if err := unix.Rename(backfillDir, diffDir); err != nil {
+		return 0, errors.Wrapf(err, "failed to replace lazy content of layer %s", id)
+	}
+	return directory.Size(context.TODO(), diffDir)
+}
+
+// unmountLazy unmounts the lazily fetched content of the layer with id, if
+// any. Mounts of the layer which are still in use keep the content until
+// they are unmounted.
+func (d *Driver) unmountLazy(id string) error {
+	d.lazyMu.Lock()
+	unmount, ok := d.lazy[id]
+	delete(d.lazy, id)
+	d.lazyMu.Unlock()
+	if !ok {
+		return nil
+	}
+	if err := unmount(); err != nil {
+		return errors.Wrapf(err, "failed to unmount lazy content of layer %s", id)
+	}
+	return nil
+}
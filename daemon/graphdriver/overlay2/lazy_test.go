@@ -0,0 +1,105 @@
+//go:build linux
+// +build linux
+
+package overlay2 // import "github.com/docker/docker/daemon/graphdriver/overlay2"
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/idtools"
+	"golang.org/x/sys/unix"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestLazyDiffBackfill(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "test requires root")
+	skipIfNaive(t)
+
+	drv, err := Init(t.TempDir(), nil, idtools.IdentityMapping{})
+	if err != nil {
+		t.Skipf("overlay2 is not supported: %v", err)
+	}
+	d := drv.(*Driver)
+	defer d.Cleanup()
+
+	// The lazy content is emulated with a bind mount.
+	src := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "file"), []byte("lazy"), 0644))
+	var unmounted bool
+	mount := func(target string) (func() error, error) {
+		if err := unix.Mount(src, target, "", unix.MS_BIND, ""); err != nil {
+			return nil, err
+		}
+		return func() error {
+			unmounted = true
+			return unix.Unmount(target, unix.MNT_DETACH)
+		}, nil
+	}
+
+	assert.NilError(t, d.Create("base", "", nil))
+	assert.NilError(t, d.ApplyLazyDiff("base", "", graphdriver.LazyMountFunc(mount)))
+
+	assert.NilError(t, d.CreateReadWrite("before", "base", nil))
+	before, err := d.Get("before", "")
+	assert.NilError(t, err)
+	defer d.Put("before")
+	content, err := os.ReadFile(filepath.Join(before, "file"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "lazy"))
+
+	var diff bytes.Buffer
+	tw := tar.NewWriter(&diff)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0644, Size: 4}))
+	_, err = tw.Write([]byte("full"))
+	assert.NilError(t, err)
+	assert.NilError(t, tw.Close())
+
+	size, err := d.BackfillDiff("base", "", &diff)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(size, int64(4)))
+	assert.Check(t, unmounted)
+
+	// Mounts created after the backfill use the extracted content, and
+	// existing ones keep the lazy content.
+	assert.NilError(t, d.CreateReadWrite("after", "base", nil))
+	after, err := d.Get("after", "")
+	assert.NilError(t, err)
+	defer d.Put("after")
+	content, err = os.ReadFile(filepath.Join(after, "file"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "full"))
+	content, err = os.ReadFile(filepath.Join(before, "file"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "lazy"))
+}
+
+func TestLazyDiffRemove(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "test requires root")
+	skipIfNaive(t)
+
+	drv, err := Init(t.TempDir(), nil, idtools.IdentityMapping{})
+	if err != nil {
+		t.Skipf("overlay2 is not supported: %v", err)
+	}
+	d := drv.(*Driver)
+	defer d.Cleanup()
+
+	var unmounted bool
+	mount := func(target string) (func() error, error) {
+		return func() error {
+			unmounted = true
+			return nil
+		}, nil
+	}
+	assert.NilError(t, d.Create("base", "", nil))
+	assert.NilError(t, d.ApplyLazyDiff("base", "", mount))
+	assert.NilError(t, d.Remove("base"))
+	assert.Check(t, unmounted)
+}
@@ -100,6 +100,11 @@ type Driver struct {
 	supportsDType bool
 	usingMetacopy bool
 	locker        *locker.Locker
+
+	// lazy holds the functions unmounting the lazily fetched content of
+	// layers, by layer id.
+	lazy   map[string]func() error
+	lazyMu sync.Mutex
 }
 
 var (
@@ -305,6 +310,17 @@ func (d *Driver) GetMetadata(id string) (map[string]string, error) {
 // is being shutdown. For now, we just have to unmount the bind mounted
 // we had created.
 func (d *Driver) Cleanup() error {
+	d.lazyMu.Lock()
+	ids := make([]string, 0, len(d.lazy))
+	for id := range d.lazy {
+		ids = append(ids, id)
+	}
+	d.lazyMu.Unlock()
+	for _, id := range ids {
+		if err := d.unmountLazy(id); err != nil {
+			logger.Warn(err)
+		}
+	}
 	return mount.RecursiveUnmount(d.home)
 }
 
@@ -493,6 +509,9 @@ func (d *Driver) Remove(id string) error {
 	}
 	d.locker.Lock(id)
 	defer d.locker.Unlock(id)
+	if err := d.unmountLazy(id); err != nil {
+		logger.Warn(err)
+	}
 	dir := d.dir(id)
 	lid, err := os.ReadFile(path.Join(dir, "link"))
 	if err == nil {
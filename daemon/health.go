@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -55,6 +57,7 @@ type probe interface {
 
 // cmdProbe implements the "CMD" probe type.
 type cmdProbe struct {
+	config *containertypes.HealthConfig
 	// Run the command with the system's default shell instead of execing it directly.
 	shell bool
 }
@@ -63,7 +66,7 @@ type cmdProbe struct {
 // Returns the exit code and probe output (if any)
 func (p *cmdProbe) run(ctx context.Context, d *Daemon, cntr *container.Container) (*types.HealthcheckResult, error) {
 	startTime := time.Now()
-	cmdSlice := strslice.StrSlice(cntr.Config.Healthcheck.Test)[1:]
+	cmdSlice := strslice.StrSlice(p.config.Test)[1:]
 	if p.shell {
 		cmdSlice = append(getShell(cntr), cmdSlice...)
 	}
@@ -125,7 +128,7 @@ func (p *cmdProbe) run(ctx context.Context, d *Daemon, cntr *container.Container
 	if !tm.Stop() {
 		<-tm.C
 	}
-	probeTimeout := timeoutWithDefault(cntr.Config.Healthcheck.Timeout, defaultProbeTimeout)
+	probeTimeout := timeoutWithDefault(p.config.Timeout, defaultProbeTimeout)
 	tm.Reset(probeTimeout)
 	select {
 	case <-tm.C:
@@ -179,17 +182,30 @@ func (p *cmdProbe) run(ctx context.Context, d *Daemon, cntr *container.Container
 
 // Update the container's Status.Health struct based on the latest probe's result.
 func handleProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) {
+	updateHealth(d, c, c.Config.Healthcheck, false, result, done)
+}
+
+// Update the container's Status.Health struct based on the latest startup
+// probe's result. Returns true once the startup probe succeeded.
+func handleStartupProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) bool {
+	return updateHealth(d, c, c.Config.Healthcheck.StartupProbe, true, result, done)
+}
+
+// updateHealth updates the container's health state from the result of the
+// probe configured by config, which is either the healthcheck or, if startup
+// is set, the startup probe. Returns whether the probe succeeded.
+func updateHealth(d *Daemon, c *container.Container, config *containertypes.HealthConfig, startup bool, result *types.HealthcheckResult, done chan struct{}) bool {
 	c.Lock()
 	defer c.Unlock()
 
 	// probe may have been cancelled while waiting on lock. Ignore result then
 	select {
 	case <-done:
-		return
+		return false
 	default:
 	}
 
-	retries := c.Config.Healthcheck.Retries
+	retries := config.Retries
 	if retries <= 0 {
 		retries = defaultProbeRetries
 	}
@@ -205,7 +221,13 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 
 	if result.ExitCode == exitStatusHealthy {
 		h.FailingStreak = 0
-		h.SetStatus(types.Healthy)
+		// A successful startup probe hands over to the healthcheck, which
+		// decides whether the container is healthy.
+		if !startup || getProbe(c) == nil {
+			h.SetStatus(types.Healthy)
+		} else if h.Status() == types.Unhealthy {
+			h.SetStatus(types.Starting)
+		}
 	} else { // Failure (including invalid exit code)
 		shouldIncrementStreak := true
 
@@ -213,7 +235,7 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 		// then we check if we are within the start period of the container in which
 		// case we do not increment the failure streak.
 		if h.Status() == types.Starting {
-			startPeriod := timeoutWithDefault(c.Config.Healthcheck.StartPeriod, defaultStartPeriod)
+			startPeriod := timeoutWithDefault(config.StartPeriod, defaultStartPeriod)
 			timeSinceStart := result.Start.Sub(c.State.StartedAt)
 
 			// If still within the start period, then don't increment failing streak.
@@ -232,6 +254,26 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 		// Else we're starting or healthy. Stay in that state.
 	}
 
+	var action containertypes.UnhealthyAction
+	if c.HostConfig != nil && !c.HostConfig.UnhealthyPolicy.IsNone() && h.Status() == types.Unhealthy {
+		policy := c.HostConfig.UnhealthyPolicy
+		threshold := policy.Threshold
+		if threshold < retries {
+			threshold = retries
+		}
+		if h.FailingStreak >= threshold {
+			action = policy.Action
+			h.LastAction = &types.HealthAction{
+				Action:        string(action),
+				Time:          time.Now().UTC(),
+				FailingStreak: h.FailingStreak,
+			}
+			// Stop probing; the health monitor is started again when the
+			// container is restarted.
+			h.CloseMonitorChannel()
+		}
+	}
+
 	// replicate Health status changes
 	if err := c.CheckpointTo(d.containersReplica); err != nil {
 		// queries will be inconsistent until the next probe runs or other state mutations
@@ -242,13 +284,147 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 	current := h.Status()
 	if oldStatus != current {
 		d.LogContainerEvent(c, "health_status: "+current)
+		c.NotifyHealthChange()
+	}
+
+	if action != "" {
+		d.LogContainerEventWithAttributes(c, "health_action: "+string(action), map[string]string{
+			"failingStreak": strconv.Itoa(h.FailingStreak),
+		})
+		go d.handleUnhealthy(c, action)
+	}
+	return result.ExitCode == exitStatusHealthy
+}
+
+// Update the container's Status.Health.Readiness struct based on the latest
+// readiness probe's result.
+func handleReadinessProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	// probe may have been cancelled while waiting on lock. Ignore result then
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	retries := c.Config.Healthcheck.ReadinessProbe.Retries
+	if retries <= 0 {
+		retries = defaultProbeRetries
+	}
+
+	r := c.State.Health.Readiness
+	oldStatus := r.Status
+
+	if len(r.Log) >= maxLogEntries {
+		r.Log = append(r.Log[len(r.Log)+1-maxLogEntries:], result)
+	} else {
+		r.Log = append(r.Log, result)
+	}
+
+	if result.ExitCode == exitStatusHealthy {
+		r.FailingStreak = 0
+		r.Status = types.Ready
+	} else {
+		r.FailingStreak++
+		if r.FailingStreak >= retries {
+			r.Status = types.NotReady
+		}
+	}
+
+	if err := c.CheckpointTo(d.containersReplica); err != nil {
+		logrus.Errorf("Error replicating readiness state for container %s: %v", c.ID, err)
+	}
+
+	if oldStatus != r.Status {
+		d.setServiceReady(c, r.Status == types.Ready)
+		d.LogContainerEvent(c, "readiness_status: "+r.Status)
+		c.NotifyHealthChange()
+	}
+}
+
+// setServiceReady sets whether the container's network aliases are resolved
+// by the embedded DNS server, so that containers which are not ready drop out
+// of service discovery.
+func (daemon *Daemon) setServiceReady(c *container.Container, ready bool) {
+	if daemon.netController == nil || c.NetworkSettings == nil || c.NetworkSettings.SandboxID == "" {
+		return
+	}
+	sb, err := daemon.netController.SandboxByID(c.NetworkSettings.SandboxID)
+	if err != nil {
+		logrus.WithError(err).WithField("container", c.ID).Debug("failed to update service readiness")
+		return
+	}
+	sb.SetReady(ready)
+}
+
+// handleUnhealthy takes the given action on a container which kept failing
+// its healthcheck. The container is killed without being marked as manually
+// stopped, so that its restart policy applies; the "restart" action makes the
+// restart manager restart it regardless of the policy.
+func (daemon *Daemon) handleUnhealthy(c *container.Container, action containertypes.UnhealthyAction) {
+	c.Lock()
+	tsk, err := c.GetRunningTask()
+	c.Unlock()
+	if err != nil {
+		logrus.WithError(err).WithField("container", c.ID).Debug("not taking action on unhealthy container")
+		return
+	}
+
+	if action == containertypes.UnhealthyActionRestart {
+		c.ForceRestart()
+	}
+	logrus.WithField("container", c.ID).WithField("action", action).Info("taking action on unhealthy container")
+	if err := tsk.Kill(context.Background(), syscall.SIGKILL); err != nil {
+		logrus.WithError(err).WithField("container", c.ID).Warn("failed to kill unhealthy container")
 	}
 }
 
 // Run the container's monitoring thread until notified via "stop".
 // There is never more than one monitor thread running per container at a time.
 func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe) {
-	probeInterval := timeoutWithDefault(c.Config.Healthcheck.Interval, defaultProbeInterval)
+	config := c.Config.Healthcheck
+
+	// The health state may be replaced while the container is locked, such
+	// as when the container is restarted.
+	c.Lock()
+	starting := c.State.Health != nil && c.State.Health.Status() == types.Starting
+	c.Unlock()
+
+	// The startup probe runs until it succeeds, unless a previous monitor
+	// already got past it (for example, before the container was paused).
+	if startup := config.StartupProbe; startup != nil && starting {
+		done := runProbes(d, c, stop, newProbe(c, startup), startup, func(result *types.HealthcheckResult) bool {
+			return handleStartupProbeResult(d, c, result, stop)
+		})
+		if !done {
+			return
+		}
+	}
+
+	if readiness := config.ReadinessProbe; readiness != nil {
+		go runProbes(d, c, stop, newProbe(c, readiness), readiness, func(result *types.HealthcheckResult) bool {
+			handleReadinessProbeResult(d, c, result, stop)
+			return false
+		})
+	}
+
+	if probe == nil {
+		<-stop
+		return
+	}
+	runProbes(d, c, stop, probe, config, func(result *types.HealthcheckResult) bool {
+		handleProbeResult(d, c, result, stop)
+		return false
+	})
+}
+
+// runProbes runs probe at the interval set in config, passing each result to
+// handle, until notified via "stop" or until handle returns true. It returns
+// false if it was stopped.
+func runProbes(d *Daemon, c *container.Container, stop chan struct{}, probe probe, config *containertypes.HealthConfig, handle func(*types.HealthcheckResult) bool) bool {
+	probeInterval := timeoutWithDefault(config.Interval, defaultProbeInterval)
 
 	intervalTimer := time.NewTimer(probeInterval)
 	defer intervalTimer.Stop()
@@ -259,7 +435,7 @@ func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe)
 		select {
 		case <-stop:
 			logrus.Debugf("Stop healthcheck monitoring for container %s (received while idle)", c.ID)
-			return
+			return false
 		case <-intervalTimer.C:
 			logrus.Debugf("Running health check for container %s ...", c.ID)
 			startTime := time.Now()
@@ -291,10 +467,13 @@ func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe)
 				// Wait for probe to exit (it might take a while to respond to the TERM
 				// signal and we don't want dying probes to pile up).
 				<-results
-				return
+				return false
 			case result := <-results:
-				handleProbeResult(d, c, result, stop)
+				done := handle(result)
 				cancelProbe()
+				if done {
+					return true
+				}
 			}
 		}
 	}
@@ -303,15 +482,24 @@ func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe)
 // Get a suitable probe implementation for the container's healthcheck configuration.
 // Nil will be returned if no healthcheck was configured or NONE was set.
 func getProbe(c *container.Container) probe {
-	config := c.Config.Healthcheck
+	return newProbe(c, c.Config.Healthcheck)
+}
+
+// newProbe returns a suitable probe implementation for the given probe
+// configuration, or nil if the probe is not configured or NONE was set.
+func newProbe(c *container.Container, config *containertypes.HealthConfig) probe {
 	if config == nil || len(config.Test) == 0 {
 		return nil
 	}
 	switch config.Test[0] {
 	case "CMD":
-		return &cmdProbe{shell: false}
+		return &cmdProbe{config: config, shell: false}
 	case "CMD-SHELL":
-		return &cmdProbe{shell: true}
+		return &cmdProbe{config: config, shell: true}
+	case "HTTP":
+		return &httpProbe{config: config}
+	case "TCP":
+		return &tcpProbe{config: config}
 	case "NONE":
 		return nil
 	default:
@@ -320,6 +508,16 @@ func getProbe(c *container.Container) probe {
 	}
 }
 
+// hasProbes returns whether any of the healthcheck, startup probe or
+// readiness probe is configured for the container.
+func hasProbes(c *container.Container) bool {
+	config := c.Config.Healthcheck
+	if config == nil {
+		return false
+	}
+	return getProbe(c) != nil || config.StartupProbe != nil || config.ReadinessProbe != nil
+}
+
 // Ensure the health-check monitor is running or not, depending on the current
 // state of the container.
 // Called from monitor.go, with c locked.
@@ -330,7 +528,7 @@ func (daemon *Daemon) updateHealthMonitor(c *container.Container) {
 	}
 
 	probe := getProbe(c)
-	wantRunning := c.Running && !c.Paused && probe != nil
+	wantRunning := c.Running && !c.Paused && hasProbes(c)
 	if wantRunning {
 		if stop := h.OpenMonitorChannel(); stop != nil {
 			go monitor(daemon, c, stop, probe)
@@ -346,22 +544,31 @@ func (daemon *Daemon) updateHealthMonitor(c *container.Container) {
 // Called with c locked.
 func (daemon *Daemon) initHealthMonitor(c *container.Container) {
 	// If no healthcheck is setup then don't init the monitor
-	if getProbe(c) == nil {
+	if !hasProbes(c) {
 		return
 	}
 
 	// This is needed in case we're auto-restarting
 	daemon.stopHealthchecks(c)
 
-	if h := c.State.Health; h != nil {
+	h := c.State.Health
+	if h != nil {
 		h.SetStatus(types.Starting)
 		h.FailingStreak = 0
 	} else {
-		h := &container.Health{}
+		h = &container.Health{}
 		h.SetStatus(types.Starting)
 		c.State.Health = h
 	}
 
+	if c.Config.Healthcheck.ReadinessProbe != nil {
+		// Containers are not ready until their readiness probe passes.
+		h.Readiness = &types.Readiness{Status: types.NotReady}
+		daemon.setServiceReady(c, false)
+	} else {
+		h.Readiness = nil
+	}
+
 	daemon.updateHealthMonitor(c)
 }
 
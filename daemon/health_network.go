@@ -0,0 +1,220 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/libnetwork"
+	"github.com/pkg/errors"
+)
+
+// dialFunc opens a connection to the given address.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dialLoopback returns a dialFunc which opens connections to the IPv4
+// loopback address, and falls back to the IPv6 loopback address for services
+// which only listen on "[::1]". The error of the IPv4 connection is returned
+// if both fail.
+func dialLoopback(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err == nil || ctx.Err() != nil {
+			return conn, err
+		}
+		host, port, splitErr := net.SplitHostPort(address)
+		if splitErr != nil || host != "127.0.0.1" {
+			return nil, err
+		}
+		conn, err6 := dial(ctx, network, net.JoinHostPort("::1", port))
+		if err6 != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// httpProbe implements the "HTTP" probe type.
+type httpProbe struct {
+	config *containertypes.HealthConfig
+}
+
+func (p *httpProbe) run(ctx context.Context, d *Daemon, cntr *container.Container) (*types.HealthcheckResult, error) {
+	dial, err := d.containerDialer(cntr)
+	if err != nil {
+		return nil, err
+	}
+	return runHTTPProbe(ctx, p.config, dial), nil
+}
+
+// tcpProbe implements the "TCP" probe type.
+type tcpProbe struct {
+	config *containertypes.HealthConfig
+}
+
+func (p *tcpProbe) run(ctx context.Context, d *Daemon, cntr *container.Container) (*types.HealthcheckResult, error) {
+	dial, err := d.containerDialer(cntr)
+	if err != nil {
+		return nil, err
+	}
+	return runTCPProbe(ctx, p.config, dial), nil
+}
+
+// runHTTPProbe sends a GET request as configured by config.HTTP, and reports
+// the container as healthy if the response has one of the expected status
+// codes.
+func runHTTPProbe(ctx context.Context, config *containertypes.HealthConfig, dial dialFunc) *types.HealthcheckResult {
+	probeTimeout := timeoutWithDefault(config.Timeout, defaultProbeTimeout)
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	path := config.HTTP.Path
+	if path == "" {
+		path = "/"
+	}
+	url := "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(config.HTTP.Port)) + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return probeFailure(err.Error())
+	}
+	for k, v := range config.HTTP.Headers {
+		if http.CanonicalHeaderKey(k) == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext:       dialLoopback(dial),
+			DisableKeepAlives: true,
+		},
+		// Redirects are reported as is; by default, they are healthy.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return probeTimedOut(probeTimeout)
+		}
+		return probeFailure(fmt.Sprintf("HTTP GET %s failed: %v", url, err))
+	}
+	defer resp.Body.Close()
+
+	output := &limitedBuffer{}
+	fmt.Fprintf(output, "HTTP GET %s: %s\n", url, resp.Status)
+	_, _ = io.Copy(output, io.LimitReader(resp.Body, maxOutputLen))
+
+	exitCode := 1
+	if isExpectedStatus(config.HTTP.ExpectedStatus, resp.StatusCode) {
+		exitCode = exitStatusHealthy
+	}
+	return &types.HealthcheckResult{
+		End:      time.Now(),
+		ExitCode: exitCode,
+		Output:   output.String(),
+	}
+}
+
+func isExpectedStatus(expected []int, status int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 400
+	}
+	for _, s := range expected {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// runTCPProbe reports the container as healthy if a TCP connection can be
+// opened to the port configured by config.TCP.
+func runTCPProbe(ctx context.Context, config *containertypes.HealthConfig, dial dialFunc) *types.HealthcheckResult {
+	probeTimeout := timeoutWithDefault(config.Timeout, defaultProbeTimeout)
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(config.TCP.Port))
+	conn, err := dialLoopback(dial)(ctx, "tcp", address)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return probeTimedOut(probeTimeout)
+		}
+		return probeFailure(fmt.Sprintf("TCP connection to %s failed: %v", address, err))
+	}
+	defer conn.Close()
+	return &types.HealthcheckResult{
+		End:      time.Now(),
+		ExitCode: exitStatusHealthy,
+		Output:   "Connected to " + conn.RemoteAddr().String(),
+	}
+}
+
+func probeFailure(msg string) *types.HealthcheckResult {
+	return &types.HealthcheckResult{
+		End:      time.Now(),
+		ExitCode: 1,
+		Output:   msg,
+	}
+}
+
+func probeTimedOut(probeTimeout time.Duration) *types.HealthcheckResult {
+	return &types.HealthcheckResult{
+		End:      time.Now(),
+		ExitCode: -1,
+		Output:   fmt.Sprintf("Health check exceeded timeout (%v)", probeTimeout),
+	}
+}
+
+// containerDialer returns a dialFunc which opens connections from inside the
+// network namespace of the container.
+func (daemon *Daemon) containerDialer(cntr *container.Container) (dialFunc, error) {
+	sb, err := daemon.containerSandbox(cntr)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		var (
+			conn    net.Conn
+			dialErr error
+		)
+		// The socket is bound to the network namespace it was created in, so
+		// it can be used after returning to the daemon's namespace.
+		if err := sb.ExecFunc(func() {
+			var d net.Dialer
+			conn, dialErr = d.DialContext(ctx, network, address)
+		}); err != nil {
+			return nil, err
+		}
+		return conn, dialErr
+	}, nil
+}
+
+// containerSandbox returns the sandbox holding the network namespace of the
+// container, which may belong to another container if it joined its network
+// namespace.
+func (daemon *Daemon) containerSandbox(cntr *container.Container) (libnetwork.Sandbox, error) {
+	if cntr.HostConfig.NetworkMode.IsContainer() {
+		nc, err := daemon.getNetworkedContainer(cntr.ID, cntr.HostConfig.NetworkMode.ConnectedContainer())
+		if err != nil {
+			return nil, err
+		}
+		return daemon.containerSandbox(nc)
+	}
+	if daemon.netController == nil || cntr.NetworkSettings == nil || cntr.NetworkSettings.SandboxID == "" {
+		return nil, errors.Errorf("container %s has no network namespace", cntr.ID)
+	}
+	return daemon.netController.SandboxByID(cntr.NetworkSettings.SandboxID)
+}
@@ -0,0 +1,145 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func serverPort(t *testing.T, addr string) int {
+	_, port, err := net.SplitHostPort(addr)
+	assert.NilError(t, err)
+	p, err := strconv.Atoi(port)
+	assert.NilError(t, err)
+	return p
+}
+
+func TestHTTPProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Probe") != "docker" || r.Host != "example.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/healthz":
+			_, _ = w.Write([]byte("ok"))
+		case "/redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var d net.Dialer
+	config := &containertypes.HealthConfig{
+		Test: []string{"HTTP"},
+		HTTP: &containertypes.HTTPProbe{
+			Port:    serverPort(t, srv.Listener.Addr().String()),
+			Path:    "/healthz",
+			Headers: map[string]string{"X-Probe": "docker", "Host": "example.com"},
+		},
+	}
+
+	result := runHTTPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 0))
+	assert.Check(t, strings.HasPrefix(result.Output, "HTTP GET http://127.0.0.1:"), result.Output)
+	assert.Check(t, strings.HasSuffix(result.Output, "200 OK\nok"), result.Output)
+
+	config.HTTP.Path = "/failing"
+	result = runHTTPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 1))
+
+	// redirects are not followed, and are healthy unless other status codes
+	// are expected.
+	config.HTTP.Path = "/redirect"
+	result = runHTTPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 0))
+	config.HTTP.ExpectedStatus = []int{200}
+	result = runHTTPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 1))
+}
+
+func TestHTTPProbeTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	var d net.Dialer
+	config := &containertypes.HealthConfig{
+		Test:    []string{"HTTP"},
+		Timeout: 100 * time.Millisecond,
+		HTTP:    &containertypes.HTTPProbe{Port: serverPort(t, srv.Listener.Addr().String())},
+	}
+	result := runHTTPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, -1))
+	assert.Check(t, is.Equal(result.Output, "Health check exceeded timeout (100ms)"))
+}
+
+func TestTCPProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	port := serverPort(t, l.Addr().String())
+
+	var d net.Dialer
+	config := &containertypes.HealthConfig{
+		Test: []string{"TCP"},
+		TCP:  &containertypes.TCPProbe{Port: port},
+	}
+	result := runTCPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 0))
+
+	l.Close()
+	result = runTCPProbe(context.Background(), config, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 1))
+	assert.Check(t, strings.Contains(result.Output, "connection refused"), result.Output)
+}
+
+func TestNetworkProbeIPv6Loopback(t *testing.T) {
+	l, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback address is not available")
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+	port := serverPort(t, l.Addr().String())
+
+	// Only fall back to the IPv6 loopback address if nothing listens on the
+	// IPv4 loopback address with the same port.
+	if l4, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port))); err != nil {
+		t.Skip("IPv4 loopback port is in use")
+	} else {
+		l4.Close()
+	}
+
+	var d net.Dialer
+	result := runHTTPProbe(context.Background(), &containertypes.HealthConfig{
+		Test: []string{"HTTP"},
+		HTTP: &containertypes.HTTPProbe{Port: port},
+	}, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 0), result.Output)
+
+	result = runTCPProbe(context.Background(), &containertypes.HealthConfig{
+		Test: []string{"TCP"},
+		TCP:  &containertypes.TCPProbe{Port: port},
+	}, d.DialContext)
+	assert.Check(t, is.Equal(result.ExitCode, 0), result.Output)
+	assert.Check(t, is.Equal(result.Output, "Connected to "+l.Addr().String()))
+}
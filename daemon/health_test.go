@@ -153,3 +153,183 @@ func TestHealthStates(t *testing.T) {
 		t.Errorf("Expecting FailingStreak=0, but got %d\n", c.State.Health.FailingStreak)
 	}
 }
+
+func TestHealthUnhealthyAction(t *testing.T) {
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+
+	c := &container.Container{
+		ID:   "container_id",
+		Name: "container_name",
+		Config: &containertypes.Config{
+			Image: "image_name",
+			Healthcheck: &containertypes.HealthConfig{
+				Retries: 1,
+			},
+		},
+		HostConfig: &containertypes.HostConfig{
+			UnhealthyPolicy: containertypes.UnhealthyPolicy{
+				Action:    containertypes.UnhealthyActionKill,
+				Threshold: 2,
+			},
+		},
+	}
+
+	store, err := container.NewViewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := &Daemon{
+		EventsService:     e,
+		containersReplica: store,
+	}
+	muteLogs()
+	reset(c)
+
+	handleResult := func(startTime time.Time, exitCode int) {
+		handleProbeResult(daemon, c, &types.HealthcheckResult{
+			Start:    startTime,
+			End:      startTime,
+			ExitCode: exitCode,
+		}, nil)
+	}
+	expect := func(expected string) {
+		select {
+		case event := <-l:
+			ev := event.(eventtypes.Message)
+			if ev.Status != expected {
+				t.Errorf("Expecting event %#v, but got %#v\n", expected, ev.Status)
+			}
+		case <-time.After(1 * time.Second):
+			t.Errorf("Expecting event %#v, but got nothing\n", expected)
+		}
+	}
+
+	handleResult(c.State.StartedAt.Add(1*time.Second), 1)
+	expect("health_status: unhealthy")
+	if c.State.Health.LastAction != nil {
+		t.Errorf("Expecting no action below the threshold, but got %#v\n", c.State.Health.LastAction)
+	}
+
+	handleResult(c.State.StartedAt.Add(2*time.Second), 1)
+	expect("health_action: kill")
+	if a := c.State.Health.LastAction; a == nil || a.Action != "kill" || a.FailingStreak != 2 {
+		t.Errorf("Expecting kill action after 2 failures, but got %#v\n", a)
+	}
+}
+
+func TestStartupAndReadinessProbes(t *testing.T) {
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+
+	c := &container.Container{
+		ID:   "container_id",
+		Name: "container_name",
+		Config: &containertypes.Config{
+			Image: "image_name",
+			Healthcheck: &containertypes.HealthConfig{
+				Test:    []string{"CMD", "true"},
+				Retries: 1,
+				StartupProbe: &containertypes.HealthConfig{
+					Test:    []string{"CMD", "true"},
+					Retries: 2,
+				},
+				ReadinessProbe: &containertypes.HealthConfig{
+					Test:    []string{"CMD", "true"},
+					Retries: 2,
+				},
+			},
+		},
+		State: &container.State{},
+	}
+
+	store, err := container.NewViewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := &Daemon{
+		EventsService:     e,
+		containersReplica: store,
+	}
+	muteLogs()
+
+	expect := func(expected string) {
+		select {
+		case event := <-l:
+			ev := event.(eventtypes.Message)
+			if ev.Status != expected {
+				t.Errorf("Expecting event %#v, but got %#v\n", expected, ev.Status)
+			}
+		case <-time.After(1 * time.Second):
+			t.Errorf("Expecting event %#v, but got nothing\n", expected)
+		}
+	}
+	result := func(exitCode int) *types.HealthcheckResult {
+		return &types.HealthcheckResult{Start: c.State.StartedAt.Add(time.Minute), ExitCode: exitCode}
+	}
+
+	daemon.initHealthMonitor(c)
+	if r := c.State.Health.Readiness; r == nil || r.Status != types.NotReady {
+		t.Fatalf("Expecting not-ready before the readiness probe ran, but got %#v\n", r)
+	}
+
+	// a successful startup probe hands over to the healthcheck.
+	if handleStartupProbeResult(daemon, c, result(1), nil) {
+		t.Error("Expecting startup probe to not be done after a failure")
+	}
+	if !handleStartupProbeResult(daemon, c, result(0), nil) {
+		t.Error("Expecting startup probe to be done after a success")
+	}
+	if status := c.State.Health.Status(); status != types.Starting {
+		t.Errorf("Expecting starting, but got %#v\n", status)
+	}
+	handleProbeResult(daemon, c, result(0), nil)
+	expect("health_status: healthy")
+
+	// readiness does not affect the health status.
+	handleReadinessProbeResult(daemon, c, result(0), nil)
+	expect("readiness_status: ready")
+	handleReadinessProbeResult(daemon, c, result(1), nil)
+	if r := c.State.Health.Readiness; r.Status != types.Ready || r.FailingStreak != 1 {
+		t.Errorf("Expecting ready with FailingStreak=1, but got %#v\n", r)
+	}
+	handleReadinessProbeResult(daemon, c, result(1), nil)
+	expect("readiness_status: not-ready")
+	if status := c.State.Health.Status(); status != types.Healthy {
+		t.Errorf("Expecting healthy, but got %#v\n", status)
+	}
+
+	// a failing startup probe makes the container unhealthy.
+	reset(c)
+	handleStartupProbeResult(daemon, c, result(1), nil)
+	handleStartupProbeResult(daemon, c, result(1), nil)
+	expect("health_status: unhealthy")
+}
+
+func TestValidateWaitCondition(t *testing.T) {
+	probe := &containertypes.HealthConfig{Test: []string{"CMD", "true"}}
+	cases := []struct {
+		doc         string
+		healthcheck *containertypes.HealthConfig
+		healthy     bool
+		ready       bool
+	}{
+		{doc: "no healthcheck"},
+		{doc: "healthcheck", healthcheck: &containertypes.HealthConfig{Test: probe.Test}, healthy: true, ready: true},
+		{doc: "startup probe only", healthcheck: &containertypes.HealthConfig{StartupProbe: probe}, healthy: true},
+		{doc: "readiness probe only", healthcheck: &containertypes.HealthConfig{ReadinessProbe: probe}, ready: true},
+	}
+	for _, tc := range cases {
+		c := &container.Container{ID: "container_id", Config: &containertypes.Config{Healthcheck: tc.healthcheck}}
+		if err := validateWaitCondition(c, container.WaitConditionHealthy); (err == nil) != tc.healthy {
+			t.Errorf("%s: unexpected result waiting for healthy: %v", tc.doc, err)
+		}
+		if err := validateWaitCondition(c, container.WaitConditionReady); (err == nil) != tc.ready {
+			t.Errorf("%s: unexpected result waiting for ready: %v", tc.doc, err)
+		}
+	}
+}
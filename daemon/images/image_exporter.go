@@ -4,16 +4,23 @@ import (
 	"context"
 	"io"
 
+	"github.com/docker/docker/distribution"
 	"github.com/docker/docker/image/tarexport"
+	"github.com/docker/docker/pkg/archive"
 )
 
 // ExportImage exports a list of images to the given output stream. The
 // exported images are archived into a tar when written to the output
 // stream. All images with the given tag and all versions containing
 // the same tag are exported. names is the set of tags to export, and
-// outStream is the writer which the images are written to.
-func (i *ImageService) ExportImage(ctx context.Context, names []string, outStream io.Writer) error {
-	imageExporter := tarexport.NewTarExporter(i.imageStore, i.layerStore, i.referenceStore, i)
+// outStream is the writer which the images are written to. The layers are
+// compressed with compression, if it is not empty.
+func (i *ImageService) ExportImage(ctx context.Context, names []string, compression string, outStream io.Writer) error {
+	c, err := saveCompression(compression)
+	if err != nil {
+		return err
+	}
+	imageExporter := tarexport.NewTarExporterWithCompression(i.imageStore, i.layerStore, i.referenceStore, i, c)
 	return imageExporter.Save(names, outStream)
 }
 
@@ -24,3 +31,22 @@ func (i *ImageService) LoadImage(ctx context.Context, inTar io.ReadCloser, outSt
 	imageExporter := tarexport.NewTarExporter(i.imageStore, i.layerStore, i.referenceStore, i)
 	return imageExporter.Load(inTar, outStream, quiet)
 }
+
+// saveCompression returns the compression of the layers of saved images for
+// the requested layer compression. Layers are not compressed unless a
+// compression is requested. The table of contents of zstd:chunked layers is
+// only useful when fetching layers from a registry, so saved layers are
+// compressed with plain zstd instead.
+func saveCompression(compression string) (archive.Compression, error) {
+	if compression == "" {
+		return archive.Uncompressed, nil
+	}
+	c, err := distribution.ParseLayerCompression(compression)
+	if err != nil {
+		return archive.Uncompressed, err
+	}
+	if c == distribution.LayerCompressionGzip {
+		return archive.Gzip, nil
+	}
+	return archive.Zstd, nil
+}
@@ -0,0 +1,26 @@
+package images
+
+import (
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestSaveCompression(t *testing.T) {
+	for compression, expected := range map[string]archive.Compression{
+		"":             archive.Uncompressed,
+		"gzip":         archive.Gzip,
+		"zstd":         archive.Zstd,
+		"zstd:chunked": archive.Zstd,
+	} {
+		c, err := saveCompression(compression)
+		assert.Check(t, err, compression)
+		assert.Check(t, is.Equal(c, expected), compression)
+	}
+
+	_, err := saveCompression("xz")
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+}
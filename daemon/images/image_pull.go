@@ -126,6 +126,7 @@ func (i *ImageService) pullImageWithReference(ctx context.Context, ref reference
 		},
 		DownloadManager: i.downloadManager,
 		Platform:        platform,
+		LazyPull:        i.lazyPull,
 	}
 
 	err = distribution.Pull(ctx, ref, imagePullConfig, cs)
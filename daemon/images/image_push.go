@@ -14,8 +14,17 @@ import (
 )
 
 // PushImage initiates a push operation on the repository named localName.
-func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHeaders map[string][]string, authConfig *registry.AuthConfig, outStream io.Writer) error {
+// Layers are compressed with the given compression, or with the compression
+// configured for the daemon if empty.
+func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHeaders map[string][]string, authConfig *registry.AuthConfig, compression string, outStream io.Writer) error {
 	start := time.Now()
+	if compression == "" {
+		compression = i.layerCompression
+	}
+	layerCompression, err := distribution.ParseLayerCompression(compression)
+	if err != nil {
+		return err
+	}
 	ref, err := reference.ParseNormalizedNamed(image)
 	if err != nil {
 		return err
@@ -52,10 +61,11 @@ func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHea
 			ImageStore:       distribution.NewImageConfigStoreFromStore(i.imageStore),
 			ReferenceStore:   i.referenceStore,
 		},
-		ConfigMediaType: schema2.MediaTypeImageConfig,
-		LayerStores:     distribution.NewLayerProvidersFromStore(i.layerStore),
-		TrustKey:        i.trustKey,
-		UploadManager:   i.uploadManager,
+		ConfigMediaType:  schema2.MediaTypeImageConfig,
+		LayerStores:      distribution.NewLayerProvidersFromStore(i.layerStore),
+		TrustKey:         i.trustKey,
+		UploadManager:    i.uploadManager,
+		LayerCompression: layerCompression,
 	}
 
 	err = distribution.Push(ctx, ref, imagePushConfig)
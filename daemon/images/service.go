@@ -43,6 +43,8 @@ type ImageServiceConfig struct {
 	MaxConcurrentDownloads    int
 	MaxConcurrentUploads      int
 	MaxDownloadAttempts       int
+	LayerCompression          string
+	LazyPull                  bool
 	ReferenceStore            dockerreference.Store
 	RegistryService           registry.Service
 	TrustKey                  libtrust.PrivateKey
@@ -60,6 +62,8 @@ func NewImageService(config ImageServiceConfig) *ImageService {
 		eventsService:             config.EventsService,
 		imageStore:                &imageStoreWithLease{Store: config.ImageStore, leases: config.Leases, ns: config.ContentNamespace},
 		layerStore:                config.LayerStore,
+		layerCompression:          config.LayerCompression,
+		lazyPull:                  config.LazyPull,
 		referenceStore:            config.ReferenceStore,
 		registryService:           config.RegistryService,
 		trustKey:                  config.TrustKey,
@@ -78,6 +82,8 @@ type ImageService struct {
 	eventsService             *daemonevents.Events
 	imageStore                image.Store
 	layerStore                layer.Store
+	layerCompression          string
+	lazyPull                  bool
 	pruneRunning              int32
 	referenceStore            dockerreference.Store
 	registryService           registry.Service
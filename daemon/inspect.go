@@ -144,6 +144,17 @@ func (daemon *Daemon) getInspectData(container *container.Container) (*types.Con
 			FailingStreak: container.State.Health.FailingStreak,
 			Log:           append([]*types.HealthcheckResult{}, container.State.Health.Log...),
 		}
+		if a := container.State.Health.LastAction; a != nil {
+			lastAction := *a
+			containerHealth.LastAction = &lastAction
+		}
+		if r := container.State.Health.Readiness; r != nil {
+			containerHealth.Readiness = &types.Readiness{
+				Status:        r.Status,
+				FailingStreak: r.FailingStreak,
+				Log:           append([]*types.HealthcheckResult{}, r.Log...),
+			}
+		}
 	}
 
 	containerState := &types.ContainerState{
@@ -30,15 +30,31 @@ type Copier struct {
 	copyJobs  sync.WaitGroup
 	closeOnce sync.Once
 	closed    chan struct{}
+	multiline *MultilineConfig
+}
+
+// CopierOption configures a Copier.
+type CopierOption func(*Copier)
+
+// WithMultiline aggregates the consecutive lines of each source into
+// multiline messages as configured by config, before logging them.
+func WithMultiline(config MultilineConfig) CopierOption {
+	return func(c *Copier) {
+		c.multiline = &config
+	}
 }
 
 // NewCopier creates a new Copier
-func NewCopier(srcs map[string]io.Reader, dst Logger) *Copier {
-	return &Copier{
+func NewCopier(srcs map[string]io.Reader, dst Logger, opts ...CopierOption) *Copier {
+	c := &Copier{
 		srcs:   srcs,
 		dst:    dst,
 		closed: make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Run starts logs copying
@@ -63,6 +79,21 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	}
 	buf := make([]byte, bufSize)
 
+	logFullLine := func(msg *Message) {
+		if logErr := c.dst.Log(msg); logErr != nil {
+			logDriverError(c.dst.Name(), string(msg.Line), logErr)
+		}
+	}
+	// flushMultiline logs the pending multiline message before a partial
+	// message, which is not aggregated.
+	flushMultiline := func() {}
+	if c.multiline != nil {
+		aggregator := newMultilineAggregator(*c.multiline, c.dst, bufSize)
+		defer aggregator.Flush()
+		logFullLine = aggregator.Log
+		flushMultiline = aggregator.Flush
+	}
+
 	n := 0
 	eof := false
 	var partialid string
@@ -121,12 +152,12 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 					}
 					if msg.PLogMetaData == nil {
 						msg.Timestamp = time.Now().UTC()
+						logFullLine(msg)
 					} else {
 						msg.Timestamp = partialTS
-					}
-
-					if logErr := c.dst.Log(msg); logErr != nil {
-						logDriverError(c.dst.Name(), string(msg.Line), logErr)
+						if logErr := c.dst.Log(msg); logErr != nil {
+							logDriverError(c.dst.Name(), string(msg.Line), logErr)
+						}
 					}
 				}
 				p += q + 1
@@ -157,6 +188,7 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 					ordinal++
 					hasMorePartial = true
 
+					flushMultiline()
 					if logErr := c.dst.Log(msg); logErr != nil {
 						logDriverError(c.dst.Name(), string(msg.Line), logErr)
 					}
@@ -125,8 +125,19 @@ func GetLogDriver(name string) (Creator, error) {
 }
 
 var builtInLogOpts = map[string]bool{
-	"mode":            true,
-	"max-buffer-size": true,
+	"mode":                 true,
+	"max-buffer-size":      true,
+	rateLimitLinesKey:      true,
+	rateLimitLinesBurstKey: true,
+	rateLimitBytesKey:      true,
+	rateLimitBytesBurstKey: true,
+	rateLimitPolicyKey:     true,
+	rateLimitSampleKey:     true,
+
+	multilinePatternKey:      true,
+	multilineMaxLinesKey:     true,
+	multilineMaxBytesKey:     true,
+	multilineFlushTimeoutKey: true,
 }
 
 // ValidateLogOpts checks the options for the given log driver. The
@@ -151,6 +162,14 @@ func ValidateLogOpts(name string, cfg map[string]string) error {
 		}
 	}
 
+	if _, err := ParseRateLimitConfig(cfg); err != nil {
+		return err
+	}
+
+	if _, err := ParseMultilineConfig(cfg); err != nil {
+		return err
+	}
+
 	if err := validateExternal(cfg); err != nil {
 		return err
 	}
@@ -8,6 +8,8 @@ var (
 	logWritesFailedCount metrics.Counter
 	logReadsFailedCount  metrics.Counter
 	totalPartialLogs     metrics.Counter
+
+	logMessagesDroppedCount metrics.Counter
 )
 
 func init() {
@@ -16,6 +18,7 @@ func init() {
 	logWritesFailedCount = loggerMetrics.NewCounter("log_write_operations_failed", "Number of log write operations that failed")
 	logReadsFailedCount = loggerMetrics.NewCounter("log_read_operations_failed", "Number of log reads from container stdio that failed")
 	totalPartialLogs = loggerMetrics.NewCounter("log_entries_size_greater_than_buffer", "Number of log entries which are larger than the log buffer")
+	logMessagesDroppedCount = loggerMetrics.NewCounter("log_messages_dropped", "Number of log messages dropped because they exceeded the rate limit")
 
 	metrics.Register(loggerMetrics)
 }
@@ -0,0 +1,166 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+// Log options configuring the aggregation of multiline messages.
+const (
+	multilinePatternKey      = "multiline-pattern"
+	multilineMaxLinesKey     = "multiline-max-lines"
+	multilineMaxBytesKey     = "multiline-max-bytes"
+	multilineFlushTimeoutKey = "multiline-flush-timeout"
+)
+
+const (
+	defaultMultilineMaxLines     = 500
+	defaultMultilineMaxBytes     = defaultBufSize
+	defaultMultilineFlushTimeout = time.Second
+)
+
+// MultilineConfig configures the aggregation of consecutive lines of a
+// stream into a single message, such as the lines of a stack trace.
+type MultilineConfig struct {
+	// Pattern matches the first line of a message. Lines which do not match
+	// are appended to the message started by the previous line.
+	Pattern *regexp.Regexp
+	// MaxLines is the maximum number of lines in a message.
+	MaxLines int
+	// MaxBytes is the maximum size of a message.
+	MaxBytes int
+	// FlushTimeout is the time after which a message is logged if no
+	// line was appended to it.
+	FlushTimeout time.Duration
+}
+
+// ParseMultilineConfig parses the multiline log options in cfg. It returns
+// nil if multiline messages are not aggregated.
+func ParseMultilineConfig(cfg map[string]string) (*MultilineConfig, error) {
+	pattern, ok := cfg[multilinePatternKey]
+	if !ok || pattern == "" {
+		for _, k := range []string{multilineMaxLinesKey, multilineMaxBytesKey, multilineFlushTimeoutKey} {
+			if _, ok := cfg[k]; ok {
+				return nil, fmt.Errorf("logger: %s option requires %s", k, multilinePatternKey)
+			}
+		}
+		return nil, nil
+	}
+
+	c := &MultilineConfig{
+		MaxLines:     defaultMultilineMaxLines,
+		MaxBytes:     defaultMultilineMaxBytes,
+		FlushTimeout: defaultMultilineFlushTimeout,
+	}
+	var err error
+	if c.Pattern, err = regexp.Compile(pattern); err != nil {
+		return nil, errors.Wrapf(err, "error parsing option %s", multilinePatternKey)
+	}
+	if s, ok := cfg[multilineMaxLinesKey]; ok {
+		if c.MaxLines, err = strconv.Atoi(s); err != nil || c.MaxLines <= 0 {
+			return nil, fmt.Errorf("logger: invalid %s option: %q: must be a positive integer", multilineMaxLinesKey, s)
+		}
+	}
+	if s, ok := cfg[multilineMaxBytesKey]; ok {
+		maxBytes, err := units.RAMInBytes(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing option %s", multilineMaxBytesKey)
+		}
+		if maxBytes <= 0 {
+			return nil, fmt.Errorf("logger: invalid %s option: %s: must be positive", multilineMaxBytesKey, s)
+		}
+		c.MaxBytes = int(maxBytes)
+	}
+	if s, ok := cfg[multilineFlushTimeoutKey]; ok {
+		if c.FlushTimeout, err = time.ParseDuration(s); err != nil || c.FlushTimeout <= 0 {
+			return nil, fmt.Errorf("logger: invalid %s option: %q: must be a positive duration", multilineFlushTimeoutKey, s)
+		}
+	}
+	return c, nil
+}
+
+// multilineAggregator aggregates the lines of a stream into multiline
+// messages, which are logged to dst.
+type multilineAggregator struct {
+	config   MultilineConfig
+	maxBytes int
+	dst      Logger
+
+	mu      sync.Mutex
+	pending *Message
+	lines   int
+	timer   *time.Timer
+}
+
+func newMultilineAggregator(config MultilineConfig, dst Logger, bufSize int) *multilineAggregator {
+	maxBytes := config.MaxBytes
+	if maxBytes > bufSize {
+		// messages larger than the buffer of the logger would be split again.
+		maxBytes = bufSize
+	}
+	return &multilineAggregator{
+		config:   config,
+		maxBytes: maxBytes,
+		dst:      dst,
+	}
+}
+
+// Log appends the line in msg to the pending message, or starts a new
+// message with it.
+func (a *multilineAggregator) Log(msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.pending != nil {
+		if a.config.Pattern.Match(msg.Line) || len(a.pending.Line)+1+len(msg.Line) > a.maxBytes {
+			a.flushLocked()
+		} else {
+			a.pending.Line = append(a.pending.Line, '\n')
+			a.pending.Line = append(a.pending.Line, msg.Line...)
+			a.lines++
+			PutMessage(msg)
+		}
+	}
+	if a.pending == nil {
+		a.pending = msg
+		a.lines = 1
+	}
+	if a.lines >= a.config.MaxLines {
+		a.flushLocked()
+		return
+	}
+
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.config.FlushTimeout, a.Flush)
+	} else {
+		a.timer.Reset(a.config.FlushTimeout)
+	}
+}
+
+// Flush logs the pending message, if any.
+func (a *multilineAggregator) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.flushLocked()
+}
+
+func (a *multilineAggregator) flushLocked() {
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	if a.pending == nil {
+		return
+	}
+	msg := a.pending
+	a.pending = nil
+	a.lines = 0
+	if logErr := a.dst.Log(msg); logErr != nil {
+		logDriverError(a.dst.Name(), string(msg.Line), logErr)
+	}
+}
@@ -0,0 +1,130 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/poll"
+)
+
+type syncBufferedLogger struct {
+	mu sync.Mutex
+	bufferedLogger
+}
+
+func (l *syncBufferedLogger) Log(msg *Message) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bufferedLogger.Log(msg)
+}
+
+func (l *syncBufferedLogger) lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bufferedLogger.lines()
+}
+
+func TestCopierMultiline(t *testing.T) {
+	config, err := ParseMultilineConfig(map[string]string{
+		"multiline-pattern":   `^\S`,
+		"multiline-max-lines": "3",
+	})
+	assert.NilError(t, err)
+
+	stdout := strings.Join([]string{
+		`Exception in thread "main" java.lang.IllegalStateException`,
+		"\tat com.example.Main.run(Main.java:12)",
+		"\tat com.example.Main.main(Main.java:5)",
+		"done",
+		"Traceback (most recent call last):",
+		`  File "main.py", line 1, in <module>`,
+		"  raise ValueError",
+		"ValueError",
+		"",
+	}, "\n")
+	stderr := "one\ntwo\n  three\n"
+
+	dst := &syncBufferedLogger{}
+	c := NewCopier(map[string]io.Reader{"stdout": strings.NewReader(stdout), "stderr": strings.NewReader(stderr)}, dst, WithMultiline(*config))
+	c.Run()
+	c.Wait()
+
+	var stdoutLines, stderrLines []string
+	for _, line := range dst.lines() {
+		if strings.HasPrefix(line, "stdout: ") {
+			stdoutLines = append(stdoutLines, strings.TrimPrefix(line, "stdout: "))
+		} else {
+			stderrLines = append(stderrLines, strings.TrimPrefix(line, "stderr: "))
+		}
+	}
+	assert.Check(t, is.DeepEqual(stdoutLines, []string{
+		"Exception in thread \"main\" java.lang.IllegalStateException\n\tat com.example.Main.run(Main.java:12)\n\tat com.example.Main.main(Main.java:5)",
+		"done",
+		// the message is split once it has the maximum number of lines.
+		"Traceback (most recent call last):\n  File \"main.py\", line 1, in <module>\n  raise ValueError",
+		"ValueError",
+	}))
+	assert.Check(t, is.DeepEqual(stderrLines, []string{"one", "two\n  three"}))
+}
+
+func TestMultilineAggregatorMaxBytes(t *testing.T) {
+	config, err := ParseMultilineConfig(map[string]string{"multiline-pattern": `^\S`, "multiline-max-bytes": "11"})
+	assert.NilError(t, err)
+	dst := &syncBufferedLogger{}
+	a := newMultilineAggregator(*config, dst, defaultBufSize)
+
+	for _, line := range []string{"start", " 1234", " 5678", " 9"} {
+		a.Log(&Message{Source: "stdout", Line: []byte(line)})
+	}
+	a.Flush()
+	assert.Check(t, is.DeepEqual(dst.lines(), []string{"stdout: start\n 1234", "stdout:  5678\n 9"}))
+}
+
+func TestMultilineAggregatorFlushTimeout(t *testing.T) {
+	config, err := ParseMultilineConfig(map[string]string{"multiline-pattern": `^\S`, "multiline-flush-timeout": "10ms"})
+	assert.NilError(t, err)
+	dst := &syncBufferedLogger{}
+	a := newMultilineAggregator(*config, dst, defaultBufSize)
+
+	a.Log(&Message{Source: "stdout", Line: []byte("start")})
+	a.Log(&Message{Source: "stdout", Line: []byte(" continued")})
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if len(dst.lines()) == 0 {
+			return poll.Continue("waiting for the message to be flushed")
+		}
+		return poll.Success()
+	}, poll.WithDelay(5*time.Millisecond), poll.WithTimeout(5*time.Second))
+	assert.Check(t, is.DeepEqual(dst.lines(), []string{"stdout: start\n continued"}))
+}
+
+func TestParseMultilineConfig(t *testing.T) {
+	config, err := ParseMultilineConfig(map[string]string{})
+	assert.NilError(t, err)
+	assert.Check(t, config == nil)
+
+	config, err = ParseMultilineConfig(map[string]string{"multiline-pattern": "^start", "multiline-max-bytes": "1k"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(config.Pattern.String(), "^start"))
+	assert.Check(t, is.Equal(config.MaxLines, defaultMultilineMaxLines))
+	assert.Check(t, is.Equal(config.MaxBytes, 1024))
+	assert.Check(t, is.Equal(config.FlushTimeout, defaultMultilineFlushTimeout))
+
+	for _, tc := range []struct {
+		opts        map[string]string
+		expectedErr string
+	}{
+		{opts: map[string]string{"multiline-max-lines": "10"}, expectedErr: "multiline-max-lines option requires multiline-pattern"},
+		{opts: map[string]string{"multiline-pattern": "("}, expectedErr: "error parsing option multiline-pattern"},
+		{opts: map[string]string{"multiline-pattern": "^a", "multiline-max-lines": "0"}, expectedErr: "invalid multiline-max-lines option"},
+		{opts: map[string]string{"multiline-pattern": "^a", "multiline-max-bytes": "-1"}, expectedErr: "multiline-max-bytes"},
+		{opts: map[string]string{"multiline-pattern": "^a", "multiline-flush-timeout": "1"}, expectedErr: "invalid multiline-flush-timeout option"},
+	} {
+		_, err := ParseMultilineConfig(tc.opts)
+		assert.Check(t, is.ErrorContains(err, tc.expectedErr), "%v", tc.opts)
+	}
+}
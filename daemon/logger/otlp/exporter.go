@@ -0,0 +1,173 @@
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/pkg/pools"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// exportMethod is the method of the OTLP logs service exporting logs.
+	exportMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+	// maxResponseSize is the max amount that will be read from an http response
+	maxResponseSize = 64 * 1024
+)
+
+// exporter sends encoded ExportLogsServiceRequests to a collector.
+type exporter interface {
+	// export sends the request, and returns the response of the collector.
+	// Errors which may succeed when retried are wrapped in a retryableError.
+	export(ctx context.Context, request []byte) ([]byte, error)
+	close() error
+}
+
+// retryableError is an error exporting logs, which may succeed if retried
+// after the given delay, if any.
+type retryableError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
+// rawCodec sends and receives messages which are already encoded.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	// the messages are protobuf messages, so that the content-type of
+	// requests matches what collectors expect.
+	return "proto"
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	headers metadata.MD
+}
+
+func newGRPCExporter(endpoint string, tlsConfig *tls.Config, headers map[string]string) (*grpcExporter, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcExporter{conn: conn, headers: metadata.New(headers)}, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, request []byte) ([]byte, error) {
+	ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	var response []byte
+	err := e.conn.Invoke(ctx, exportMethod, &request, &response, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+			codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+			return nil, retryableError{err: err}
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPExporter(url string, tlsConfig *tls.Config, headers map[string]string) *httpExporter {
+	return &httpExporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+				Proxy:           http.ProxyFromEnvironment,
+			},
+		},
+		url:     url,
+		headers: headers,
+	}
+}
+
+func (e *httpExporter) export(ctx context.Context, request []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, retryableError{err: err}
+	}
+	defer func() {
+		pools.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, retryableError{err: err}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		var delay time.Duration
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+			delay = time.Duration(s) * time.Second
+		}
+		return nil, retryableError{err: errors.Errorf("failed to export logs: %s", resp.Status), delay: delay}
+	default:
+		return nil, errors.Errorf("failed to export logs: %s", resp.Status)
+	}
+}
+
+func (e *httpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
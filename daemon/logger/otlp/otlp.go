@@ -0,0 +1,480 @@
+// Package otlp provides the log driver for forwarding server logs to
+// OpenTelemetry collectors using the OpenTelemetry Protocol (OTLP).
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/dockerversion"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	driverName = "otlp"
+
+	endpointKey      = "otlp-endpoint"
+	protocolKey      = "otlp-protocol"
+	insecureKey      = "otlp-insecure"
+	tlsCACertKey     = "otlp-tls-ca-cert"
+	tlsCertKey       = "otlp-tls-cert"
+	tlsKeyKey        = "otlp-tls-key"
+	tlsSkipVerifyKey = "otlp-tls-skip-verify"
+	headersKey       = "otlp-headers"
+	timeoutKey       = "otlp-timeout"
+	batchSizeKey     = "otlp-batch-size"
+	batchTimeoutKey  = "otlp-batch-timeout"
+	maxRetriesKey    = "otlp-max-retries"
+	envKey           = "env"
+	envRegexKey      = "env-regex"
+	labelsKey        = "labels"
+	labelsRegexKey   = "labels-regex"
+)
+
+const (
+	protocolGRPC = "grpc"
+	protocolHTTP = "http/protobuf"
+
+	// httpLogsPath is the path logs are exported to with OTLP/HTTP, unless
+	// the endpoint has another path.
+	httpLogsPath = "/v1/logs"
+
+	defaultTimeout      = 10 * time.Second
+	defaultBatchSize    = 512
+	defaultBatchTimeout = time.Second
+	defaultMaxRetries   = 5
+
+	// scopeName is the name of the instrumentation scope of log records.
+	scopeName = "github.com/docker/docker/daemon/logger/otlp"
+)
+
+var (
+	// initial and maximum delay between retries of failed exports.
+	retryInitialDelay = 500 * time.Millisecond
+	retryMaxDelay     = 30 * time.Second
+)
+
+type otlpLogger struct {
+	exporter exporter
+	resource []byte
+	scope    []byte
+
+	timeout      time.Duration
+	batchSize    int
+	batchTimeout time.Duration
+	maxRetries   int
+
+	// records are encoded by Log, and sent to the worker which batches
+	// and exports them. Records are dropped if the worker falls behind, so
+	// that a slow collector does not block the container.
+	stream  chan []byte
+	dropped uint64
+	done    chan struct{}
+	lock    sync.RWMutex
+	closed  bool
+
+	// ctx is cancelled by Close, which interrupts the retries of exports,
+	// so that the remaining records are exported once without waiting.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(driverName, New); err != nil {
+		panic(err)
+	}
+	if err := logger.RegisterLogOptValidator(driverName, ValidateLogOpt); err != nil {
+		panic(err)
+	}
+}
+
+// New creates an otlp logger using the configuration passed in on the
+// context.
+func New(info logger.Info) (logger.Logger, error) {
+	cfg, err := parseConfig(info.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var exp exporter
+	switch cfg.protocol {
+	case protocolHTTP:
+		exp = newHTTPExporter(cfg.endpoint, cfg.tlsConfig, cfg.headers)
+	default:
+		exp, err = newGRPCExporter(cfg.endpoint, cfg.tlsConfig, cfg.headers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attrs, err := resourceAttributes(info)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := encodeResource(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &otlpLogger{
+		exporter:     exp,
+		resource:     resource,
+		scope:        encodeScope(scopeName, dockerversion.Version),
+		timeout:      cfg.timeout,
+		batchSize:    cfg.batchSize,
+		batchTimeout: cfg.batchTimeout,
+		maxRetries:   cfg.maxRetries,
+		stream:       make(chan []byte, 4*cfg.batchSize),
+		done:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+	go l.worker()
+	return l, nil
+}
+
+// resourceAttributes returns the attributes of the resource the records of
+// the container are attributed to.
+func resourceAttributes(info logger.Info) (map[string]string, error) {
+	hostname, err := info.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := info.ExtraAttributes(nil)
+	if err != nil {
+		return nil, err
+	}
+	attrs["service.name"] = info.Name()
+	attrs["host.name"] = hostname
+	attrs["container.id"] = info.ContainerID
+	attrs["container.name"] = info.Name()
+	attrs["container.image.name"] = info.ImageName()
+	attrs["container.image.id"] = info.ImageFullID()
+	attrs["container.runtime"] = "docker"
+
+	for label, attr := range map[string]string{
+		"com.docker.swarm.service.id":   "docker.swarm.service.id",
+		"com.docker.swarm.service.name": "docker.swarm.service.name",
+		"com.docker.swarm.task.id":      "docker.swarm.task.id",
+		"com.docker.swarm.task.name":    "docker.swarm.task.name",
+		"com.docker.swarm.node.id":      "docker.swarm.node.id",
+	} {
+		if v := info.ContainerLabels[label]; v != "" {
+			attrs[attr] = v
+		}
+	}
+	if service := info.ContainerLabels["com.docker.swarm.service.name"]; service != "" {
+		attrs["service.name"] = service
+	}
+	return attrs, nil
+}
+
+func (l *otlpLogger) Log(msg *logger.Message) error {
+	record, err := encodeLogRecord(msg, time.Now())
+	logger.PutMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l.closed {
+		return fmt.Errorf("%s: driver is closed", driverName)
+	}
+	select {
+	case l.stream <- record:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+	}
+	return nil
+}
+
+// reportDropped logs the number of records dropped by Log since it was last
+// reported.
+func (l *otlpLogger) reportDropped() {
+	if n := atomic.SwapUint64(&l.dropped, 0); n > 0 {
+		logrus.WithField("module", "logger/otlp").Warnf("Log queue is full, dropped %d log records", n)
+	}
+}
+
+func (l *otlpLogger) worker() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.batchTimeout)
+	defer ticker.Stop()
+
+	var records [][]byte
+	for {
+		select {
+		case record, open := <-l.stream:
+			if !open {
+				// the driver is closing, so only try to export once.
+				l.export(records, true)
+				l.reportDropped()
+				return
+			}
+			records = append(records, record)
+			if len(records) >= l.batchSize {
+				l.export(records, false)
+				records = nil
+			}
+		case <-ticker.C:
+			l.reportDropped()
+			if len(records) > 0 {
+				l.export(records, false)
+				records = nil
+			}
+		}
+	}
+}
+
+// export exports the records, retrying on failures which may be temporary,
+// until the driver is closed. The records are dropped if they could not be
+// exported.
+func (l *otlpLogger) export(records [][]byte, lastChance bool) {
+	if len(records) == 0 {
+		return
+	}
+	request := encodeExportRequest(l.resource, l.scope, records)
+	for attempt := 0; ; attempt++ {
+		parent := l.ctx
+		if lastChance {
+			parent = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(parent, l.timeout)
+		response, err := l.exporter.export(ctx, request)
+		cancel()
+		if err == nil {
+			rejected, msg, err := decodeExportResponse(response)
+			if err != nil {
+				logrus.WithError(err).WithField("module", "logger/otlp").Warn("Error decoding response of the collector")
+			} else if rejected > 0 {
+				logrus.WithField("module", "logger/otlp").Warnf("Collector rejected %d log records: %s", rejected, msg)
+			}
+			return
+		}
+
+		if !lastChance && l.ctx.Err() != nil {
+			// the driver is closing, so only try to export once more.
+			lastChance = true
+			continue
+		}
+		var retryable retryableError
+		if lastChance || attempt >= l.maxRetries || !errors.As(err, &retryable) {
+			logrus.WithError(err).WithField("module", "logger/otlp").Errorf("Failed to export logs, dropping %d log records", len(records))
+			return
+		}
+		delay := retryable.delay
+		if delay == 0 {
+			delay = retryMaxDelay
+			if attempt < 16 {
+				delay = retryInitialDelay << attempt
+			}
+		}
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+		logrus.WithError(err).WithField("module", "logger/otlp").Debugf("Failed to export logs, retrying in %s", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-l.ctx.Done():
+			timer.Stop()
+			lastChance = true
+		}
+	}
+}
+
+// Close stops the retries of exports, and waits for the remaining records to
+// be exported. The worker only tries once more to export the batch it was
+// retrying, and once to export the remaining records, so waiting for it is
+// capped at twice the export timeout.
+func (l *otlpLogger) Close() error {
+	l.lock.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.stream)
+	}
+	l.lock.Unlock()
+	l.cancel()
+
+	timer := time.NewTimer(2 * l.timeout)
+	defer timer.Stop()
+	select {
+	case <-l.done:
+	case <-timer.C:
+		logrus.WithField("module", "logger/otlp").Warn("Timed out exporting the remaining log records")
+	}
+	return l.exporter.close()
+}
+
+func (l *otlpLogger) Name() string {
+	return driverName
+}
+
+type config struct {
+	protocol     string
+	endpoint     string
+	tlsConfig    *tls.Config
+	headers      map[string]string
+	timeout      time.Duration
+	batchSize    int
+	batchTimeout time.Duration
+	maxRetries   int
+}
+
+func parseConfig(cfg map[string]string) (*config, error) {
+	c := &config{
+		protocol:     protocolGRPC,
+		timeout:      defaultTimeout,
+		batchSize:    defaultBatchSize,
+		batchTimeout: defaultBatchTimeout,
+		maxRetries:   defaultMaxRetries,
+	}
+	if p, ok := cfg[protocolKey]; ok {
+		switch p {
+		case protocolGRPC, protocolHTTP:
+			c.protocol = p
+		default:
+			return nil, fmt.Errorf("%s: invalid %s: %q: must be %q or %q", driverName, protocolKey, p, protocolGRPC, protocolHTTP)
+		}
+	}
+
+	endpoint, ok := cfg[endpointKey]
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("%s: %s is expected", driverName, endpointKey)
+	}
+	insecure := false
+	if s, ok := cfg[insecureKey]; ok {
+		var err error
+		if insecure, err = strconv.ParseBool(s); err != nil {
+			return nil, errors.Wrapf(err, "%s: invalid %s", driverName, insecureKey)
+		}
+	}
+	var useTLS bool
+	switch c.protocol {
+	case protocolHTTP:
+		// the scheme of the endpoint selects whether to use TLS.
+		if _, ok := cfg[insecureKey]; ok {
+			return nil, fmt.Errorf("%s: %s is not supported with protocol %s: use an http:// endpoint instead", driverName, insecureKey, protocolHTTP)
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%s: expected format http(s)://host:port[/path] for %s with protocol %s", driverName, endpointKey, protocolHTTP)
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = httpLogsPath
+		}
+		c.endpoint = u.String()
+		useTLS = u.Scheme == "https"
+	default:
+		// the endpoint of gRPC exporters is a host and port, optionally
+		// prefixed with a scheme selecting whether to use TLS.
+		useTLS = !insecure
+		if u, err := url.Parse(endpoint); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			useTLS = u.Scheme == "https"
+			endpoint = u.Host
+		}
+		c.endpoint = endpoint
+	}
+
+	if useTLS {
+		_, skipVerify := cfg[tlsSkipVerifyKey]
+		if s, ok := cfg[tlsSkipVerifyKey]; ok && s != "" {
+			var err error
+			if skipVerify, err = strconv.ParseBool(s); err != nil {
+				return nil, errors.Wrapf(err, "%s: invalid %s", driverName, tlsSkipVerifyKey)
+			}
+		}
+		tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             cfg[tlsCACertKey],
+			CertFile:           cfg[tlsCertKey],
+			KeyFile:            cfg[tlsKeyKey],
+			InsecureSkipVerify: skipVerify,
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.tlsConfig = tlsConfig
+	}
+
+	if s, ok := cfg[headersKey]; ok && s != "" {
+		c.headers = make(map[string]string)
+		for _, h := range strings.Split(s, ",") {
+			kv := strings.SplitN(h, "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return nil, fmt.Errorf("%s: invalid header %q in %s: expected key=value", driverName, h, headersKey)
+			}
+			c.headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	var err error
+	if s, ok := cfg[timeoutKey]; ok {
+		if c.timeout, err = parsePositiveDuration(timeoutKey, s); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := cfg[batchTimeoutKey]; ok {
+		if c.batchTimeout, err = parsePositiveDuration(batchTimeoutKey, s); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := cfg[batchSizeKey]; ok {
+		if c.batchSize, err = strconv.Atoi(s); err != nil || c.batchSize <= 0 {
+			return nil, fmt.Errorf("%s: invalid %s: %q: must be a positive integer", driverName, batchSizeKey, s)
+		}
+	}
+	if s, ok := cfg[maxRetriesKey]; ok {
+		if c.maxRetries, err = strconv.Atoi(s); err != nil || c.maxRetries < 0 {
+			return nil, fmt.Errorf("%s: invalid %s: %q: must be a non-negative integer", driverName, maxRetriesKey, s)
+		}
+	}
+	return c, nil
+}
+
+func parsePositiveDuration(key, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s: invalid %s: %q: must be a positive duration", driverName, key, s)
+	}
+	return d, nil
+}
+
+// ValidateLogOpt looks for all supported by otlp driver options
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case endpointKey:
+		case protocolKey:
+		case insecureKey:
+		case tlsCACertKey:
+		case tlsCertKey:
+		case tlsKeyKey:
+		case tlsSkipVerifyKey:
+		case headersKey:
+		case timeoutKey:
+		case batchSizeKey:
+		case batchTimeoutKey:
+		case maxRetriesKey:
+		case envKey:
+		case envRegexKey:
+		case labelsKey:
+		case labelsRegexKey:
+		default:
+			return fmt.Errorf("unknown log opt '%s' for %s log driver", key, driverName)
+		}
+	}
+	_, err := parseConfig(cfg)
+	return err
+}
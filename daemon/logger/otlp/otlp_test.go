@@ -0,0 +1,324 @@
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type exportedRecord struct {
+	body  string
+	attrs map[string]string
+}
+
+// collectorStub is an in-process collector, which records the logs it
+// receives.
+type collectorStub struct {
+	mu       sync.Mutex
+	requests int
+	resource map[string]string
+	records  []exportedRecord
+	headers  map[string]string
+}
+
+func (c *collectorStub) receive(t *testing.T, request []byte, headers map[string]string) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	c.headers = headers
+
+	resourceLogs := fieldValues(t, request, exportRequestResourceLogs)
+	assert.Assert(t, is.Len(resourceLogs, 1))
+	var resource resourcepb.Resource
+	assert.NilError(t, proto.Unmarshal(fieldValues(t, resourceLogs[0], resourceLogsResource)[0], &resource))
+	c.resource = keyValueMap(resource.Attributes)
+
+	for _, scopeLogs := range fieldValues(t, resourceLogs[0], resourceLogsScopeLogs) {
+		scope := fieldValues(t, scopeLogs, scopeLogsScope)[0]
+		assert.Check(t, is.Equal(string(fieldValues(t, scope, scopeFieldName)[0]), scopeName))
+		for _, record := range fieldValues(t, scopeLogs, scopeLogsLogRecords) {
+			var body commonpb.AnyValue
+			assert.NilError(t, proto.Unmarshal(fieldValues(t, record, logRecordBody)[0], &body))
+			var attrs []*commonpb.KeyValue
+			for _, b := range fieldValues(t, record, logRecordAttributes) {
+				var kv commonpb.KeyValue
+				assert.NilError(t, proto.Unmarshal(b, &kv))
+				attrs = append(attrs, &kv)
+			}
+			c.records = append(c.records, exportedRecord{body: body.GetStringValue(), attrs: keyValueMap(attrs)})
+		}
+	}
+}
+
+func (c *collectorStub) received() ([]exportedRecord, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.records, c.requests
+}
+
+// fieldValues returns the values of the length-delimited field num in b.
+func fieldValues(t *testing.T, b []byte, num protowire.Number) [][]byte {
+	t.Helper()
+	var values [][]byte
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		assert.Assert(t, l >= 0)
+		b = b[l:]
+		if n == num && typ == protowire.BytesType {
+			v, l := protowire.ConsumeBytes(b)
+			assert.Assert(t, l >= 0)
+			values = append(values, v)
+			b = b[l:]
+			continue
+		}
+		l = protowire.ConsumeFieldValue(n, typ, b)
+		assert.Assert(t, l >= 0)
+		b = b[l:]
+	}
+	return values
+}
+
+func keyValueMap(kvs []*commonpb.KeyValue) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value.GetStringValue()
+	}
+	return m
+}
+
+func newGRPCCollectorStub(t *testing.T) (*collectorStub, string) {
+	c := &collectorStub{}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	srv := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "opentelemetry.proto.collector.logs.v1.LogsService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				var request []byte
+				if err := dec(&request); err != nil {
+					return nil, err
+				}
+				md, _ := metadata.FromIncomingContext(ctx)
+				c.receive(t, request, map[string]string{"authorization": md.Get("authorization")[0]})
+				response := []byte{}
+				return &response, nil
+			},
+		}},
+	}, c)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return c, l.Addr().String()
+}
+
+func testInfo(config map[string]string) logger.Info {
+	return logger.Info{
+		Config:             config,
+		ContainerID:        "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		ContainerName:      "/web",
+		ContainerImageID:   "sha256:abcd",
+		ContainerImageName: "nginx:latest",
+		ContainerLabels: map[string]string{
+			"com.example.team":              "infra",
+			"com.docker.swarm.service.name": "frontend",
+			"com.docker.swarm.task.id":      "task1",
+		},
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	collector, addr := newGRPCCollectorStub(t)
+
+	l, err := New(testInfo(map[string]string{
+		endpointKey:     addr,
+		insecureKey:     "true",
+		headersKey:      "authorization=Bearer token",
+		batchSizeKey:    "2",
+		batchTimeoutKey: "1h",
+		labelsKey:       "com.example.team",
+	}))
+	assert.NilError(t, err)
+
+	for _, line := range []string{"one", "two", "three"} {
+		assert.NilError(t, l.Log(&logger.Message{Line: []byte(line), Source: "stdout", Timestamp: time.Now()}))
+	}
+	// the first batch is exported once full, the rest when closing.
+	poll(t, func() bool {
+		_, requests := collector.received()
+		return requests == 1
+	})
+	assert.NilError(t, l.Close())
+
+	records, requests := collector.received()
+	assert.Check(t, is.Equal(requests, 2))
+	assert.Assert(t, is.Len(records, 3))
+	for i, line := range []string{"one", "two", "three"} {
+		assert.Check(t, is.Equal(records[i].body, line))
+		assert.Check(t, is.Equal(records[i].attrs["log.iostream"], "stdout"))
+	}
+	assert.Check(t, is.Equal(collector.headers["authorization"], "Bearer token"))
+	assert.Check(t, is.Equal(collector.resource["service.name"], "frontend"))
+	assert.Check(t, is.Equal(collector.resource["container.name"], "web"))
+	assert.Check(t, is.Equal(collector.resource["container.id"], "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"))
+	assert.Check(t, is.Equal(collector.resource["container.image.name"], "nginx:latest"))
+	assert.Check(t, is.Equal(collector.resource["docker.swarm.service.name"], "frontend"))
+	assert.Check(t, is.Equal(collector.resource["docker.swarm.task.id"], "task1"))
+	assert.Check(t, is.Equal(collector.resource["com.example.team"], "infra"))
+}
+
+func TestHTTPRetry(t *testing.T) {
+	defer func(d time.Duration) { retryInitialDelay = d }(retryInitialDelay)
+	retryInitialDelay = time.Millisecond
+
+	collector := &collectorStub{}
+	var attempts int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Check(t, is.Equal(r.URL.Path, httpLogsPath))
+		assert.Check(t, is.Equal(r.Header.Get("Content-Type"), "application/x-protobuf"))
+		b, err := io.ReadAll(r.Body)
+		assert.Check(t, err)
+		collector.receive(t, b, nil)
+	}))
+	defer srv.Close()
+
+	l, err := New(testInfo(map[string]string{
+		endpointKey:      srv.URL,
+		protocolKey:      protocolHTTP,
+		tlsSkipVerifyKey: "true",
+		batchTimeoutKey:  "10ms",
+	}))
+	assert.NilError(t, err)
+	assert.NilError(t, l.Log(&logger.Message{Line: []byte("hello"), Source: "stderr", Timestamp: time.Now()}))
+	poll(t, func() bool {
+		_, requests := collector.received()
+		return requests == 1
+	})
+	assert.NilError(t, l.Close())
+
+	records, _ := collector.received()
+	assert.Assert(t, is.Len(records, 1))
+	assert.Check(t, is.Equal(records[0].body, "hello"))
+	assert.Check(t, is.Equal(records[0].attrs["log.iostream"], "stderr"))
+	assert.Check(t, is.Equal(attempts, 3))
+}
+
+func TestHTTPNotRetried(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	l, err := New(testInfo(map[string]string{
+		endpointKey: srv.URL + "/custom/path",
+		protocolKey: protocolHTTP,
+	}))
+	assert.NilError(t, err)
+	l.(*otlpLogger).export([][]byte{{}}, false)
+	assert.Check(t, is.Equal(attempts, 1))
+	assert.NilError(t, l.Close())
+}
+
+func TestCloseInterruptsRetries(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	l, err := New(testInfo(map[string]string{
+		endpointKey:     srv.URL,
+		protocolKey:     protocolHTTP,
+		batchTimeoutKey: "10ms",
+	}))
+	assert.NilError(t, err)
+	assert.NilError(t, l.Log(&logger.Message{Line: []byte("hello"), Timestamp: time.Now()}))
+	poll(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts == 1
+	})
+
+	start := time.Now()
+	assert.NilError(t, l.Close())
+	assert.Check(t, time.Since(start) < 10*time.Second, "Close waited for the retry delay")
+	mu.Lock()
+	defer mu.Unlock()
+	// the records being retried are exported once more when closing.
+	assert.Check(t, is.Equal(attempts, 2))
+}
+
+func TestLogQueueFull(t *testing.T) {
+	l := &otlpLogger{stream: make(chan []byte, 1)}
+	assert.NilError(t, l.Log(&logger.Message{Line: []byte("first"), Timestamp: time.Now()}))
+	assert.NilError(t, l.Log(&logger.Message{Line: []byte("second"), Timestamp: time.Now()}))
+	assert.Check(t, is.Len(l.stream, 1))
+	assert.Check(t, is.Equal(l.dropped, uint64(1)))
+
+	l.reportDropped()
+	assert.Check(t, is.Equal(l.dropped, uint64(0)))
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	assert.Check(t, ValidateLogOpt(map[string]string{endpointKey: "collector:4317", insecureKey: "true"}))
+	assert.Check(t, ValidateLogOpt(map[string]string{endpointKey: "http://collector:4318", protocolKey: protocolHTTP}))
+
+	for _, tc := range []struct {
+		cfg         map[string]string
+		expectedErr string
+	}{
+		{cfg: map[string]string{}, expectedErr: "otlp-endpoint is expected"},
+		{cfg: map[string]string{endpointKey: "collector:4317", "foo": "bar"}, expectedErr: "unknown log opt 'foo'"},
+		{cfg: map[string]string{endpointKey: "collector:4317", protocolKey: "udp"}, expectedErr: "invalid otlp-protocol"},
+		{cfg: map[string]string{endpointKey: "collector:4318", protocolKey: protocolHTTP}, expectedErr: "expected format http(s)://host:port[/path]"},
+		{cfg: map[string]string{endpointKey: "https://collector:4318", protocolKey: protocolHTTP, insecureKey: "true"}, expectedErr: "otlp-insecure is not supported with protocol http/protobuf"},
+		{cfg: map[string]string{endpointKey: "collector:4317", headersKey: "foo"}, expectedErr: "invalid header"},
+		{cfg: map[string]string{endpointKey: "collector:4317", batchSizeKey: "0"}, expectedErr: "invalid otlp-batch-size"},
+		{cfg: map[string]string{endpointKey: "collector:4317", timeoutKey: "1"}, expectedErr: "invalid otlp-timeout"},
+		{cfg: map[string]string{endpointKey: "collector:4317", maxRetriesKey: "-1"}, expectedErr: "invalid otlp-max-retries"},
+	} {
+		assert.Check(t, is.ErrorContains(ValidateLogOpt(tc.cfg), tc.expectedErr), "%v", tc.cfg)
+	}
+}
+
+func poll(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the collector")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
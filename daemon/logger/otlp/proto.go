@@ -0,0 +1,213 @@
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docker/docker/daemon/logger"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// The messages of the OTLP logs service are encoded directly, as only the
+// common and resource messages are available as generated code. See
+// https://github.com/open-telemetry/opentelemetry-proto/blob/v1.0.0/opentelemetry/proto/logs/v1/logs.proto
+// for the definitions of the field numbers below.
+const (
+	// ExportLogsServiceRequest
+	exportRequestResourceLogs protowire.Number = 1
+
+	// ExportLogsServiceResponse
+	exportResponsePartialSuccess protowire.Number = 1
+
+	// ExportLogsPartialSuccess
+	partialSuccessRejectedLogRecords protowire.Number = 1
+	partialSuccessErrorMessage       protowire.Number = 2
+
+	// ResourceLogs
+	resourceLogsResource  protowire.Number = 1
+	resourceLogsScopeLogs protowire.Number = 2
+
+	// ScopeLogs
+	scopeLogsScope      protowire.Number = 1
+	scopeLogsLogRecords protowire.Number = 2
+
+	// InstrumentationScope
+	scopeFieldName    protowire.Number = 1
+	scopeFieldVersion protowire.Number = 2
+
+	// LogRecord
+	logRecordTimeUnixNano         protowire.Number = 1
+	logRecordBody                 protowire.Number = 5
+	logRecordAttributes           protowire.Number = 6
+	logRecordObservedTimeUnixNano protowire.Number = 11
+)
+
+// encodeResource encodes the resource the records of a container are
+// attributed to.
+func encodeResource(attrs map[string]string) ([]byte, error) {
+	return proto.Marshal(&resourcepb.Resource{Attributes: keyValues(attrs)})
+}
+
+// encodeScope encodes the instrumentation scope of the records, identifying
+// the daemon as their producer.
+func encodeScope(name, version string) []byte {
+	var b []byte
+	b = appendString(b, scopeFieldName, name)
+	b = appendString(b, scopeFieldVersion, version)
+	return b
+}
+
+// encodeExportRequest encodes an ExportLogsServiceRequest holding the given
+// log records, which are all attributed to the same resource and scope.
+func encodeExportRequest(resource, scope []byte, records [][]byte) []byte {
+	var scopeLogs []byte
+	scopeLogs = appendBytes(scopeLogs, scopeLogsScope, scope)
+	for _, r := range records {
+		scopeLogs = appendBytes(scopeLogs, scopeLogsLogRecords, r)
+	}
+
+	var resourceLogs []byte
+	resourceLogs = appendBytes(resourceLogs, resourceLogsResource, resource)
+	resourceLogs = appendBytes(resourceLogs, resourceLogsScopeLogs, scopeLogs)
+
+	return appendBytes(nil, exportRequestResourceLogs, resourceLogs)
+}
+
+// encodeLogRecord encodes msg as a LogRecord.
+func encodeLogRecord(msg *logger.Message, observed time.Time) ([]byte, error) {
+	body := &commonpb.AnyValue{}
+	if utf8.Valid(msg.Line) {
+		body.Value = &commonpb.AnyValue_StringValue{StringValue: string(msg.Line)}
+	} else {
+		body.Value = &commonpb.AnyValue_BytesValue{BytesValue: append([]byte(nil), msg.Line...)}
+	}
+	encodedBody, err := proto.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []*commonpb.KeyValue{stringKeyValue("log.iostream", msg.Source)}
+	for _, a := range msg.Attrs {
+		attrs = append(attrs, stringKeyValue(a.Key, a.Value))
+	}
+	if p := msg.PLogMetaData; p != nil {
+		attrs = append(attrs,
+			stringKeyValue("docker.partial_id", p.ID),
+			&commonpb.KeyValue{Key: "docker.partial_ordinal", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(p.Ordinal)}}},
+			&commonpb.KeyValue{Key: "docker.partial_last", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: p.Last}}},
+		)
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, logRecordTimeUnixNano, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(msg.Timestamp.UnixNano()))
+	b = appendBytes(b, logRecordBody, encodedBody)
+	for _, kv := range attrs {
+		encoded, err := proto.Marshal(kv)
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, logRecordAttributes, encoded)
+	}
+	b = protowire.AppendTag(b, logRecordObservedTimeUnixNano, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(observed.UnixNano()))
+	return b, nil
+}
+
+// decodeExportResponse decodes an ExportLogsServiceResponse, returning the
+// number of log records the collector rejected, and why.
+func decodeExportResponse(b []byte) (rejected int64, errMsg string, err error) {
+	partialSuccess, err := findBytes(b, exportResponsePartialSuccess)
+	if err != nil || partialSuccess == nil {
+		return 0, "", err
+	}
+	for len(partialSuccess) > 0 {
+		num, typ, n := protowire.ConsumeTag(partialSuccess)
+		if n < 0 {
+			return 0, "", protowire.ParseError(n)
+		}
+		partialSuccess = partialSuccess[n:]
+		switch {
+		case num == partialSuccessRejectedLogRecords && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(partialSuccess)
+			if n < 0 {
+				return 0, "", protowire.ParseError(n)
+			}
+			rejected = int64(v)
+			partialSuccess = partialSuccess[n:]
+		case num == partialSuccessErrorMessage && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(partialSuccess)
+			if n < 0 {
+				return 0, "", protowire.ParseError(n)
+			}
+			errMsg = string(v)
+			partialSuccess = partialSuccess[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, partialSuccess)
+			if n < 0 {
+				return 0, "", protowire.ParseError(n)
+			}
+			partialSuccess = partialSuccess[n:]
+		}
+	}
+	return rejected, errMsg, nil
+}
+
+// findBytes returns the value of the last length-delimited field num in b,
+// or nil if there is no such field.
+func findBytes(b []byte, num protowire.Number) ([]byte, error) {
+	var found []byte
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return nil, protowire.ParseError(l)
+		}
+		b = b[l:]
+		if n == num && typ == protowire.BytesType {
+			v, l := protowire.ConsumeBytes(b)
+			if l < 0 {
+				return nil, protowire.ParseError(l)
+			}
+			found = v
+			b = b[l:]
+			continue
+		}
+		l = protowire.ConsumeFieldValue(n, typ, b)
+		if l < 0 {
+			return nil, protowire.ParseError(l)
+		}
+		b = b[l:]
+	}
+	return found, nil
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func stringKeyValue(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+func keyValues(attrs map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, k := range keys {
+		kvs = append(kvs, stringKeyValue(k, attrs[k]))
+	}
+	return kvs
+}
@@ -0,0 +1,320 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// Log options configuring the rate limit of the messages of a container.
+const (
+	rateLimitLinesKey      = "rate-limit-lines"
+	rateLimitLinesBurstKey = "rate-limit-lines-burst"
+	rateLimitBytesKey      = "rate-limit-bytes"
+	rateLimitBytesBurstKey = "rate-limit-bytes-burst"
+	rateLimitPolicyKey     = "rate-limit-policy"
+	rateLimitSampleKey     = "rate-limit-sample"
+)
+
+// Policies applied to messages exceeding the rate limit.
+const (
+	// RateLimitPolicyDrop drops all messages exceeding the rate limit.
+	RateLimitPolicyDrop = "drop"
+	// RateLimitPolicySample keeps one message out of every N messages
+	// exceeding the rate limit, and drops the others.
+	RateLimitPolicySample = "sample"
+
+	defaultRateLimitSample = 10
+)
+
+// RateLimitConfig is the rate limit applied to the messages of a container.
+type RateLimitConfig struct {
+	// Lines is the number of messages per second, or 0 if unlimited.
+	Lines int
+	// LinesBurst is the number of messages which may exceed Lines at once.
+	LinesBurst int
+	// Bytes is the number of bytes per second, or 0 if unlimited.
+	Bytes int64
+	// BytesBurst is the number of bytes which may exceed Bytes at once.
+	BytesBurst int64
+	// Policy is RateLimitPolicyDrop or RateLimitPolicySample.
+	Policy string
+	// Sample is the number of messages exceeding the rate limit out of
+	// which one is kept with RateLimitPolicySample.
+	Sample int
+}
+
+// ParseRateLimitConfig parses the rate limit log options in cfg. It returns
+// nil if no rate limit is configured.
+func ParseRateLimitConfig(cfg map[string]string) (*RateLimitConfig, error) {
+	c := &RateLimitConfig{Policy: RateLimitPolicyDrop, Sample: defaultRateLimitSample}
+	var err error
+	if s, ok := cfg[rateLimitLinesKey]; ok {
+		if c.Lines, err = parsePositiveInt(rateLimitLinesKey, s); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := cfg[rateLimitBytesKey]; ok {
+		if c.Bytes, err = parsePositiveSize(rateLimitBytesKey, s); err != nil {
+			return nil, err
+		}
+	}
+	if c.Lines == 0 && c.Bytes == 0 {
+		for _, k := range []string{rateLimitLinesBurstKey, rateLimitBytesBurstKey, rateLimitPolicyKey, rateLimitSampleKey} {
+			if _, ok := cfg[k]; ok {
+				return nil, fmt.Errorf("logger: %s option requires %s or %s", k, rateLimitLinesKey, rateLimitBytesKey)
+			}
+		}
+		return nil, nil
+	}
+
+	// by default, allow bursts of one second worth of logs.
+	c.LinesBurst, c.BytesBurst = c.Lines, c.Bytes
+	if s, ok := cfg[rateLimitLinesBurstKey]; ok {
+		if c.Lines == 0 {
+			return nil, fmt.Errorf("logger: %s option requires %s", rateLimitLinesBurstKey, rateLimitLinesKey)
+		}
+		if c.LinesBurst, err = parsePositiveInt(rateLimitLinesBurstKey, s); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := cfg[rateLimitBytesBurstKey]; ok {
+		if c.Bytes == 0 {
+			return nil, fmt.Errorf("logger: %s option requires %s", rateLimitBytesBurstKey, rateLimitBytesKey)
+		}
+		if c.BytesBurst, err = parsePositiveSize(rateLimitBytesBurstKey, s); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := cfg[rateLimitPolicyKey]; ok {
+		switch s {
+		case RateLimitPolicyDrop, RateLimitPolicySample:
+			c.Policy = s
+		default:
+			return nil, fmt.Errorf("logger: invalid %s option: %q: must be %q or %q", rateLimitPolicyKey, s, RateLimitPolicyDrop, RateLimitPolicySample)
+		}
+	}
+	if s, ok := cfg[rateLimitSampleKey]; ok {
+		if c.Policy != RateLimitPolicySample {
+			return nil, fmt.Errorf("logger: %s option is only supported with '%s=%s'", rateLimitSampleKey, rateLimitPolicyKey, RateLimitPolicySample)
+		}
+		if c.Sample, err = parsePositiveInt(rateLimitSampleKey, s); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func parsePositiveInt(key, s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing option %s", key)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("logger: invalid %s option: %d: must be positive", key, v)
+	}
+	return v, nil
+}
+
+func parsePositiveSize(key, s string) (int64, error) {
+	v, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing option %s", key)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("logger: invalid %s option: %s: must be positive", key, s)
+	}
+	return v, nil
+}
+
+// RateLimitedLogger is a Logger which limits the rate of the messages it
+// forwards to the wrapped logger. Messages exceeding the rate limit are
+// dropped or sampled, and a message reporting the number of dropped messages
+// is logged once messages are forwarded again.
+type RateLimitedLogger struct {
+	l      Logger
+	config RateLimitConfig
+	lines  *rate.Limiter
+	bytes  *rate.Limiter
+	now    func() time.Time
+
+	mu sync.Mutex
+	// dropped is the number of messages dropped for each source since a
+	// message was last forwarded.
+	dropped map[string]int
+	// exceeded is the number of messages which exceeded the rate limit for
+	// each source, used to sample them.
+	exceeded map[string]int
+	// partial is the ID of the partial message being forwarded for each
+	// source, so that all parts of a message are dropped or forwarded
+	// together.
+	partial map[string]partialDecision
+}
+
+type partialDecision struct {
+	id      string
+	forward bool
+}
+
+var _ SizedLogger = &RateLimitedLogger{}
+
+type rateLimitedWithReader struct {
+	*RateLimitedLogger
+}
+
+func (r *rateLimitedWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
+	reader, ok := r.l.(LogReader)
+	if !ok {
+		// something is wrong if we get here
+		panic("expected log reader")
+	}
+	return reader.ReadLogs(cfg)
+}
+
+func newRateLimitedLogger(driver Logger, config RateLimitConfig) *RateLimitedLogger {
+	l := &RateLimitedLogger{
+		l:        driver,
+		config:   config,
+		now:      time.Now,
+		dropped:  make(map[string]int),
+		exceeded: make(map[string]int),
+		partial:  make(map[string]partialDecision),
+	}
+	if config.Lines > 0 {
+		l.lines = rate.NewLimiter(rate.Limit(config.Lines), config.LinesBurst)
+	}
+	if config.Bytes > 0 {
+		l.bytes = rate.NewLimiter(rate.Limit(config.Bytes), int(config.BytesBurst))
+	}
+	return l
+}
+
+// NewRateLimitedLogger creates a new Logger which limits the rate of the
+// messages forwarded to the passed in logger.
+func NewRateLimitedLogger(driver Logger, config RateLimitConfig) Logger {
+	l := newRateLimitedLogger(driver, config)
+	if _, ok := driver.(LogReader); ok {
+		return &rateLimitedWithReader{l}
+	}
+	return l
+}
+
+// BufSize returns the buffer size of the underlying logger.
+// Returns -1 if the logger doesn't match SizedLogger interface.
+func (r *RateLimitedLogger) BufSize() int {
+	if sl, ok := r.l.(SizedLogger); ok {
+		return sl.BufSize()
+	}
+	return -1
+}
+
+// Log forwards the message to the underlying logger if it does not exceed
+// the rate limit.
+func (r *RateLimitedLogger) Log(msg *Message) error {
+	r.mu.Lock()
+	forward := r.allow(msg)
+	if !forward {
+		r.dropped[msg.Source]++
+		r.mu.Unlock()
+		logMessagesDroppedCount.Inc(1)
+		PutMessage(msg)
+		return nil
+	}
+	dropped := r.dropped[msg.Source]
+	delete(r.dropped, msg.Source)
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		if err := r.l.Log(droppedMessage(msg.Source, dropped, msg.Timestamp)); err != nil {
+			logDriverError(r.l.Name(), "", err)
+		}
+	}
+	return r.l.Log(msg)
+}
+
+// allow returns whether msg should be forwarded. It must be called with the
+// lock held.
+func (r *RateLimitedLogger) allow(msg *Message) bool {
+	if msg.PLogMetaData != nil {
+		// the decision for the first part of a message applies to all its
+		// other parts.
+		if d, ok := r.partial[msg.Source]; ok && d.id == msg.PLogMetaData.ID {
+			if msg.PLogMetaData.Last {
+				delete(r.partial, msg.Source)
+			}
+			return d.forward
+		}
+	}
+
+	forward := r.withinLimit(len(msg.Line))
+	if !forward && r.config.Policy == RateLimitPolicySample {
+		r.exceeded[msg.Source]++
+		if r.exceeded[msg.Source] >= r.config.Sample {
+			r.exceeded[msg.Source] = 0
+			forward = true
+		}
+	}
+	if msg.PLogMetaData != nil && !msg.PLogMetaData.Last {
+		r.partial[msg.Source] = partialDecision{id: msg.PLogMetaData.ID, forward: forward}
+	}
+	return forward
+}
+
+func (r *RateLimitedLogger) withinLimit(size int) bool {
+	now := r.now()
+	if r.bytes != nil {
+		// messages larger than the burst are allowed once the bucket is full,
+		// instead of never being allowed.
+		if size > r.bytes.Burst() {
+			size = r.bytes.Burst()
+		}
+		res := r.bytes.ReserveN(now, size)
+		if res.DelayFrom(now) > 0 {
+			res.CancelAt(now)
+			return false
+		}
+		if r.lines != nil && !r.lines.AllowN(now, 1) {
+			res.CancelAt(now)
+			return false
+		}
+		return true
+	}
+	return r.lines.AllowN(now, 1)
+}
+
+// droppedMessage returns a message reporting that n messages from source
+// were dropped.
+func droppedMessage(source string, n int, ts time.Time) *Message {
+	msg := NewMessage()
+	msg.Source = source
+	msg.Timestamp = ts
+	msg.Line = append(msg.Line, fmt.Sprintf("%d messages dropped due to log rate limit", n)...)
+	return msg
+}
+
+// Name returns the name of the underlying logger
+func (r *RateLimitedLogger) Name() string {
+	return r.l.Name()
+}
+
+// Close reports messages dropped since a message was last forwarded, and
+// closes the underlying logger.
+func (r *RateLimitedLogger) Close() error {
+	r.mu.Lock()
+	dropped := r.dropped
+	r.dropped = make(map[string]int)
+	r.mu.Unlock()
+
+	now := r.now().UTC()
+	for source, n := range dropped {
+		if err := r.l.Log(droppedMessage(source, n, now)); err != nil {
+			logDriverError(r.l.Name(), "", err)
+		}
+	}
+	return r.l.Close()
+}
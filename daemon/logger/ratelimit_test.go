@@ -0,0 +1,151 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/backend"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type bufferedLogger struct{ msgs []*Message }
+
+func (l *bufferedLogger) Log(msg *Message) error {
+	l.msgs = append(l.msgs, msg)
+	return nil
+}
+
+func (l *bufferedLogger) Name() string {
+	return "buffered"
+}
+
+func (l *bufferedLogger) Close() error {
+	return nil
+}
+
+func (l *bufferedLogger) lines() []string {
+	var lines []string
+	for _, m := range l.msgs {
+		lines = append(lines, m.Source+": "+string(m.Line))
+	}
+	return lines
+}
+
+func newTestRateLimitedLogger(t *testing.T, opts map[string]string) (*RateLimitedLogger, *bufferedLogger, *time.Time) {
+	t.Helper()
+	config, err := ParseRateLimitConfig(opts)
+	assert.NilError(t, err)
+	assert.Assert(t, config != nil)
+	dst := &bufferedLogger{}
+	now := time.Now()
+	l := newRateLimitedLogger(dst, *config)
+	l.now = func() time.Time { return now }
+	return l, dst, &now
+}
+
+func logLine(t *testing.T, l Logger, source, line string) {
+	t.Helper()
+	assert.NilError(t, l.Log(&Message{Source: source, Line: []byte(line)}))
+}
+
+func TestRateLimitedLoggerDrop(t *testing.T) {
+	l, dst, now := newTestRateLimitedLogger(t, map[string]string{"rate-limit-lines": "2"})
+
+	for _, line := range []string{"1", "2", "3", "4"} {
+		logLine(t, l, "stdout", line)
+	}
+	logLine(t, l, "stderr", "5")
+	*now = now.Add(time.Second)
+	logLine(t, l, "stdout", "6")
+	logLine(t, l, "stderr", "7")
+	assert.Check(t, is.DeepEqual(dst.lines(), []string{
+		"stdout: 1",
+		"stdout: 2",
+		"stdout: 2 messages dropped due to log rate limit",
+		"stdout: 6",
+		"stderr: 1 messages dropped due to log rate limit",
+		"stderr: 7",
+	}))
+}
+
+func TestRateLimitedLoggerBytes(t *testing.T) {
+	l, dst, now := newTestRateLimitedLogger(t, map[string]string{"rate-limit-bytes": "10", "rate-limit-bytes-burst": "20"})
+
+	logLine(t, l, "stdout", strings.Repeat("a", 15))
+	logLine(t, l, "stdout", strings.Repeat("b", 10))
+	logLine(t, l, "stdout", strings.Repeat("c", 5))
+	*now = now.Add(2 * time.Second)
+	// messages larger than the burst are allowed once the bucket is full.
+	logLine(t, l, "stdout", strings.Repeat("d", 30))
+	assert.Check(t, is.DeepEqual(dst.lines(), []string{
+		"stdout: " + strings.Repeat("a", 15),
+		"stdout: 1 messages dropped due to log rate limit",
+		"stdout: " + strings.Repeat("c", 5),
+		"stdout: " + strings.Repeat("d", 30),
+	}))
+}
+
+func TestRateLimitedLoggerSample(t *testing.T) {
+	l, dst, _ := newTestRateLimitedLogger(t, map[string]string{"rate-limit-lines": "1", "rate-limit-policy": "sample", "rate-limit-sample": "3"})
+
+	for _, line := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+		logLine(t, l, "stdout", line)
+	}
+	assert.NilError(t, l.Close())
+	assert.Check(t, is.DeepEqual(dst.lines(), []string{
+		"stdout: 1",
+		"stdout: 2 messages dropped due to log rate limit",
+		"stdout: 4",
+		"stdout: 2 messages dropped due to log rate limit",
+		"stdout: 7",
+	}))
+}
+
+func TestRateLimitedLoggerPartial(t *testing.T) {
+	l, dst, _ := newTestRateLimitedLogger(t, map[string]string{"rate-limit-lines": "1", "rate-limit-lines-burst": "2"})
+
+	logLine(t, l, "stdout", "1")
+	// the first part is allowed, so are the other parts.
+	for i, last := range []bool{false, false, true} {
+		assert.NilError(t, l.Log(&Message{Source: "stdout", Line: []byte("a"), PLogMetaData: &backend.PartialLogMetaData{ID: "a", Ordinal: i + 1, Last: last}}))
+	}
+	// the first part is dropped, so are the other parts.
+	for i, last := range []bool{false, true} {
+		assert.NilError(t, l.Log(&Message{Source: "stdout", Line: []byte("b"), PLogMetaData: &backend.PartialLogMetaData{ID: "b", Ordinal: i + 1, Last: last}}))
+	}
+	assert.Check(t, is.DeepEqual(dst.lines(), []string{"stdout: 1", "stdout: a", "stdout: a", "stdout: a"}))
+}
+
+func TestParseRateLimitConfig(t *testing.T) {
+	config, err := ParseRateLimitConfig(map[string]string{"mode": "non-blocking"})
+	assert.NilError(t, err)
+	assert.Check(t, config == nil)
+
+	config, err = ParseRateLimitConfig(map[string]string{"rate-limit-lines": "100", "rate-limit-bytes": "1k"})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(*config, RateLimitConfig{
+		Lines:      100,
+		LinesBurst: 100,
+		Bytes:      1024,
+		BytesBurst: 1024,
+		Policy:     RateLimitPolicyDrop,
+		Sample:     defaultRateLimitSample,
+	}))
+
+	for _, tc := range []struct {
+		opts        map[string]string
+		expectedErr string
+	}{
+		{opts: map[string]string{"rate-limit-lines": "0"}, expectedErr: "invalid rate-limit-lines option"},
+		{opts: map[string]string{"rate-limit-bytes": "foo"}, expectedErr: "error parsing option rate-limit-bytes"},
+		{opts: map[string]string{"rate-limit-policy": "drop"}, expectedErr: "rate-limit-policy option requires"},
+		{opts: map[string]string{"rate-limit-bytes": "1k", "rate-limit-lines-burst": "10"}, expectedErr: "rate-limit-lines-burst option requires rate-limit-lines"},
+		{opts: map[string]string{"rate-limit-lines": "1", "rate-limit-policy": "block"}, expectedErr: "invalid rate-limit-policy option"},
+		{opts: map[string]string{"rate-limit-lines": "1", "rate-limit-sample": "10"}, expectedErr: "rate-limit-sample option is only supported with 'rate-limit-policy=sample'"},
+	} {
+		_, err := ParseRateLimitConfig(tc.opts)
+		assert.Check(t, is.ErrorContains(err, tc.expectedErr), "%v", tc.opts)
+	}
+}
@@ -353,6 +353,18 @@ func (daemon *Daemon) createNetwork(create types.NetworkCreateRequest, id string
 		nwOptions = append(nwOptions, libnetwork.NetworkOptionConfigFrom(create.ConfigFrom.Network))
 	}
 
+	if create.DNS != nil {
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionDNS(getDNSConfig(create.DNS)))
+	}
+
+	if create.Policy != nil {
+		policyOption, err := getNetworkPolicy(driver, create.Policy)
+		if err != nil {
+			return nil, err
+		}
+		nwOptions = append(nwOptions, policyOption)
+	}
+
 	if agent && driver == "overlay" {
 		nodeIP, exists := daemon.GetAttachmentStore().GetIPForNetwork(id)
 		if !exists {
@@ -368,6 +380,9 @@ func (daemon *Daemon) createNetwork(create types.NetworkCreateRequest, id string
 			//nolint: revive
 			return nil, errors.New("This node is not a swarm manager. Use \"docker swarm init\" or \"docker swarm join\" to connect this node to swarm and try again.")
 		}
+		if _, ok := err.(networktypes.BadRequestError); ok {
+			return nil, errdefs.InvalidParameter(err)
+		}
 		return nil, err
 	}
 
@@ -406,6 +421,60 @@ func (daemon *Daemon) pluginRefCount(driver, capability string, mode int) {
 	}
 }
 
+func getDNSConfig(dnsConfig *network.DNSConfig) ([]libnetwork.DNSRecord, []libnetwork.DNSForwarder) {
+	if dnsConfig == nil {
+		return nil, nil
+	}
+	var (
+		records    []libnetwork.DNSRecord
+		forwarders []libnetwork.DNSForwarder
+	)
+	for _, r := range dnsConfig.Records {
+		records = append(records, libnetwork.DNSRecord{Name: r.Name, Type: r.Type, Value: r.Value, TTL: r.TTL})
+	}
+	for _, f := range dnsConfig.Forwarders {
+		forwarders = append(forwarders, libnetwork.DNSForwarder{Domain: f.Domain, Servers: f.Servers})
+	}
+	return records, forwarders
+}
+
+// supportsPolicy returns whether the networks of driver enforce policies.
+func supportsPolicy(driver string) bool {
+	return driver == "bridge" || driver == "overlay"
+}
+
+// getNetworkPolicy returns the option setting the policy of a network of
+// driver, or removing it if the policy is empty.
+func getNetworkPolicy(driver string, policy *network.Policy) (libnetwork.NetworkOption, error) {
+	if policy.Default == "" && len(policy.Rules) == 0 {
+		return libnetwork.NetworkOptionPolicy(nil), nil
+	}
+	if !supportsPolicy(driver) {
+		return nil, errdefs.InvalidParameter(fmt.Errorf("network policies are not supported by the %s driver", driver))
+	}
+	p := &networktypes.NetworkPolicy{Default: policy.Default}
+	for i, r := range policy.Rules {
+		rule := networktypes.PolicyRule{
+			Action:   r.Action,
+			From:     r.From,
+			To:       r.To,
+			Protocol: r.Protocol,
+		}
+		for _, ports := range r.Ports {
+			start, end, err := nat.ParsePortRange(ports)
+			if err != nil {
+				return nil, errdefs.InvalidParameter(errors.Wrapf(err, "invalid ports %q of rule %d of network policy", ports, i))
+			}
+			rule.Ports = append(rule.Ports, networktypes.PortRange{Start: uint16(start), End: uint16(end)})
+		}
+		p.Rules = append(p.Rules, rule)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+	return libnetwork.NetworkOptionPolicy(p), nil
+}
+
 func getIpamConfig(data []network.IPAMConfig) ([]*libnetwork.IpamConf, []*libnetwork.IpamConf, error) {
 	ipamV4Cfg := []*libnetwork.IpamConf{}
 	ipamV6Cfg := []*libnetwork.IpamConf{}
@@ -415,6 +484,8 @@ func getIpamConfig(data []network.IPAMConfig) ([]*libnetwork.IpamConf, []*libnet
 		iCfg.SubPool = d.IPRange
 		iCfg.Gateway = d.Gateway
 		iCfg.AuxAddresses = d.AuxAddress
+		iCfg.Reservations = d.Reservations
+		iCfg.Options = d.Options
 		ip, _, err := net.ParseCIDR(d.Subnet)
 		if err != nil {
 			return nil, nil, fmt.Errorf("Invalid subnet %s : %v", d.Subnet, err)
@@ -548,6 +619,118 @@ func (daemon *Daemon) deleteNetwork(nw libnetwork.Network, dynamic bool) error {
 	return nil
 }
 
+// UpdateNetwork applies the changes in update to the configuration of a
+// user-defined network.
+func (daemon *Daemon) UpdateNetwork(networkID string, update types.NetworkUpdate) error {
+	nw, err := daemon.GetNetworkByID(networkID)
+	if err != nil {
+		return errors.Wrap(err, "could not find network by ID")
+	}
+	if runconfig.IsPreDefinedNetwork(nw.Name()) {
+		return errdefs.Forbidden(fmt.Errorf("%s is a pre-defined network and cannot be updated", nw.Name()))
+	}
+	if nw.Info().Dynamic() {
+		return errdefs.InvalidParameter(fmt.Errorf("swarm-scoped network %s cannot be updated", nw.Name()))
+	}
+
+	var nwOptions []libnetwork.NetworkOption
+	if update.Options != nil {
+		driverOpts := make(map[string]string)
+		for k, v := range nw.Info().DriverOptions() {
+			driverOpts[k] = v
+		}
+		for k, v := range update.Options {
+			driverOpts[k] = v
+		}
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionDriverOpts(driverOpts))
+	}
+	if update.Labels != nil {
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionLabels(update.Labels))
+	}
+	if update.Internal != nil {
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionInternal(*update.Internal))
+	}
+	if update.Attachable != nil {
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionAttachable(*update.Attachable))
+	}
+	if update.IPAM != nil {
+		ipamOption, err := mergeIpamConfig(nw, update.IPAM)
+		if err != nil {
+			return err
+		}
+		nwOptions = append(nwOptions, ipamOption)
+	}
+	if update.DNS != nil {
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionDNS(getDNSConfig(update.DNS)))
+	}
+	if update.Policy != nil {
+		policyOption, err := getNetworkPolicy(nw.Type(), update.Policy)
+		if err != nil {
+			return err
+		}
+		nwOptions = append(nwOptions, policyOption)
+	}
+
+	if err := nw.Update(nwOptions...); err != nil {
+		if _, ok := err.(networktypes.BadRequestError); ok {
+			return errdefs.InvalidParameter(err)
+		}
+		return err
+	}
+	daemon.LogNetworkEvent(nw, "update")
+	return nil
+}
+
+// mergeIpamConfig returns the option setting the IPAM configuration of nw
+// updated with ipam: the pools with the subnet of an existing pool replace
+// it, and the other pools are appended.
+func mergeIpamConfig(nw libnetwork.Network, ipam *network.IPAM) (libnetwork.NetworkOption, error) {
+	ipamDriver, ipamOptions, v4Conf, v6Conf := nw.Info().IpamConfig()
+	v4Info, v6Info := nw.Info().IpamInfo()
+	if ipam.Driver != "" {
+		ipamDriver = ipam.Driver
+	}
+	if ipam.Options != nil {
+		ipamOptions = ipam.Options
+	}
+
+	v4Update, v6Update, err := getIpamConfig(ipam.Config)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+	merge := func(conf []*libnetwork.IpamConf, info []*libnetwork.IpamInfo, update []*libnetwork.IpamConf) []*libnetwork.IpamConf {
+		for _, u := range update {
+			i := 0
+			for ; i < len(conf); i++ {
+				if conf[i].PreferredPool == u.PreferredPool || (i < len(info) && info[i].Pool.String() == u.PreferredPool) {
+					break
+				}
+			}
+			if i < len(conf) {
+				// The gateway, auxiliary addresses, reservations and
+				// options of the pool are kept if they are not set.
+				if u.Gateway == "" {
+					u.Gateway = conf[i].Gateway
+				}
+				if u.AuxAddresses == nil {
+					u.AuxAddresses = conf[i].AuxAddresses
+				}
+				if u.Reservations == nil {
+					u.Reservations = conf[i].Reservations
+				}
+				if u.Options == nil {
+					u.Options = conf[i].Options
+				}
+				conf[i] = u
+			} else {
+				conf = append(conf, u)
+			}
+		}
+		return conf
+	}
+	return libnetwork.NetworkOptionIpam(ipamDriver, "", merge(v4Conf, v4Info, v4Update), merge(v6Conf, v6Info, v6Update), ipamOptions), nil
+}
+
 // GetNetworks returns a list of all networks
 func (daemon *Daemon) GetNetworks(filter filters.Args, config types.NetworkListConfig) ([]types.NetworkResource, error) {
 	networks := daemon.getAllNetworks()
@@ -614,9 +797,48 @@ func buildNetworkResource(nw libnetwork.Network) types.NetworkResource {
 		r.Peers = buildPeerInfoResources(peers)
 	}
 
+	r.DNS = buildDNSResource(info)
+	r.Policy = buildPolicyResource(info)
+
 	return r
 }
 
+func buildPolicyResource(info libnetwork.NetworkInfo) *network.Policy {
+	p := info.Policy()
+	if p == nil {
+		return nil
+	}
+	policy := &network.Policy{Default: p.Default}
+	for _, r := range p.Rules {
+		rule := network.PolicyRule{
+			Action:   r.Action,
+			From:     r.From,
+			To:       r.To,
+			Protocol: r.Protocol,
+		}
+		for _, pr := range r.Ports {
+			rule.Ports = append(rule.Ports, pr.String())
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	return policy
+}
+
+func buildDNSResource(info libnetwork.NetworkInfo) *network.DNSConfig {
+	records, forwarders := info.DNSRecords(), info.DNSForwarders()
+	if len(records) == 0 && len(forwarders) == 0 {
+		return nil
+	}
+	dnsConfig := &network.DNSConfig{}
+	for _, r := range records {
+		dnsConfig.Records = append(dnsConfig.Records, network.DNSRecord{Name: r.Name, Type: r.Type, Value: r.Value, TTL: r.TTL})
+	}
+	for _, f := range forwarders {
+		dnsConfig.Forwarders = append(dnsConfig.Forwarders, network.DNSForwarder{Domain: f.Domain, Servers: f.Servers})
+	}
+	return dnsConfig
+}
+
 func buildDetailedNetworkResources(r *types.NetworkResource, nw libnetwork.Network, verbose bool) {
 	if nw == nil {
 		return
@@ -691,6 +913,8 @@ func buildIpamResources(r *types.NetworkResource, nwInfo libnetwork.NetworkInfo)
 		iData.IPRange = ip4.SubPool
 		iData.Gateway = ip4.Gateway
 		iData.AuxAddress = ip4.AuxAddresses
+		iData.Reservations = ip4.Reservations
+		iData.Options = ip4.Options
 		r.IPAM.Config = append(r.IPAM.Config, iData)
 	}
 
@@ -716,6 +940,8 @@ func buildIpamResources(r *types.NetworkResource, nwInfo libnetwork.NetworkInfo)
 		iData.IPRange = ip6.SubPool
 		iData.Gateway = ip6.Gateway
 		iData.AuxAddress = ip6.AuxAddresses
+		iData.Reservations = ip6.Reservations
+		iData.Options = ip6.Options
 		r.IPAM.Config = append(r.IPAM.Config, iData)
 	}
 
@@ -754,9 +980,33 @@ func buildEndpointResource(id string, name string, info libnetwork.EndpointInfo)
 			er.IPv6Address = ipv6.String()
 		}
 	}
+	if bw := ei.Bandwidth(); bw != nil {
+		er.Bandwidth = &network.EndpointBandwidth{
+			IngressRate:  bw.IngressRate,
+			IngressBurst: bw.IngressBurst,
+			EgressRate:   bw.EgressRate,
+			EgressBurst:  bw.EgressBurst,
+			Priority:     bw.Priority,
+		}
+	}
 	return er
 }
 
+// endpointBandwidth returns the libnetwork form of the bandwidth limits bw,
+// or nil if it does not set any.
+func endpointBandwidth(bw *network.EndpointBandwidth) *networktypes.Bandwidth {
+	if bw == nil || *bw == (network.EndpointBandwidth{}) {
+		return nil
+	}
+	return &networktypes.Bandwidth{
+		IngressRate:  bw.IngressRate,
+		IngressBurst: bw.IngressBurst,
+		EgressRate:   bw.EgressRate,
+		EgressBurst:  bw.EgressBurst,
+		Priority:     bw.Priority,
+	}
+}
+
 // clearAttachableNetworks removes the attachable networks
 // after disconnecting any connected container
 func (daemon *Daemon) clearAttachableNetworks() {
@@ -835,6 +1085,15 @@ func buildCreateEndpointOptions(c *container.Container, n libnetwork.Network, ep
 		for k, v := range epConfig.DriverOpts {
 			createOptions = append(createOptions, libnetwork.EndpointOptionGeneric(options.Generic{k: v}))
 		}
+		if bw := endpointBandwidth(epConfig.Bandwidth); bw != nil {
+			createOptions = append(createOptions, libnetwork.CreateOptionBandwidth(bw))
+		}
+	}
+
+	// The labels of the container select the rules of the network policy
+	// which apply to it.
+	if supportsPolicy(n.Type()) && c.Config != nil {
+		createOptions = append(createOptions, libnetwork.CreateOptionLabels(c.Config.Labels))
 	}
 
 	if c.NetworkSettings.Service != nil {
@@ -153,6 +153,11 @@ func (daemon *Daemon) containerStart(container *container.Container, checkpoint
 
 	spec, err := daemon.createSpec(container)
 	if err != nil {
+		// a volume which cannot be mounted because of its access mode is a
+		// conflict, not a system error
+		if errdefs.IsConflict(err) {
+			return err
+		}
 		return errdefs.System(err)
 	}
 
@@ -114,7 +114,7 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 				if err != nil {
 					return err
 				}
-				cp.Volume = &volumeWrapper{v: v, s: daemon.volumes}
+				cp.Volume = &volumeWrapper{v: v, s: daemon.volumes, container: container.ID, readOnly: !cp.RW}
 			}
 			dereferenceIfExists(cp.Destination)
 			mountPoints[cp.Destination] = cp
@@ -147,7 +147,7 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 			if err != nil {
 				return err
 			}
-			bind.Volume = &volumeWrapper{v: v, s: daemon.volumes}
+			bind.Volume = &volumeWrapper{v: v, s: daemon.volumes, container: container.ID, readOnly: !bind.RW}
 			bind.Source = v.Mountpoint
 			// bind.Name is an already existing volume, we need to use that here
 			bind.Driver = v.Driver
@@ -199,7 +199,7 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 				return err
 			}
 
-			mp.Volume = &volumeWrapper{v: v, s: daemon.volumes}
+			mp.Volume = &volumeWrapper{v: v, s: daemon.volumes, container: container.ID, readOnly: !mp.RW}
 			mp.Name = v.Name
 			mp.Driver = v.Driver
 
@@ -244,24 +244,42 @@ func (daemon *Daemon) lazyInitializeVolume(containerID string, m *volumemounts.M
 		if err != nil {
 			return err
 		}
-		m.Volume = &volumeWrapper{v: v, s: daemon.volumes}
+		m.Volume = &volumeWrapper{v: v, s: daemon.volumes, container: containerID, readOnly: !m.RW}
 	}
 	return nil
 }
 
+// restoreVolumeHolders records the volume mounts of a container which kept
+// running while the daemon was restarted, so that the access modes of its
+// volumes account for them until the container releases them.
+func (daemon *Daemon) restoreVolumeHolders(c *container.Container) {
+	for _, m := range c.MountPoints {
+		if m.Type != mounttypes.TypeVolume || m.ID == "" {
+			continue
+		}
+		if err := daemon.volumes.RestoreMount(context.TODO(), m.Name, m.ID, volumeopts.WithMountHolder(c.ID), volumeopts.WithMountReadOnly(!m.RW)); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"container": c.ID, "volume": m.Name}).Warn("failed to restore volume mount")
+		}
+	}
+}
+
 // VolumesService is used to perform volume operations
 func (daemon *Daemon) VolumesService() *service.VolumesService {
 	return daemon.volumes
 }
 
 type volumeMounter interface {
-	Mount(ctx context.Context, v *volumetypes.Volume, ref string) (string, error)
+	Mount(ctx context.Context, v *volumetypes.Volume, ref string, mountOpts ...volumeopts.MountOption) (string, error)
 	Unmount(ctx context.Context, v *volumetypes.Volume, ref string) error
 }
 
 type volumeWrapper struct {
 	v *volumetypes.Volume
 	s volumeMounter
+	// container and readOnly describe how the volume is mounted, and are
+	// checked against the access mode of the volume.
+	container string
+	readOnly  bool
 }
 
 func (v *volumeWrapper) Name() string {
@@ -277,7 +295,7 @@ func (v *volumeWrapper) Path() string {
 }
 
 func (v *volumeWrapper) Mount(ref string) (string, error) {
-	return v.s.Mount(context.TODO(), v.v, ref)
+	return v.s.Mount(context.TODO(), v.v, ref, volumeopts.WithMountHolder(v.container), volumeopts.WithMountReadOnly(v.readOnly))
 }
 
 func (v *volumeWrapper) Unmount(ref string) error {
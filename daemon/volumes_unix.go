@@ -12,9 +12,12 @@ import (
 
 	mounttypes "github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/idtools"
 	volumemounts "github.com/docker/docker/volume/mounts"
 	"github.com/moby/sys/mount"
+	"github.com/moby/sys/mountinfo"
 )
 
 // setupMounts iterates through each of the mount points for a container and
@@ -53,6 +56,12 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 		if err != nil {
 			return nil, err
 		}
+		if mapping, ok := m.IDMapping(daemon.idMapping); ok {
+			path, err = setupIDMappedMount(c, m, path, mapping)
+			if err != nil {
+				return nil, err
+			}
+		}
 		if !c.TrySetNetworkMount(m.Destination, path) {
 			mnt := container.Mount{
 				Source:      path,
@@ -96,6 +105,43 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 	return append(mounts, netMounts...), nil
 }
 
+// setupIDMappedMount mounts the source of the mount point, at path, as an
+// idmapped mount in the directory of the container, and returns the path of
+// the idmapped mount. Idmapped mounts are unmounted with the other mounts in
+// the directory of the container when the container is cleaned up, or by
+// DetachAndUnmount for those of a container which is not running.
+func setupIDMappedMount(c *container.Container, m *volumemounts.MountPoint, path string, mapping idtools.IdentityMapping) (string, error) {
+	if mapping.Empty() {
+		return "", errdefs.InvalidParameter(fmt.Errorf("idmapped mount at %s requires uid and gid mappings, or a daemon with user namespace remapping", m.Destination))
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	target := c.IDMappedMountPath(m.Destination)
+	if mounted, _ := mountinfo.Mounted(target); mounted {
+		// The volumes of a running container are also mounted by `docker cp`.
+		// Idmapped mounts keep the device and inode of their source, so a
+		// mount left over for another source is replaced.
+		if tstat, err := os.Stat(target); err == nil && os.SameFile(stat, tstat) {
+			return target, nil
+		}
+		if err := mount.Unmount(target); err != nil {
+			return "", fmt.Errorf("error removing stale idmapped mount at %s: %w", m.Destination, err)
+		}
+	}
+
+	if err := fileutils.CreateIfNotExists(target, stat.IsDir()); err != nil {
+		return "", err
+	}
+	recursive := m.Spec.BindOptions == nil || !m.Spec.BindOptions.NonRecursive
+	if err := volumemounts.MountIDMapped(path, target, mapping, recursive); err != nil {
+		return "", fmt.Errorf("error setting up idmapped mount at %s: %w", m.Destination, err)
+	}
+	return target, nil
+}
+
 // sortMounts sorts an array of mounts in lexicographic order. This ensure that
 // when mounting, the mounts don't shadow other mounts. For example, if mounting
 // /etc and /etc/resolv.conf, /etc/resolv.conf must not be mounted first.
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
 )
 
 // ContainerWait waits until the given container is in a certain state
@@ -19,5 +21,26 @@ func (daemon *Daemon) ContainerWait(ctx context.Context, name string, condition
 		return nil, err
 	}
 
+	if err := validateWaitCondition(cntr, condition); err != nil {
+		return nil, err
+	}
 	return cntr.Wait(ctx, condition), nil
 }
+
+// validateWaitCondition returns an error if the container has no probe which
+// can meet the condition. A container with only a startup probe becomes
+// healthy once its startup probe succeeds.
+func validateWaitCondition(cntr *container.Container, condition container.WaitCondition) error {
+	config := cntr.Config.Healthcheck
+	switch condition {
+	case container.WaitConditionHealthy:
+		if getProbe(cntr) == nil && (config == nil || config.StartupProbe == nil) {
+			return errdefs.InvalidParameter(errors.New("cannot wait for container to be healthy: container has no healthcheck"))
+		}
+	case container.WaitConditionReady:
+		if getProbe(cntr) == nil && (config == nil || config.ReadinessProbe == nil) {
+			return errdefs.InvalidParameter(errors.New("cannot wait for container to be ready: container has no readiness probe or healthcheck"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/klauspost/compress/zstd"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// LayerCompression is the compression applied to layers when pushing them
+// to a registry.
+type LayerCompression string
+
+const (
+	// LayerCompressionGzip compresses layers with gzip. This is the default.
+	LayerCompressionGzip LayerCompression = "gzip"
+	// LayerCompressionZstd compresses layers with zstd.
+	LayerCompressionZstd LayerCompression = "zstd"
+	// LayerCompressionZstdChunked compresses layers with zstd, in the
+	// zstd:chunked format, which adds a table of contents allowing clients
+	// to fetch individual files.
+	LayerCompressionZstdChunked LayerCompression = "zstd:chunked"
+)
+
+// ParseLayerCompression parses a layer compression. An empty string is
+// parsed as the default, LayerCompressionGzip.
+func ParseLayerCompression(s string) (LayerCompression, error) {
+	switch c := LayerCompression(s); c {
+	case "":
+		return LayerCompressionGzip, nil
+	case LayerCompressionGzip, LayerCompressionZstd, LayerCompressionZstdChunked:
+		return c, nil
+	default:
+		return "", errdefs.InvalidParameter(fmt.Errorf("invalid layer compression %q: must be one of %q, %q or %q", s, LayerCompressionGzip, LayerCompressionZstd, LayerCompressionZstdChunked))
+	}
+}
+
+// mediaType returns the media type of layers compressed with c.
+func (c LayerCompression) mediaType() string {
+	switch c {
+	case LayerCompressionZstd, LayerCompressionZstdChunked:
+		return v1.MediaTypeImageLayerZstd
+	default:
+		return schema2.MediaTypeLayer
+	}
+}
+
+// matches returns whether the blob described by meta was compressed with c,
+// and can therefore be reused when pushing layers compressed with c.
+func (c LayerCompression) matches(meta metadata.V2Metadata) bool {
+	switch meta.MediaType {
+	case "", schema2.MediaTypeLayer, schema2.MediaTypeForeignLayer, v1.MediaTypeImageLayerGzip:
+		// Metadata which predates tracking the media type always refers
+		// to gzip-compressed layers.
+		return c == LayerCompressionGzip || c == ""
+	case v1.MediaTypeImageLayerZstd:
+		if meta.TOCDigest != "" {
+			return c == LayerCompressionZstdChunked
+		}
+		return c == LayerCompressionZstd
+	default:
+		return false
+	}
+}
+
+// layerCompressor compresses a layer.
+type layerCompressor interface {
+	io.WriteCloser
+	// Annotations returns the annotations to add to the descriptor of the
+	// compressed layer. It is only valid after the compressor was closed.
+	Annotations() map[string]string
+}
+
+type plainCompressor struct {
+	io.WriteCloser
+}
+
+func (plainCompressor) Annotations() map[string]string {
+	return nil
+}
+
+func newLayerCompressor(w io.Writer, compression LayerCompression) (layerCompressor, error) {
+	switch compression {
+	case LayerCompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return plainCompressor{zw}, nil
+	case LayerCompressionZstdChunked:
+		return archive.NewZstdChunkedWriter(w)
+	default:
+		return plainCompressor{gzip.NewWriter(w)}, nil
+	}
+}
+
+// compress returns an io.ReadCloser which will supply a compressed version of
+// the provided Reader. The caller must close the ReadCloser after reading the
+// compressed data.
+//
+// Note that this function returns a reader instead of taking a writer as an
+// argument so that it can be used with httpBlobWriter's ReadFrom method.
+// Using httpBlobWriter's Write method would send a PATCH request for every
+// Write call.
+//
+// The second return value is a channel that gets closed when the goroutine
+// is finished. This allows the caller to make sure the goroutine finishes
+// before it releases any resources connected with the reader that was
+// passed in. The last return value is the compressor, which provides the
+// annotations of the compressed layer once it was read entirely.
+func compress(in io.Reader, compression LayerCompression) (io.ReadCloser, chan struct{}, layerCompressor, error) {
+	compressionDone := make(chan struct{})
+
+	pipeReader, pipeWriter := io.Pipe()
+	// Use a bufio.Writer to avoid excessive chunking in HTTP request.
+	bufWriter := bufio.NewWriterSize(pipeWriter, compressionBufSize)
+	compressor, err := newLayerCompressor(bufWriter, compression)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	go func() {
+		_, err := io.Copy(compressor, in)
+		if err == nil {
+			err = compressor.Close()
+		}
+		if err == nil {
+			err = bufWriter.Flush()
+		}
+		if err != nil {
+			pipeWriter.CloseWithError(err)
+		} else {
+			pipeWriter.Close()
+		}
+		close(compressionDone)
+	}()
+
+	return pipeReader, compressionDone, compressor, nil
+}
@@ -0,0 +1,45 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"testing"
+
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/docker/distribution/metadata"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLayerCompressionMatches(t *testing.T) {
+	legacy := metadata.V2Metadata{Digest: "sha256:legacy"}
+	gzipped := metadata.V2Metadata{Digest: "sha256:gzip", MediaType: schema2.MediaTypeLayer}
+	zstd := metadata.V2Metadata{Digest: "sha256:zstd", MediaType: v1.MediaTypeImageLayerZstd}
+	chunked := metadata.V2Metadata{Digest: "sha256:chunked", MediaType: v1.MediaTypeImageLayerZstd, TOCDigest: "sha256:toc", TOCPosition: "1:2:3:1"}
+
+	for _, tc := range []struct {
+		compression LayerCompression
+		expected    []metadata.V2Metadata
+	}{
+		{compression: "", expected: []metadata.V2Metadata{legacy, gzipped}},
+		{compression: LayerCompressionGzip, expected: []metadata.V2Metadata{legacy, gzipped}},
+		{compression: LayerCompressionZstd, expected: []metadata.V2Metadata{zstd}},
+		{compression: LayerCompressionZstdChunked, expected: []metadata.V2Metadata{chunked}},
+	} {
+		pd := &pushDescriptor{compression: tc.compression}
+		actual := pd.filterMetadata([]metadata.V2Metadata{legacy, gzipped, zstd, chunked})
+		assert.Check(t, is.DeepEqual(actual, tc.expected), "compression %q", tc.compression)
+	}
+}
+
+func TestParseLayerCompression(t *testing.T) {
+	c, err := ParseLayerCompression("")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(c, LayerCompressionGzip))
+
+	c, err = ParseLayerCompression("zstd:chunked")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(c, LayerCompressionZstdChunked))
+
+	_, err = ParseLayerCompression("xz")
+	assert.Check(t, is.ErrorContains(err, `invalid layer compression "xz"`))
+}
@@ -63,6 +63,11 @@ type ImagePullConfig struct {
 	Schema2Types []string
 	// Platform is the requested platform of the image being pulled
 	Platform *specs.Platform
+	// LazyPull mounts the layers in the eStargz and zstd:chunked formats
+	// from the registry, reading their files on first access, instead of
+	// downloading them before they are registered. Their content is then
+	// downloaded in the background.
+	LazyPull bool
 }
 
 // ImagePushConfig stores push configuration.
@@ -79,6 +84,9 @@ type ImagePushConfig struct {
 	TrustKey libtrust.PrivateKey
 	// UploadManager dispatches uploads.
 	UploadManager *xfer.LayerUploadManager
+	// LayerCompression is the compression applied to uncompressed layers.
+	// Pushing layers compressed with zstd produces an OCI image manifest.
+	LayerCompression LayerCompression
 }
 
 // ImageConfigStore handles storing and getting image configurations
@@ -0,0 +1,272 @@
+// Package lazy implements the lazy pull of layers in the eStargz and
+// zstd:chunked formats, whose files are read on demand from the registry
+// using the table of contents of the layer.
+package lazy // import "github.com/docker/docker/distribution/lazy"
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/docker/distribution"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/opencontainers/go-digest"
+)
+
+// estargzTOCDigestAnnotation is the annotation of eStargz layer descriptors
+// holding the digest of the table of contents of the layer.
+const estargzTOCDigestAnnotation = "containerd.io/snapshot/stargz/toc.digest"
+
+// Whiteouts of the tar stream of layers, which are converted to their
+// overlay representation as archive.OverlayWhiteoutFormat does.
+const (
+	whiteoutPrefix     = ".wh."
+	whiteoutOpaqueDir  = ".wh..wh..opq"
+	overlayOpaqueXattr = "trusted.overlay.opaque"
+)
+
+// Diff is a layer whose files are read from its compressed blob when they
+// are first opened. It implements layer.LazyDiff.
+type Diff struct {
+	diffID layer.DiffID
+	size   int64
+	root   *file
+}
+
+var _ layer.LazyDiff = (*Diff)(nil)
+
+// file is a file of a Diff.
+type file struct {
+	typ      string // "dir", "reg", "symlink", "char", "block" or "fifo"
+	mode     int64
+	uid, gid int
+	size     int64
+	modTime  time.Time
+	link     string
+	devMajor int64
+	devMinor int64
+	xattrs   map[string][]byte
+	children map[string]*file
+
+	// open returns the content of a regular file.
+	open func() (io.ReadCloser, error)
+}
+
+// Open reads the table of contents of the layer blob read from ra, with the
+// given size, and returns the Diff serving its files. It returns an error
+// implementing errdefs.NotImplemented if the layer is neither in the eStargz
+// nor in the zstd:chunked format.
+func Open(ra io.ReaderAt, size int64, desc distribution.Descriptor, diffID layer.DiffID) (*Diff, error) {
+	d := &Diff{diffID: diffID, root: newDir()}
+	var err error
+	switch {
+	case desc.Annotations[archive.ZstdChunkedManifestChecksumAnnotation] != "":
+		err = d.openZstdChunked(ra, desc.Annotations)
+	case desc.Annotations[estargzTOCDigestAnnotation] != "":
+		err = d.openEstargz(ra, size, desc.Annotations[estargzTOCDigestAnnotation])
+	default:
+		return nil, errdefs.NotImplemented(fmt.Errorf("layer %s has no table of contents", desc.Digest))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table of contents of layer %s: %w", desc.Digest, err)
+	}
+	return d, nil
+}
+
+// DiffID returns the uncompressed digest of the layer.
+func (d *Diff) DiffID() layer.DiffID {
+	return d.diffID
+}
+
+// Size returns the size of the files of the layer.
+func (d *Diff) Size() int64 {
+	return d.size
+}
+
+func (d *Diff) openZstdChunked(ra io.ReaderAt, annotations map[string]string) error {
+	r, err := archive.OpenZstdChunked(ra, annotations)
+	if err != nil {
+		return err
+	}
+	byName := map[string]*file{}
+	for _, e := range r.Entries() {
+		e := e
+		name := cleanName(e.Name)
+		if e.Type == "hardlink" {
+			target, ok := byName[cleanName(e.LinkName)]
+			if !ok {
+				return fmt.Errorf("%s is a hardlink to %s, which is not found", e.Name, e.LinkName)
+			}
+			d.add(name, target)
+			continue
+		}
+		f := &file{
+			typ:     e.Type,
+			mode:    e.Mode,
+			uid:     e.UID,
+			gid:     e.GID,
+			size:    e.Size,
+			modTime: e.ModTime,
+			link:    e.LinkName,
+		}
+		if f.typ == "reg" {
+			f.open = func() (io.ReadCloser, error) {
+				return r.OpenFile(e)
+			}
+		}
+		byName[name] = f
+		d.add(name, f)
+	}
+	return nil
+}
+
+func (d *Diff) openEstargz(ra io.ReaderAt, size int64, tocDigest string) error {
+	dgst, err := digest.Parse(tocDigest)
+	if err != nil {
+		return err
+	}
+	r, err := estargz.Open(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return err
+	}
+	if _, err := r.VerifyTOC(dgst); err != nil {
+		return err
+	}
+	root, ok := r.Lookup("")
+	if !ok {
+		return fmt.Errorf("no root directory")
+	}
+
+	// Hardlinks share the entry of their target.
+	files := map[*estargz.TOCEntry]*file{}
+	var walk func(dir string, e *estargz.TOCEntry) error
+	walk = func(dir string, e *estargz.TOCEntry) error {
+		var err error
+		e.ForeachChild(func(baseName string, c *estargz.TOCEntry) bool {
+			name := path.Join(dir, baseName)
+			if f, ok := files[c]; ok {
+				d.add(name, f)
+				return true
+			}
+			f := &file{
+				typ:      c.Type,
+				mode:     c.Mode,
+				uid:      c.UID,
+				gid:      c.GID,
+				size:     c.Size,
+				modTime:  c.ModTime(),
+				link:     c.LinkName,
+				devMajor: int64(c.DevMajor),
+				devMinor: int64(c.DevMinor),
+				xattrs:   c.Xattrs,
+			}
+			if f.typ == "reg" {
+				c := c
+				f.open = func() (io.ReadCloser, error) {
+					if c.Size == 0 {
+						return io.NopCloser(strings.NewReader("")), nil
+					}
+					sr, err := r.OpenFile(c.Name)
+					if err != nil {
+						return nil, err
+					}
+					return newVerifiedReadCloser(sr, c.Name, c.Digest)
+				}
+			}
+			files[c] = f
+			d.add(name, f)
+			if f.typ == "dir" {
+				err = walk(name, c)
+			}
+			return err == nil
+		})
+		return err
+	}
+	return walk("/", root)
+}
+
+// add adds f to the tree of the diff at name, which is cleaned. Parent
+// directories missing from the table of contents are created, and whiteouts
+// are converted to their overlay representation.
+func (d *Diff) add(name string, f *file) {
+	if name == "/" {
+		if f.typ == "dir" {
+			f.children = d.root.children
+			d.root = f
+		}
+		return
+	}
+	parent := d.root
+	dir, base := path.Split(name)
+	for _, p := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if p == "" {
+			continue
+		}
+		c, ok := parent.children[p]
+		if !ok || c.typ != "dir" {
+			c = newDir()
+			parent.children[p] = c
+		}
+		parent = c
+	}
+
+	switch {
+	case base == whiteoutOpaqueDir:
+		if parent.xattrs == nil {
+			parent.xattrs = map[string][]byte{}
+		}
+		parent.xattrs[overlayOpaqueXattr] = []byte("y")
+	case strings.HasPrefix(base, whiteoutPrefix):
+		parent.children[strings.TrimPrefix(base, whiteoutPrefix)] = &file{typ: "char", modTime: f.modTime}
+	default:
+		if existing, ok := parent.children[base]; ok && existing.typ == "dir" && f.typ == "dir" {
+			f.children = existing.children
+		} else if f.typ == "dir" && f.children == nil {
+			f.children = map[string]*file{}
+		}
+		if f.typ == "reg" {
+			d.size += f.size
+		}
+		parent.children[base] = f
+	}
+}
+
+func newDir() *file {
+	return &file{typ: "dir", mode: 0755, children: map[string]*file{}}
+}
+
+func cleanName(name string) string {
+	return path.Clean("/" + name)
+}
+
+// newVerifiedReadCloser returns a reader of the content of the named file,
+// which fails at its end if the content does not match dgst.
+func newVerifiedReadCloser(r io.Reader, name, dgst string) (io.ReadCloser, error) {
+	d, err := digest.Parse(dgst)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest of %s: %w", name, err)
+	}
+	v := &verifiedReader{r: r, name: name, verifier: d.Verifier()}
+	return ioutils.NewReadCloserWrapper(v, func() error { return nil }), nil
+}
+
+type verifiedReader struct {
+	r        io.Reader
+	name     string
+	verifier digest.Verifier
+}
+
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.verifier.Write(p[:n])
+	if err == io.EOF && !v.verifier.Verified() {
+		return n, fmt.Errorf("content of %s does not match its digest", v.name)
+	}
+	return n, err
+}
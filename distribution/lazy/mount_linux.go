@@ -0,0 +1,256 @@
+package lazy // import "github.com/docker/docker/distribution/lazy"
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// attrTimeout is how long the kernel caches the attributes and the entries
+// of the mounted files, which never change.
+const attrTimeout = time.Hour
+
+// Mount mounts the files of the diff read-only at target, with FUSE. The
+// content of a regular file is fetched in full in a cache directory next to
+// target when it is first opened.
+//
+// The returned function lazily unmounts the files: mounts which use them,
+// such as the root filesystems of running containers, keep them until they
+// are unmounted, after which the cache directory is removed.
+func (d *Diff) Mount(target string) (func() error, error) {
+	cacheDir, err := os.MkdirTemp(filepath.Dir(target), "lazy-cache-")
+	if err != nil {
+		return nil, err
+	}
+	timeout := attrTimeout
+	server, err := fs.Mount(target, &node{f: d.root, fsys: &mountedFS{cacheDir: cacheDir, inodes: map[*file]*fs.Inode{}}}, &fs.Options{
+		EntryTimeout: &timeout,
+		AttrTimeout:  &timeout,
+		MountOptions: fuse.MountOptions{
+			AllowOther:  true,
+			DirectMount: true,
+			FsName:      "lazy",
+			Name:        "lazy",
+			Options:     []string{"ro", "default_permissions"},
+		},
+	})
+	if err != nil {
+		os.RemoveAll(cacheDir)
+		return nil, errors.Wrapf(err, "failed to mount lazy layer %s", d.diffID)
+	}
+	return func() error {
+		if err := unix.Unmount(target, unix.MNT_DETACH); err != nil {
+			return err
+		}
+		go func() {
+			server.Wait()
+			if err := os.RemoveAll(cacheDir); err != nil {
+				logrus.WithError(err).WithField("dir", cacheDir).Warn("failed to remove cache of lazy layer")
+			}
+		}()
+		return nil
+	}, nil
+}
+
+// mountedFS is the state of a mounted Diff.
+type mountedFS struct {
+	cacheDir string
+	// inodes are the inodes of the files, shared by hardlinks.
+	inodes map[*file]*fs.Inode
+
+	mu      sync.Mutex
+	fetches map[*file]*fetch
+	fetched int
+}
+
+// fetch is the fetch of the content of a file to the cache.
+type fetch struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// node is the inode of a file of a mounted Diff.
+type node struct {
+	fs.Inode
+	f    *file
+	fsys *mountedFS
+}
+
+var (
+	_ fs.NodeOnAdder     = (*node)(nil)
+	_ fs.NodeGetattrer   = (*node)(nil)
+	_ fs.NodeGetxattrer  = (*node)(nil)
+	_ fs.NodeListxattrer = (*node)(nil)
+	_ fs.NodeReadlinker  = (*node)(nil)
+	_ fs.NodeOpener      = (*node)(nil)
+)
+
+// OnAdd builds the tree of inodes when the root is mounted.
+func (n *node) OnAdd(ctx context.Context) {
+	n.fsys.addChildren(ctx, &n.Inode, n.f)
+}
+
+func (m *mountedFS) addChildren(ctx context.Context, parent *fs.Inode, dir *file) {
+	for name, f := range dir.children {
+		ino, ok := m.inodes[f]
+		if !ok {
+			ino = parent.NewPersistentInode(ctx, &node{f: f, fsys: m}, fs.StableAttr{Mode: typeMode(f.typ)})
+			m.inodes[f] = ino
+			if f.typ == "dir" {
+				m.addChildren(ctx, ino, f)
+			}
+		}
+		parent.AddChild(name, ino, false)
+	}
+}
+
+func (n *node) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	f := n.f
+	out.Mode = typeMode(f.typ) | uint32(f.mode&07777)
+	out.Uid = uint32(f.uid)
+	out.Gid = uint32(f.gid)
+	out.Size = uint64(f.size)
+	if f.typ == "symlink" {
+		out.Size = uint64(len(f.link))
+	}
+	out.Blocks = (out.Size + 511) / 512
+	out.Rdev = uint32(unix.Mkdev(uint32(f.devMajor), uint32(f.devMinor)))
+	out.Nlink = 1
+	if f.typ == "dir" {
+		out.Nlink = 2
+	}
+	out.SetTimes(&f.modTime, &f.modTime, &f.modTime)
+	return 0
+}
+
+func (n *node) Getxattr(ctx context.Context, attr string, dest []byte) (uint32, syscall.Errno) {
+	v, ok := n.f.xattrs[attr]
+	if !ok {
+		return 0, syscall.ENODATA
+	}
+	if len(dest) < len(v) {
+		return uint32(len(v)), syscall.ERANGE
+	}
+	return uint32(copy(dest, v)), 0
+}
+
+func (n *node) Listxattr(ctx context.Context, dest []byte) (uint32, syscall.Errno) {
+	var names strings.Builder
+	for k := range n.f.xattrs {
+		names.WriteString(k)
+		names.WriteByte(0)
+	}
+	if len(dest) < names.Len() {
+		return uint32(names.Len()), syscall.ERANGE
+	}
+	return uint32(copy(dest, names.String())), 0
+}
+
+func (n *node) Readlink(ctx context.Context) ([]byte, syscall.Errno) {
+	if n.f.typ != "symlink" {
+		return nil, syscall.EINVAL
+	}
+	return []byte(n.f.link), 0
+}
+
+func (n *node) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_TRUNC|syscall.O_APPEND) != 0 {
+		return nil, 0, syscall.EROFS
+	}
+	if n.f.typ != "reg" {
+		return nil, 0, syscall.EINVAL
+	}
+	p, err := n.fsys.fetch(n.f)
+	if err != nil {
+		logrus.WithError(err).Error("failed to fetch file of lazy layer")
+		return nil, 0, syscall.EIO
+	}
+	fd, err := syscall.Open(p, syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, 0, fs.ToErrno(err)
+	}
+	return fs.NewLoopbackFile(fd), fuse.FOPEN_KEEP_CACHE, 0
+}
+
+// fetch fetches the content of f to the cache once, and returns the path of
+// the cached file.
+func (m *mountedFS) fetch(f *file) (string, error) {
+	m.mu.Lock()
+	if m.fetches == nil {
+		m.fetches = map[*file]*fetch{}
+	}
+	ft, ok := m.fetches[f]
+	if !ok {
+		m.fetched++
+		ft = &fetch{path: filepath.Join(m.cacheDir, strconv.Itoa(m.fetched))}
+		m.fetches[f] = ft
+	}
+	m.mu.Unlock()
+
+	ft.once.Do(func() {
+		ft.err = fetchFile(f, ft.path)
+	})
+	if ft.err != nil {
+		// Let a later open retry the fetch.
+		m.mu.Lock()
+		if m.fetches[f] == ft {
+			delete(m.fetches, f)
+		}
+		m.mu.Unlock()
+	}
+	return ft.path, ft.err
+}
+
+func fetchFile(f *file, p string) error {
+	rc, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tmp, err := os.OpenFile(p+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// typeMode returns the file type bits of the mode of files of type typ.
+func typeMode(typ string) uint32 {
+	switch typ {
+	case "dir":
+		return syscall.S_IFDIR
+	case "symlink":
+		return syscall.S_IFLNK
+	case "char":
+		return syscall.S_IFCHR
+	case "block":
+		return syscall.S_IFBLK
+	case "fifo":
+		return syscall.S_IFIFO
+	default:
+		return syscall.S_IFREG
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package lazy // import "github.com/docker/docker/distribution/lazy"
+
+import (
+	"errors"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// Mount is not supported on this platform.
+func (d *Diff) Mount(target string) (func() error, error) {
+	return nil, errdefs.NotImplemented(errors.New("lazy layers are not supported on this platform"))
+}
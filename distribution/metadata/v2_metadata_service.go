@@ -36,6 +36,13 @@ type V2Metadata struct {
 	// HMAC hashes above attributes with recent authconfig digest used as a key in order to determine matching
 	// metadata entries accompanied by the same credentials without actually exposing them.
 	HMAC string
+	// MediaType is the media type of the blob. Metadata recorded before the
+	// media type was tracked has none, and refers to gzip-compressed blobs.
+	MediaType string `json:",omitempty"`
+	// TOCDigest and TOCPosition locate the table of contents of zstd:chunked
+	// blobs, as found in the annotations of their descriptor.
+	TOCDigest   string `json:",omitempty"`
+	TOCPosition string `json:",omitempty"`
 }
 
 // CheckV2MetadataHMAC returns true if the given "meta" is tagged with a hmac hashed by the given "key".
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"runtime"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"github.com/docker/docker/image"
 	v1 "github.com/docker/docker/image/v1"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/stringid"
@@ -71,12 +73,13 @@ type puller struct {
 	config          *ImagePullConfig
 	repoInfo        *registry.RepositoryInfo
 	repo            distribution.Repository
+	transport       http.RoundTripper
 	manifestStore   *manifestStore
 }
 
 func (p *puller) pull(ctx context.Context, ref reference.Named) (err error) {
 	// TODO(tiborvass): was ReceiveTimeout
-	p.repo, err = newRepository(ctx, p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
+	p.repo, p.transport, err = newRepositoryWithTransport(ctx, p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
 	if err != nil {
 		logrus.Warnf("Error getting v2 registry: %v", err)
 		return err
@@ -161,6 +164,11 @@ type layerDescriptor struct {
 	tmpFile         *os.File
 	verifier        digest.Verifier
 	src             distribution.Descriptor
+
+	// blobURL and transport locate the blob of a layer which is pulled
+	// lazily.
+	blobURL   string
+	transport http.RoundTripper
 }
 
 func (ld *layerDescriptor) Key() string {
@@ -191,6 +199,9 @@ func (ld *layerDescriptor) Download(ctx context.Context, progressOutput progress
 		if err != nil {
 			return nil, 0, xfer.DoNotRetry{Err: err}
 		}
+		// The layer may be downloaded again after a complete download,
+		// to backfill a lazily pulled layer.
+		ld.verifier = nil
 	} else {
 		offset, err = ld.tmpFile.Seek(0, io.SeekEnd)
 		if err != nil {
@@ -344,7 +355,13 @@ func (ld *layerDescriptor) truncateDownloadFile() error {
 
 func (ld *layerDescriptor) Registered(diffID layer.DiffID) {
 	// Cache mapping from this layer's DiffID to the blobsum
-	_ = ld.metadataService.Add(diffID, metadata.V2Metadata{Digest: ld.digest, SourceRepository: ld.repoInfo.Name.Name()})
+	_ = ld.metadataService.Add(diffID, metadata.V2Metadata{
+		Digest:           ld.digest,
+		SourceRepository: ld.repoInfo.Name.Name(),
+		MediaType:        ld.src.MediaType,
+		TOCDigest:        ld.src.Annotations[archive.ZstdChunkedManifestChecksumAnnotation],
+		TOCPosition:      ld.src.Annotations[archive.ZstdChunkedManifestPositionAnnotation],
+	})
 }
 
 func (p *puller) pullTag(ctx context.Context, ref reference.Named, platform *specs.Platform) (tagUpdated bool, err error) {
@@ -702,6 +719,30 @@ func (p *puller) pullSchema2Layers(ctx context.Context, target distribution.Desc
 		}
 	}
 
+	if p.config.LazyPull && configJSON == nil {
+		// Lazily pulled layers are registered before they are downloaded,
+		// so their DiffIDs must be known first.
+		configJSON, configRootFS, _, err = receiveConfig(configChan, configErrChan)
+		if err != nil {
+			return "", err
+		}
+		if configRootFS == nil {
+			return "", errRootFSInvalid
+		}
+		if len(descriptors) != len(configRootFS.DiffIDs) {
+			return "", errRootFSMismatch
+		}
+		for i, d := range descriptors {
+			ld := d.(*layerDescriptor)
+			ld.diffID = configRootFS.DiffIDs[i]
+			ld.blobURL, err = p.blobURL(ld.digest)
+			if err != nil {
+				return "", err
+			}
+			ld.transport = p.transport
+		}
+	}
+
 	// Assume that the operating system is the host OS if blank, and validate it
 	// to ensure we don't cause a panic by an invalid index into the layerstores.
 	if layerStoreOS != "" && !system.IsOSSupported(layerStoreOS) {
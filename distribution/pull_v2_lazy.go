@@ -0,0 +1,129 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	v2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/docker/distribution/lazy"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/layer"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// blobReadAhead is the minimum size of the ranges of blobs fetched by
+	// lazily pulled layers, so that small reads following each other are
+	// served by the same request.
+	blobReadAhead = 1 << 20
+
+	// blobRangeTimeout is the timeout of the requests fetching ranges of
+	// blobs.
+	blobRangeTimeout = 5 * time.Minute
+)
+
+// blobURL returns the URL of the blob with the given digest in the
+// repository.
+func (p *puller) blobURL(dgst digest.Digest) (string, error) {
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL.String(), false)
+	if err != nil {
+		return "", err
+	}
+	ref, err := reference.WithDigest(p.repo.Named(), dgst)
+	if err != nil {
+		return "", err
+	}
+	return ub.BuildBlobURL(ref)
+}
+
+// LazyDiff returns the content of the layer read on demand from the
+// registry, if the layer is pulled lazily.
+func (ld *layerDescriptor) LazyDiff() (layer.LazyDiff, error) {
+	if ld.blobURL == "" {
+		return nil, errdefs.NotImplemented(errors.New("layer is not pulled lazily"))
+	}
+	ra := &blobReaderAt{
+		client: &http.Client{Transport: ld.transport, Timeout: blobRangeTimeout},
+		url:    ld.blobURL,
+		size:   ld.src.Size,
+	}
+	return lazy.Open(ra, ld.src.Size, ld.src, ld.diffID)
+}
+
+// blobReaderAt reads a blob from the registry at arbitrary offsets, with
+// range requests. The last range fetched is kept, to serve the reads within
+// it.
+type blobReaderAt struct {
+	client *http.Client
+	url    string
+	size   int64
+
+	mu     sync.Mutex
+	buf    []byte
+	bufOff int64
+}
+
+func (r *blobReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int
+	for n < len(p) && off+int64(n) < r.size {
+		pos := off + int64(n)
+		if pos < r.bufOff || pos >= r.bufOff+int64(len(r.buf)) {
+			length := int64(len(p) - n)
+			if length < blobReadAhead {
+				length = blobReadAhead
+			}
+			if err := r.fetch(pos, length); err != nil {
+				return n, err
+			}
+		}
+		n += copy(p[n:], r.buf[pos-r.bufOff:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetch fetches length bytes of the blob from offset, or up to its end.
+func (r *blobReaderAt) fetch(offset, length int64) error {
+	end := offset + length
+	if end > r.size {
+		end = r.size
+	}
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end-1))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The registry ignored the range, and sends the whole blob.
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unexpected status fetching range of blob %s: %s", r.url, resp.Status)
+	}
+
+	buf := make([]byte, end-offset)
+	if _, err := io.ReadFull(resp.Body, buf); err != nil {
+		return err
+	}
+	r.buf, r.bufOff = buf, offset
+	return nil
+}
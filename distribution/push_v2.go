@@ -11,6 +11,7 @@ import (
 	"sync"
 
 	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/ocischema"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
@@ -20,11 +21,13 @@ import (
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -157,6 +160,7 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 		endpoint:        p.endpoint,
 		repo:            p.repo,
 		pushState:       &p.pushState,
+		compression:     p.config.LayerCompression,
 	}
 
 	// Loop bounds condition is to avoid pushing the base layer on Windows.
@@ -173,8 +177,18 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 		return err
 	}
 
-	// Try schema2 first
-	builder := schema2.NewManifestBuilder(p.repo.Blobs(ctx), p.config.ConfigMediaType, imgConfig)
+	// Try schema2 first, unless layers use a compression which is only
+	// supported by OCI image manifests.
+	var builder distribution.ManifestBuilder
+	if requiresOCIManifest(descriptors) {
+		builder = ocischema.NewManifestBuilder(p.repo.Blobs(ctx), imgConfig, nil)
+		for _, d := range descriptors {
+			d := d.(*pushDescriptor)
+			d.remoteDescriptor.MediaType = ociLayerMediaType(d.remoteDescriptor.MediaType)
+		}
+	} else {
+		builder = schema2.NewManifestBuilder(p.repo.Blobs(ctx), p.config.ConfigMediaType, imgConfig)
+	}
 	manifest, err := manifestFromBuilder(ctx, builder, descriptors)
 	if err != nil {
 		return err
@@ -237,6 +251,11 @@ func (p *pusher) pushTag(ctx context.Context, ref reference.NamedTagged, id dige
 		if err != nil {
 			return err
 		}
+	case *ocischema.DeserializedManifest:
+		_, canonicalManifest, err = v.Payload()
+		if err != nil {
+			return err
+		}
 	}
 
 	manifestDigest := digest.FromBytes(canonicalManifest)
@@ -265,6 +284,29 @@ func manifestFromBuilder(ctx context.Context, builder distribution.ManifestBuild
 	return builder.Build(ctx)
 }
 
+// requiresOCIManifest returns whether any of the layers has a media type
+// which is not supported by schema2 manifests.
+func requiresOCIManifest(descriptors []xfer.UploadDescriptor) bool {
+	for _, d := range descriptors {
+		if d.(*pushDescriptor).remoteDescriptor.MediaType == v1.MediaTypeImageLayerZstd {
+			return true
+		}
+	}
+	return false
+}
+
+// ociLayerMediaType returns the OCI equivalent of a schema2 layer media type.
+func ociLayerMediaType(mediaType string) string {
+	switch mediaType {
+	case schema2.MediaTypeLayer:
+		return v1.MediaTypeImageLayerGzip
+	case schema2.MediaTypeForeignLayer:
+		return v1.MediaTypeImageLayerNonDistributableGzip //nolint:staticcheck // ignore SA1019: non-distributable layers are deprecated.
+	default:
+		return mediaType
+	}
+}
+
 type pushDescriptor struct {
 	layer            PushLayer
 	metadataService  metadata.V2MetadataService
@@ -277,10 +319,15 @@ type pushDescriptor struct {
 	remoteDescriptor distribution.Descriptor
 	// a set of digests whose presence has been checked in a target repository
 	checkedDigests map[digest.Digest]struct{}
+	compression    LayerCompression
 }
 
 func (pd *pushDescriptor) Key() string {
-	return "v2push:" + pd.ref.Name() + " " + pd.layer.DiffID().String()
+	key := "v2push:" + pd.ref.Name() + " " + pd.layer.DiffID().String()
+	if pd.compression != "" && pd.compression != LayerCompressionGzip {
+		key += " " + string(pd.compression)
+	}
+	return key
 }
 
 func (pd *pushDescriptor) ID() string {
@@ -316,9 +363,11 @@ func (pd *pushDescriptor) Upload(ctx context.Context, progressOutput progress.Ou
 
 	maxMountAttempts, maxExistenceChecks, checkOtherRepositories := getMaxMountAndExistenceCheckAttempts(pd.layer)
 
-	// Do we have any metadata associated with this layer's DiffID?
+	// Do we have any metadata associated with this layer's DiffID? Only
+	// blobs with the requested compression can be reused.
 	metaData, err := pd.metadataService.GetMetadata(diffID)
 	if err == nil {
+		metaData = pd.filterMetadata(metaData)
 		// check for blob existence in the target repository
 		descriptor, exists, err := pd.layerAlreadyExists(ctx, progressOutput, diffID, true, 1, metaData)
 		if exists || err != nil {
@@ -373,7 +422,8 @@ func (pd *pushDescriptor) Upload(ctx context.Context, progressOutput progress.Ou
 		case distribution.ErrBlobMounted:
 			progress.Updatef(progressOutput, pd.ID(), "Mounted from %s", err.From.Name())
 
-			err.Descriptor.MediaType = schema2.MediaTypeLayer
+			err.Descriptor.MediaType = pd.compression.mediaType()
+			err.Descriptor.Annotations = tocAnnotations(mountCandidate)
 
 			pd.pushState.Lock()
 			pd.pushState.remoteLayers[diffID] = err.Descriptor
@@ -383,6 +433,9 @@ func (pd *pushDescriptor) Upload(ctx context.Context, progressOutput progress.Ou
 			if err := pd.metadataService.TagAndAdd(diffID, pd.hmacKey, metadata.V2Metadata{
 				Digest:           err.Descriptor.Digest,
 				SourceRepository: pd.repoInfo.Name(),
+				MediaType:        mountCandidate.MediaType,
+				TOCDigest:        mountCandidate.TOCDigest,
+				TOCPosition:      mountCandidate.TOCPosition,
 			}); err != nil {
 				return distribution.Descriptor{}, xfer.DoNotRetry{Err: err}
 			}
@@ -467,14 +520,24 @@ func (pd *pushDescriptor) uploadUsingSession(
 
 	reader = progress.NewProgressReader(ioutils.NewCancelReadCloser(ctx, contentReader), progressOutput, pd.layer.Size(), pd.ID(), "Pushing")
 
+	var (
+		mediaType  = schema2.MediaTypeLayer
+		compressor layerCompressor
+	)
 	switch m := pd.layer.MediaType(); m {
 	case schema2.MediaTypeUncompressedLayer:
-		compressedReader, compressionDone := compress(reader)
+		compressedReader, compressionDone, c, err := compress(reader, pd.compression)
+		if err != nil {
+			reader.Close()
+			return distribution.Descriptor{}, xfer.DoNotRetry{Err: err}
+		}
 		defer func(closer io.Closer) {
 			closer.Close()
 			<-compressionDone
 		}(reader)
 		reader = compressedReader
+		mediaType = pd.compression.mediaType()
+		compressor = c
 	case schema2.MediaTypeLayer:
 	default:
 		reader.Close()
@@ -498,18 +561,28 @@ func (pd *pushDescriptor) uploadUsingSession(
 	logrus.Debugf("uploaded layer %s (%s), %d bytes", diffID, pushDigest, nn)
 	progress.Update(progressOutput, pd.ID(), "Pushed")
 
+	// The compressor is done once all of its output was read.
+	var annotations map[string]string
+	if compressor != nil {
+		annotations = compressor.Annotations()
+	}
+
 	// Cache mapping from this layer's DiffID to the blobsum
 	if err := pd.metadataService.TagAndAdd(diffID, pd.hmacKey, metadata.V2Metadata{
 		Digest:           pushDigest,
 		SourceRepository: pd.repoInfo.Name(),
+		MediaType:        mediaType,
+		TOCDigest:        annotations[archive.ZstdChunkedManifestChecksumAnnotation],
+		TOCPosition:      annotations[archive.ZstdChunkedManifestPositionAnnotation],
 	}); err != nil {
 		return distribution.Descriptor{}, xfer.DoNotRetry{Err: err}
 	}
 
 	desc := distribution.Descriptor{
-		Digest:    pushDigest,
-		MediaType: schema2.MediaTypeLayer,
-		Size:      nn,
+		Digest:      pushDigest,
+		MediaType:   mediaType,
+		Size:        nn,
+		Annotations: annotations,
 	}
 
 	pd.pushState.Lock()
@@ -575,11 +648,15 @@ attempts:
 				if err := pd.metadataService.TagAndAdd(diffID, pd.hmacKey, metadata.V2Metadata{
 					Digest:           desc.Digest,
 					SourceRepository: pd.repoInfo.Name(),
+					MediaType:        meta.MediaType,
+					TOCDigest:        meta.TOCDigest,
+					TOCPosition:      meta.TOCPosition,
 				}); err != nil {
 					return distribution.Descriptor{}, false, xfer.DoNotRetry{Err: err}
 				}
 			}
-			desc.MediaType = schema2.MediaTypeLayer
+			desc.MediaType = pd.compression.mediaType()
+			desc.Annotations = tocAnnotations(*meta)
 			exists = true
 			break attempts
 		case distribution.ErrBlobUnknown:
@@ -602,6 +679,30 @@ attempts:
 	return desc, exists, nil
 }
 
+// filterMetadata returns the metadata of the blobs which were compressed
+// with the compression requested for this push.
+func (pd *pushDescriptor) filterMetadata(v2Metadata []metadata.V2Metadata) []metadata.V2Metadata {
+	var filtered []metadata.V2Metadata
+	for _, meta := range v2Metadata {
+		if pd.compression.matches(meta) {
+			filtered = append(filtered, meta)
+		}
+	}
+	return filtered
+}
+
+// tocAnnotations returns the descriptor annotations locating the table of
+// contents of a zstd:chunked layer, if meta describes one.
+func tocAnnotations(meta metadata.V2Metadata) map[string]string {
+	if meta.TOCDigest == "" {
+		return nil
+	}
+	return map[string]string{
+		archive.ZstdChunkedManifestChecksumAnnotation: meta.TOCDigest,
+		archive.ZstdChunkedManifestPositionAnnotation: meta.TOCPosition,
+	}
+}
+
 // getMaxMountAndExistenceCheckAttempts returns a maximum number of cross repository mount attempts from
 // source repositories of target registry, maximum number of layer existence checks performed on the target
 // repository and whether the check shall be done also with digests mapped to different repositories. The
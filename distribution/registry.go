@@ -60,6 +60,16 @@ func newRepository(
 	ctx context.Context, repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint,
 	metaHeaders http.Header, authConfig *registrytypes.AuthConfig, actions ...string,
 ) (repo distribution.Repository, err error) {
+	repo, _, err = newRepositoryWithTransport(ctx, repoInfo, endpoint, metaHeaders, authConfig, actions...)
+	return repo, err
+}
+
+// newRepositoryWithTransport returns a repository as newRepository does, and
+// the HTTP transport used to access it.
+func newRepositoryWithTransport(
+	ctx context.Context, repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint,
+	metaHeaders http.Header, authConfig *registrytypes.AuthConfig, actions ...string,
+) (repo distribution.Repository, tr http.RoundTripper, err error) {
 	repoName := repoInfo.Name.Name()
 	// If endpoint does not support CanonicalName, use the RemoteName instead
 	if endpoint.TrimHostname {
@@ -91,7 +101,7 @@ func newRepository(
 			transportOK = true
 			err = responseErr.Err
 		}
-		return nil, fallbackError{
+		return nil, nil, fallbackError{
 			err:         err,
 			transportOK: transportOK,
 		}
@@ -118,11 +128,11 @@ func newRepository(
 		basicHandler := auth.NewBasicHandler(creds)
 		modifiers = append(modifiers, auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
 	}
-	tr := transport.NewTransport(base, modifiers...)
+	tr = transport.NewTransport(base, modifiers...)
 
 	repoNameRef, err := reference.WithName(repoName)
 	if err != nil {
-		return nil, fallbackError{
+		return nil, nil, fallbackError{
 			err:         err,
 			transportOK: true,
 		}
@@ -104,6 +104,16 @@ type DigestRegisterer interface {
 	Registered(diffID layer.DiffID)
 }
 
+// LazyDescriptor can be implemented by a DownloadDescriptor whose layer can
+// be registered with its content mounted lazily, before it is downloaded. The
+// layer is then downloaded in the background, to backfill its content. This
+// requires the DiffID of the layer to be known.
+type LazyDescriptor interface {
+	// LazyDiff returns the lazily mounted content of the layer, or an
+	// error if the layer cannot be mounted lazily.
+	LazyDiff() (layer.LazyDiff, error)
+}
+
 // Download is a blocking function which ensures the requested layers are
 // present in the layer store. It uses the string returned by the Key method to
 // deduplicate downloads. If a given layer is not already known to present in
@@ -249,6 +259,10 @@ func (ldm *LayerDownloadManager) makeDownloadFunc(descriptor DownloadDescriptor,
 				<-start
 			}
 
+			if ldm.registerLazy(d, descriptor, parentLayer, parentDownload, progressOutput) {
+				return
+			}
+
 			if parentDownload != nil {
 				// Did the parent download already fail or get
 				// cancelled?
@@ -384,6 +398,108 @@ func (ldm *LayerDownloadManager) makeDownloadFunc(descriptor DownloadDescriptor,
 	}
 }
 
+// registerLazy registers the layer of descriptor with its content mounted
+// lazily, if descriptor is a LazyDescriptor and the layer store a
+// layer.LazyStore, and starts the download of the layer in the background to
+// backfill it. It returns false if the layer must be downloaded instead.
+func (ldm *LayerDownloadManager) registerLazy(d *downloadTransfer, descriptor DownloadDescriptor, parentLayer layer.ChainID, parentDownload *downloadTransfer, progressOutput progress.Output) bool {
+	ld, ok := descriptor.(LazyDescriptor)
+	if !ok {
+		return false
+	}
+	ls, ok := d.layerStore.(layer.LazyStore)
+	if !ok {
+		return false
+	}
+	diff, err := ld.LazyDiff()
+	if err != nil {
+		logrus.WithError(err).Debugf("Not mounting layer %s lazily", descriptor.ID())
+		return false
+	}
+
+	if parentDownload != nil {
+		select {
+		case <-d.transfer.context().Done():
+			return false
+		case <-parentDownload.done():
+		}
+		l, err := parentDownload.result()
+		if err != nil {
+			return false
+		}
+		parentLayer = l.ChainID()
+	}
+
+	var src distribution.Descriptor
+	if fs, ok := descriptor.(distribution.Describable); ok {
+		src = fs.Descriptor()
+	}
+	l, backfill, err := ls.RegisterLazy(diff, parentLayer, src)
+	if err != nil {
+		logrus.WithError(err).Debugf("Not mounting layer %s lazily", descriptor.ID())
+		return false
+	}
+	d.layer = l
+
+	progress.Update(progressOutput, descriptor.ID(), "Pull complete (lazy)")
+
+	if withRegistered, ok := descriptor.(DigestRegisterer); ok {
+		withRegistered.Registered(d.layer.DiffID())
+	}
+
+	go func() {
+		<-d.transfer.released()
+		layer.ReleaseAndLog(d.layerStore, d.layer)
+	}()
+
+	if backfill == nil {
+		descriptor.Close()
+		return true
+	}
+	go func() {
+		defer descriptor.Close()
+		if err := ldm.backfill(descriptor, backfill); err != nil {
+			logrus.WithError(err).Errorf("Failed to download lazily pulled layer %s", descriptor.ID())
+		}
+	}()
+	return true
+}
+
+// backfill downloads the layer of descriptor and passes its content to
+// backfill, retrying as the download of layers does. Once out of retries, it
+// gives up on the layer by passing the last error to backfill.
+func (ldm *LayerDownloadManager) backfill(descriptor DownloadDescriptor, backfill func(io.Reader, error) error) error {
+	// The download outlives the pull which registered the layer.
+	ctx := context.Background()
+	for retries := 1; ; retries++ {
+		err := backfillOnce(ctx, descriptor, backfill)
+		if err == nil {
+			return nil
+		}
+		if _, isDNR := err.(DoNotRetry); isDNR || retries > ldm.maxDownloadAttempts {
+			return backfill(nil, err)
+		}
+		logrus.Infof("Backfill of lazily pulled layer %s failed, retrying (%d/%d): %v", descriptor.ID(), retries, ldm.maxDownloadAttempts, err)
+		time.Sleep(time.Duration(retries*5) * ldm.waitDuration)
+	}
+}
+
+func backfillOnce(ctx context.Context, descriptor DownloadDescriptor, backfill func(io.Reader, error) error) error {
+	downloadReader, _, err := descriptor.Download(ctx, progress.DiscardOutput())
+	if err != nil {
+		return err
+	}
+	defer downloadReader.Close()
+
+	inflatedLayerData, err := archive.DecompressStream(downloadReader)
+	if err != nil {
+		return fmt.Errorf("could not get decompression stream: %v", err)
+	}
+	defer inflatedLayerData.Close()
+
+	return backfill(inflatedLayerData, nil)
+}
+
 // makeDownloadFuncFromDownload returns a function that performs the layer
 // registration when the layer data is coming from an existing download. It
 // waits for sourceDownload and parentDownload to complete, and then
@@ -422,3 +422,134 @@ func TestMaxDownloadAttempts(t *testing.T) {
 		})
 	}
 }
+
+type mockLazyDiff struct {
+	diffID layer.DiffID
+}
+
+func (d *mockLazyDiff) DiffID() layer.DiffID {
+	return d.diffID
+}
+
+func (d *mockLazyDiff) Size() int64 {
+	return 0
+}
+
+func (d *mockLazyDiff) Mount(string) (func() error, error) {
+	return nil, errors.New("not implemented")
+}
+
+type mockLazyLayerStore struct {
+	*mockLayerStore
+	backfilled       chan []byte
+	backfillFailures int
+}
+
+func (ls *mockLazyLayerStore) RegisterLazy(diff layer.LazyDiff, parentID layer.ChainID, _ distribution.Descriptor) (layer.Layer, func(io.Reader, error) error, error) {
+	var parent layer.Layer
+	if parentID != "" {
+		var err error
+		if parent, err = ls.Get(parentID); err != nil {
+			return nil, nil, err
+		}
+	}
+	l := &mockLayer{parent: parent, diffID: diff.DiffID()}
+	l.chainID = createChainIDFromParent(parentID, l.diffID)
+	ls.layers[l.chainID] = l
+
+	return l, func(ts io.Reader, err error) error {
+		if err != nil {
+			close(ls.backfilled)
+			return err
+		}
+		b, err := io.ReadAll(ts)
+		if err != nil {
+			return err
+		}
+		if ls.backfillFailures > 0 {
+			ls.backfillFailures--
+			return errors.New("simulating backfill failure")
+		}
+		ls.backfilled <- b
+		return nil
+	}, nil
+}
+
+type mockLazyDownloadDescriptor struct {
+	*mockDownloadDescriptor
+	lazyErr error
+}
+
+func (d *mockLazyDownloadDescriptor) LazyDiff() (layer.LazyDiff, error) {
+	if d.lazyErr != nil {
+		return nil, d.lazyErr
+	}
+	return &mockLazyDiff{diffID: d.expectedDiffID}, nil
+}
+
+func TestLazyDownload(t *testing.T) {
+	layerStore := &mockLazyLayerStore{
+		mockLayerStore:   &mockLayerStore{make(map[layer.ChainID]*mockLayer)},
+		backfilled:       make(chan []byte, 1),
+		backfillFailures: 1,
+	}
+	ldm := NewLayerDownloadManager(layerStore, maxDownloadConcurrency, func(m *LayerDownloadManager) { m.waitDuration = time.Millisecond })
+
+	progressChan := make(chan progress.Progress)
+	progressDone := make(chan struct{})
+	receivedProgress := make(map[string]progress.Progress)
+
+	go func() {
+		for p := range progressChan {
+			receivedProgress[p.ID] = p
+		}
+		close(progressDone)
+	}()
+
+	descriptors := []DownloadDescriptor{
+		&mockLazyDownloadDescriptor{
+			mockDownloadDescriptor: &mockDownloadDescriptor{
+				id:             "id1",
+				expectedDiffID: layer.DiffID("sha256:68e2c75dc5c78ea9240689c60d7599766c213ae210434c53af18470ae8c53ec1"),
+			},
+		},
+		&mockLazyDownloadDescriptor{
+			mockDownloadDescriptor: &mockDownloadDescriptor{
+				id:             "id2",
+				expectedDiffID: layer.DiffID("sha256:64a636223116aa837973a5d9c2bdd17d9b204e4f95ac423e20e65dfbb3655473"),
+			},
+			lazyErr: errors.New("not a seekable layer"),
+		},
+	}
+
+	rootFS, releaseFunc, err := ldm.Download(context.Background(), *image.NewRootFS(), descriptors, progress.ChanOutput(progressChan))
+	assert.NilError(t, err)
+	releaseFunc()
+
+	close(progressChan)
+	<-progressDone
+
+	assert.Equal(t, len(rootFS.DiffIDs), len(descriptors))
+	for i, d := range descriptors {
+		descriptor := d.(*mockLazyDownloadDescriptor)
+		assert.Equal(t, rootFS.DiffIDs[i], descriptor.expectedDiffID)
+		assert.Equal(t, descriptor.registeredDiffID, descriptor.expectedDiffID)
+	}
+	assert.Equal(t, receivedProgress["id1"].Action, "Pull complete (lazy)")
+	assert.Equal(t, receivedProgress["id2"].Action, "Pull complete")
+
+	// The lazily registered layer is downloaded in the background, its
+	// failed backfill being retried, the other one is not.
+	select {
+	case b, ok := <-layerStore.backfilled:
+		assert.Assert(t, ok, "backfill failed")
+		assert.Equal(t, string(b), "id1id1id1id1id1")
+	case <-time.After(10 * time.Second):
+		t.Fatal("layer was not backfilled")
+	}
+	select {
+	case <-layerStore.backfilled:
+		t.Fatal("unexpected backfill")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -26,7 +26,10 @@ swagger generate model -f api/swagger.yaml \
 	-t api -m types/volume --skip-validator -C api/swagger-gen.yaml \
 	-n Volume \
 	-n VolumeCreateOptions \
-	-n VolumeListResponse
+	-n VolumeHolder \
+	-n VolumeListResponse \
+	-n VolumeSnapshot \
+	-n VolumeSnapshotSource
 
 swagger generate operation -f api/swagger.yaml \
 	-t api -a types -m types -C api/swagger-gen.yaml \
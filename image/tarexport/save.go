@@ -391,7 +391,15 @@ func (s *saveSession) saveLayer(id layer.ChainID, legacyImg image.V1Image, creat
 		}
 		defer arch.Close()
 
-		if _, err := io.Copy(tarFile, arch); err != nil {
+		w, err := archive.CompressStream(tarFile, s.compression)
+		if err != nil {
+			return distribution.Descriptor{}, err
+		}
+		if _, err := io.Copy(w, arch); err != nil {
+			w.Close()
+			return distribution.Descriptor{}, err
+		}
+		if err := w.Close(); err != nil {
 			return distribution.Descriptor{}, err
 		}
 
@@ -4,6 +4,7 @@ import (
 	"github.com/docker/distribution"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	refstore "github.com/docker/docker/reference"
 )
 
@@ -28,6 +29,7 @@ type tarexporter struct {
 	lss            layer.Store
 	rs             refstore.Store
 	loggerImgEvent LogImageEvent
+	compression    archive.Compression
 }
 
 // LogImageEvent defines interface for event generation related to image tar(load and save) operations
@@ -38,10 +40,18 @@ type LogImageEvent interface {
 
 // NewTarExporter returns new Exporter for tar packages
 func NewTarExporter(is image.Store, lss layer.Store, rs refstore.Store, loggerImgEvent LogImageEvent) image.Exporter {
+	return NewTarExporterWithCompression(is, lss, rs, loggerImgEvent, archive.Uncompressed)
+}
+
+// NewTarExporterWithCompression returns new Exporter for tar packages, which
+// compresses the layers it saves with the given compression. Loading detects
+// the compression of layers, so it is not affected.
+func NewTarExporterWithCompression(is image.Store, lss layer.Store, rs refstore.Store, loggerImgEvent LogImageEvent, compression archive.Compression) image.Exporter {
 	return &tarexporter{
 		is:             is,
 		lss:            lss,
 		rs:             rs,
 		loggerImgEvent: loggerImgEvent,
+		compression:    compression,
 	}
 }
@@ -386,6 +386,7 @@ func TestLiveRestore(t *testing.T) {
 	skip.If(t, runtime.GOOS == "windows", "cannot start multiple daemons on windows")
 
 	t.Run("volume references", testLiveRestoreVolumeReferences)
+	t.Run("volume access mode", testLiveRestoreVolumeAccessMode)
 }
 
 func testLiveRestoreVolumeReferences(t *testing.T) {
@@ -435,3 +436,41 @@ func testLiveRestoreVolumeReferences(t *testing.T) {
 		runTest(t, "no")
 	})
 }
+
+func testLiveRestoreVolumeAccessMode(t *testing.T) {
+	t.Parallel()
+
+	d := daemon.New(t)
+	d.StartWithBusybox(t, "--live-restore", "--iptables=false")
+	defer func() {
+		d.Stop(t)
+		d.Cleanup(t)
+	}()
+
+	c := d.NewClientT(t)
+	ctx := context.Background()
+
+	volName := "test-live-restore-volume-access-mode"
+	_, err := c.VolumeCreate(ctx, volume.CreateOptions{Name: volName, AccessMode: volume.AccessModeSingleContainer})
+	assert.NilError(t, err)
+
+	m := mount.Mount{
+		Type:   mount.TypeVolume,
+		Source: volName,
+		Target: "/foo",
+	}
+	cID := container.Run(ctx, t, c, container.WithMount(m), container.WithCmd("top"))
+	defer c.ContainerRemove(ctx, cID, types.ContainerRemoveOptions{Force: true})
+
+	d.Restart(t, "--live-restore", "--iptables=false")
+
+	// The running container still holds the volume after the restart.
+	v, err := c.VolumeInspect(ctx, volName)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(v.Holders, 1))
+
+	other := container.Create(ctx, t, c, container.WithMount(m), container.WithCmd("top"))
+	defer c.ContainerRemove(ctx, other, types.ContainerRemoveOptions{Force: true})
+	err = c.ContainerStart(ctx, other, types.ContainerStartOptions{})
+	assert.ErrorContains(t, err, "already mounted by container "+cID)
+}
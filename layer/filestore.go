@@ -13,6 +13,7 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -124,6 +125,31 @@ func (fm *fileMetadataTransaction) TarSplitWriter(compressInput bool) (io.WriteC
 	}), nil
 }
 
+// setLazy marks the layer as lazily fetched, until its backfill is committed
+// with commitBackfill.
+func (fm *fileMetadataTransaction) setLazy() error {
+	return fm.ws.WriteFile("lazy", nil, 0644)
+}
+
+// commitBackfill moves the files of the transaction into the directory of
+// the already committed layer, and clears its lazy mark.
+func (fm *fileMetadataTransaction) commitBackfill(layer ChainID) error {
+	dir := fm.store.getLayerDirectory(layer)
+	files, err := os.ReadDir(fm.ws.String())
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Rename(filepath.Join(fm.ws.String(), f.Name()), filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, "lazy")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return fm.ws.Cancel()
+}
+
 func (fm *fileMetadataTransaction) Commit(layer ChainID) error {
 	finalDir := fm.store.getLayerDirectory(layer)
 	if err := os.MkdirAll(filepath.Dir(finalDir), 0755); err != nil {
@@ -155,6 +181,12 @@ func (fms *fileMetadataStore) GetSize(layer ChainID) (int64, error) {
 	return size, nil
 }
 
+// isLazy returns whether the layer is marked as lazily fetched.
+func (fms *fileMetadataStore) isLazy(layer ChainID) bool {
+	_, err := os.Stat(fms.getLayerFilename(layer, "lazy"))
+	return err == nil
+}
+
 func (fms *fileMetadataStore) GetParent(layer ChainID) (ChainID, error) {
 	content, err := os.ReadFile(fms.getLayerFilename(layer, "parent"))
 	if err != nil {
@@ -353,6 +385,20 @@ func (fms *fileMetadataStore) getOrphan() ([]roLayer, error) {
 	return orphanLayers, nil
 }
 
+// setOrphan marks the layer for removal, so that its metadata and the layer
+// itself are removed with the other orphan layers.
+func (fms *fileMetadataStore) setOrphan(layer ChainID) error {
+	dgst := digest.Digest(layer)
+	for {
+		tmpID := fmt.Sprintf("%s-%s-removing", dgst.Hex(), stringid.GenerateRandomID())
+		err := os.Rename(fms.getLayerDirectory(layer), filepath.Join(fms.root, string(dgst.Algorithm()), tmpID))
+		if os.IsExist(err) {
+			continue
+		}
+		return err
+	}
+}
+
 func (fms *fileMetadataStore) List() ([]ChainID, []string, error) {
 	var ids []ChainID
 	for _, algorithm := range supportedAlgorithms {
@@ -190,6 +190,29 @@ type DescribableStore interface {
 	RegisterWithDescriptor(io.Reader, ChainID, distribution.Descriptor) (Layer, error)
 }
 
+// LazyDiff is the content of a layer which can be mounted before it has been
+// fetched, such as a layer read on demand from a registry.
+type LazyDiff interface {
+	// DiffID returns the uncompressed digest of the layer.
+	DiffID() DiffID
+	// Size returns the uncompressed size of the layer content.
+	Size() int64
+	// Mount mounts the content at target, and returns the function
+	// unmounting it.
+	Mount(target string) (unmount func() error, err error)
+}
+
+// LazyStore represents a layer store capable of registering layers whose
+// content is mounted lazily until it has been fully fetched.
+type LazyStore interface {
+	// RegisterLazy registers the layer with the content of diff. Once the
+	// whole tar stream of the layer is available, it must be passed to
+	// backfill, which turns the layer into a regular one, or backfill must
+	// be called with the error of fetching it. backfill is nil if the layer
+	// was already registered.
+	RegisterLazy(diff LazyDiff, parent ChainID, descriptor distribution.Descriptor) (l Layer, backfill func(ts io.Reader, err error) error, err error)
+}
+
 // CreateChainID returns ID for a layerDigest slice
 func CreateChainID(dgsts []DiffID) ChainID {
 	return createChainIDFromParent("", dgsts...)
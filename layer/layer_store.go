@@ -98,6 +98,7 @@ func newStoreFromGraphDriver(root string, driver graphdriver.Driver) (Store, err
 	if err != nil {
 		return nil, err
 	}
+	ids = ls.removeIncompleteLazyLayers(ids)
 
 	for _, id := range ids {
 		l, err := ls.loadLayer(id)
@@ -265,6 +266,16 @@ func (ls *layerStore) Register(ts io.Reader, parent ChainID) (Layer, error) {
 }
 
 func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descriptor distribution.Descriptor) (Layer, error) {
+	l, _, err := ls.register(parent, descriptor, func(tx *fileMetadataTransaction, layer *roLayer, pid string) error {
+		return ls.applyTar(tx, ts, pid, layer)
+	})
+	return l, err
+}
+
+// register registers a layer on top of parent, whose content is set up by
+// apply. It returns the layer, and whether it was created rather than
+// already registered.
+func (ls *layerStore) register(parent ChainID, descriptor distribution.Descriptor, apply func(tx *fileMetadataTransaction, layer *roLayer, pid string) error) (Layer, bool, error) {
 	// err is used to hold the error which will always trigger
 	// cleanup of creates sources but may not be an error returned
 	// to the caller (already exists).
@@ -277,7 +288,7 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 		p = ls.get(parent)
 		ls.layerL.Unlock()
 		if p == nil {
-			return nil, ErrLayerDoesNotExist
+			return nil, false, ErrLayerDoesNotExist
 		}
 		pid = p.cacheID
 		// Release parent chain if error
@@ -290,7 +301,7 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 		}()
 		if p.depth() >= maxLayerDepth {
 			err = ErrMaxDepthExceeded
-			return nil, err
+			return nil, false, err
 		}
 	}
 
@@ -305,12 +316,12 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 	}
 
 	if err = ls.driver.Create(layer.cacheID, pid, nil); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	tx, err := ls.store.StartTransaction()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	defer func() {
@@ -325,8 +336,8 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 		}
 	}()
 
-	if err = ls.applyTar(tx, ts, pid, layer); err != nil {
-		return nil, err
+	if err = apply(tx, layer, pid); err != nil {
+		return nil, false, err
 	}
 
 	if layer.parent == nil {
@@ -336,7 +347,7 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 	}
 
 	if err = storeLayer(tx, layer); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	ls.layerL.Lock()
@@ -345,16 +356,16 @@ func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descr
 	if existingLayer := ls.get(layer.chainID); existingLayer != nil {
 		// Set error for cleanup, but do not return the error
 		err = errors.New("layer already exists")
-		return existingLayer.getReference(), nil
+		return existingLayer.getReference(), false, nil
 	}
 
 	if err = tx.Commit(layer.chainID); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	ls.layerMap[layer.chainID] = layer
 
-	return layer.getReference(), nil
+	return layer.getReference(), true, nil
 }
 
 func (ls *layerStore) get(layer ChainID) *roLayer {
@@ -395,7 +406,7 @@ func (ls *layerStore) deleteLayer(layer *roLayer, metadata *Metadata) error {
 	// Rename layer digest folder first so we detect orphan layer(s)
 	// if ls.driver.Remove fails
 	var dir string
-	for {
+	for !layer.orphaned {
 		dgst := digest.Digest(layer.chainID)
 		tmpID := fmt.Sprintf("%s-%s-removing", dgst.Hex(), stringid.GenerateRandomID())
 		dir = filepath.Join(ls.store.root, string(dgst.Algorithm()), tmpID)
@@ -409,7 +420,13 @@ func (ls *layerStore) deleteLayer(layer *roLayer, metadata *Metadata) error {
 	if err != nil {
 		return err
 	}
-	err = os.RemoveAll(dir)
+	if layer.orphaned {
+		// The layer folder was already renamed when the layer was
+		// orphaned, and another layer may have the chain ID since.
+		err = ls.store.Remove(layer.chainID, layer.cacheID)
+	} else {
+		err = os.RemoveAll(dir)
+	}
 	if err != nil {
 		return err
 	}
@@ -444,7 +461,9 @@ func (ls *layerStore) releaseLayer(l *roLayer) ([]Metadata, error) {
 		}
 		// Remove layer from layer map first so it is not considered to exist
 		// when if ls.deleteLayer fails.
-		delete(ls.layerMap, l.chainID)
+		if !l.orphaned {
+			delete(ls.layerMap, l.chainID)
+		}
 
 		var metadata Metadata
 		if err := ls.deleteLayer(l, &metadata); err != nil {
@@ -465,6 +484,9 @@ func (ls *layerStore) Release(l Layer) ([]Metadata, error) {
 	ls.layerL.Lock()
 	defer ls.layerL.Unlock()
 	layer, ok := ls.layerMap[l.ChainID()]
+	if ref, isRef := l.(*referencedCacheLayer); isRef && ref.orphaned {
+		layer, ok = ref.roLayer, true
+	}
 	if !ok {
 		return []Metadata{}, nil
 	}
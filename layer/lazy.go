@@ -0,0 +1,259 @@
+package layer // import "github.com/docker/docker/layer"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/docker/distribution"
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"github.com/vbatts/tar-split/tar/asm"
+	"github.com/vbatts/tar-split/tar/storage"
+)
+
+// lazyState tracks the backfill of a lazily fetched layer.
+type lazyState struct {
+	done chan struct{}
+	err  error
+}
+
+// waitBackfill waits until the content of the layer has been backfilled, if
+// it was lazily fetched, and returns the error of the backfill.
+func (rl *roLayer) waitBackfill() error {
+	if rl.lazy == nil {
+		return nil
+	}
+	<-rl.lazy.done
+	return rl.lazy.err
+}
+
+// RegisterLazy registers a layer whose content is mounted lazily from diff,
+// until backfill is called with its full tar stream. A failed backfill may be
+// retried by calling backfill again, until it is called with a non-nil error,
+// which gives up on the layer: it is then removed from the store along with
+// the layers on top of it, and deleted once they are all released.
+func (ls *layerStore) RegisterLazy(diff LazyDiff, parent ChainID, descriptor distribution.Descriptor) (Layer, func(io.Reader, error) error, error) {
+	driver, ok := ls.driver.(graphdriver.LazyDiffDriver)
+	if !ok {
+		return nil, nil, graphdriver.ErrNotSupported
+	}
+
+	var rl *roLayer
+	l, created, err := ls.register(parent, descriptor, func(tx *fileMetadataTransaction, layer *roLayer, pid string) error {
+		if err := driver.ApplyLazyDiff(layer.cacheID, pid, diff.Mount); err != nil {
+			return err
+		}
+		if err := tx.setLazy(); err != nil {
+			return err
+		}
+		layer.diffID = diff.DiffID()
+		layer.size = diff.Size()
+		layer.lazy = &lazyState{done: make(chan struct{})}
+		rl = layer
+		return nil
+	})
+	if err != nil || !created {
+		return l, nil, err
+	}
+
+	// Keep the layer until the end of its backfill, even if all the
+	// references to it are released in the meantime.
+	ls.layerL.Lock()
+	rl.referenceCount++
+	ls.layerL.Unlock()
+
+	var (
+		mu       sync.Mutex
+		finished bool
+	)
+	backfill := func(ts io.Reader, err error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			return fmt.Errorf("layer %s is already backfilled", rl.diffID)
+		}
+		ls.layerL.Lock()
+		orphaned := rl.orphaned
+		ls.layerL.Unlock()
+		if orphaned {
+			err = errors.New("layer was removed")
+		}
+		if err == nil {
+			err = ls.backfill(driver, rl, ts)
+			if err != nil {
+				// Leave the layer lazily mounted, so that its backfill
+				// can be retried.
+				return fmt.Errorf("failed to backfill layer %s: %w", rl.diffID, err)
+			}
+		} else {
+			err = fmt.Errorf("failed to backfill layer %s: %w", rl.diffID, err)
+		}
+		finished = true
+
+		ls.layerL.Lock()
+		defer ls.layerL.Unlock()
+		if err != nil {
+			ls.orphanLazyLayer(rl)
+		}
+		rl.lazy.err = err
+		close(rl.lazy.done)
+		if _, rerr := ls.releaseLayer(rl); rerr != nil {
+			logrus.WithError(rerr).WithField("chain-id", rl.chainID).Error("failed to release layer after its backfill")
+		}
+		return err
+	}
+	return l, backfill, nil
+}
+
+// orphanLazyLayer removes layer, whose backfill failed, and the layers on top
+// of it from the layer map, and marks them for removal, so that a later pull
+// registers them again. Each of them is deleted once it is released. It must
+// be called with layerL held.
+func (ls *layerStore) orphanLazyLayer(layer *roLayer) {
+	for _, l := range ls.layerMap {
+		for p := l; p != nil; p = p.parent {
+			if p != layer {
+				continue
+			}
+			logrus.WithField("chain-id", l.chainID).Warn("removing layer which could not be fully fetched by a lazy pull")
+			delete(ls.layerMap, l.chainID)
+			if err := ls.store.setOrphan(l.chainID); err != nil {
+				logrus.WithError(err).WithField("chain-id", l.chainID).Error("failed to mark layer for removal")
+			}
+			l.orphaned = true
+			break
+		}
+	}
+}
+
+// backfill replaces the lazily mounted content of layer by the tar stream ts,
+// and stores the metadata of the now regular layer.
+func (ls *layerStore) backfill(driver graphdriver.LazyDiffDriver, layer *roLayer, ts io.Reader) (retErr error) {
+	tx, err := ls.store.StartTransaction()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			if err := tx.Cancel(); err != nil {
+				logrus.Errorf("Error canceling metadata transaction %q: %s", tx.String(), err)
+			}
+		}
+	}()
+
+	var rdr io.Reader = &diffVerifier{r: ts, diffID: layer.diffID, verifier: digest.Digest(layer.diffID).Verifier()}
+	var tsw io.WriteCloser
+	if ls.useTarSplit {
+		tsw, err = tx.TarSplitWriter(true)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if tsw != nil {
+				tsw.Close()
+			}
+		}()
+
+		rdr, err = asm.NewInputTarStream(rdr, storage.NewJSONPacker(tsw), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	var pid string
+	if layer.parent != nil {
+		pid = layer.parent.cacheID
+	}
+	size, err := driver.BackfillDiff(layer.cacheID, pid, rdr)
+	if err != nil {
+		io.Copy(io.Discard, rdr) // release the resources of the tar-split stream, see applyTar
+		return err
+	}
+	// Read the stream to its end, so that it is verified.
+	if _, err := io.Copy(io.Discard, rdr); err != nil {
+		return err
+	}
+	if err := tx.SetSize(size); err != nil {
+		return err
+	}
+	if tsw != nil {
+		err := tsw.Close()
+		tsw = nil
+		if err != nil {
+			return err
+		}
+	}
+	// The layer may have been orphaned along with a parent layer whose
+	// backfill failed, its chain ID now belonging to another layer.
+	ls.layerL.Lock()
+	defer ls.layerL.Unlock()
+	if layer.orphaned {
+		return errors.New("layer was removed")
+	}
+	if err := tx.commitBackfill(layer.chainID); err != nil {
+		return err
+	}
+	layer.size = size
+
+	logrus.Debugf("Backfilled layer %s to %s, size: %d", layer.diffID, layer.cacheID, size)
+
+	return nil
+}
+
+// removeIncompleteLazyLayers marks the layers whose backfill did not complete,
+// and the layers on top of them, for removal. It returns the other layers.
+func (ls *layerStore) removeIncompleteLazyLayers(ids []ChainID) []ChainID {
+	incomplete := map[ChainID]bool{}
+	var isIncomplete func(ChainID) bool
+	isIncomplete = func(id ChainID) bool {
+		v, ok := incomplete[id]
+		if ok {
+			return v
+		}
+		v = ls.store.isLazy(id)
+		if !v {
+			if parent, err := ls.store.GetParent(id); err == nil && parent != "" {
+				v = isIncomplete(parent)
+			}
+		}
+		incomplete[id] = v
+		return v
+	}
+
+	var complete []ChainID
+	for _, id := range ids {
+		if !isIncomplete(id) {
+			complete = append(complete, id)
+			continue
+		}
+		logrus.WithField("chain-id", id).Warn("removing layer which was not fully fetched by a lazy pull")
+		if err := ls.store.setOrphan(id); err != nil {
+			logrus.WithError(err).WithField("chain-id", id).Error("failed to mark layer for removal")
+		}
+	}
+	return complete
+}
+
+// diffVerifier verifies that the content read from r matches diffID, and
+// fails at its end otherwise.
+type diffVerifier struct {
+	r        io.Reader
+	diffID   DiffID
+	verifier digest.Verifier
+}
+
+func (v *diffVerifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	if n > 0 {
+		if _, werr := v.verifier.Write(p[:n]); werr != nil {
+			return n, werr
+		}
+	}
+	if err == io.EOF && !v.verifier.Verified() {
+		err = fmt.Errorf("layer content does not match its diff ID %s", v.diffID)
+	}
+	return n, err
+}
@@ -0,0 +1,222 @@
+package layer // import "github.com/docker/docker/layer"
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// lazyTestDriver is a graphdriver using lazy content, whose lazy mounts
+// are emulated with regular files.
+type lazyTestDriver struct {
+	graphdriver.Driver
+	unmounted map[string]bool
+}
+
+func (d *lazyTestDriver) ApplyLazyDiff(id, parent string, mount graphdriver.LazyMountFunc) error {
+	dir, err := d.Get(id, "")
+	if err != nil {
+		return err
+	}
+	defer d.Put(id)
+	if _, err := mount(dir); err != nil {
+		return err
+	}
+	d.unmounted[id] = false
+	return nil
+}
+
+func (d *lazyTestDriver) BackfillDiff(id, parent string, diff io.Reader) (int64, error) {
+	size, err := d.ApplyDiff(id, parent, diff)
+	if err != nil {
+		return 0, err
+	}
+	d.unmounted[id] = true
+	return size, nil
+}
+
+type lazyTestDiff struct {
+	content []byte
+}
+
+func (d *lazyTestDiff) DiffID() DiffID {
+	return DiffID(digest.FromBytes(d.content))
+}
+
+func (d *lazyTestDiff) Size() int64 {
+	return int64(len(d.content))
+}
+
+func (d *lazyTestDiff) Mount(target string) (func() error, error) {
+	return func() error { return nil }, os.WriteFile(filepath.Join(target, "lazy"), nil, 0644)
+}
+
+func lazyTestTar(t *testing.T, name, content string) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, tw.Close())
+	return b.Bytes()
+}
+
+func TestRegisterLazy(t *testing.T) {
+	td := t.TempDir()
+	graph, err := newVFSGraphDriver(filepath.Join(td, "graph"))
+	assert.NilError(t, err)
+	driver := &lazyTestDriver{Driver: graph, unmounted: map[string]bool{}}
+	ls, err := newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+
+	content := lazyTestTar(t, "hello", "hello world")
+	l, backfill, err := ls.(LazyStore).RegisterLazy(&lazyTestDiff{content: content}, "", distribution.Descriptor{})
+	assert.NilError(t, err)
+	assert.Assert(t, backfill != nil)
+	assert.Check(t, is.Equal(l.DiffID(), DiffID(digest.FromBytes(content))))
+
+	// The tar stream of the layer is only available once it is backfilled.
+	tarStream := make(chan []byte)
+	go func() {
+		ts, err := l.TarStream()
+		if err != nil {
+			close(tarStream)
+			return
+		}
+		defer ts.Close()
+		b, _ := io.ReadAll(ts)
+		tarStream <- b
+	}()
+	select {
+	case <-tarStream:
+		t.Fatal("tar stream of the layer available before its backfill")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Registering the layer again returns the existing one.
+	l2, backfill2, err := ls.(LazyStore).RegisterLazy(&lazyTestDiff{content: content}, "", distribution.Descriptor{})
+	assert.NilError(t, err)
+	assert.Check(t, backfill2 == nil)
+	assert.Check(t, is.Equal(l2.ChainID(), l.ChainID()))
+	_, err = ls.Release(l2)
+	assert.NilError(t, err)
+
+	assert.NilError(t, backfill(bytes.NewReader(content), nil))
+	assert.Check(t, driver.unmounted[l.(*referencedCacheLayer).cacheID])
+	assert.Check(t, is.DeepEqual(<-tarStream, content))
+	assert.Check(t, !ls.(*layerStore).store.isLazy(l.ChainID()))
+
+	// The layer is kept once backfilled.
+	ls, err = newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+	_, err = ls.Get(l.ChainID())
+	assert.NilError(t, err)
+}
+
+func TestRegisterLazyBackfillRetry(t *testing.T) {
+	td := t.TempDir()
+	graph, err := newVFSGraphDriver(filepath.Join(td, "graph"))
+	assert.NilError(t, err)
+	driver := &lazyTestDriver{Driver: graph, unmounted: map[string]bool{}}
+	ls, err := newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+
+	content := lazyTestTar(t, "hello", "hello world")
+	l, backfill, err := ls.(LazyStore).RegisterLazy(&lazyTestDiff{content: content}, "", distribution.Descriptor{})
+	assert.NilError(t, err)
+
+	err = backfill(bytes.NewReader(lazyTestTar(t, "hello", "other")), nil)
+	assert.Check(t, is.ErrorContains(err, "does not match its diff ID"))
+	assert.Check(t, ls.(*layerStore).store.isLazy(l.ChainID()))
+
+	// The layer is left lazily mounted, and its backfill can be retried.
+	assert.NilError(t, backfill(bytes.NewReader(content), nil))
+	ts, err := l.TarStream()
+	assert.NilError(t, err)
+	defer ts.Close()
+	b, err := io.ReadAll(ts)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(b, content))
+	assert.Check(t, !ls.(*layerStore).store.isLazy(l.ChainID()))
+}
+
+func TestRegisterLazyBackfillFailure(t *testing.T) {
+	td := t.TempDir()
+	graph, err := newVFSGraphDriver(filepath.Join(td, "graph"))
+	assert.NilError(t, err)
+	driver := &lazyTestDriver{Driver: graph, unmounted: map[string]bool{}}
+	ls, err := newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+
+	content := lazyTestTar(t, "hello", "hello world")
+	l, backfill, err := ls.(LazyStore).RegisterLazy(&lazyTestDiff{content: content}, "", distribution.Descriptor{})
+	assert.NilError(t, err)
+	child, err := ls.Register(bytes.NewReader(lazyTestTar(t, "child", "child")), l.ChainID())
+	assert.NilError(t, err)
+
+	err = backfill(bytes.NewReader(lazyTestTar(t, "hello", "other")), nil)
+	assert.Check(t, is.ErrorContains(err, "does not match its diff ID"))
+	err = backfill(nil, errors.New("giving up"))
+	assert.Check(t, is.ErrorContains(err, "giving up"))
+	_, err = l.TarStream()
+	assert.Check(t, is.ErrorContains(err, "giving up"))
+
+	// The layer and the layers on top of it are removed from the store, so
+	// that they are registered again by a later pull.
+	_, err = ls.Get(l.ChainID())
+	assert.Check(t, is.Equal(err, ErrLayerDoesNotExist))
+	_, err = ls.Get(child.ChainID())
+	assert.Check(t, is.Equal(err, ErrLayerDoesNotExist))
+
+	l2, backfill2, err := ls.(LazyStore).RegisterLazy(&lazyTestDiff{content: content}, "", distribution.Descriptor{})
+	assert.NilError(t, err)
+	assert.Assert(t, backfill2 != nil)
+	assert.Check(t, is.Equal(l2.ChainID(), l.ChainID()))
+	assert.NilError(t, backfill2(bytes.NewReader(content), nil))
+
+	// Releasing the removed layers deletes them, but not the new one.
+	oldCacheID := l.(*referencedCacheLayer).cacheID
+	_, err = ls.Release(child)
+	assert.NilError(t, err)
+	_, err = ls.Release(l)
+	assert.NilError(t, err)
+	_, err = os.Stat(filepath.Join(td, "graph", "dir", oldCacheID))
+	assert.Check(t, os.IsNotExist(err))
+
+	ls, err = newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+	l3, err := ls.Get(l.ChainID())
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(l3.(*referencedCacheLayer).cacheID, l2.(*referencedCacheLayer).cacheID))
+}
+
+func TestRegisterLazyRemovedOnRestart(t *testing.T) {
+	td := t.TempDir()
+	graph, err := newVFSGraphDriver(filepath.Join(td, "graph"))
+	assert.NilError(t, err)
+	driver := &lazyTestDriver{Driver: graph, unmounted: map[string]bool{}}
+	ls, err := newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+
+	content := lazyTestTar(t, "hello", "hello world")
+	l, _, err := ls.(LazyStore).RegisterLazy(&lazyTestDiff{content: content}, "", distribution.Descriptor{})
+	assert.NilError(t, err)
+
+	// A layer which was not backfilled is removed on restart.
+	assert.Check(t, ls.(*layerStore).store.isLazy(l.ChainID()))
+	ls, err = newStoreFromGraphDriver(filepath.Join(td, "layers"), driver)
+	assert.NilError(t, err)
+	_, err = ls.Get(l.ChainID())
+	assert.Check(t, is.Equal(err, ErrLayerDoesNotExist))
+}
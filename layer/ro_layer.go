@@ -17,6 +17,13 @@ type roLayer struct {
 	layerStore *layerStore
 	descriptor distribution.Descriptor
 
+	// lazy is set while the content of the layer is mounted lazily,
+	// until it has been backfilled.
+	lazy *lazyState
+	// orphaned is set once the layer has been removed from the layer map
+	// and its metadata marked for removal, before it is released.
+	orphaned bool
+
 	referenceCount int
 	references     map[Layer]struct{}
 }
@@ -24,6 +31,9 @@ type roLayer struct {
 // TarStream for roLayer guarantees that the data that is produced is the exact
 // data that the layer was registered with.
 func (rl *roLayer) TarStream() (io.ReadCloser, error) {
+	if err := rl.waitBackfill(); err != nil {
+		return nil, err
+	}
 	rc, err := rl.layerStore.getTarStream(rl)
 	if err != nil {
 		return nil, err
@@ -51,6 +61,11 @@ func (rl *roLayer) TarStreamFrom(parent ChainID) (io.ReadCloser, error) {
 	if parent != ChainID("") && parentCacheID == "" {
 		return nil, fmt.Errorf("layer ID '%s' is not a parent of the specified layer: cannot provide diff to non-parent", parent)
 	}
+	for l := rl; l != nil && l.chainID != parent; l = l.parent {
+		if err := l.waitBackfill(); err != nil {
+			return nil, err
+		}
+	}
 	return rl.layerStore.driver.Diff(rl.cacheID, parentCacheID)
 }
 
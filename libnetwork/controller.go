@@ -163,6 +163,8 @@ type controller struct {
 	watchCh          chan *endpoint
 	unWatchCh        chan *endpoint
 	svcRecords       map[string]svcInfo
+	networkDNS       map[string]*networkDNS // parsed static DNS records and forwarding rules, by network ID
+	unreadyEndpoints map[string]struct{}    // endpoints of containers which are not ready; see Sandbox.SetReady
 	nmap             map[string]*netWatch
 	serviceBindings  map[serviceKey]*service
 	defOsSbox        osl.Sandbox
@@ -182,6 +184,18 @@ type initializer struct {
 	ntype string
 }
 
+// setEndpointReady sets whether the aliases of an endpoint are resolved by
+// the embedded DNS server.
+func (c *controller) setEndpointReady(eid string, ready bool) {
+	c.Lock()
+	defer c.Unlock()
+	if ready {
+		delete(c.unreadyEndpoints, eid)
+	} else {
+		c.unreadyEndpoints[eid] = struct{}{}
+	}
+}
+
 // New creates a new instance of network controller.
 func New(cfgOptions ...config.Option) (NetworkController, error) {
 	c := &controller{
@@ -189,12 +203,15 @@ func New(cfgOptions ...config.Option) (NetworkController, error) {
 		cfg:              config.New(cfgOptions...),
 		sandboxes:        sandboxTable{},
 		svcRecords:       make(map[string]svcInfo),
+		networkDNS:       make(map[string]*networkDNS),
+		unreadyEndpoints: make(map[string]struct{}),
 		serviceBindings:  make(map[serviceKey]*service),
 		agentInitDone:    make(chan struct{}),
 		networkLocker:    locker.New(),
 		DiagnosticServer: diagnostic.New(),
 	}
 	c.DiagnosticServer.Init()
+	c.DiagnosticServer.RegisterHandler(c, resolverPaths2Func)
 
 	if err := c.initStores(); err != nil {
 		return nil, err
@@ -812,7 +829,8 @@ addToStore:
 		}
 	}
 
-	if !c.isDistributedControl() {
+	// The ingress ports are accepted by a set of the nftables backend.
+	if !c.isDistributedControl() && !c.useNftables() {
 		c.Lock()
 		arrangeIngressFilterRule()
 		c.Unlock()
@@ -1279,19 +1297,12 @@ func (c *controller) IsDiagnosticEnabled() bool {
 }
 
 func (c *controller) iptablesEnabled() bool {
-	c.Lock()
-	defer c.Unlock()
-
-	if c.cfg == nil {
-		return false
-	}
-	// parse map cfg["bridge"]["generic"]["EnableIPTable"]
-	cfgBridge, ok := c.cfg.DriverCfg["bridge"].(map[string]interface{})
+	cfgGeneric, ok := c.bridgeConfig()
 	if !ok {
 		return false
 	}
-	cfgGeneric, ok := cfgBridge[netlabel.GenericData].(options.Generic)
-	if !ok {
+	if backend, _ := cfgGeneric["FirewallBackend"].(string); backend == "nftables" {
+		// the rules of the user are in a table of the nftables backend
 		return false
 	}
 	enabled, ok := cfgGeneric["EnableIPTables"].(bool)
@@ -1301,3 +1312,37 @@ func (c *controller) iptablesEnabled() bool {
 	}
 	return enabled
 }
+
+// firewallBackend returns the firewall backend selected for the bridge
+// driver, or an empty string for the default iptables backend.
+func (c *controller) firewallBackend() string {
+	cfgGeneric, ok := c.bridgeConfig()
+	if !ok {
+		return ""
+	}
+	backend, _ := cfgGeneric["FirewallBackend"].(string)
+	return backend
+}
+
+// useNftables returns whether the rules of the services are programmed with
+// nftables, as the ones of the bridge networks.
+func (c *controller) useNftables() bool {
+	return c.firewallBackend() == "nftables"
+}
+
+// bridgeConfig returns the generic options of the bridge driver.
+func (c *controller) bridgeConfig() (options.Generic, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.cfg == nil {
+		return nil, false
+	}
+	// parse map cfg["bridge"]["generic"]
+	cfgBridge, ok := c.cfg.DriverCfg["bridge"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	cfgGeneric, ok := cfgBridge[netlabel.GenericData].(options.Generic)
+	return cfgGeneric, ok
+}
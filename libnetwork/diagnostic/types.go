@@ -130,3 +130,24 @@ type NetworkStatsResult struct {
 func (n *NetworkStatsResult) String() string {
 	return fmt.Sprintf("entries: %d, qlen: %d\n", n.Entries, n.QueueLen)
 }
+
+// DNSCacheStatsObj counters of the cache of the embedded DNS server of a sandbox
+type DNSCacheStatsObj struct {
+	Index     int    `json:"-"`
+	Sandbox   string `json:"sandbox"`
+	Container string `json:"container"`
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Forwards  uint64 `json:"forwards"`
+}
+
+func (d *DNSCacheStatsObj) String() string {
+	return fmt.Sprintf("%d) sandbox:`%s` container:`%s` entries:%d hits:%d misses:%d forwards:%d\n", d.Index, d.Sandbox, d.Container, d.Entries, d.Hits, d.Misses, d.Forwards)
+}
+
+// DNSCacheStatsResult fully typed message for proper unmarshaling on the client side
+type DNSCacheStatsResult struct {
+	TableObj
+	Elements []DNSCacheStatsObj `json:"entries"`
+}
@@ -89,6 +89,27 @@ type Driver interface {
 	IsBuiltIn() bool
 }
 
+// NetworkUpdater is implemented by the drivers able to change the
+// configuration of an existing network.
+type NetworkUpdater interface {
+	// UpdateNetwork invokes the driver method to apply the updated network
+	// specific config and ip related information of a network, passed in
+	// the same form as to CreateNetwork. The driver must return an error,
+	// leaving the network unchanged, if it does not support one of the
+	// changes.
+	UpdateNetwork(nid string, options map[string]interface{}, ipV4Data, ipV6Data []IPAMData) error
+}
+
+// EndpointUpdater is implemented by the drivers able to change the
+// configuration of an existing endpoint.
+type EndpointUpdater interface {
+	// UpdateEndpoint invokes the driver method to apply the updated
+	// endpoint specific config, passed in the same form as to
+	// CreateEndpoint. The driver must return an error, leaving the
+	// endpoint unchanged, if it does not support one of the changes.
+	UpdateEndpoint(nid, eid string, options map[string]interface{}) error
+}
+
 // NetworkInfo provides a go interface for drivers to provide network
 // specific information to libnetwork.
 type NetworkInfo interface {
@@ -6,10 +6,12 @@ package bridge
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"sync"
 	"syscall"
@@ -37,6 +39,18 @@ const (
 	maxAllocatePortAttempts    = 10
 )
 
+// Gateway modes of the networks, for each address family.
+const (
+	// gatewayModeNAT masquerades the connections of the containers, and
+	// publishes their ports through DNAT. This is the default.
+	gatewayModeNAT = "nat"
+	// gatewayModeRouted routes the connections of the containers without
+	// NAT, and published ports are reachable at the address of the container.
+	gatewayModeRouted = "routed"
+	// gatewayModeIsolated isolates the network as an internal network.
+	gatewayModeIsolated = "isolated"
+)
+
 const (
 	// DefaultGatewayV4AuxKey represents the default-gateway configured by the user
 	DefaultGatewayV4AuxKey = "DefaultGatewayIPv4"
@@ -62,6 +76,7 @@ type configuration struct {
 	EnableIP6Tables     bool
 	EnableUserlandProxy bool
 	UserlandProxyPath   string
+	FirewallBackend     string
 }
 
 // networkConfiguration for network specific configuration
@@ -77,6 +92,9 @@ type networkConfiguration struct {
 	DefaultBridge        bool
 	HostIP               net.IP
 	ContainerIfacePrefix string
+	GatewayModeIPv4      string
+	GatewayModeIPv6      string
+	Policy               *types.NetworkPolicy
 	// Internal fields set after ipam data parsing
 	AddressIPv4        *net.IPNet
 	AddressIPv6        *net.IPNet
@@ -101,6 +119,8 @@ const (
 // endpointConfiguration represents the user specified configuration for the sandbox endpoint
 type endpointConfiguration struct {
 	MacAddress net.HardwareAddr
+	Bandwidth  *types.Bandwidth  `json:",omitempty"`
+	Labels     map[string]string `json:",omitempty"`
 }
 
 // containerConfiguration represents the user specified configuration for a container
@@ -119,6 +139,7 @@ type bridgeEndpoint struct {
 	id              string
 	nid             string
 	srcName         string
+	hostIfName      string
 	addr            *net.IPNet
 	addrv6          *net.IPNet
 	macAddress      net.HardwareAddr
@@ -139,6 +160,7 @@ type bridgeNetwork struct {
 	portMapperV6  *portmapper.PortMapper
 	driver        *driver // The network's driver
 	iptCleanFuncs iptablesCleanFuncs
+	policyMu      sync.Mutex // Serializes the updates of the policy rules
 	sync.Mutex
 }
 
@@ -152,6 +174,7 @@ type driver struct {
 	filterChainV6     *iptables.ChainInfo
 	isolationChain1V6 *iptables.ChainInfo
 	isolationChain2V6 *iptables.ChainInfo
+	firewall          firewaller
 	networks          map[string]*bridgeNetwork
 	store             datastore.DataStore
 	nlh               *netlink.Handle
@@ -270,12 +293,76 @@ func (c *networkConfiguration) fromLabels(labels map[string]string) error {
 			if c.HostIP = net.ParseIP(value); c.HostIP == nil {
 				return parseErr(label, value, "nil ip")
 			}
+		case GatewayModeIPv4:
+			if c.GatewayModeIPv4, err = parseGatewayMode(value); err != nil {
+				return parseErr(label, value, err.Error())
+			}
+		case GatewayModeIPv6:
+			if c.GatewayModeIPv6, err = parseGatewayMode(value); err != nil {
+				return parseErr(label, value, err.Error())
+			}
 		}
 	}
 
 	return nil
 }
 
+func parseGatewayMode(value string) (string, error) {
+	switch value {
+	case gatewayModeNAT, gatewayModeRouted, gatewayModeIsolated:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid gateway mode, expected %q, %q or %q", gatewayModeNAT, gatewayModeRouted, gatewayModeIsolated)
+	}
+}
+
+// gatewayMode returns the gateway mode of the network for the address family
+// of ip.
+func (c *networkConfiguration) gatewayMode(ip net.IP) string {
+	mode := c.GatewayModeIPv4
+	if ip.To4() == nil {
+		mode = c.GatewayModeIPv6
+	}
+	if mode == "" {
+		return gatewayModeNAT
+	}
+	return mode
+}
+
+// isolated returns whether the network is isolated for the address family of
+// ip, either as an internal network, or by its gateway mode.
+func (c *networkConfiguration) isolated(ip net.IP) bool {
+	return c.Internal || c.gatewayMode(ip) == gatewayModeIsolated
+}
+
+// checkUpdate returns an error if o changes settings of the network
+// configured by c which cannot be updated.
+func (c *networkConfiguration) checkUpdate(o *networkConfiguration) error {
+	for _, s := range []struct {
+		setting string
+		changed bool
+	}{
+		{BridgeName, c.BridgeName != o.BridgeName},
+		{netlabel.EnableIPv6, c.EnableIPv6 != o.EnableIPv6},
+		{InhibitIPv4, c.InhibitIPv4 != o.InhibitIPv4},
+		{DefaultBridge, c.DefaultBridge != o.DefaultBridge},
+		{DefaultBindingIP, !c.DefaultBindingIP.Equal(o.DefaultBindingIP)},
+		{netlabel.HostIP, !c.HostIP.Equal(o.HostIP)},
+		{netlabel.ContainerIfacePrefix, c.ContainerIfacePrefix != o.ContainerIfacePrefix},
+		{GatewayModeIPv4, c.GatewayModeIPv4 != o.GatewayModeIPv4},
+		{GatewayModeIPv6, c.GatewayModeIPv6 != o.GatewayModeIPv6},
+		{"IPv4 address", !types.CompareIPNet(c.AddressIPv4, o.AddressIPv4)},
+		{"IPv6 address", !types.CompareIPNet(c.AddressIPv6, o.AddressIPv6)},
+		{DefaultGatewayV4AuxKey, !c.DefaultGatewayIPv4.Equal(o.DefaultGatewayIPv4)},
+		{DefaultGatewayV6AuxKey, !c.DefaultGatewayIPv6.Equal(o.DefaultGatewayIPv6)},
+	} {
+		if s.changed {
+			return types.ForbiddenErrorf("%s cannot be updated on bridge network %s", s.setting, c.ID)
+		}
+	}
+	return nil
+}
+
 func parseErr(label, value, errString string) error {
 	return types.BadRequestErrorf("failed to parse %s value: %v (%s)", label, value, errString)
 }
@@ -299,6 +386,13 @@ func (n *bridgeNetwork) getDriverChains(version iptables.IPVersion) (*iptables.C
 	return n.driver.natChain, n.driver.filterChain, n.driver.isolationChain1, n.driver.isolationChain2, nil
 }
 
+func (n *bridgeNetwork) getConfig() *networkConfiguration {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.config
+}
+
 func (n *bridgeNetwork) getNetworkBridgeName() string {
 	n.Lock()
 	config := n.config
@@ -347,6 +441,178 @@ func (n *bridgeNetwork) isolateNetwork(enable bool) error {
 	return nil
 }
 
+// setupNetworkIsolation installs the iptables rules isolating the network
+// from each of the other networks.
+func (n *bridgeNetwork) setupNetworkIsolation(config *networkConfiguration, i *bridgeInterface) error {
+	if err := n.isolateNetwork(true); err != nil {
+		if err = n.isolateNetwork(false); err != nil {
+			logrus.Warnf("Failed on removing the inter-network iptables rules on cleanup: %v", err)
+		}
+		return err
+	}
+	// register the cleanup function
+	n.registerIptCleanFunc(func() error {
+		return n.isolateNetwork(false)
+	})
+	return nil
+}
+
+// queueFirewallSteps queues the steps installing the firewall rules of the
+// network for config, except for those queued by queueIsolationSteps.
+func (n *bridgeNetwork) queueFirewallSteps(bridgeSetup *bridgeSetup, config *networkConfiguration) {
+	d := n.driver
+	useIPTables := d.firewall == nil
+
+	for _, step := range []struct {
+		Condition bool
+		Fn        setupStep
+	}{
+		// Setup IPTables.
+		{useIPTables && d.config.EnableIPTables, n.setupIP4Tables},
+
+		// Setup IP6Tables.
+		{useIPTables && config.EnableIPv6 && d.config.EnableIP6Tables, n.setupIP6Tables},
+
+		// Setup the rules of another firewall backend, including the
+		// inter-network communication rules.
+		{!useIPTables && d.config.EnableIPTables, n.setupFirewall4},
+		{!useIPTables && config.EnableIPv6 && d.config.EnableIP6Tables, n.setupFirewall6},
+	} {
+		if step.Condition {
+			bridgeSetup.queueStep(step.Fn)
+		}
+	}
+}
+
+// queueIsolationSteps queues the steps installing the inter-network
+// communication rules and the policy of the network, and the filtering of
+// bridged traffic, which follow the other setup steps of the network.
+func (n *bridgeNetwork) queueIsolationSteps(bridgeSetup *bridgeSetup, config *networkConfiguration) {
+	d := n.driver
+	useIPTables := d.firewall == nil
+
+	for _, step := range []struct {
+		Condition bool
+		Fn        setupStep
+	}{
+		// Add inter-network communication rules.
+		{useIPTables && d.config.EnableIPTables, n.setupNetworkIsolation},
+
+		// Enforce the policy of the network between its containers.
+		{config.Policy != nil && (d.config.EnableIPTables || d.config.EnableIP6Tables), n.setupPolicy},
+
+		// Configure bridge networking filtering if ICC is off, or the
+		// network has a policy, and IP tables are enabled
+		{(!config.EnableICC || config.Policy != nil) && d.config.EnableIPTables, setupBridgeNetFiltering},
+	} {
+		if step.Condition {
+			bridgeSetup.queueStep(step.Fn)
+		}
+	}
+}
+
+// cleanFirewall removes the firewall rules installed for the network.
+func (n *bridgeNetwork) cleanFirewall() {
+	n.Lock()
+	cleanFuncs := n.iptCleanFuncs
+	n.iptCleanFuncs = nil
+	n.Unlock()
+
+	for _, cleanFunc := range cleanFuncs {
+		if err := cleanFunc(); err != nil {
+			logrus.Warnf("Failed to clean iptables rules for bridge network: %v", err)
+		}
+	}
+}
+
+// update applies the MTU, inter-container communication, IP masquerading,
+// internal and policy settings of config to the network, whose other settings
+// are unchanged.
+func (n *bridgeNetwork) update(config *networkConfiguration) error {
+	n.Lock()
+	oldConfig := n.config
+	n.Unlock()
+
+	if config.Mtu != oldConfig.Mtu {
+		if err := n.setMTU(config.Mtu); err != nil {
+			return err
+		}
+	}
+
+	if config.EnableICC == oldConfig.EnableICC &&
+		config.EnableIPMasquerade == oldConfig.EnableIPMasquerade &&
+		config.Internal == oldConfig.Internal &&
+		reflect.DeepEqual(config.Policy, oldConfig.Policy) {
+		n.Lock()
+		n.config = config
+		n.Unlock()
+		return nil
+	}
+
+	// Replace the firewall rules of the network, restoring the previous ones
+	// on failure.
+	n.cleanFirewall()
+	n.Lock()
+	n.config = config
+	n.Unlock()
+	bridgeSetup := newBridgeSetup(config, n.bridge)
+	n.queueFirewallSteps(bridgeSetup, config)
+	n.queueIsolationSteps(bridgeSetup, config)
+	if err := bridgeSetup.apply(); err != nil {
+		n.cleanFirewall()
+		n.Lock()
+		n.config = oldConfig
+		n.Unlock()
+		rollback := newBridgeSetup(oldConfig, n.bridge)
+		n.queueFirewallSteps(rollback, oldConfig)
+		n.queueIsolationSteps(rollback, oldConfig)
+		if err := rollback.apply(); err != nil {
+			logrus.WithError(err).Warnf("Failed to restore the firewall rules of bridge network %s", oldConfig.ID)
+		}
+		if config.Mtu != oldConfig.Mtu {
+			if err := n.setMTU(oldConfig.Mtu); err != nil {
+				logrus.WithError(err).Warnf("Failed to restore the MTU of bridge network %s", oldConfig.ID)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// setMTU sets the MTU of the host side of the veth pairs of the endpoints,
+// and so of the bridge. The container side is set by libnetwork.
+func (n *bridgeNetwork) setMTU(mtu int) error {
+	if mtu == 0 {
+		// Restore the default MTU of the veth devices.
+		mtu = 1500
+	}
+	nlh := n.driver.nlh
+
+	n.Lock()
+	endpoints := make([]*bridgeEndpoint, 0, len(n.endpoints))
+	for _, ep := range n.endpoints {
+		endpoints = append(endpoints, ep)
+	}
+	n.Unlock()
+
+	for _, ep := range endpoints {
+		if ep.hostIfName == "" {
+			// Endpoints restored from a store written before the host
+			// interface was recorded.
+			logrus.Warnf("Cannot set the MTU of the host interface of endpoint %.7s: unknown interface name", ep.id)
+			continue
+		}
+		link, err := nlh.LinkByName(ep.hostIfName)
+		if err != nil {
+			return types.InternalErrorf("failed to find host interface %s of endpoint %s: %v", ep.hostIfName, ep.id, err)
+		}
+		if err := nlh.LinkSetMTU(link, mtu); err != nil {
+			return types.InternalErrorf("failed to set MTU on host interface %s: %v", ep.hostIfName, err)
+		}
+	}
+	return nil
+}
+
 func (d *driver) configure(option map[string]interface{}) error {
 	var (
 		config            *configuration
@@ -387,7 +653,13 @@ func (d *driver) configure(option map[string]interface{}) error {
 		}
 	}
 
-	if config.EnableIPTables {
+	// The nftables backend replaces the iptables chains of the driver.
+	fw, err := newFirewaller(config)
+	if err != nil {
+		return err
+	}
+
+	if config.EnableIPTables && fw == nil {
 		removeIPChains(iptables.IPv4)
 
 		natChain, filterChain, isolationChain1, isolationChain2, err = setupIPChains(config, iptables.IPv4)
@@ -404,7 +676,7 @@ func (d *driver) configure(option map[string]interface{}) error {
 		})
 	}
 
-	if config.EnableIP6Tables {
+	if config.EnableIP6Tables && fw == nil {
 		removeIPChains(iptables.IPv6)
 
 		natChainV6, filterChainV6, isolationChain1V6, isolationChain2V6, err = setupIPChains(config, iptables.IPv6)
@@ -422,7 +694,7 @@ func (d *driver) configure(option map[string]interface{}) error {
 	}
 
 	if config.EnableIPForwarding {
-		err = setupIPForwarding(config.EnableIPTables, config.EnableIP6Tables)
+		err = setupIPForwarding(config.EnableIPTables && fw == nil, config.EnableIP6Tables && fw == nil)
 		if err != nil {
 			logrus.Warn(err)
 			return err
@@ -438,6 +710,7 @@ func (d *driver) configure(option map[string]interface{}) error {
 	d.filterChainV6 = filterChainV6
 	d.isolationChain1V6 = isolationChain1V6
 	d.isolationChain2V6 = isolationChain2V6
+	d.firewall = fw
 	d.config = config
 	d.Unlock()
 
@@ -547,6 +820,14 @@ func parseNetworkOptions(id string, option options.Generic) (*networkConfigurati
 		}
 	}
 
+	if val, ok := option[netlabel.Policy]; ok && val != nil {
+		p, ok := val.(*types.NetworkPolicy)
+		if !ok {
+			return nil, types.BadRequestErrorf("invalid policy of network %s", id)
+		}
+		config.Policy = p
+	}
+
 	// Finally validate the configuration
 	if err = config.Validate(); err != nil {
 		return nil, err
@@ -647,6 +928,51 @@ func (d *driver) CreateNetwork(id string, option map[string]interface{}, nInfo d
 	return d.storeUpdate(config)
 }
 
+// UpdateNetwork applies the updated MTU, inter-container communication, IP
+// masquerading, internal and policy settings of a network. The other settings
+// cannot be updated.
+func (d *driver) UpdateNetwork(id string, option map[string]interface{}, ipV4Data, ipV6Data []driverapi.IPAMData) error {
+	defer osl.InitOSContext()()
+
+	n, err := d.getNetwork(id)
+	if err != nil {
+		return err
+	}
+
+	config, err := parseNetworkOptions(id, option)
+	if err != nil {
+		return err
+	}
+	if err = config.processIPAM(id, ipV4Data, ipV6Data); err != nil {
+		return err
+	}
+
+	d.configNetwork.Lock()
+	defer d.configNetwork.Unlock()
+
+	n.Lock()
+	oldConfig := n.config
+	n.Unlock()
+
+	if err = oldConfig.checkUpdate(config); err != nil {
+		return err
+	}
+	config.BridgeIfaceCreator = oldConfig.BridgeIfaceCreator
+	config.dbIndex = oldConfig.dbIndex
+	config.dbExists = oldConfig.dbExists
+
+	if err = n.update(config); err != nil {
+		return err
+	}
+	if err = d.storeUpdate(config); err != nil {
+		if err := n.update(oldConfig); err != nil {
+			logrus.WithError(err).Warnf("Failed to restore the configuration of bridge network %s", id)
+		}
+		return err
+	}
+	return nil
+}
+
 func (d *driver) checkConflict(config *networkConfiguration) error {
 	networkList := d.getNetworks()
 	for _, nw := range networkList {
@@ -710,21 +1036,6 @@ func (d *driver) createNetwork(config *networkConfiguration) (err error) {
 		}
 	}()
 
-	// Add inter-network communication rules.
-	setupNetworkIsolationRules := func(config *networkConfiguration, i *bridgeInterface) error {
-		if err := network.isolateNetwork(true); err != nil {
-			if err = network.isolateNetwork(false); err != nil {
-				logrus.Warnf("Failed on removing the inter-network iptables rules on cleanup: %v", err)
-			}
-			return err
-		}
-		// register the cleanup function
-		network.registerIptCleanFunc(func() error {
-			return network.isolateNetwork(false)
-		})
-		return nil
-	}
-
 	// Prepare the bridge setup configuration
 	bridgeSetup := newBridgeSetup(config, bridgeIface)
 
@@ -745,6 +1056,7 @@ func (d *driver) createNetwork(config *networkConfiguration) (err error) {
 	bridgeSetup.queueStep(setupBridgeIPv4)
 
 	enableIPv6Forwarding := d.config.EnableIPForwarding && config.AddressIPv6 != nil
+	useIPTables := d.firewall == nil
 
 	// Conditionally queue setup steps depending on configuration values.
 	for _, step := range []struct {
@@ -766,36 +1078,42 @@ func (d *driver) createNetwork(config *networkConfiguration) (err error) {
 
 		// Setup Loopback Addresses Routing
 		{!d.config.EnableUserlandProxy, setupLoopbackAddressesRouting},
+	} {
+		if step.Condition {
+			bridgeSetup.queueStep(step.Fn)
+		}
+	}
 
-		// Setup IPTables.
-		{d.config.EnableIPTables, network.setupIP4Tables},
-
-		// Setup IP6Tables.
-		{config.EnableIPv6 && d.config.EnableIP6Tables, network.setupIP6Tables},
+	// Setup the firewall rules of the network.
+	network.queueFirewallSteps(bridgeSetup, config)
 
+	for _, step := range []struct {
+		Condition bool
+		Fn        setupStep
+	}{
 		// We want to track firewalld configuration so that
 		// if it is started/reloaded, the rules can be applied correctly
-		{d.config.EnableIPTables, network.setupFirewalld},
+		{useIPTables && d.config.EnableIPTables, network.setupFirewalld},
 		// same for IPv6
-		{config.EnableIPv6 && d.config.EnableIP6Tables, network.setupFirewalld6},
+		{useIPTables && config.EnableIPv6 && d.config.EnableIP6Tables, network.setupFirewalld6},
+		// The other firewall backends only need the bridge in the zone
+		{!useIPTables, network.setupFirewalldZone},
 
 		// Setup DefaultGatewayIPv4
 		{config.DefaultGatewayIPv4 != nil, setupGatewayIPv4},
 
 		// Setup DefaultGatewayIPv6
 		{config.DefaultGatewayIPv6 != nil, setupGatewayIPv6},
-
-		// Add inter-network communication rules.
-		{d.config.EnableIPTables, setupNetworkIsolationRules},
-
-		// Configure bridge networking filtering if ICC is off and IP tables are enabled
-		{!config.EnableICC && d.config.EnableIPTables, setupBridgeNetFiltering},
 	} {
 		if step.Condition {
 			bridgeSetup.queueStep(step.Fn)
 		}
 	}
 
+	// Add the inter-network communication rules and the policy of the
+	// network.
+	network.queueIsolationSteps(bridgeSetup, config)
+
 	// Apply the prepared list of steps, and abort at the first error.
 	bridgeSetup.queueStep(setupDeviceUp)
 	return bridgeSetup.apply()
@@ -871,11 +1189,7 @@ func (d *driver) deleteNetwork(nid string) error {
 	}
 
 	// clean all relevant iptables rules
-	for _, cleanFunc := range n.iptCleanFuncs {
-		if errClean := cleanFunc(); errClean != nil {
-			logrus.Warnf("Failed to clean iptables rules for bridge network: %v", errClean)
-		}
-	}
+	n.cleanFirewall()
 	return d.storeDelete(config)
 }
 
@@ -1067,8 +1381,15 @@ func (d *driver) CreateEndpoint(nid, eid string, ifInfo driverapi.InterfaceInfo,
 		}
 	}
 
+	if epConfig != nil && epConfig.Bandwidth != nil {
+		if err = osl.SetBandwidth(hostIfName, epConfig.Bandwidth); err != nil {
+			return types.InternalErrorf("failed to set the bandwidth limits of endpoint %.7s: %v", eid, err)
+		}
+	}
+
 	// Store the sandbox side pipe interface parameters
 	endpoint.srcName = containerIfName
+	endpoint.hostIfName = hostIfName
 	endpoint.macAddress = ifInfo.MacAddress()
 	endpoint.addr = ifInfo.Address()
 	endpoint.addrv6 = ifInfo.AddressIPv6()
@@ -1111,6 +1432,10 @@ func (d *driver) CreateEndpoint(nid, eid string, ifInfo driverapi.InterfaceInfo,
 		}
 	}
 
+	if err = n.updatePolicy(); err != nil {
+		return err
+	}
+
 	if err = d.storeUpdate(endpoint); err != nil {
 		return fmt.Errorf("failed to save bridge endpoint %.7s to store: %v", endpoint.id, err)
 	}
@@ -1118,6 +1443,63 @@ func (d *driver) CreateEndpoint(nid, eid string, ifInfo driverapi.InterfaceInfo,
 	return nil
 }
 
+// UpdateEndpoint applies the updated bandwidth limits of an endpoint, the
+// only endpoint setting which can be updated.
+func (d *driver) UpdateEndpoint(nid, eid string, epOptions map[string]interface{}) error {
+	defer osl.InitOSContext()()
+
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	ep, err := n.getEndpoint(eid)
+	if err != nil {
+		return err
+	}
+	if ep == nil {
+		return EndpointNotFoundError(eid)
+	}
+
+	bw, err := parseBandwidthOption(epOptions)
+	if err != nil {
+		return err
+	}
+
+	n.Lock()
+	oldConfig := ep.config
+	hostIfName := ep.hostIfName
+	n.Unlock()
+	epConfig := &endpointConfiguration{}
+	if oldConfig != nil {
+		*epConfig = *oldConfig
+	} else {
+		oldConfig = &endpointConfiguration{}
+	}
+	epConfig.Bandwidth = bw
+	if hostIfName == "" {
+		// Endpoints restored from a store written before the host
+		// interface was recorded.
+		return types.ForbiddenErrorf("the bandwidth limits of endpoint %.7s cannot be updated, reconnect it to the network", eid)
+	}
+
+	if err := osl.SetBandwidth(hostIfName, epConfig.Bandwidth); err != nil {
+		return types.InternalErrorf("failed to set the bandwidth limits of endpoint %.7s: %v", eid, err)
+	}
+	n.Lock()
+	ep.config = epConfig
+	n.Unlock()
+	if err := d.storeUpdate(ep); err != nil {
+		if err := osl.SetBandwidth(hostIfName, oldConfig.Bandwidth); err != nil {
+			logrus.WithError(err).Warnf("Failed to restore the bandwidth limits of endpoint %.7s", eid)
+		}
+		n.Lock()
+		ep.config = oldConfig
+		n.Unlock()
+		return fmt.Errorf("failed to save bridge endpoint %.7s to store: %v", eid, err)
+	}
+	return nil
+}
+
 func (d *driver) DeleteEndpoint(nid, eid string) error {
 	var err error
 
@@ -1177,6 +1559,10 @@ func (d *driver) DeleteEndpoint(nid, eid string) error {
 		}
 	}
 
+	if err := n.updatePolicy(); err != nil {
+		logrus.WithError(err).Warnf("Failed to update the policy rules of bridge network %.7s on deletion of endpoint %.7s", nid, eid)
+	}
+
 	if err := d.storeDelete(ep); err != nil {
 		logrus.Warnf("Failed to remove bridge endpoint %.7s from store: %v", ep.id, err)
 	}
@@ -1431,7 +1817,7 @@ func (d *driver) link(network *bridgeNetwork, endpoint *bridgeEndpoint, enable b
 
 			l := newLink(parentEndpoint.addr.IP.String(),
 				endpoint.addr.IP.String(),
-				ec.ExposedPorts, network.config.BridgeName)
+				ec.ExposedPorts, network.config.BridgeName, d.firewall)
 			if enable {
 				err = l.Enable()
 				if err != nil {
@@ -1464,7 +1850,7 @@ func (d *driver) link(network *bridgeNetwork, endpoint *bridgeEndpoint, enable b
 
 		l := newLink(endpoint.addr.IP.String(),
 			childEndpoint.addr.IP.String(),
-			childEndpoint.extConnConfig.ExposedPorts, network.config.BridgeName)
+			childEndpoint.extConnConfig.ExposedPorts, network.config.BridgeName, d.firewall)
 		if enable {
 			err = l.Enable()
 			if err != nil {
@@ -1516,9 +1902,40 @@ func parseEndpointOptions(epOptions map[string]interface{}) (*endpointConfigurat
 		}
 	}
 
+	bw, err := parseBandwidthOption(epOptions)
+	if err != nil {
+		return nil, err
+	}
+	ec.Bandwidth = bw
+
+	if opt, ok := epOptions[netlabel.EndpointLabels]; ok {
+		if labels, ok := opt.(map[string]string); ok {
+			ec.Labels = labels
+		} else {
+			return nil, &ErrInvalidEndpointConfig{}
+		}
+	}
+
 	return ec, nil
 }
 
+func parseBandwidthOption(epOptions map[string]interface{}) (*types.Bandwidth, error) {
+	opt, ok := epOptions[netlabel.Bandwidth]
+	if !ok {
+		return nil, nil
+	}
+	bw, ok := opt.(*types.Bandwidth)
+	if !ok {
+		return nil, &ErrInvalidEndpointConfig{}
+	}
+	// The traffic sent by the container is policed with a 32-bit rate and
+	// burst, and the traffic it receives is shaped with a 32-bit burst.
+	if bw.EgressRate > math.MaxUint32 || bw.EgressBurst > math.MaxUint32 || bw.IngressBurst > math.MaxUint32 {
+		return nil, types.BadRequestErrorf("the egress rate and the bursts of the bandwidth limits cannot exceed %d bytes (per second)", uint32(math.MaxUint32))
+	}
+	return bw, nil
+}
+
 func parseContainerOptions(cOptions map[string]interface{}) (*containerConfiguration, error) {
 	if cOptions == nil {
 		return nil, nil
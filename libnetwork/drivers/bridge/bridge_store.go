@@ -97,6 +97,14 @@ func (d *driver) populateEndpoints() error {
 		logrus.Debugf("Endpoint (%.7s) restored to network (%.7s)", ep.id, ep.nid)
 	}
 
+	// The policy rules were installed with the networks, before their
+	// endpoints were restored.
+	for _, n := range d.networks {
+		if err := n.updatePolicy(); err != nil {
+			logrus.WithError(err).Warnf("Failed to restore the policy rules of bridge network %.7s", n.id)
+		}
+	}
+
 	return nil
 }
 
@@ -150,6 +158,11 @@ func (ncfg *networkConfiguration) MarshalJSON() ([]byte, error) {
 	nMap["DefaultGatewayIPv6"] = ncfg.DefaultGatewayIPv6.String()
 	nMap["ContainerIfacePrefix"] = ncfg.ContainerIfacePrefix
 	nMap["BridgeIfaceCreator"] = ncfg.BridgeIfaceCreator
+	nMap["GatewayModeIPv4"] = ncfg.GatewayModeIPv4
+	nMap["GatewayModeIPv6"] = ncfg.GatewayModeIPv6
+	if ncfg.Policy != nil {
+		nMap["Policy"] = ncfg.Policy
+	}
 
 	if ncfg.AddressIPv4 != nil {
 		nMap["AddressIPv4"] = ncfg.AddressIPv4.String()
@@ -214,6 +227,25 @@ func (ncfg *networkConfiguration) UnmarshalJSON(b []byte) error {
 		ncfg.BridgeIfaceCreator = ifaceCreator(v.(float64))
 	}
 
+	if v, ok := nMap["GatewayModeIPv4"]; ok {
+		ncfg.GatewayModeIPv4 = v.(string)
+	}
+
+	if v, ok := nMap["GatewayModeIPv6"]; ok {
+		ncfg.GatewayModeIPv6 = v.(string)
+	}
+
+	if v, ok := nMap["Policy"]; ok {
+		ba, err := json.Marshal(v)
+		if err != nil {
+			return types.InternalErrorf("failed to decode bridge network policy after json unmarshal: %v", err)
+		}
+		ncfg.Policy = &types.NetworkPolicy{}
+		if err := json.Unmarshal(ba, ncfg.Policy); err != nil {
+			return types.InternalErrorf("failed to decode bridge network policy after json unmarshal: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -273,6 +305,9 @@ func (ep *bridgeEndpoint) MarshalJSON() ([]byte, error) {
 	epMap["id"] = ep.id
 	epMap["nid"] = ep.nid
 	epMap["SrcName"] = ep.srcName
+	if ep.hostIfName != "" {
+		epMap["HostIfName"] = ep.hostIfName
+	}
 	epMap["MacAddress"] = ep.macAddress.String()
 	epMap["Addr"] = ep.addr.String()
 	if ep.addrv6 != nil {
@@ -314,6 +349,9 @@ func (ep *bridgeEndpoint) UnmarshalJSON(b []byte) error {
 	ep.id = epMap["id"].(string)
 	ep.nid = epMap["nid"].(string)
 	ep.srcName = epMap["SrcName"].(string)
+	if v, ok := epMap["HostIfName"]; ok {
+		ep.hostIfName = v.(string)
+	}
 	d, _ := json.Marshal(epMap["Config"])
 	if err := json.Unmarshal(d, &ep.config); err != nil {
 		logrus.Warnf("Failed to decode endpoint config %v", err)
@@ -33,7 +33,8 @@ func TestEndpointMarshalling(t *testing.T) {
 		addrv6:     ip2,
 		macAddress: mac,
 		srcName:    "veth123456",
-		config:     &endpointConfiguration{MacAddress: mac},
+		hostIfName: "veth654321",
+		config:     &endpointConfiguration{MacAddress: mac, Bandwidth: &types.Bandwidth{IngressRate: 1 << 20, Priority: 3}},
 		containerConfig: &containerConfiguration{
 			ParentEndpoints: []string{"one", "due", "three"},
 			ChildEndpoints:  []string{"four", "five", "six"},
@@ -86,7 +87,7 @@ func TestEndpointMarshalling(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if e.id != ee.id || e.nid != ee.nid || e.srcName != ee.srcName || !bytes.Equal(e.macAddress, ee.macAddress) ||
+	if e.id != ee.id || e.nid != ee.nid || e.srcName != ee.srcName || e.hostIfName != ee.hostIfName || !bytes.Equal(e.macAddress, ee.macAddress) ||
 		!types.CompareIPNet(e.addr, ee.addr) || !types.CompareIPNet(e.addrv6, ee.addrv6) ||
 		!compareEpConfig(e.config, ee.config) ||
 		!compareContainerConfig(e.containerConfig, ee.containerConfig) ||
@@ -103,6 +104,9 @@ func compareEpConfig(a, b *endpointConfiguration) bool {
 	if a == nil || b == nil {
 		return false
 	}
+	if (a.Bandwidth == nil) != (b.Bandwidth == nil) || (a.Bandwidth != nil && *a.Bandwidth != *b.Bandwidth) {
+		return false
+	}
 	return bytes.Equal(a.MacAddress, b.MacAddress)
 }
 
@@ -1134,3 +1138,78 @@ func TestCreateParallel(t *testing.T) {
 		t.Fatalf("Success should be 1 instead: %d", success)
 	}
 }
+
+func TestGatewayModeLabels(t *testing.T) {
+	c := networkConfiguration{}
+	err := c.fromLabels(map[string]string{
+		GatewayModeIPv4: "nat",
+		GatewayModeIPv6: "routed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := c.gatewayMode(net.ParseIP("172.17.0.2")); mode != gatewayModeNAT {
+		t.Fatalf("expected IPv4 gateway mode nat, got %s", mode)
+	}
+	if mode := c.gatewayMode(net.ParseIP("2001:db8::2")); mode != gatewayModeRouted {
+		t.Fatalf("expected IPv6 gateway mode routed, got %s", mode)
+	}
+
+	c = networkConfiguration{}
+	if mode := c.gatewayMode(net.ParseIP("2001:db8::2")); mode != gatewayModeNAT {
+		t.Fatalf("expected default gateway mode nat, got %s", mode)
+	}
+
+	err = c.fromLabels(map[string]string{GatewayModeIPv4: "bridged"})
+	if _, ok := err.(types.BadRequestError); !ok {
+		t.Fatalf("expected a bad request error for an invalid gateway mode, got %v", err)
+	}
+}
+
+func TestCheckUpdate(t *testing.T) {
+	c := &networkConfiguration{ID: "dummy", BridgeName: "br-dummy", EnableICC: true, Mtu: 1500}
+
+	o := *c
+	o.EnableICC = false
+	o.EnableIPMasquerade = true
+	o.Mtu = 1400
+	o.Internal = true
+	if err := c.checkUpdate(&o); err != nil {
+		t.Fatalf("unexpected error updating the ICC, masquerade, MTU and internal settings: %v", err)
+	}
+
+	o = *c
+	o.BridgeName = "br-other"
+	if _, ok := c.checkUpdate(&o).(types.ForbiddenError); !ok {
+		t.Fatal("expected a forbidden error updating the bridge name")
+	}
+
+	o = *c
+	o.GatewayModeIPv4 = gatewayModeRouted
+	if _, ok := c.checkUpdate(&o).(types.ForbiddenError); !ok {
+		t.Fatal("expected a forbidden error updating the IPv4 gateway mode")
+	}
+}
+
+func TestParseBandwidthOption(t *testing.T) {
+	bw, err := parseBandwidthOption(map[string]interface{}{})
+	if err != nil || bw != nil {
+		t.Fatalf("expected no bandwidth limits, got %v, %v", bw, err)
+	}
+
+	exp := &types.Bandwidth{IngressRate: 1 << 40, EgressRate: 1 << 20, Priority: 1}
+	bw, err = parseBandwidthOption(map[string]interface{}{netlabel.Bandwidth: exp})
+	if err != nil || bw != exp {
+		t.Fatalf("expected bandwidth limits %v, got %v, %v", exp, bw, err)
+	}
+
+	_, err = parseBandwidthOption(map[string]interface{}{netlabel.Bandwidth: &types.Bandwidth{EgressRate: 1 << 40}})
+	if _, ok := err.(types.BadRequestError); !ok {
+		t.Fatalf("expected a bad request error for an egress rate above 32 bits, got %v", err)
+	}
+
+	_, err = parseBandwidthOption(map[string]interface{}{netlabel.Bandwidth: "1mbit"})
+	if _, ok := err.(*ErrInvalidEndpointConfig); !ok {
+		t.Fatalf("expected an invalid endpoint configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build linux
+// +build linux
+
+package bridge
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/portmapper"
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Firewall backends of the bridge driver.
+const (
+	firewallBackendIPTables = "iptables"
+	firewallBackendNFTables = "nftables"
+)
+
+// firewaller is a firewall backend, programming the packet filtering and NAT
+// rules of the bridge networks. The default iptables backend is implemented by
+// the driver itself, see setup_ip_tables.go.
+type firewaller interface {
+	// addNetwork installs the rules of a network, for the address family
+	// of its subnet addr. This includes the isolation from other networks.
+	addNetwork(config *networkConfiguration, addr *net.IPNet) error
+	// delNetwork removes the rules installed by addNetwork.
+	delNetwork(config *networkConfiguration, addr *net.IPNet) error
+	// forwarder returns the Forwarder programming the published ports.
+	forwarder(ipv6 bool) portmapper.Forwarder
+	// routePort allows, or disallows, the connections from outside the
+	// network to a port of a container of a network in routed mode.
+	routePort(enable bool, containerIP net.IP, proto string, port int, bridge string) error
+	// link allows, or disallows, the connections between the containers of
+	// a legacy link.
+	link(enable bool, parentIP, childIP string, ports []types.TransportPort, bridge string) error
+	// setPolicy replaces the rules enforcing the policy of a network between
+	// its containers for an address family. They are removed by delNetwork.
+	setPolicy(config *networkConfiguration, ipv6 bool, filters []types.PolicyFilter) error
+}
+
+func newFirewaller(config *configuration) (firewaller, error) {
+	switch config.FirewallBackend {
+	case "", firewallBackendIPTables:
+		return nil, nil
+	case firewallBackendNFTables:
+		if !config.EnableIPTables && !config.EnableIP6Tables {
+			return nil, nil
+		}
+		fw, err := newNftFirewall(config)
+		if err != nil {
+			return nil, err
+		}
+		if iptables.UsingFirewalld() {
+			if err := iptables.SetupDockerForwardingPolicy(); err != nil {
+				logrus.WithError(err).Warn("Failed to setup the firewalld policy forwarding connections to the docker zone")
+			}
+		}
+		return fw, nil
+	default:
+		return nil, fmt.Errorf("invalid firewall backend %q", config.FirewallBackend)
+	}
+}
+
+func (n *bridgeNetwork) setupFirewall4(config *networkConfiguration, i *bridgeInterface) error {
+	return n.setupFirewall(config, i.bridgeIPv4, n.portMapper, false)
+}
+
+func (n *bridgeNetwork) setupFirewall6(config *networkConfiguration, i *bridgeInterface) error {
+	return n.setupFirewall(config, i.bridgeIPv6, n.portMapperV6, true)
+}
+
+func (n *bridgeNetwork) setupFirewall(config *networkConfiguration, bridgeIP *net.IPNet, pm *portmapper.PortMapper, ipv6 bool) error {
+	d := n.driver
+	d.Lock()
+	fw := d.firewall
+	d.Unlock()
+
+	maskedAddr := &net.IPNet{
+		IP:   bridgeIP.IP.Mask(bridgeIP.Mask),
+		Mask: bridgeIP.Mask,
+	}
+	if err := fw.addNetwork(config, maskedAddr); err != nil {
+		return fmt.Errorf("failed to setup the firewall rules: %v", err)
+	}
+	n.registerIptCleanFunc(func() error {
+		return fw.delNetwork(config, maskedAddr)
+	})
+	if !config.isolated(maskedAddr.IP) {
+		pm.SetForwarder(fw.forwarder(ipv6), config.BridgeName)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+//go:build linux
+// +build linux
+
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/nftables"
+	"github.com/docker/docker/libnetwork/portmapper"
+	"github.com/docker/docker/libnetwork/types"
+)
+
+const (
+	// nftTable is the table holding the rules of the nftables backend. It is
+	// owned by the driver, and replaced as a whole when the driver starts.
+	nftTable = nftables.DockerTable
+	// nftUserTable is the table for the rules of the user, which are applied
+	// before the rules of the driver. Like the DOCKER-USER iptables chain, it
+	// is created if missing, and never modified afterwards.
+	nftUserTable = "docker-user"
+)
+
+// nftFirewall is the nftables firewall backend. The rules of each network are
+// in chains of their own, which are selected by verdict maps keyed by bridge
+// interface, and published ports are elements of maps and sets, so that
+// neither networks nor ports require rules to be searched for and deleted.
+// Every change is applied as a single atomic nft transaction.
+type nftFirewall struct {
+	hairpin bool
+	apply   func(*nftables.Batch) error
+
+	mu sync.Mutex
+	// refs counts the references to the elements of sets, as several port
+	// mappings or links may require the same element.
+	refs map[string]int
+}
+
+// nftElement is an element of a set, or of a map if it has a value.
+type nftElement struct {
+	set   string
+	key   string
+	value string
+}
+
+func newNftFirewall(config *configuration) (*nftFirewall, error) {
+	fw := &nftFirewall{
+		hairpin: !config.EnableUserlandProxy,
+		apply:   (*nftables.Batch).Apply,
+		refs:    make(map[string]int),
+	}
+	var families []nftables.Family
+	if config.EnableIPTables {
+		families = append(families, nftables.IPv4)
+	}
+	if config.EnableIP6Tables {
+		families = append(families, nftables.IPv6)
+	}
+	if err := fw.init(families...); err != nil {
+		return nil, fmt.Errorf("failed to setup the nftables ruleset: %v", err)
+	}
+	return fw, nil
+}
+
+func nftFamily(ip net.IP) nftables.Family {
+	if ip.To4() == nil {
+		return nftables.IPv6
+	}
+	return nftables.IPv4
+}
+
+func nftAddrType(f nftables.Family) string {
+	if f == nftables.IPv6 {
+		return "ipv6_addr"
+	}
+	return "ipv4_addr"
+}
+
+// init creates the tables of the given address families.
+func (fw *nftFirewall) init(families ...nftables.Family) error {
+	b := &nftables.Batch{}
+	for _, f := range families {
+		t := fmt.Sprintf("%s %s", f, nftTable)
+		at := nftAddrType(f)
+
+		// Adding the table first, so that deleting it cannot fail.
+		b.Add("add table %s", t)
+		b.Add("delete table %s", t)
+		b.Add("add table %s", t)
+
+		// The bridges of the networks which are not internal.
+		b.Add("add set %s bridges { type ifname; }", t)
+		// The bridges from which published ports are not translated, as
+		// the userland proxy handles them.
+		b.Add("add set %s no-dnat { type ifname; }", t)
+		// The published ports: host address . protocol . host port mapped to
+		// container address . container port, with a separate map for the
+		// ports published on all the addresses of the host.
+		b.Add("add map %s published { type %s . inet_proto . inet_service : %s . inet_service; }", t, at, at)
+		b.Add("add map %s published-any { type inet_proto . inet_service : %s . inet_service; }", t, at)
+		b.Add("add set %s published-dests { type %s . inet_proto . inet_service; }", t, at)
+		b.Add("add set %s hairpin { type %s . %s . inet_proto . inet_service; }", t, at, at)
+		// The legacy links: parent address . child address . protocol . port.
+		b.Add("add set %s links { type %s . %s . inet_proto . inet_service; }", t, at, at)
+		// The chains of the networks, by bridge and by subnet.
+		b.Add("add map %s forward-in { type ifname : verdict; }", t)
+		b.Add("add map %s forward-out { type ifname : verdict; }", t)
+		b.Add("add map %s snat { type %s : verdict; flags interval; }", t, at)
+
+		b.Add("add chain %s forward { type filter hook forward priority 0; policy accept; }", t)
+		if f == nftables.IPv4 {
+			// The ports published by the ingress network of swarm mode are
+			// accepted before the isolation of the networks, as the
+			// DOCKER-INGRESS chain is with iptables.
+			b.Add("add set %s %s { type inet_proto . inet_service; }", t, nftables.IngressPorts)
+			b.Add("add rule %s forward meta l4proto . th dport @%s accept", t, nftables.IngressPorts)
+			b.Add("add rule %s forward ct state established,related meta l4proto . th sport @%s accept", t, nftables.IngressPorts)
+		}
+		b.Add("add rule %s forward oifname vmap @forward-out", t)
+		b.Add("add rule %s forward iifname vmap @forward-in", t)
+
+		b.Add("add chain %s dnat", t)
+		b.Add("add rule %s dnat iifname @no-dnat return", t)
+		b.Add("add rule %s dnat dnat %s addr . port to %s daddr . meta l4proto . th dport map @published", t, f, f)
+		b.Add("add rule %s dnat dnat %s addr . port to meta l4proto . th dport map @published-any", t, f)
+		b.Add("add chain %s prerouting { type nat hook prerouting priority -100; policy accept; }", t)
+		b.Add("add rule %s prerouting fib daddr type local jump dnat", t)
+		b.Add("add chain %s output { type nat hook output priority -100; policy accept; }", t)
+		if fw.hairpin && f == nftables.IPv4 {
+			b.Add("add rule %s output fib daddr type local jump dnat", t)
+		} else {
+			// There is no equivalent of route_localnet for IPv6: connections
+			// to the loopback address are left to the listener of the port
+			// mapper.
+			b.Add("add rule %s output %s daddr != %s fib daddr type local jump dnat", t, f, nftLoopback(f))
+		}
+
+		b.Add("add chain %s postrouting { type nat hook postrouting priority 100; policy accept; }", t)
+		b.Add("add rule %s postrouting %s saddr . %s daddr . meta l4proto . th dport @hairpin masquerade", t, f, f)
+		if fw.hairpin && f == nftables.IPv4 {
+			// Connections from the loopback interface to published ports.
+			b.Add("add rule %s postrouting %s saddr %s oifname @bridges masquerade", t, f, nftLoopback(f))
+		}
+		b.Add("add rule %s postrouting %s saddr vmap @snat", t, f)
+
+		b.Add("add table %s %s", f, nftUserTable)
+		b.Add("add chain %s %s forward { type filter hook forward priority -1; policy accept; }", f, nftUserTable)
+	}
+	return fw.apply(b)
+}
+
+func nftLoopback(f nftables.Family) string {
+	if f == nftables.IPv6 {
+		return "::1"
+	}
+	return "127.0.0.0/8"
+}
+
+func (fw *nftFirewall) addNetwork(config *networkConfiguration, addr *net.IPNet) error {
+	var (
+		f    = nftFamily(addr.IP)
+		t    = fmt.Sprintf("%s %s", f, nftTable)
+		br   = nftables.Quote(config.BridgeName)
+		in   = nftables.Quote("in-" + config.BridgeName)
+		out  = nftables.Quote("out-" + config.BridgeName)
+		snat = nftables.Quote("snat-" + config.BridgeName)
+		icc  = "drop"
+		b    = &nftables.Batch{}
+	)
+	if config.EnableICC {
+		icc = "accept"
+	}
+	if config.Policy != nil {
+		// The connections between the containers are decided by the
+		// policy chain, whose rules are replaced by setPolicy, instead
+		// of the inter-container communication setting.
+		policy := nftables.Quote("policy-" + config.BridgeName)
+		b.Add("add chain %s %s", t, policy)
+		icc = fmt.Sprintf("jump %s", policy)
+	}
+	// Connections are only translated in NAT mode.
+	masquerade := config.EnableIPMasquerade && config.gatewayMode(addr.IP) == gatewayModeNAT
+
+	for _, chain := range []string{in, out} {
+		b.Add("add chain %s %s", t, chain)
+		b.Add("flush chain %s %s", t, chain)
+	}
+	if config.isolated(addr.IP) {
+		b.Add("add rule %s %s %s saddr != %s drop", t, out, f, addr)
+		b.Add("add rule %s %s iifname %s %s", t, out, br, icc)
+		b.Add("add rule %s %s drop", t, out)
+		b.Add("add rule %s %s drop", t, in)
+	} else {
+		// Isolation from the other networks.
+		b.Add("add rule %s %s iifname != %s iifname @bridges drop", t, out, br)
+		b.Add("add rule %s %s ct state established,related accept", t, out)
+		b.Add("add rule %s %s iifname != %s %s daddr . meta l4proto . th dport @published-dests accept", t, out, br, f)
+		b.Add("add rule %s %s iifname %s %s saddr . %s daddr . meta l4proto . th dport @links accept", t, out, br, f, f)
+		b.Add("add rule %s %s iifname %s %s daddr . %s saddr . meta l4proto . th sport @links accept", t, out, br, f, f)
+		b.Add("add rule %s %s iifname %s %s", t, out, br, icc)
+		b.Add("add rule %s %s drop", t, out)
+		b.Add("add rule %s %s accept", t, in)
+		b.Add("add element %s bridges { %s }", t, br)
+
+		if masquerade {
+			if !fw.hairpin {
+				b.Add("add element %s no-dnat { %s }", t, br)
+			}
+			b.Add("add chain %s %s", t, snat)
+			b.Add("flush chain %s %s", t, snat)
+			if config.HostIP != nil && nftFamily(config.HostIP) == f {
+				b.Add("add rule %s %s oifname != %s snat to %s", t, snat, br, config.HostIP)
+			} else {
+				b.Add("add rule %s %s oifname != %s masquerade", t, snat, br)
+			}
+			b.Add("add element %s snat { %s : jump %s }", t, addr, snat)
+		}
+	}
+	b.Add("add element %s forward-out { %s : jump %s }", t, br, out)
+	b.Add("add element %s forward-in { %s : jump %s }", t, br, in)
+	return fw.apply(b)
+}
+
+func (fw *nftFirewall) delNetwork(config *networkConfiguration, addr *net.IPNet) error {
+	var (
+		f  = nftFamily(addr.IP)
+		t  = fmt.Sprintf("%s %s", f, nftTable)
+		br = nftables.Quote(config.BridgeName)
+		b  = &nftables.Batch{}
+	)
+	b.Add("delete element %s forward-out { %s }", t, br)
+	b.Add("delete element %s forward-in { %s }", t, br)
+	b.Add("delete chain %s %s", t, nftables.Quote("out-"+config.BridgeName))
+	b.Add("delete chain %s %s", t, nftables.Quote("in-"+config.BridgeName))
+	if config.Policy != nil {
+		b.Add("delete chain %s %s", t, nftables.Quote("policy-"+config.BridgeName))
+	}
+	if !config.isolated(addr.IP) {
+		b.Add("delete element %s bridges { %s }", t, br)
+		if config.EnableIPMasquerade && config.gatewayMode(addr.IP) == gatewayModeNAT {
+			if !fw.hairpin {
+				b.Add("delete element %s no-dnat { %s }", t, br)
+			}
+			b.Add("delete element %s snat { %s }", t, addr)
+			b.Add("delete chain %s %s", t, nftables.Quote("snat-"+config.BridgeName))
+		}
+	}
+	return fw.apply(b)
+}
+
+func (fw *nftFirewall) forwarder(ipv6 bool) portmapper.Forwarder {
+	if ipv6 {
+		return &nftForwarder{fw: fw, family: nftables.IPv6}
+	}
+	return &nftForwarder{fw: fw, family: nftables.IPv4}
+}
+
+func (fw *nftFirewall) routePort(enable bool, containerIP net.IP, proto string, port int, bridge string) error {
+	elems := []nftElement{{set: "published-dests", key: fmt.Sprintf("%s . %s . %d", containerIP, proto, port)}}
+	return fw.update(nftFamily(containerIP), enable, elems)
+}
+
+func (fw *nftFirewall) link(enable bool, parentIP, childIP string, ports []types.TransportPort, bridge string) error {
+	ip1 := net.ParseIP(parentIP)
+	if ip1 == nil {
+		return InvalidLinkIPAddrError(parentIP)
+	}
+	ip2 := net.ParseIP(childIP)
+	if ip2 == nil {
+		return InvalidLinkIPAddrError(childIP)
+	}
+	elems := make([]nftElement, 0, len(ports))
+	for _, port := range ports {
+		elems = append(elems, nftElement{set: "links", key: fmt.Sprintf("%s . %s . %s . %d", ip1, ip2, port.Proto, port.Port)})
+	}
+	return fw.update(nftFamily(ip1), enable, elems)
+}
+
+func (fw *nftFirewall) setPolicy(config *networkConfiguration, ipv6 bool, filters []types.PolicyFilter) error {
+	var (
+		f      = nftables.IPv4
+		t      string
+		policy = nftables.Quote("policy-" + config.BridgeName)
+		b      = &nftables.Batch{}
+	)
+	if ipv6 {
+		f = nftables.IPv6
+	}
+	t = fmt.Sprintf("%s %s", f, nftTable)
+
+	b.Add("add chain %s %s", t, policy)
+	b.Add("flush chain %s %s", t, policy)
+	b.Add("add rule %s %s ct state established,related accept", t, policy)
+	for _, pf := range filters {
+		var match []string
+		if len(pf.Sources) > 0 {
+			match = append(match, fmt.Sprintf("%s saddr { %s }", f, nftIPs(pf.Sources)))
+		}
+		if len(pf.Destinations) > 0 {
+			match = append(match, fmt.Sprintf("%s daddr { %s }", f, nftIPs(pf.Destinations)))
+		}
+		if pf.Protocol != "" {
+			match = append(match, "meta l4proto "+pf.Protocol)
+		}
+		if len(pf.Ports) > 0 {
+			ports := make([]string, 0, len(pf.Ports))
+			for _, pr := range pf.Ports {
+				ports = append(ports, pr.String())
+			}
+			match = append(match, fmt.Sprintf("th dport { %s }", strings.Join(ports, ", ")))
+		}
+		verdict := "drop"
+		if pf.Accept {
+			verdict = "accept"
+		}
+		b.Add("add rule %s %s %s", t, policy, strings.Join(append(match, verdict), " "))
+	}
+	return fw.apply(b)
+}
+
+func nftIPs(ips []net.IP) string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// update adds, or deletes, references to elements of the sets of the family
+// f. Elements are added with their first reference, and deleted with their
+// last one.
+func (fw *nftFirewall) update(f nftables.Family, add bool, elems []nftElement) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	b := &nftables.Batch{}
+	refs := make(map[string]int)
+	for _, e := range elems {
+		id := fmt.Sprintf("%s %s %s", f, e.set, e.key)
+		n, ok := refs[id]
+		if !ok {
+			n = fw.refs[id]
+		}
+		switch {
+		case add:
+			if n == 0 {
+				if e.value != "" {
+					b.Add("add element %s %s %s { %s : %s }", f, nftTable, e.set, e.key, e.value)
+				} else {
+					b.Add("add element %s %s %s { %s }", f, nftTable, e.set, e.key)
+				}
+			}
+			n++
+		case n > 0:
+			if n == 1 {
+				b.Add("delete element %s %s %s { %s }", f, nftTable, e.set, e.key)
+			}
+			n--
+		}
+		refs[id] = n
+	}
+	if err := fw.apply(b); err != nil {
+		return err
+	}
+	for id, n := range refs {
+		if n == 0 {
+			delete(fw.refs, id)
+		} else {
+			fw.refs[id] = n
+		}
+	}
+	return nil
+}
+
+// nftForwarder programs the port mappings of an address family.
+type nftForwarder struct {
+	fw     *nftFirewall
+	family nftables.Family
+}
+
+// Forward implements portmapper.Forwarder.
+func (fwd *nftForwarder) Forward(action iptables.Action, ip net.IP, port int, proto, destAddr string, destPort int, bridgeName string) error {
+	published := nftElement{
+		set:   "published",
+		key:   fmt.Sprintf("%s . %s . %d", ip, proto, port),
+		value: fmt.Sprintf("%s . %d", destAddr, destPort),
+	}
+	if ip == nil || ip.IsUnspecified() {
+		published.set = "published-any"
+		published.key = fmt.Sprintf("%s . %d", proto, port)
+	}
+	elems := []nftElement{
+		published,
+		{set: "published-dests", key: fmt.Sprintf("%s . %s . %d", destAddr, proto, destPort)},
+		{set: "hairpin", key: fmt.Sprintf("%s . %s . %s . %d", destAddr, destAddr, proto, destPort)},
+	}
+	return fwd.fw.update(fwd.family, action != iptables.Delete, elems)
+}
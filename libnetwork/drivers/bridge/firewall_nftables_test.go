@@ -0,0 +1,251 @@
+//go:build linux
+// +build linux
+
+package bridge
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/nftables"
+	"github.com/docker/docker/libnetwork/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// newTestNftFirewall returns an nftables backend which records the batches it
+// applies, instead of running nft.
+func newTestNftFirewall(hairpin bool) (*nftFirewall, *[]string) {
+	var scripts []string
+	fw := &nftFirewall{
+		hairpin: hairpin,
+		apply: func(b *nftables.Batch) error {
+			scripts = append(scripts, b.String())
+			return nil
+		},
+		refs: make(map[string]int),
+	}
+	return fw, &scripts
+}
+
+func TestNftInit(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	assert.NilError(t, fw.init(nftables.IPv4, nftables.IPv6))
+	assert.Assert(t, is.Len(*scripts, 1))
+
+	script := (*scripts)[0]
+	for _, cmd := range []string{
+		"delete table ip docker\n",
+		"add map ip docker published { type ipv4_addr . inet_proto . inet_service : ipv4_addr . inet_service; }\n",
+		"add map ip6 docker published { type ipv6_addr . inet_proto . inet_service : ipv6_addr . inet_service; }\n",
+		"add rule ip docker output ip daddr != 127.0.0.0/8 fib daddr type local jump dnat\n",
+		"add rule ip6 docker output ip6 daddr != ::1 fib daddr type local jump dnat\n",
+		"add chain ip docker-user forward { type filter hook forward priority -1; policy accept; }\n",
+	} {
+		assert.Check(t, is.Contains(script, cmd))
+	}
+	// the table of the user is not replaced.
+	assert.Check(t, !strings.Contains(script, "delete table ip docker-user"))
+}
+
+func TestNftInitIngress(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	assert.NilError(t, fw.init(nftables.IPv4, nftables.IPv6))
+	assert.Assert(t, is.Len(*scripts, 1))
+
+	// The ports of the ingress network are accepted before the networks are
+	// isolated from each other, and only for IPv4.
+	script := (*scripts)[0]
+	accept := strings.Index(script, "add rule ip docker forward meta l4proto . th dport @ingress-ports accept\n")
+	isolation := strings.Index(script, "add rule ip docker forward oifname vmap @forward-out\n")
+	assert.Check(t, accept != -1)
+	assert.Check(t, accept < isolation)
+	assert.Check(t, is.Contains(script, "add rule ip docker forward ct state established,related meta l4proto . th sport @ingress-ports accept\n"))
+	assert.Check(t, !strings.Contains(script, "ip6 docker ingress-ports"))
+}
+
+func TestNftInitHairpin(t *testing.T) {
+	fw, scripts := newTestNftFirewall(true)
+	assert.NilError(t, fw.init(nftables.IPv4, nftables.IPv6))
+	assert.Assert(t, is.Len(*scripts, 1))
+
+	script := (*scripts)[0]
+	assert.Check(t, is.Contains(script, "add rule ip docker output fib daddr type local jump dnat\n"))
+	assert.Check(t, is.Contains(script, "add rule ip docker postrouting ip saddr 127.0.0.0/8 oifname @bridges masquerade\n"))
+	// IPv6 connections from the loopback interface cannot be routed to containers.
+	assert.Check(t, is.Contains(script, "add rule ip6 docker output ip6 daddr != ::1 fib daddr type local jump dnat\n"))
+}
+
+func TestNftNetwork(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	_, addr, _ := net.ParseCIDR("172.20.0.0/16")
+	config := &networkConfiguration{
+		BridgeName:         "br-test",
+		EnableIPMasquerade: true,
+		HostIP:             net.ParseIP("192.168.1.10"),
+	}
+	assert.NilError(t, fw.addNetwork(config, addr))
+	assert.NilError(t, fw.delNetwork(config, addr))
+	assert.Assert(t, is.Len(*scripts, 2))
+
+	assert.Check(t, is.Contains((*scripts)[0], `add rule ip docker "out-br-test" iifname != "br-test" iifname @bridges drop
+add rule ip docker "out-br-test" ct state established,related accept
+add rule ip docker "out-br-test" iifname != "br-test" ip daddr . meta l4proto . th dport @published-dests accept
+`))
+	assert.Check(t, is.Contains((*scripts)[0], `add rule ip docker "out-br-test" iifname "br-test" drop
+`))
+	assert.Check(t, is.Contains((*scripts)[0], `add element ip docker no-dnat { "br-test" }
+`))
+	assert.Check(t, is.Contains((*scripts)[0], `add rule ip docker "snat-br-test" oifname != "br-test" snat to 192.168.1.10
+add element ip docker snat { 172.20.0.0/16 : jump "snat-br-test" }
+`))
+	assert.Check(t, is.Equal((*scripts)[1], `delete element ip docker forward-out { "br-test" }
+delete element ip docker forward-in { "br-test" }
+delete chain ip docker "out-br-test"
+delete chain ip docker "in-br-test"
+delete element ip docker bridges { "br-test" }
+delete element ip docker no-dnat { "br-test" }
+delete element ip docker snat { 172.20.0.0/16 }
+delete chain ip docker "snat-br-test"
+`))
+}
+
+func TestNftRoutedNetwork(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	_, addr, _ := net.ParseCIDR("2001:db8::/64")
+	config := &networkConfiguration{
+		BridgeName:         "br-routed",
+		EnableIPMasquerade: true,
+		GatewayModeIPv6:    gatewayModeRouted,
+	}
+	assert.NilError(t, fw.addNetwork(config, addr))
+	assert.NilError(t, fw.delNetwork(config, addr))
+	assert.Assert(t, is.Len(*scripts, 2))
+
+	// the connections of the containers are not translated.
+	for _, script := range *scripts {
+		assert.Check(t, !strings.Contains(script, "snat"))
+		assert.Check(t, !strings.Contains(script, "no-dnat"))
+	}
+	assert.Check(t, is.Contains((*scripts)[0], `add element ip6 docker bridges { "br-routed" }
+`))
+}
+
+func TestNftInternalNetwork(t *testing.T) {
+	fw, scripts := newTestNftFirewall(true)
+	_, addr, _ := net.ParseCIDR("fd00::/64")
+	config := &networkConfiguration{
+		BridgeName:         "br-internal",
+		EnableICC:          true,
+		EnableIPMasquerade: true,
+		Internal:           true,
+	}
+	assert.NilError(t, fw.addNetwork(config, addr))
+	assert.Assert(t, is.Len(*scripts, 1))
+	assert.Check(t, is.Equal((*scripts)[0], `add chain ip6 docker "in-br-internal"
+flush chain ip6 docker "in-br-internal"
+add chain ip6 docker "out-br-internal"
+flush chain ip6 docker "out-br-internal"
+add rule ip6 docker "out-br-internal" ip6 saddr != fd00::/64 drop
+add rule ip6 docker "out-br-internal" iifname "br-internal" accept
+add rule ip6 docker "out-br-internal" drop
+add rule ip6 docker "in-br-internal" drop
+add element ip6 docker forward-out { "br-internal" : jump "out-br-internal" }
+add element ip6 docker forward-in { "br-internal" : jump "in-br-internal" }
+`))
+}
+
+func TestNftPolicy(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	_, addr, _ := net.ParseCIDR("172.20.0.0/16")
+	config := &networkConfiguration{
+		BridgeName: "br-test",
+		EnableICC:  true,
+		Policy:     &types.NetworkPolicy{Default: types.PolicyDeny},
+	}
+	assert.NilError(t, fw.addNetwork(config, addr))
+	assert.NilError(t, fw.setPolicy(config, false, []types.PolicyFilter{
+		{
+			Accept:       true,
+			Sources:      []net.IP{net.ParseIP("172.20.0.2").To4()},
+			Destinations: []net.IP{net.ParseIP("172.20.0.3").To4(), net.ParseIP("172.20.0.4").To4()},
+			Protocol:     "tcp",
+			Ports:        []types.PortRange{{Start: 5432, End: 5432}, {Start: 8000, End: 8080}},
+		},
+		{},
+	}))
+	assert.NilError(t, fw.delNetwork(config, addr))
+	assert.Assert(t, is.Len(*scripts, 3))
+
+	assert.Check(t, is.Contains((*scripts)[0], `add chain ip docker "policy-br-test"
+`))
+	assert.Check(t, is.Contains((*scripts)[0], `add rule ip docker "out-br-test" iifname "br-test" jump "policy-br-test"
+`))
+	assert.Check(t, is.Equal((*scripts)[1], `add chain ip docker "policy-br-test"
+flush chain ip docker "policy-br-test"
+add rule ip docker "policy-br-test" ct state established,related accept
+add rule ip docker "policy-br-test" ip saddr { 172.20.0.2 } ip daddr { 172.20.0.3, 172.20.0.4 } meta l4proto tcp th dport { 5432, 8000-8080 } accept
+add rule ip docker "policy-br-test" drop
+`))
+	assert.Check(t, is.Contains((*scripts)[2], `delete chain ip docker "policy-br-test"
+`))
+}
+
+func TestNftForward(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	fwd := fw.forwarder(false)
+
+	assert.NilError(t, fwd.Forward(iptables.Append, net.IPv4zero, 8080, "tcp", "172.17.0.2", 80, "docker0"))
+	assert.NilError(t, fwd.Forward(iptables.Append, net.ParseIP("127.0.0.1"), 8081, "tcp", "172.17.0.2", 80, "docker0"))
+	assert.NilError(t, fwd.Forward(iptables.Delete, net.IPv4zero, 8080, "tcp", "172.17.0.2", 80, "docker0"))
+	assert.NilError(t, fwd.Forward(iptables.Delete, net.ParseIP("127.0.0.1"), 8081, "tcp", "172.17.0.2", 80, "docker0"))
+
+	assert.Check(t, is.DeepEqual(*scripts, []string{
+		`add element ip docker published-any { tcp . 8080 : 172.17.0.2 . 80 }
+add element ip docker published-dests { 172.17.0.2 . tcp . 80 }
+add element ip docker hairpin { 172.17.0.2 . 172.17.0.2 . tcp . 80 }
+`,
+		// the container port is already published.
+		`add element ip docker published { 127.0.0.1 . tcp . 8081 : 172.17.0.2 . 80 }
+`,
+		`delete element ip docker published-any { tcp . 8080 }
+`,
+		`delete element ip docker published { 127.0.0.1 . tcp . 8081 }
+delete element ip docker published-dests { 172.17.0.2 . tcp . 80 }
+delete element ip docker hairpin { 172.17.0.2 . 172.17.0.2 . tcp . 80 }
+`,
+	}))
+	assert.Check(t, is.Len(fw.refs, 0))
+}
+
+func TestNftForwardFailure(t *testing.T) {
+	fw, _ := newTestNftFirewall(false)
+	fw.apply = func(*nftables.Batch) error { return errors.New("failed") }
+
+	err := fw.forwarder(true).Forward(iptables.Append, net.ParseIP("::1"), 8080, "udp", "fd00::2", 53, "docker0")
+	assert.Check(t, is.Error(err, "failed"))
+	// the references are only taken once the batch is applied.
+	assert.Check(t, is.Len(fw.refs, 0))
+}
+
+func TestNftLink(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	ports := []types.TransportPort{{Proto: types.TCP, Port: 5000}, {Proto: types.UDP, Port: 53}}
+
+	l := newLink("172.17.0.3", "172.17.0.2", ports, "docker0", fw)
+	assert.NilError(t, l.Enable())
+	l.Disable()
+	assert.Check(t, is.DeepEqual(*scripts, []string{
+		`add element ip docker links { 172.17.0.3 . 172.17.0.2 . tcp . 5000 }
+add element ip docker links { 172.17.0.3 . 172.17.0.2 . udp . 53 }
+`,
+		`delete element ip docker links { 172.17.0.3 . 172.17.0.2 . tcp . 5000 }
+delete element ip docker links { 172.17.0.3 . 172.17.0.2 . udp . 53 }
+`,
+	}))
+
+	assert.Check(t, is.Error(fw.link(true, "invalid", "172.17.0.2", ports, "docker0"), "Cannot link to a container with Invalid IP Address 'invalid'"))
+}
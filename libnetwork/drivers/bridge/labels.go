@@ -18,4 +18,12 @@ const (
 
 	// DefaultBridge label
 	DefaultBridge = "com.docker.network.bridge.default_bridge"
+
+	// GatewayModeIPv4 label for the gateway mode of the IPv4 network: nat,
+	// routed or isolated
+	GatewayModeIPv4 = "com.docker.network.bridge.gateway_mode_ipv4"
+
+	// GatewayModeIPv6 label for the gateway mode of the IPv6 network: nat,
+	// routed or isolated
+	GatewayModeIPv6 = "com.docker.network.bridge.gateway_mode_ipv6"
 )
@@ -17,22 +17,28 @@ type link struct {
 	childIP  string
 	ports    []types.TransportPort
 	bridge   string
+	fw       firewaller
 }
 
 func (l *link) String() string {
 	return fmt.Sprintf("%s <-> %s [%v] on %s", l.parentIP, l.childIP, l.ports, l.bridge)
 }
 
-func newLink(parentIP, childIP string, ports []types.TransportPort, bridge string) *link {
+func newLink(parentIP, childIP string, ports []types.TransportPort, bridge string, fw firewaller) *link {
 	return &link{
 		childIP:  childIP,
 		parentIP: parentIP,
 		ports:    ports,
 		bridge:   bridge,
+		fw:       fw,
 	}
 }
 
 func (l *link) Enable() error {
+	if l.fw != nil {
+		return l.fw.link(true, l.parentIP, l.childIP, l.ports, l.bridge)
+	}
+
 	// -A == iptables append flag
 	linkFunction := func() error {
 		return linkContainers("-A", l.parentIP, l.childIP, l.ports, l.bridge, false)
@@ -43,6 +49,13 @@ func (l *link) Enable() error {
 }
 
 func (l *link) Disable() {
+	if l.fw != nil {
+		if err := l.fw.link(false, l.parentIP, l.childIP, l.ports, l.bridge); err != nil {
+			logrus.Errorf("Error removing firewall rules for a link %s due to %s", l.String(), err.Error())
+		}
+		return
+	}
+
 	// -D == iptables delete flag
 	err := linkContainers("-D", l.parentIP, l.childIP, l.ports, l.bridge, true)
 	if err != nil {
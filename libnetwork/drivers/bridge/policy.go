@@ -0,0 +1,113 @@
+//go:build linux
+// +build linux
+
+package bridge
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/types"
+)
+
+// setupPolicy installs the rules enforcing the policy of the network between
+// its containers, which are replaced as endpoints are created and deleted.
+func (n *bridgeNetwork) setupPolicy(config *networkConfiguration, i *bridgeInterface) error {
+	d := n.driver
+	d.Lock()
+	fw := d.firewall
+	d.Unlock()
+
+	if err := n.programPolicy(config); err != nil {
+		return err
+	}
+	// The rules of the other firewall backends are removed with the other
+	// rules of the network.
+	if fw == nil {
+		n.registerIptCleanFunc(func() error {
+			return n.removePolicyRules(config)
+		})
+	}
+	return nil
+}
+
+// updatePolicy replaces the rules enforcing the policy of the network, if it
+// has one, for its current endpoints.
+func (n *bridgeNetwork) updatePolicy() error {
+	config := n.getConfig()
+	if config.Policy == nil {
+		return nil
+	}
+	return n.programPolicy(config)
+}
+
+// programPolicy replaces the rules enforcing the policy of config between the
+// endpoints of the network, for the address families with a firewall.
+func (n *bridgeNetwork) programPolicy(config *networkConfiguration) error {
+	d := n.driver
+	d.Lock()
+	fw := d.firewall
+	driverConfig := d.config
+	d.Unlock()
+
+	n.policyMu.Lock()
+	defer n.policyMu.Unlock()
+
+	n.Lock()
+	endpoints := make([]types.PolicyEndpoint, 0, len(n.endpoints))
+	for _, ep := range n.endpoints {
+		var pe types.PolicyEndpoint
+		if ep.addr != nil {
+			pe.Addrs = append(pe.Addrs, ep.addr.IP)
+		}
+		if ep.addrv6 != nil {
+			pe.Addrs = append(pe.Addrs, ep.addrv6.IP)
+		}
+		if ep.config != nil {
+			pe.Labels = ep.config.Labels
+		}
+		endpoints = append(endpoints, pe)
+	}
+	n.Unlock()
+
+	for _, family := range []struct {
+		ipv6    bool
+		version iptables.IPVersion
+		enabled bool
+	}{
+		{false, iptables.IPv4, driverConfig.EnableIPTables},
+		{true, iptables.IPv6, config.EnableIPv6 && driverConfig.EnableIP6Tables},
+	} {
+		if !family.enabled {
+			continue
+		}
+		filters := config.Policy.Filters(endpoints, family.ipv6)
+		var err error
+		if fw != nil {
+			err = fw.setPolicy(config, family.ipv6, filters)
+		} else {
+			err = programPolicyRules(family.version, config.BridgeName, filters)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to program the policy rules of bridge network %s: %v", config.ID, err)
+		}
+	}
+	return nil
+}
+
+// removePolicyRules removes the iptables rules installed by programPolicy.
+func (n *bridgeNetwork) removePolicyRules(config *networkConfiguration) error {
+	n.policyMu.Lock()
+	defer n.policyMu.Unlock()
+
+	driverConfig := n.driver.config
+	if driverConfig.EnableIPTables {
+		if err := removePolicyChain(iptables.IPv4, config.BridgeName); err != nil {
+			return err
+		}
+	}
+	if config.EnableIPv6 && driverConfig.EnableIP6Tables {
+		return removePolicyChain(iptables.IPv6, config.BridgeName)
+	}
+	return nil
+}
@@ -43,7 +43,7 @@ func (n *bridgeNetwork) allocatePortsInternal(bindings []types.PortBinding, cont
 		bIPv4 := c.GetCopy()
 		bIPv6 := c.GetCopy()
 		// Allocate IPv4 Port mappings
-		if ok := n.validatePortBindingIPv4(&bIPv4, containerIPv4, defHostIP); ok {
+		if ok := n.validatePortBindingIPv4(&bIPv4, containerIPv4, defHostIP); ok && n.config.gatewayMode(bIPv4.HostIP) != gatewayModeIsolated {
 			if err := n.allocatePort(&bIPv4, ulPxyEnabled); err != nil {
 				// On allocation failure, release previously allocated ports. On cleanup error, just log a warning message
 				if cuErr := n.releasePortsInternal(bs); cuErr != nil {
@@ -66,10 +66,10 @@ func (n *bridgeNetwork) allocatePortsInternal(bindings []types.PortBinding, cont
 		// by setting up the binding with the IPv4 interface if the userland proxy is enabled
 		// This change was added to keep backward compatibility
 		containerIP := containerIPv6
-		if ulPxyEnabled && (containerIPv6 == nil) {
+		if ulPxyEnabled && (containerIPv6 == nil) && n.config.gatewayMode(net.IPv6zero) == gatewayModeNAT {
 			containerIP = containerIPv4
 		}
-		if ok := n.validatePortBindingIPv6(&bIPv6, containerIP, defHostIP); ok {
+		if ok := n.validatePortBindingIPv6(&bIPv6, containerIP, defHostIP); ok && n.config.gatewayMode(bIPv6.HostIP) != gatewayModeIsolated {
 			if err := n.allocatePort(&bIPv6, ulPxyEnabled); err != nil {
 				// On allocation failure, release previously allocated ports. On cleanup error, just log a warning message
 				if cuErr := n.releasePortsInternal(bs); cuErr != nil {
@@ -136,6 +136,27 @@ func (n *bridgeNetwork) allocatePort(bnd *types.PortBinding, ulPxyEnabled bool)
 		err  error
 	)
 
+	// In routed mode, the port is reachable at the address of the container,
+	// without any host port, so the host port can only be the container port.
+	if n.config.gatewayMode(bnd.HostIP) == gatewayModeRouted {
+		if bnd.HostPort != 0 {
+			hostPortEnd := bnd.HostPortEnd
+			if hostPortEnd == 0 {
+				hostPortEnd = bnd.HostPort
+			}
+			if bnd.Port < bnd.HostPort || bnd.Port > hostPortEnd {
+				return types.BadRequestErrorf("host port %d cannot be mapped to port %d/%s of the container in routed mode", bnd.HostPort, bnd.Port, bnd.Proto)
+			}
+		}
+		if err := n.setRoutedPort(*bnd, true); err != nil {
+			return err
+		}
+		bnd.HostIP = types.GetIPCopy(bnd.IP)
+		bnd.HostPort = bnd.Port
+		bnd.HostPortEnd = bnd.Port
+		return nil
+	}
+
 	// Adjust HostPortEnd if this is not a range.
 	if bnd.HostPortEnd == 0 {
 		bnd.HostPortEnd = bnd.HostPort
@@ -207,6 +228,10 @@ func (n *bridgeNetwork) releasePortsInternal(bindings []types.PortBinding) error
 }
 
 func (n *bridgeNetwork) releasePort(bnd types.PortBinding) error {
+	if n.config.gatewayMode(bnd.HostIP) == gatewayModeRouted {
+		return n.setRoutedPort(bnd, false)
+	}
+
 	// Construct the host side transport address
 	host, err := bnd.HostAddr()
 	if err != nil {
@@ -222,6 +247,23 @@ func (n *bridgeNetwork) releasePort(bnd types.PortBinding) error {
 	return portmapper.Unmap(host)
 }
 
+// setRoutedPort allows, or disallows, the connections from outside the
+// network to the container port of a binding in routed mode.
+func (n *bridgeNetwork) setRoutedPort(bnd types.PortBinding, enable bool) error {
+	d := n.driver
+	d.Lock()
+	driverConfig, fw := d.config, d.firewall
+	d.Unlock()
+
+	if bnd.IP.To4() != nil && !driverConfig.EnableIPTables || bnd.IP.To4() == nil && !driverConfig.EnableIP6Tables {
+		return nil
+	}
+	if fw != nil {
+		return fw.routePort(enable, bnd.IP, bnd.Proto.String(), int(bnd.Port), n.config.BridgeName)
+	}
+	return programRoutedPortRule(bnd.IP, bnd.Proto.String(), int(bnd.Port), n.config.BridgeName, enable)
+}
+
 var (
 	v6ListenableCached bool
 	v6ListenableOnce   sync.Once
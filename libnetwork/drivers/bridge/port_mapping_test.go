@@ -4,6 +4,7 @@
 package bridge
 
 import (
+	"net"
 	"os"
 	"testing"
 
@@ -183,3 +184,50 @@ func loopbackUp() error {
 	}
 	return nlHandle.LinkSetUp(iface)
 }
+
+func TestRoutedPortMapping(t *testing.T) {
+	fw, scripts := newTestNftFirewall(false)
+	d := newDriver()
+	d.config = &configuration{EnableIPTables: true}
+	d.firewall = fw
+	n := &bridgeNetwork{
+		config: &networkConfiguration{
+			BridgeName:      "br-routed",
+			GatewayModeIPv4: gatewayModeRouted,
+			GatewayModeIPv6: gatewayModeIsolated,
+		},
+		driver: d,
+	}
+
+	containerIPv4 := net.ParseIP("192.0.2.2")
+	containerIPv6 := net.ParseIP("2001:db8::2")
+	// the port is not translated, so no other host port can be requested.
+	bindings := []types.PortBinding{{Proto: types.TCP, Port: 80, HostPort: 8080}}
+	if _, err := n.allocatePortsInternal(bindings, containerIPv4, containerIPv6, net.IPv4zero, true); err == nil {
+		t.Fatal("expected host port 8080 to be rejected in routed mode")
+	}
+	if len(*scripts) != 0 {
+		t.Fatalf("unexpected nft scripts: %q", *scripts)
+	}
+
+	bindings = []types.PortBinding{{Proto: types.TCP, Port: 80, HostPort: 80}}
+	pbs, err := n.allocatePortsInternal(bindings, containerIPv4, containerIPv6, net.IPv4zero, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the port is reachable at the address of the container, and not
+	// published in the isolated IPv6 network.
+	expected := types.PortBinding{Proto: types.TCP, IP: containerIPv4, Port: 80, HostIP: containerIPv4, HostPort: 80, HostPortEnd: 80}
+	if len(pbs) != 1 || !pbs[0].Equal(&expected) {
+		t.Fatalf("expected %v, got %v", expected, pbs)
+	}
+
+	if err := n.releasePortsInternal(pbs); err != nil {
+		t.Fatal(err)
+	}
+	if len(*scripts) != 2 ||
+		(*scripts)[0] != "add element ip docker published-dests { 192.0.2.2 . tcp . 80 }\n" ||
+		(*scripts)[1] != "delete element ip docker published-dests { 192.0.2.2 . tcp . 80 }\n" {
+		t.Fatalf("unexpected nft scripts: %q", *scripts)
+	}
+}
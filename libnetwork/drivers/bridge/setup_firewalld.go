@@ -3,7 +3,10 @@
 
 package bridge
 
-import "github.com/docker/docker/libnetwork/iptables"
+import (
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/sirupsen/logrus"
+)
 
 func (n *bridgeNetwork) setupFirewalld(config *networkConfiguration, i *bridgeInterface) error {
 	d := n.driver
@@ -16,7 +19,9 @@ func (n *bridgeNetwork) setupFirewalld(config *networkConfiguration, i *bridgeIn
 		return IPTableCfgError(config.BridgeName)
 	}
 
-	iptables.OnReloaded(func() { n.setupIP4Tables(config, i) })
+	// The rules are restored for the configuration of the network at the
+	// time of the reload, which may have been updated.
+	iptables.OnReloaded(func() { n.setupIP4Tables(n.getConfig(), i) })
 	iptables.OnReloaded(n.portMapper.ReMapAll)
 	return nil
 }
@@ -32,7 +37,34 @@ func (n *bridgeNetwork) setupFirewalld6(config *networkConfiguration, i *bridgeI
 		return IPTableCfgError(config.BridgeName)
 	}
 
-	iptables.OnReloaded(func() { n.setupIP6Tables(config, i) })
+	iptables.OnReloaded(func() { n.setupIP6Tables(n.getConfig(), i) })
 	iptables.OnReloaded(n.portMapperV6.ReMapAll)
 	return nil
 }
+
+// setupFirewalldZone adds the bridge of the network to the docker zone of
+// firewalld, if it is running, as the iptables backend does when programming
+// its chains.
+func (n *bridgeNetwork) setupFirewalldZone(config *networkConfiguration, i *bridgeInterface) error {
+	if !iptables.UsingFirewalld() {
+		return nil
+	}
+	if err := iptables.AddInterfaceFirewalld(config.BridgeName); err != nil {
+		return err
+	}
+	n.registerIptCleanFunc(func() error {
+		return iptables.DelInterfaceFirewalld(config.BridgeName)
+	})
+
+	// The interfaces added to the zone are lost when firewalld is reloaded,
+	// unlike the rules of the backend, which are in tables of their own.
+	iptables.OnReloaded(func() {
+		if _, err := n.driver.getNetwork(n.id); err != nil {
+			return
+		}
+		if err := iptables.AddInterfaceFirewalld(config.BridgeName); err != nil {
+			logrus.WithError(err).Warnf("Failed to add bridge %s to the firewalld zone on reload", config.BridgeName)
+		}
+	})
+	return nil
+}
@@ -7,8 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 
 	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/types"
 	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 )
@@ -27,6 +30,10 @@ const (
 	// result in the packet being dropped. No match returns to the parent chain.
 	IsolationChain1 = "DOCKER-ISOLATION-STAGE-1"
 	IsolationChain2 = "DOCKER-ISOLATION-STAGE-2"
+	// The policy of a network is enforced by a chain of the filter table,
+	// named after its bridge, which the packets forwarded between its
+	// containers jump to before the inter-container communication rule.
+	policyChainPrefix = "DOCKER-POL-"
 )
 
 func setupIPChains(config *configuration, version iptables.IPVersion) (*iptables.ChainInfo, *iptables.ChainInfo, *iptables.ChainInfo, *iptables.ChainInfo, error) {
@@ -148,7 +155,10 @@ func (n *bridgeNetwork) setupIPTables(ipVersion iptables.IPVersion, maskedAddr *
 
 	iptable := iptables.GetIptable(ipVersion)
 
-	if config.Internal {
+	// Connections are only translated in NAT mode.
+	nat := config.gatewayMode(maskedAddr.IP) == gatewayModeNAT
+
+	if config.isolated(maskedAddr.IP) {
 		if err = setupInternalNetworkRules(config.BridgeName, maskedAddr, config.EnableICC, true); err != nil {
 			return fmt.Errorf("Failed to Setup IP tables: %s", err.Error())
 		}
@@ -156,11 +166,13 @@ func (n *bridgeNetwork) setupIPTables(ipVersion iptables.IPVersion, maskedAddr *
 			return setupInternalNetworkRules(config.BridgeName, maskedAddr, config.EnableICC, false)
 		})
 	} else {
-		if err = setupIPTablesInternal(config.HostIP, config.BridgeName, maskedAddr, config.EnableICC, config.EnableIPMasquerade, hairpinMode, true); err != nil {
+		ipmasq := nat && config.EnableIPMasquerade
+		hairpin := nat && hairpinMode
+		if err = setupIPTablesInternal(config.HostIP, config.BridgeName, maskedAddr, config.EnableICC, ipmasq, hairpin, true); err != nil {
 			return fmt.Errorf("Failed to Setup IP tables: %s", err.Error())
 		}
 		n.registerIptCleanFunc(func() error {
-			return setupIPTablesInternal(config.HostIP, config.BridgeName, maskedAddr, config.EnableICC, config.EnableIPMasquerade, hairpinMode, false)
+			return setupIPTablesInternal(config.HostIP, config.BridgeName, maskedAddr, config.EnableICC, ipmasq, hairpin, false)
 		})
 		natChain, filterChain, _, _, err := n.getDriverChains(ipVersion)
 		if err != nil {
@@ -214,22 +226,27 @@ func setupIPTablesInternal(hostIP net.IP, bridgeIface string, addr *net.IPNet, i
 	if hostIP != nil {
 		hostAddr := hostIP.String()
 		natArgs = []string{"-s", address, "!", "-o", bridgeIface, "-j", "SNAT", "--to-source", hostAddr}
-		hpNatArgs = []string{"-m", "addrtype", "--src-type", "LOCAL", "-o", bridgeIface, "-j", "SNAT", "--to-source", hostAddr}
+		hpNatArgs = []string{"-o", bridgeIface, "-j", "SNAT", "--to-source", hostAddr}
 		// Else use MASQUERADE which picks the src-ip based on NH from the route table
 	} else {
 		natArgs = []string{"-s", address, "!", "-o", bridgeIface, "-j", "MASQUERADE"}
-		hpNatArgs = []string{"-m", "addrtype", "--src-type", "LOCAL", "-o", bridgeIface, "-j", "MASQUERADE"}
+		hpNatArgs = []string{"-o", bridgeIface, "-j", "MASQUERADE"}
 	}
 
-	natRule := iptRule{table: iptables.Nat, chain: "POSTROUTING", preArgs: []string{"-t", "nat"}, args: natArgs}
-	hpNatRule := iptRule{table: iptables.Nat, chain: "POSTROUTING", preArgs: []string{"-t", "nat"}, args: hpNatArgs}
-
 	ipVersion := iptables.IPv4
 
 	if addr.IP.To4() == nil {
 		ipVersion = iptables.IPv6
 	}
 
+	natRule := iptRule{table: iptables.Nat, chain: "POSTROUTING", preArgs: []string{"-t", "nat"}, args: natArgs}
+	// Only the connections from the loopback interface are masqueraded, the
+	// other local addresses are routable from the containers, and preserved.
+	hpNatRule := iptRule{table: iptables.Nat, chain: "POSTROUTING", preArgs: []string{"-t", "nat"}, args: append([]string{"-s", "127.0.0.0/8"}, hpNatArgs...)}
+	// Rule masquerading the connections from all the local addresses, which
+	// was created by previous versions.
+	legacyHpNatRule := iptRule{table: iptables.Nat, chain: "POSTROUTING", preArgs: []string{"-t", "nat"}, args: append([]string{"-m", "addrtype", "--src-type", "LOCAL"}, hpNatArgs...)}
+
 	// Set NAT.
 	if ipmasq {
 		if err := programChainRule(ipVersion, natRule, "NAT", enable); err != nil {
@@ -243,11 +260,17 @@ func setupIPTablesInternal(hostIP net.IP, bridgeIface string, addr *net.IPNet, i
 		}
 	}
 
-	// In hairpin mode, masquerade traffic from localhost
+	// In hairpin mode, masquerade traffic from localhost. IPv6 connections
+	// from the loopback interface are not forwarded by the kernel.
 	if hairpin {
-		if err := programChainRule(ipVersion, hpNatRule, "MASQ LOCAL HOST", enable); err != nil {
+		if err := programChainRule(ipVersion, legacyHpNatRule, "MASQ LOCAL HOST", false); err != nil {
 			return err
 		}
+		if ipVersion == iptables.IPv4 {
+			if err := programChainRule(ipVersion, hpNatRule, "MASQ LOCAL HOST", enable); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Set Inter Container Communication.
@@ -418,6 +441,107 @@ func setupInternalNetworkRules(bridgeIface string, addr *net.IPNet, icc, insert
 	return setIcc(version, bridgeIface, icc, insert)
 }
 
+// programRoutedPortRule allows, or disallows, the connections from outside
+// the network to a port of a container of a network in routed mode.
+func programRoutedPortRule(containerIP net.IP, proto string, port int, bridgeName string, enable bool) error {
+	version := iptables.IPv4
+	if containerIP.To4() == nil {
+		version = iptables.IPv6
+	}
+	action := iptables.Insert
+	if !enable {
+		action = iptables.Delete
+	}
+	args := []string{
+		"!", "-i", bridgeName,
+		"-o", bridgeName,
+		"-p", proto,
+		"-d", containerIP.String(),
+		"--dport", strconv.Itoa(port),
+		"-j", "ACCEPT",
+	}
+	return iptables.GetIptable(version).ProgramRule(iptables.Filter, DockerChain, action, args)
+}
+
+// programPolicyRules replaces the rules of the chain enforcing the policy of
+// the network of bridge with filters, and creates the chain and the jump to it
+// if needed. The connections allowed by the policy are accepted both ways.
+func programPolicyRules(version iptables.IPVersion, bridge string, filters []types.PolicyFilter) error {
+	iptable := iptables.GetIptable(version)
+	chain := policyChainPrefix + bridge
+
+	if _, err := iptable.NewChain(chain, iptables.Filter, false); err != nil {
+		return fmt.Errorf("failed to create policy chain %s: %v", chain, err)
+	}
+	if err := iptable.RawCombinedOutput("-F", chain); err != nil {
+		return fmt.Errorf("failed to flush policy chain %s: %v", chain, err)
+	}
+	rules := [][]string{{"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}}
+	for _, f := range filters {
+		rules = append(rules, policyFilterArgs(f)...)
+	}
+	for _, rule := range rules {
+		if err := iptable.RawCombinedOutput(append([]string{"-A", chain}, rule...)...); err != nil {
+			return fmt.Errorf("failed to add rule to policy chain %s: %v", chain, err)
+		}
+	}
+	// Inserted after the inter-container communication rule of the network,
+	// the jump precedes it.
+	jump := []string{"-i", bridge, "-o", bridge, "-j", chain}
+	if err := iptable.ProgramRule(iptables.Filter, "FORWARD", iptables.Insert, jump); err != nil {
+		return fmt.Errorf("failed to add jump to policy chain %s: %v", chain, err)
+	}
+	return nil
+}
+
+// policyFilterArgs returns the arguments of the rules of a policy filter, one
+// for each of its port ranges.
+func policyFilterArgs(f types.PolicyFilter) [][]string {
+	var args []string
+	if len(f.Sources) > 0 {
+		args = append(args, "-s", joinIPs(f.Sources))
+	}
+	if len(f.Destinations) > 0 {
+		args = append(args, "-d", joinIPs(f.Destinations))
+	}
+	if f.Protocol != "" {
+		args = append(args, "-p", f.Protocol)
+	}
+	target := []string{"-j", "DROP"}
+	if f.Accept {
+		target = []string{"-j", "ACCEPT"}
+	}
+	if len(f.Ports) == 0 {
+		return [][]string{append(args, target...)}
+	}
+	rules := make([][]string, 0, len(f.Ports))
+	for _, pr := range f.Ports {
+		rule := append(append([]string{}, args...), "--dport", fmt.Sprintf("%d:%d", pr.Start, pr.End))
+		rules = append(rules, append(rule, target...))
+	}
+	return rules
+}
+
+func joinIPs(ips []net.IP) string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	return strings.Join(s, ",")
+}
+
+// removePolicyChain removes the chain enforcing the policy of the network of
+// bridge, and the jump to it.
+func removePolicyChain(version iptables.IPVersion, bridge string) error {
+	iptable := iptables.GetIptable(version)
+	chain := policyChainPrefix + bridge
+	jump := []string{"-i", bridge, "-o", bridge, "-j", chain}
+	if err := iptable.ProgramRule(iptables.Filter, "FORWARD", iptables.Delete, jump); err != nil {
+		return fmt.Errorf("failed to remove jump to policy chain %s: %v", chain, err)
+	}
+	return iptable.RemoveExistingChain(chain, iptables.Filter)
+}
+
 func clearEndpointConnections(nlh *netlink.Handle, ep *bridgeEndpoint) {
 	var ipv4List []net.IP
 	var ipv6List []net.IP
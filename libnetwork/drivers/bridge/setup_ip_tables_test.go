@@ -5,11 +5,13 @@ package bridge
 
 import (
 	"net"
+	"reflect"
 	"testing"
 
 	"github.com/docker/docker/libnetwork/iptables"
 	"github.com/docker/docker/libnetwork/portmapper"
 	"github.com/docker/docker/libnetwork/testutils"
+	"github.com/docker/docker/libnetwork/types"
 	"github.com/vishvananda/netlink"
 )
 
@@ -80,6 +82,28 @@ func TestSetupIPChains(t *testing.T) {
 	assertBridgeConfig(config, br, d, t)
 }
 
+func TestPolicyFilterArgs(t *testing.T) {
+	f := types.PolicyFilter{
+		Accept:       true,
+		Sources:      []net.IP{net.ParseIP("172.20.0.2").To4(), net.ParseIP("172.20.0.3").To4()},
+		Destinations: []net.IP{net.ParseIP("172.20.0.4").To4()},
+		Protocol:     "tcp",
+		Ports:        []types.PortRange{{Start: 5432, End: 5432}, {Start: 8000, End: 8080}},
+	}
+	expected := [][]string{
+		{"-s", "172.20.0.2,172.20.0.3", "-d", "172.20.0.4", "-p", "tcp", "--dport", "5432:5432", "-j", "ACCEPT"},
+		{"-s", "172.20.0.2,172.20.0.3", "-d", "172.20.0.4", "-p", "tcp", "--dport", "8000:8080", "-j", "ACCEPT"},
+	}
+	if rules := policyFilterArgs(f); !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Unexpected policy rules %v, expected %v", rules, expected)
+	}
+
+	expected = [][]string{{"-j", "DROP"}}
+	if rules := policyFilterArgs(types.PolicyFilter{}); !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Unexpected default policy rules %v, expected %v", rules, expected)
+	}
+}
+
 func getBasicTestConfig() *networkConfiguration {
 	config := &networkConfiguration{
 		BridgeName:  DefaultBridgeName,
@@ -55,6 +55,53 @@ func (d *driver) CreateNetwork(nid string, option map[string]interface{}, nInfo
 	return nil
 }
 
+// UpdateNetwork applies the updated IP related information of a network, to
+// which pools may have been added. The parent interface, the mode and the
+// internal flag of the network cannot be updated.
+func (d *driver) UpdateNetwork(nid string, option map[string]interface{}, ipV4Data, ipV6Data []driverapi.IPAMData) error {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	config, err := parseNetworkOptions(nid, option)
+	if err != nil {
+		return err
+	}
+	if opts, ok := option[netlabel.GenericData].(map[string]string); ok {
+		if _, ok := opts[netlabel.DriverMTU]; ok {
+			return types.ForbiddenErrorf("the macvlan driver does not support the %s option", netlabel.DriverMTU)
+		}
+	}
+	config.processIPAM(ipV4Data, ipV6Data)
+	if config.Parent == "" {
+		config.Parent = getDummyName(stringid.TruncateID(config.ID))
+	}
+
+	n.Lock()
+	oldConfig := n.config
+	n.Unlock()
+
+	switch {
+	case config.Parent != oldConfig.Parent:
+		return types.ForbiddenErrorf("the parent interface of macvlan network %s cannot be updated", nid)
+	case config.MacvlanMode != oldConfig.MacvlanMode:
+		return types.ForbiddenErrorf("the mode of macvlan network %s cannot be updated", nid)
+	case config.Internal != oldConfig.Internal:
+		return types.ForbiddenErrorf("the internal flag of macvlan network %s cannot be updated", nid)
+	}
+	config.CreatedSlaveLink = oldConfig.CreatedSlaveLink
+	config.dbIndex = oldConfig.dbIndex
+	config.dbExists = oldConfig.dbExists
+
+	if err := d.storeUpdate(config); err != nil {
+		return err
+	}
+	n.Lock()
+	n.config = config
+	n.Unlock()
+	return nil
+}
+
 // createNetwork is used by new network callbacks and persistent network cache
 func (d *driver) createNetwork(config *configuration) (bool, error) {
 	foundExisting := false
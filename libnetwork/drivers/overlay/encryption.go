@@ -201,6 +201,14 @@ func removeEncryption(localIP, remoteIP net.IP, em *encrMap) error {
 }
 
 func programMangle(vni uint32, add bool) (err error) {
+	if useNftables() {
+		// The nftables rules of a network are programmed together.
+		if err = programNftEncryption(vni, add); err != nil {
+			logrus.Warnf("could not program the encryption rules: %v", err)
+		}
+		return
+	}
+
 	var (
 		p      = strconv.FormatUint(uint64(overlayutils.VXLANUDPPort()), 10)
 		c      = fmt.Sprintf("0>>22&0x3C@12&0xFFFFFF00=%d", int(vni)<<8)
@@ -231,6 +239,11 @@ func programMangle(vni uint32, add bool) (err error) {
 }
 
 func programInput(vni uint32, add bool) (err error) {
+	if useNftables() {
+		// Programmed along with the mangle rule by programMangle.
+		return
+	}
+
 	var (
 		port       = strconv.FormatUint(uint64(overlayutils.VXLANUDPPort()), 10)
 		vniMatch   = fmt.Sprintf("0>>22&0x3C@12&0xFFFFFF00=%d", int(vni)<<8)
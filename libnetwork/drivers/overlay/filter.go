@@ -87,12 +87,18 @@ func setNetworkChain(cname string, remove bool) error {
 func addNetworkChain(cname string) error {
 	defer filterWait()()
 
+	if useNftables() {
+		return setNftNetworkChain(cname, false)
+	}
 	return setNetworkChain(cname, false)
 }
 
 func removeNetworkChain(cname string) error {
 	defer filterWait()()
 
+	if useNftables() {
+		return setNftNetworkChain(cname, true)
+	}
 	return setNetworkChain(cname, true)
 }
 
@@ -143,11 +149,17 @@ func setFilters(cname, brName string, remove bool) error {
 func addFilters(cname, brName string) error {
 	defer filterWait()()
 
+	if useNftables() {
+		return setNftFilters(cname, brName, false)
+	}
 	return setFilters(cname, brName, false)
 }
 
 func removeFilters(cname, brName string) error {
 	defer filterWait()()
 
+	if useNftables() {
+		return setNftFilters(cname, brName, true)
+	}
 	return setFilters(cname, brName, true)
 }
@@ -0,0 +1,148 @@
+//go:build linux
+// +build linux
+
+package overlay
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/docker/docker/libnetwork/drivers/overlay/overlayutils"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/nftables"
+	"github.com/sirupsen/logrus"
+)
+
+// overlayTable is the nftables table of the host filtering the traffic of
+// the overlay networks in host mode, and the VXLAN packets of the encrypted
+// networks, when the nftables firewall backend is used.
+const overlayTable = "docker-overlay"
+
+// firewallBackend is the firewall backend programming the host rules of the
+// driver, set from the netlabel.OverlayFirewallBackend option.
+var firewallBackend string
+
+// encryptedVNIs holds the VNIs of the encrypted networks, whose chains are
+// regenerated whenever it changes.
+var encryptedVNIs = struct {
+	sync.Mutex
+	vnis map[uint32]struct{}
+}{vnis: map[uint32]struct{}{}}
+
+var nftTableOnce sync.Once
+
+func setFirewallBackend(config map[string]interface{}) {
+	firewallBackend, _ = config[netlabel.OverlayFirewallBackend].(string)
+}
+
+func useNftables() bool {
+	return firewallBackend == "nftables"
+}
+
+// nftTableBatch returns the commands replacing the table of the driver,
+// which may have been left over by an ungraceful shutdown.
+func nftTableBatch() *nftables.Batch {
+	b := &nftables.Batch{}
+	b.Add("add table ip %s", overlayTable)
+	b.Add("delete table ip %s", overlayTable)
+	b.Add("add table ip %s", overlayTable)
+	b.Add("add map ip %s bridges { type ifname : verdict; }", overlayTable)
+	for _, chain := range []string{"forward", "output"} {
+		b.Add("add chain ip %s %s { type filter hook %s priority 0; policy accept; }", overlayTable, nftables.Quote(chain), chain)
+		b.Add("add rule ip %s %s oifname vmap @bridges", overlayTable, nftables.Quote(chain))
+	}
+	b.Add("add chain ip %s encrypt-mark { type route hook output priority -150; policy accept; }", overlayTable)
+	b.Add("add chain ip %s encrypt-input { type filter hook input priority 0; policy accept; }", overlayTable)
+	return b
+}
+
+func setupNftTable() {
+	if err := nftTableBatch().Apply(); err != nil {
+		logrus.Errorf("could not create the overlay nftables table: %v", err)
+	}
+}
+
+// nftNetworkChainBatch returns the commands adding, or removing, the chain
+// accepting the traffic forwarded to the bridges of a network only when it
+// comes from one of them.
+func nftNetworkChainBatch(cname string, remove bool) *nftables.Batch {
+	var (
+		b     = &nftables.Batch{}
+		chain = nftables.Quote("net-" + cname)
+	)
+	if remove {
+		b.Add("flush chain ip %s %s", overlayTable, chain)
+		b.Add("delete chain ip %s %s", overlayTable, chain)
+		b.Add("delete set ip %s %s", overlayTable, chain)
+		return b
+	}
+	b.Add("add set ip %s %s { type ifname; }", overlayTable, chain)
+	b.Add("add chain ip %s %s", overlayTable, chain)
+	b.Add("flush chain ip %s %s", overlayTable, chain)
+	b.Add("add rule ip %s %s iifname @%s accept", overlayTable, chain, chain)
+	b.Add("add rule ip %s %s drop", overlayTable, chain)
+	return b
+}
+
+func setNftNetworkChain(cname string, remove bool) error {
+	nftTableOnce.Do(setupNftTable)
+	return nftNetworkChainBatch(cname, remove).Apply()
+}
+
+// nftFiltersBatch returns the commands adding, or removing, the bridge of a
+// subnet to the chain of its network.
+func nftFiltersBatch(cname, brName string, remove bool) *nftables.Batch {
+	var (
+		b     = &nftables.Batch{}
+		chain = nftables.Quote("net-" + cname)
+		br    = nftables.Quote(brName)
+	)
+	if remove {
+		b.Add("delete element ip %s bridges { %s }", overlayTable, br)
+		b.Add("delete element ip %s %s { %s }", overlayTable, chain, br)
+		return b
+	}
+	b.Add("add element ip %s %s { %s }", overlayTable, chain, br)
+	b.Add("add element ip %s bridges { %s : jump %s }", overlayTable, br, chain)
+	return b
+}
+
+func setNftFilters(cname, brName string, remove bool) error {
+	nftTableOnce.Do(setupNftTable)
+	return nftFiltersBatch(cname, brName, remove).Apply()
+}
+
+// nftEncryptionBatch returns the commands replacing the rules marking the
+// VXLAN packets of the encrypted networks sent by the host, so that they are
+// encrypted, and dropping the ones received in clear.
+func nftEncryptionBatch(port uint32, vnis []uint32) *nftables.Batch {
+	b := &nftables.Batch{}
+	b.Add("flush chain ip %s encrypt-mark", overlayTable)
+	b.Add("flush chain ip %s encrypt-input", overlayTable)
+	for _, vni := range vnis {
+		b.Add("add rule ip %s encrypt-mark udp dport %d @th,96,24 %d meta mark set %d", overlayTable, port, vni, r)
+		b.Add("add rule ip %s encrypt-input udp dport %d @th,96,24 %d meta ipsec missing drop", overlayTable, port, vni)
+	}
+	return b
+}
+
+func programNftEncryption(vni uint32, add bool) error {
+	nftTableOnce.Do(setupNftTable)
+
+	encryptedVNIs.Lock()
+	defer encryptedVNIs.Unlock()
+	if _, ok := encryptedVNIs.vnis[vni]; ok == add {
+		return nil
+	}
+	if add {
+		encryptedVNIs.vnis[vni] = struct{}{}
+	} else {
+		delete(encryptedVNIs.vnis, vni)
+	}
+	vnis := make([]uint32, 0, len(encryptedVNIs.vnis))
+	for v := range encryptedVNIs.vnis {
+		vnis = append(vnis, v)
+	}
+	sort.Slice(vnis, func(i, j int) bool { return vnis[i] < vnis[j] })
+	return nftEncryptionBatch(overlayutils.VXLANUDPPort(), vnis).Apply()
+}
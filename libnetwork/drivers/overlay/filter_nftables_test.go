@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package overlay
+
+import (
+	"testing"
+)
+
+func TestNftNetworkChainBatch(t *testing.T) {
+	expected := `add set ip docker-overlay "net-0123456789ab" { type ifname; }
+add chain ip docker-overlay "net-0123456789ab"
+flush chain ip docker-overlay "net-0123456789ab"
+add rule ip docker-overlay "net-0123456789ab" iifname @"net-0123456789ab" accept
+add rule ip docker-overlay "net-0123456789ab" drop
+add element ip docker-overlay "net-0123456789ab" { "ov-001001-01234" }
+add element ip docker-overlay bridges { "ov-001001-01234" : jump "net-0123456789ab" }
+`
+	script := nftNetworkChainBatch("0123456789ab", false).String() +
+		nftFiltersBatch("0123456789ab", "ov-001001-01234", false).String()
+	if script != expected {
+		t.Fatalf("Unexpected network rules:\n%s\nexpected:\n%s", script, expected)
+	}
+
+	expected = `delete element ip docker-overlay bridges { "ov-001001-01234" }
+delete element ip docker-overlay "net-0123456789ab" { "ov-001001-01234" }
+flush chain ip docker-overlay "net-0123456789ab"
+delete chain ip docker-overlay "net-0123456789ab"
+delete set ip docker-overlay "net-0123456789ab"
+`
+	script = nftFiltersBatch("0123456789ab", "ov-001001-01234", true).String() +
+		nftNetworkChainBatch("0123456789ab", true).String()
+	if script != expected {
+		t.Fatalf("Unexpected network rules removal:\n%s\nexpected:\n%s", script, expected)
+	}
+}
+
+func TestNftEncryptionBatch(t *testing.T) {
+	expected := `flush chain ip docker-overlay encrypt-mark
+flush chain ip docker-overlay encrypt-input
+add rule ip docker-overlay encrypt-mark udp dport 4789 @th,96,24 4097 meta mark set 13681891
+add rule ip docker-overlay encrypt-input udp dport 4789 @th,96,24 4097 meta ipsec missing drop
+`
+	if script := nftEncryptionBatch(4789, []uint32{4097}).String(); script != expected {
+		t.Fatalf("Unexpected encryption rules:\n%s\nexpected:\n%s", script, expected)
+	}
+}
@@ -56,6 +56,10 @@ func (d *driver) Join(nid, eid string, sboxKey string, jinfo driverapi.JoinInfo,
 
 	sbox := n.sandbox()
 
+	if err := n.programPolicy(); err != nil {
+		return err
+	}
+
 	overlayIfName, containerIfName, err := createVethPair()
 	if err != nil {
 		return err
@@ -125,6 +129,7 @@ func (d *driver) Join(nid, eid string, sboxKey string, jinfo driverapi.JoinInfo,
 		EndpointIP:       ep.addr.String(),
 		EndpointMAC:      ep.mac.String(),
 		TunnelEndpointIP: d.advertiseAddress,
+		EndpointLabels:   encodeLabels(ep.labels),
 	})
 	if err != nil {
 		return err
@@ -194,6 +199,12 @@ func (d *driver) EventNotify(etype driverapi.EventType, nid, tableName, key stri
 		return
 	}
 
+	if n := d.network(nid); n != nil {
+		if err := n.setPolicyPeer(eid, addr.IP, decodeLabels(peer.EndpointLabels), etype == driverapi.Delete); err != nil {
+			logrus.WithError(err).Warnf("Failed to update the policy rules for peer endpoint %.7s", eid)
+		}
+	}
+
 	if etype == driverapi.Delete {
 		d.peerDelete(nid, eid, addr.IP, addr.Mask, mac, vtep, false)
 		return
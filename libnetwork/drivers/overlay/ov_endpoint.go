@@ -10,6 +10,7 @@ import (
 
 	"github.com/docker/docker/libnetwork/datastore"
 	"github.com/docker/docker/libnetwork/driverapi"
+	"github.com/docker/docker/libnetwork/netlabel"
 	"github.com/docker/docker/libnetwork/netutils"
 	"github.com/docker/docker/libnetwork/ns"
 	"github.com/docker/docker/libnetwork/types"
@@ -26,6 +27,7 @@ type endpoint struct {
 	ifName   string
 	mac      net.HardwareAddr
 	addr     *net.IPNet
+	labels   map[string]string
 	dbExists bool
 	dbIndex  uint64
 }
@@ -78,6 +80,11 @@ func (d *driver) CreateEndpoint(nid, eid string, ifInfo driverapi.InterfaceInfo,
 	if ep.addr == nil {
 		return fmt.Errorf("create endpoint was not passed interface IP address")
 	}
+	if val, ok := epOptions[netlabel.EndpointLabels]; ok {
+		if ep.labels, ok = val.(map[string]string); !ok {
+			return fmt.Errorf("invalid labels of endpoint %s", eid)
+		}
+	}
 
 	if s := n.getSubnetforIP(ep.addr); s == nil {
 		return fmt.Errorf("no matching subnet for IP %q in network %q", ep.addr, nid)
@@ -118,6 +125,10 @@ func (d *driver) DeleteEndpoint(nid, eid string) error {
 
 	n.deleteEndpoint(eid)
 
+	if err := n.programPolicy(); err != nil {
+		logrus.WithError(err).Warnf("Failed to update the policy rules after deleting overlay endpoint %.7s", ep.id)
+	}
+
 	if err := d.deleteEndpointFromStore(ep); err != nil {
 		logrus.Warnf("Failed to delete overlay endpoint %.7s from local store: %v", ep.id, err)
 	}
@@ -223,6 +234,9 @@ func (ep *endpoint) MarshalJSON() ([]byte, error) {
 	if len(ep.mac) != 0 {
 		epMap["mac"] = ep.mac.String()
 	}
+	if len(ep.labels) != 0 {
+		epMap["labels"] = ep.labels
+	}
 
 	return json.Marshal(epMap)
 }
@@ -250,6 +264,12 @@ func (ep *endpoint) UnmarshalJSON(value []byte) error {
 	if v, ok := epMap["ifName"]; ok {
 		ep.ifName = v.(string)
 	}
+	if v, ok := epMap["labels"]; ok {
+		ep.labels = make(map[string]string)
+		for k, lv := range v.(map[string]interface{}) {
+			ep.labels[k] = lv.(string)
+		}
+	}
 
 	return nil
 }
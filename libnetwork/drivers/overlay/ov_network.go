@@ -71,6 +71,11 @@ type network struct {
 	subnets   []*subnet
 	secure    bool
 	mtu       int
+	// policy is the policy of the network, enforced between its local
+	// endpoints and the remote ones in policyPeers.
+	policy      *types.NetworkPolicy
+	policyPeers map[string]policyPeer
+	policyMu    sync.Mutex // Serializes the updates of the policy rules
 	sync.Mutex
 }
 
@@ -183,6 +188,14 @@ func (d *driver) CreateNetwork(id string, option map[string]interface{}, nInfo d
 			}
 		}
 	}
+	if val, ok := option[netlabel.Policy]; ok {
+		policy, ok := val.(*types.NetworkPolicy)
+		if !ok {
+			return types.BadRequestErrorf("invalid policy of network %s", id)
+		}
+		n.policy = policy.GetCopy()
+		n.policyPeers = make(map[string]policyPeer)
+	}
 
 	// If we are getting vnis from libnetwork, either we get for
 	// all subnets or none.
@@ -79,6 +79,8 @@ func Init(dc driverapi.DriverCallback, config map[string]interface{}) error {
 	d.peerOpCancel = cancel
 	go d.peerOpRoutine(ctx, d.peerOpCh)
 
+	setFirewallBackend(config)
+
 	if data, ok := config[netlabel.GlobalKVClient]; ok {
 		var err error
 		dsc, ok := data.(discoverapi.DatastoreConfigData)
@@ -46,6 +46,10 @@ type PeerRecord struct {
 	// which this container is running and can be reached by
 	// building a tunnel to that host IP.
 	TunnelEndpointIP string `protobuf:"bytes,3,opt,name=tunnel_endpoint_ip,json=tunnelEndpointIp,proto3" json:"tunnel_endpoint_ip,omitempty"`
+	// Endpoint labels are the labels of the container, as
+	// key=value strings, selecting the rules of the network
+	// policy which apply to it.
+	EndpointLabels []string `protobuf:"bytes,4,rep,name=endpoint_labels,json=endpointLabels" json:"endpoint_labels,omitempty"`
 }
 
 func (m *PeerRecord) Reset()                    { *m = PeerRecord{} }
@@ -73,6 +77,13 @@ func (m *PeerRecord) GetTunnelEndpointIP() string {
 	return ""
 }
 
+func (m *PeerRecord) GetEndpointLabels() []string {
+	if m != nil {
+		return m.EndpointLabels
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*PeerRecord)(nil), "overlay.PeerRecord")
 }
@@ -80,11 +91,12 @@ func (this *PeerRecord) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&overlay.PeerRecord{")
 	s = append(s, "EndpointIP: "+fmt.Sprintf("%#v", this.EndpointIP)+",\n")
 	s = append(s, "EndpointMAC: "+fmt.Sprintf("%#v", this.EndpointMAC)+",\n")
 	s = append(s, "TunnelEndpointIP: "+fmt.Sprintf("%#v", this.TunnelEndpointIP)+",\n")
+	s = append(s, "EndpointLabels: "+fmt.Sprintf("%#v", this.EndpointLabels)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -129,6 +141,21 @@ func (m *PeerRecord) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintOverlay(dAtA, i, uint64(len(m.TunnelEndpointIP)))
 		i += copy(dAtA[i:], m.TunnelEndpointIP)
 	}
+	if len(m.EndpointLabels) > 0 {
+		for _, s := range m.EndpointLabels {
+			dAtA[i] = 0x22
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
 	return i, nil
 }
 
@@ -156,6 +183,12 @@ func (m *PeerRecord) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovOverlay(uint64(l))
 	}
+	if len(m.EndpointLabels) > 0 {
+		for _, s := range m.EndpointLabels {
+			l = len(s)
+			n += 1 + l + sovOverlay(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -180,6 +213,7 @@ func (this *PeerRecord) String() string {
 		`EndpointIP:` + fmt.Sprintf("%v", this.EndpointIP) + `,`,
 		`EndpointMAC:` + fmt.Sprintf("%v", this.EndpointMAC) + `,`,
 		`TunnelEndpointIP:` + fmt.Sprintf("%v", this.TunnelEndpointIP) + `,`,
+		`EndpointLabels:` + fmt.Sprintf("%v", this.EndpointLabels) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -308,6 +342,35 @@ func (m *PeerRecord) Unmarshal(dAtA []byte) error {
 			}
 			m.TunnelEndpointIP = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndpointLabels", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOverlay
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOverlay
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EndpointLabels = append(m.EndpointLabels, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipOverlay(dAtA[iNdEx:])
@@ -437,19 +500,20 @@ var (
 func init() { proto.RegisterFile("drivers/overlay/overlay.proto", fileDescriptorOverlay) }
 
 var fileDescriptorOverlay = []byte{
-	// 212 bytes of a gzipped FileDescriptorProto
+	// 234 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x92, 0x4d, 0x29, 0xca, 0x2c,
 	0x4b, 0x2d, 0x2a, 0xd6, 0xcf, 0x2f, 0x4b, 0x2d, 0xca, 0x49, 0xac, 0x84, 0xd1, 0x7a, 0x05, 0x45,
 	0xf9, 0x25, 0xf9, 0x42, 0xec, 0x50, 0xae, 0x94, 0x48, 0x7a, 0x7e, 0x7a, 0x3e, 0x58, 0x4c, 0x1f,
-	0xc4, 0x82, 0x48, 0x2b, 0x6d, 0x65, 0xe4, 0xe2, 0x0a, 0x48, 0x4d, 0x2d, 0x0a, 0x4a, 0x4d, 0xce,
+	0xc4, 0x82, 0x48, 0x2b, 0xdd, 0x63, 0xe4, 0xe2, 0x0a, 0x48, 0x4d, 0x2d, 0x0a, 0x4a, 0x4d, 0xce,
 	0x2f, 0x4a, 0x11, 0xd2, 0xe7, 0xe2, 0x4e, 0xcd, 0x4b, 0x29, 0xc8, 0xcf, 0xcc, 0x2b, 0x89, 0xcf,
 	0x2c, 0x90, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x74, 0xe2, 0x7b, 0x74, 0x4f, 0x9e, 0xcb, 0x15, 0x2a,
 	0xec, 0x19, 0x10, 0xc4, 0x05, 0x53, 0xe2, 0x59, 0x20, 0x64, 0xc4, 0xc5, 0x03, 0xd7, 0x90, 0x9b,
 	0x98, 0x2c, 0xc1, 0x04, 0xd6, 0xc1, 0xff, 0xe8, 0x9e, 0x3c, 0x37, 0x4c, 0x87, 0xaf, 0xa3, 0x73,
 	0x10, 0xdc, 0x54, 0xdf, 0xc4, 0x64, 0x21, 0x27, 0x2e, 0xa1, 0x92, 0xd2, 0xbc, 0xbc, 0xd4, 0x9c,
 	0x78, 0x64, 0xbb, 0x98, 0xc1, 0x3a, 0x45, 0x1e, 0xdd, 0x93, 0x17, 0x08, 0x01, 0xcb, 0x22, 0xd9,
-	0x28, 0x50, 0x82, 0x2a, 0x52, 0xe0, 0x24, 0x71, 0xe3, 0xa1, 0x1c, 0xc3, 0x87, 0x87, 0x72, 0x8c,
-	0x0d, 0x8f, 0xe4, 0x18, 0x4f, 0x3c, 0x92, 0x63, 0xbc, 0xf0, 0x48, 0x8e, 0xf1, 0xc1, 0x23, 0x39,
-	0xc6, 0x24, 0x36, 0xb0, 0xc7, 0x8c, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0x48, 0x07, 0xf6, 0xf3,
-	0x18, 0x01, 0x00, 0x00,
+	0x28, 0x50, 0x82, 0x2a, 0x52, 0x20, 0xa4, 0xce, 0xc5, 0x0f, 0xd7, 0x9c, 0x93, 0x98, 0x94, 0x9a,
+	0x53, 0x2c, 0xc1, 0xa2, 0xc0, 0xac, 0xc1, 0x19, 0xc4, 0x07, 0x13, 0xf6, 0x01, 0x8b, 0x3a, 0x49,
+	0xdc, 0x78, 0x28, 0xc7, 0xf0, 0xe1, 0xa1, 0x1c, 0x63, 0xc3, 0x23, 0x39, 0xc6, 0x13, 0x8f, 0xe4,
+	0x18, 0x2f, 0x3c, 0x92, 0x63, 0x7c, 0xf0, 0x48, 0x8e, 0x31, 0x89, 0x0d, 0x1c, 0x02, 0xc6, 0x80,
+	0x01, 0x00, 0xff, 0xb2, 0x66, 0x28, 0x41, 0x01, 0x00, 0x00,
 }
@@ -0,0 +1,172 @@
+//go:build linux
+// +build linux
+
+package overlay
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/libnetwork/nftables"
+	"github.com/docker/docker/libnetwork/types"
+)
+
+// policyTable is the nftables table of the network sandbox filtering the
+// frames forwarded between the containers of a network with a policy. It
+// relies on the connection tracking of bridged traffic, provided by the
+// nf_conntrack_bridge kernel module.
+const policyTable = "docker-policy"
+
+// policyPeer is a remote endpoint of a network with a policy.
+type policyPeer struct {
+	addr   net.IP
+	labels map[string]string
+}
+
+// encodeLabels returns labels as sorted key=value strings, to be sent in
+// peer records.
+func encodeLabels(labels map[string]string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+	s := make([]string, 0, len(labels))
+	for k, v := range labels {
+		s = append(s, k+"="+v)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// decodeLabels returns the labels encoded by encodeLabels.
+func decodeLabels(s []string) map[string]string {
+	if len(s) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(s))
+	for _, kv := range s {
+		k, v, _ := strings.Cut(kv, "=")
+		labels[k] = v
+	}
+	return labels
+}
+
+// setPolicyPeer records, or forgets, the remote endpoint eid and replaces
+// the policy rules of the network accordingly.
+func (n *network) setPolicyPeer(eid string, addr net.IP, labels map[string]string, remove bool) error {
+	n.Lock()
+	if n.policy == nil {
+		n.Unlock()
+		return nil
+	}
+	if remove {
+		delete(n.policyPeers, eid)
+	} else {
+		n.policyPeers[eid] = policyPeer{addr: addr, labels: labels}
+	}
+	n.Unlock()
+	return n.programPolicy()
+}
+
+// programPolicy replaces the rules of the network sandbox enforcing the
+// policy of the network between its local and remote endpoints. The frames
+// sent to the remote endpoints are accepted, as they are filtered on the
+// host of their destination. It does nothing until the sandbox is created.
+func (n *network) programPolicy() error {
+	n.policyMu.Lock()
+	defer n.policyMu.Unlock()
+
+	n.Lock()
+	policy, sbox := n.policy, n.sbox
+	if policy == nil || sbox == nil {
+		n.Unlock()
+		return nil
+	}
+	endpoints := make([]types.PolicyEndpoint, 0, len(n.endpoints)+len(n.policyPeers))
+	for _, ep := range n.endpoints {
+		endpoints = append(endpoints, types.PolicyEndpoint{Addrs: []net.IP{ep.addr.IP}, Labels: ep.labels})
+	}
+	for _, p := range n.policyPeers {
+		endpoints = append(endpoints, types.PolicyEndpoint{Addrs: []net.IP{p.addr}, Labels: p.labels})
+	}
+	vxlans := make([]string, 0, len(n.subnets))
+	for _, s := range n.subnets {
+		if s.vxlanName != "" {
+			vxlans = append(vxlans, nftables.Quote(s.vxlanName))
+		}
+	}
+	n.Unlock()
+
+	// Without a namespace of their own, the networks would share the table.
+	if hostMode {
+		return fmt.Errorf("the policy of overlay network %s cannot be enforced in host mode", n.id)
+	}
+
+	b := policyBatch(vxlans, policy.Filters(endpoints, false))
+	var err error
+	if ierr := sbox.InvokeFunc(func() {
+		err = b.Apply()
+	}); ierr != nil {
+		err = ierr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to program the policy rules of overlay network %s: %v", n.id, err)
+	}
+	return nil
+}
+
+// policyBatch returns the nft commands replacing the rules of the policy
+// table with the filters, for the network whose vxlan interfaces are
+// vxlans.
+func policyBatch(vxlans []string, filters []types.PolicyFilter) *nftables.Batch {
+	var (
+		t     = fmt.Sprintf("%s %s", nftables.Bridge, policyTable)
+		chain = nftables.Quote("forward")
+		b     = &nftables.Batch{}
+	)
+	b.Add("add table %s", t)
+	b.Add("add chain %s %s { type filter hook forward priority 0; policy accept; }", t, chain)
+	b.Add("flush chain %s %s", t, chain)
+	if len(vxlans) > 0 {
+		b.Add("add rule %s %s oifname { %s } accept", t, chain, strings.Join(vxlans, ", "))
+	}
+	b.Add("add rule %s %s ct state established,related accept", t, chain)
+	for _, f := range filters {
+		var match []string
+		if len(f.Sources) > 0 {
+			match = append(match, fmt.Sprintf("ip saddr { %s }", joinIPs(f.Sources)))
+		}
+		if len(f.Destinations) > 0 {
+			match = append(match, fmt.Sprintf("ip daddr { %s }", joinIPs(f.Destinations)))
+		}
+		if f.Protocol != "" {
+			match = append(match, "meta l4proto "+f.Protocol)
+		}
+		if len(f.Ports) > 0 {
+			ports := make([]string, 0, len(f.Ports))
+			for _, pr := range f.Ports {
+				ports = append(ports, pr.String())
+			}
+			match = append(match, fmt.Sprintf("th dport { %s }", strings.Join(ports, ", ")))
+		}
+		if len(match) == 0 {
+			// Do not filter the ARP requests and replies.
+			match = append(match, "meta protocol { ip, ip6 }")
+		}
+		verdict := "drop"
+		if f.Accept {
+			verdict = "accept"
+		}
+		b.Add("add rule %s %s %s", t, chain, strings.Join(append(match, verdict), " "))
+	}
+	return b
+}
+
+func joinIPs(ips []net.IP) string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package overlay
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/gogo/protobuf/proto"
+)
+
+func TestPeerRecordLabels(t *testing.T) {
+	labels := map[string]string{"role": "web", "env": "a=b", "empty": ""}
+	buf, err := proto.Marshal(&PeerRecord{
+		EndpointIP:       "10.0.0.2/24",
+		EndpointMAC:      "02:42:0a:00:00:02",
+		TunnelEndpointIP: "192.168.1.2",
+		EndpointLabels:   encodeLabels(labels),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var peer PeerRecord
+	if err := proto.Unmarshal(buf, &peer); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"empty=", "env=a=b", "role=web"}
+	if !reflect.DeepEqual(peer.EndpointLabels, expected) {
+		t.Fatalf("Incorrect encoding of the labels: %v != %v", peer.EndpointLabels, expected)
+	}
+	if decoded := decodeLabels(peer.EndpointLabels); !reflect.DeepEqual(decoded, labels) {
+		t.Fatalf("Incorrect decoding of the labels: %v != %v", decoded, labels)
+	}
+}
+
+func TestPolicyBatch(t *testing.T) {
+	filters := []types.PolicyFilter{
+		{
+			Accept:       true,
+			Sources:      []net.IP{net.ParseIP("10.0.0.2").To4()},
+			Destinations: []net.IP{net.ParseIP("10.0.0.3").To4(), net.ParseIP("10.0.0.4").To4()},
+			Protocol:     "tcp",
+			Ports:        []types.PortRange{{Start: 5432, End: 5432}},
+		},
+		{},
+	}
+	expected := `add table bridge docker-policy
+add chain bridge docker-policy "forward" { type filter hook forward priority 0; policy accept; }
+flush chain bridge docker-policy "forward"
+add rule bridge docker-policy "forward" oifname { "vx-001001-abcde" } accept
+add rule bridge docker-policy "forward" ct state established,related accept
+add rule bridge docker-policy "forward" ip saddr { 10.0.0.2 } ip daddr { 10.0.0.3, 10.0.0.4 } meta l4proto tcp th dport { 5432 } accept
+add rule bridge docker-policy "forward" meta protocol { ip, ip6 } drop
+`
+	if script := policyBatch([]string{`"vx-001001-abcde"`}, filters).String(); script != expected {
+		t.Fatalf("Unexpected policy rules:\n%s\nexpected:\n%s", script, expected)
+	}
+}
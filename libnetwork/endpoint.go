@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/docker/docker/libnetwork/datastore"
+	"github.com/docker/docker/libnetwork/driverapi"
 	"github.com/docker/docker/libnetwork/ipamapi"
 	"github.com/docker/docker/libnetwork/netlabel"
 	"github.com/docker/docker/libnetwork/options"
@@ -40,6 +41,10 @@ type Endpoint interface {
 
 	// Delete and detaches this endpoint from the network.
 	Delete(force bool) error
+
+	// UpdateBandwidth replaces the bandwidth limits of the endpoint, which
+	// the driver applies without detaching it. A nil bw removes them.
+	UpdateBandwidth(bw *types.Bandwidth) error
 }
 
 // EndpointOption is an option setter function type used to pass various options to Network
@@ -179,6 +184,25 @@ func (ep *endpoint) UnmarshalJSON(b []byte) (err error) {
 			}
 			ep.generic[netlabel.ExposedPorts] = tplist
 		}
+
+		if opt, ok := ep.generic[netlabel.Bandwidth]; ok {
+			bw := &types.Bandwidth{}
+			if bytes, err := json.Marshal(opt); err != nil {
+				logrus.Error(err)
+			} else if err := json.Unmarshal(bytes, bw); err != nil {
+				logrus.Error(err)
+			} else {
+				ep.generic[netlabel.Bandwidth] = bw
+			}
+		}
+
+		if opt, ok := ep.generic[netlabel.EndpointLabels].(map[string]interface{}); ok {
+			labels := make(map[string]string, len(opt))
+			for k, v := range opt {
+				labels[k], _ = v.(string)
+			}
+			ep.generic[netlabel.EndpointLabels] = labels
+		}
 	}
 
 	if v, ok := epMap["anonymous"]; ok {
@@ -465,6 +489,15 @@ func (ep *endpoint) sbJoin(sb *sandbox, options ...EndpointOption) (err error) {
 
 	ep.processOptions(options...)
 
+	if !sb.isReady() {
+		n.getController().setEndpointReady(epid, false)
+		defer func() {
+			if err != nil {
+				n.getController().setEndpointReady(epid, true)
+			}
+		}()
+	}
+
 	d, err := n.driver(true)
 	if err != nil {
 		return fmt.Errorf("failed to get driver during join: %v", err)
@@ -754,6 +787,7 @@ func (ep *endpoint) sbLeave(sb *sandbox, force bool, options ...EndpointOption)
 	if err := ep.deleteServiceInfoFromCluster(sb, true, "sbLeave"); err != nil {
 		logrus.Warnf("Failed to clean up service info on container %s disconnect: %v", ep.name, err)
 	}
+	n.getController().setEndpointReady(ep.ID(), true)
 
 	if err := sb.clearNetworkResources(ep); err != nil {
 		logrus.Warnf("Failed to clean up network resources on container %s disconnect: %v", ep.name, err)
@@ -863,6 +897,62 @@ func (ep *endpoint) Delete(force bool) error {
 	return nil
 }
 
+func (ep *endpoint) UpdateBandwidth(bw *types.Bandwidth) error {
+	n, err := ep.getNetworkFromStore()
+	if err != nil {
+		return fmt.Errorf("failed to get network during bandwidth update: %v", err)
+	}
+
+	stored, err := n.getEndpointFromStore(ep.ID())
+	if err != nil {
+		return fmt.Errorf("failed to get endpoint from store during bandwidth update: %v", err)
+	}
+
+	d, err := n.driver(true)
+	if err != nil {
+		return fmt.Errorf("failed to get driver during bandwidth update: %v", err)
+	}
+	eu, ok := d.(driverapi.EndpointUpdater)
+	if !ok {
+		return types.ForbiddenErrorf("the %s driver does not support updating the bandwidth limits of endpoints", n.Type())
+	}
+
+	stored.Lock()
+	prev := stored.generic
+	generic := options.Generic{}
+	for k, v := range prev {
+		generic[k] = v
+	}
+	if bw != nil {
+		generic[netlabel.Bandwidth] = bw.GetCopy()
+	} else {
+		delete(generic, netlabel.Bandwidth)
+	}
+	stored.Unlock()
+
+	if err := eu.UpdateEndpoint(n.ID(), stored.ID(), generic); err != nil {
+		return err
+	}
+
+	stored.Lock()
+	stored.generic = generic
+	stored.Unlock()
+	if err := n.getController().updateToStore(stored); err != nil {
+		stored.Lock()
+		stored.generic = prev
+		stored.Unlock()
+		if err := eu.UpdateEndpoint(n.ID(), stored.ID(), prev); err != nil {
+			logrus.WithError(err).Warnf("Failed to restore the bandwidth limits of endpoint %s (%s)", stored.Name(), stored.ID())
+		}
+		return fmt.Errorf("failed to update endpoint %s in store: %v", stored.Name(), err)
+	}
+
+	ep.Lock()
+	ep.generic = generic
+	ep.Unlock()
+	return nil
+}
+
 func (ep *endpoint) deleteEndpoint(force bool) error {
 	ep.Lock()
 	n := ep.network
@@ -984,6 +1074,30 @@ func CreateOptionPortMapping(portBindings []types.PortBinding) EndpointOption {
 	}
 }
 
+// CreateOptionBandwidth function returns an option setter for the bandwidth
+// limits of the endpoint to be passed to network.CreateEndpoint() method.
+func CreateOptionBandwidth(bw *types.Bandwidth) EndpointOption {
+	return func(ep *endpoint) {
+		if bw != nil {
+			ep.generic[netlabel.Bandwidth] = bw.GetCopy()
+		}
+	}
+}
+
+// CreateOptionLabels function returns an option setter for the labels of the
+// container of the endpoint, which network policies select endpoints by.
+func CreateOptionLabels(labels map[string]string) EndpointOption {
+	return func(ep *endpoint) {
+		if len(labels) > 0 {
+			l := make(map[string]string, len(labels))
+			for k, v := range labels {
+				l[k] = v
+			}
+			ep.generic[netlabel.EndpointLabels] = l
+		}
+	}
+}
+
 // CreateOptionDNS function returns an option setter for dns entry option to
 // be passed to container Create method.
 func CreateOptionDNS(dns []string) EndpointOption {
@@ -1124,11 +1238,24 @@ func (ep *endpoint) assignAddressVersion(ipVer int, ipam ipamapi.Ipam) error {
 		progAdd = (*address).IP
 	}
 
+	// The endpoint is given the address reserved for its name, if any.
+	opts, name := ep.ipamOptions, ep.Name()
+	if reserved := n.reservedAddress(ipVer, name); reserved != nil {
+		if progAdd == nil {
+			progAdd = reserved
+		}
+		opts = make(map[string]string, len(ep.ipamOptions)+1)
+		for k, v := range ep.ipamOptions {
+			opts[k] = v
+		}
+		opts[ipamapi.AllocReservedFor] = name
+	}
+
 	for _, d := range ipInfo {
 		if progAdd != nil && !d.Pool.Contains(progAdd) {
 			continue
 		}
-		addr, _, err := ipam.RequestAddress(d.PoolID, progAdd, ep.ipamOptions)
+		addr, _, err := ipam.RequestAddress(d.PoolID, progAdd, opts)
 		if err == nil {
 			ep.Lock()
 			*address = addr
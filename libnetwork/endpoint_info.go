@@ -6,6 +6,7 @@ import (
 	"net"
 
 	"github.com/docker/docker/libnetwork/driverapi"
+	"github.com/docker/docker/libnetwork/netlabel"
 	"github.com/docker/docker/libnetwork/types"
 )
 
@@ -34,6 +35,9 @@ type EndpointInfo interface {
 
 	// LoadBalancer returns whether the endpoint is the load balancer endpoint for the network.
 	LoadBalancer() bool
+
+	// Bandwidth returns the bandwidth limits of the endpoint, or nil.
+	Bandwidth() *types.Bandwidth
 }
 
 // InterfaceInfo provides an interface to retrieve interface addresses bound to the endpoint.
@@ -342,6 +346,14 @@ func (ep *endpoint) LoadBalancer() bool {
 	return ep.loadBalancer
 }
 
+func (ep *endpoint) Bandwidth() *types.Bandwidth {
+	ep.Lock()
+	defer ep.Unlock()
+
+	bw, _ := ep.generic[netlabel.Bandwidth].(*types.Bandwidth)
+	return bw.GetCopy()
+}
+
 func (ep *endpoint) StaticRoutes() []*types.StaticRoute {
 	ep.Lock()
 	defer ep.Unlock()
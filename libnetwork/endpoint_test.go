@@ -10,6 +10,7 @@ import (
 	"github.com/docker/docker/libnetwork/ipamapi"
 	"github.com/docker/docker/libnetwork/osl"
 	"github.com/docker/docker/libnetwork/testutils"
+	"github.com/docker/docker/libnetwork/types"
 )
 
 func TestHostsEntries(t *testing.T) {
@@ -74,3 +75,48 @@ fe90::2	somehost.example.com somehost
 
 	osl.GC()
 }
+
+func TestEndpointUpdateBandwidth(t *testing.T) {
+	if !testutils.IsRunningInContainer() {
+		defer testutils.SetupTestOSContext(t)()
+	}
+
+	c, nws := getTestEnv(t, []NetworkOption{NetworkOptionIpam(ipamapi.DefaultIPAM, "",
+		[]*IpamConf{{PreferredPool: "192.168.223.0/24"}}, nil, nil)})
+	defer c.Stop()
+
+	bw := &types.Bandwidth{IngressRate: 1 << 20}
+	ep, err := nws[0].CreateEndpoint("ep1", CreateOptionBandwidth(bw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ep.Delete(false); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if got := ep.Info().Bandwidth(); got == nil || *got != *bw {
+		t.Fatalf("expected bandwidth limits %v, got %v", bw, got)
+	}
+
+	bw = &types.Bandwidth{IngressRate: 1 << 21, IngressBurst: 1 << 17}
+	if err := ep.UpdateBandwidth(bw); err != nil {
+		t.Fatal(err)
+	}
+
+	// The update is persisted.
+	ep2, err := nws[0].EndpointByID(ep.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ep2.Info().Bandwidth(); got == nil || *got != *bw {
+		t.Fatalf("expected bandwidth limits %v, got %v", bw, got)
+	}
+
+	if err := ep.UpdateBandwidth(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := ep.Info().Bandwidth(); got != nil {
+		t.Fatalf("expected no bandwidth limits, got %v", got)
+	}
+}
@@ -2,9 +2,12 @@ package ipam
 
 import (
 	"fmt"
+	"math/rand"
 	"net"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/docker/libnetwork/bitseq"
 	"github.com/docker/docker/libnetwork/datastore"
@@ -16,6 +19,10 @@ import (
 )
 
 const (
+	// defaultReleaseDelay is how long the lowest-free strategy holds back the
+	// released addresses by default.
+	defaultReleaseDelay = 30 * time.Second
+
 	localAddressSpace  = "LocalDefault"
 	globalAddressSpace = "GlobalDefault"
 	// datastore keyes for ipam objects
@@ -211,6 +218,11 @@ func (a *Allocator) RequestPool(addressSpace, pool, subPool string, options map[
 		return "", nil, nil, types.InternalErrorf("failed to parse pool request for address space %q pool %q subpool %q: %v", addressSpace, pool, subPool, err)
 	}
 
+	opts, err := parsePoolOptions(options)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
 	pdf := k == nil
 
 retry:
@@ -230,7 +242,13 @@ retry:
 		return "", nil, nil, err
 	}
 
-	insert, err := aSpace.updatePoolDBOnAdd(*k, nw, ipr, pdf)
+	for name, ip := range opts.reservations {
+		if !nw.Contains(ip) {
+			return "", nil, nil, types.BadRequestErrorf("address %s reserved for %s is not in pool %s", ip, name, nw)
+		}
+	}
+
+	insert, err := aSpace.updatePoolDBOnAdd(*k, nw, ipr, pdf, opts)
 	if err != nil {
 		if _, ok := err.(types.MaskableError); ok {
 			logrus.Debugf("Retrying predefined pool search: %v", err)
@@ -247,7 +265,157 @@ retry:
 		goto retry
 	}
 
-	return k.String(), nw, nil, insert()
+	if err := insert(); err != nil {
+		return "", nil, nil, err
+	}
+
+	if err := a.reserveAddresses(*k, opts.reservations); err != nil {
+		// The pool must not free the addresses it failed to reserve.
+		if err := a.updatePool(*k, func(p *PoolData) (bool, error) {
+			p.Reservations = nil
+			return true, nil
+		}); err != nil {
+			logrus.Warnf("Failed to drop the reservations of pool %s: %v", k.String(), err)
+		}
+		if err := a.ReleasePool(k.String()); err != nil {
+			logrus.Warnf("Failed to release pool %s: %v", k.String(), err)
+		}
+		return "", nil, nil, err
+	}
+
+	return k.String(), nw, nil, nil
+}
+
+// parsePoolOptions returns the allocation settings requested by the options
+// of a pool request.
+func parsePoolOptions(options map[string]string) (poolOptions, error) {
+	var opts poolOptions
+
+	switch s := options[ipamapi.AllocStrategy]; s {
+	case "", ipamapi.StrategySequential, ipamapi.StrategyRandom, ipamapi.StrategyLowestFree:
+		opts.strategy = s
+	default:
+		return opts, types.BadRequestErrorf("invalid allocation strategy %q: must be one of %s, %s or %s",
+			s, ipamapi.StrategySequential, ipamapi.StrategyRandom, ipamapi.StrategyLowestFree)
+	}
+
+	if s, ok := options[ipamapi.AllocReleaseDelay]; ok {
+		if opts.strategy != ipamapi.StrategyLowestFree {
+			return opts, types.BadRequestErrorf("a release delay requires the %s allocation strategy", ipamapi.StrategyLowestFree)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return opts, types.BadRequestErrorf("invalid release delay %q", s)
+		}
+		opts.releaseDelay = d
+	} else if opts.strategy == ipamapi.StrategyLowestFree {
+		opts.releaseDelay = defaultReleaseDelay
+	}
+
+	for k, v := range options {
+		name := strings.TrimPrefix(k, ipamapi.ReservationPrefix)
+		if name == k {
+			continue
+		}
+		ip := net.ParseIP(v)
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		if name == "" || ip == nil {
+			return opts, types.BadRequestErrorf("invalid address reservation %s=%s", k, v)
+		}
+		if opts.reservations == nil {
+			opts.reservations = make(map[string]net.IP)
+		}
+		opts.reservations[name] = ip
+	}
+
+	return opts, nil
+}
+
+// reserveAddresses allocates the addresses reserved in the pool k, so that
+// they are only handed out for the names they are reserved for.
+func (a *Allocator) reserveAddresses(k SubnetKey, reservations map[string]net.IP) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	pk := SubnetKey{AddressSpace: k.AddressSpace, Subnet: k.Subnet}
+	_, nw, err := net.ParseCIDR(k.Subnet)
+	if err != nil {
+		return types.InternalErrorf("invalid pool %s: %v", k.String(), err)
+	}
+	bm, err := a.retrieveBitmask(pk, nw)
+	if err != nil {
+		return err
+	}
+
+	// The pools of a master pool share their reservations, as a pool of a
+	// network is replaced by another one when given a new IP range.
+	aSpace, err := a.getAddrSpace(k.AddressSpace)
+	if err != nil {
+		return err
+	}
+
+	var reserved []uint64
+	for name, ip := range reservations {
+		aSpace.Lock()
+		shared := aSpace.sharesReservation(pk, k, name, ip)
+		aSpace.Unlock()
+		if shared {
+			continue
+		}
+		o, err := getOrdinal(ip, nw)
+		if err == nil {
+			err = bm.Set(o)
+		}
+		if err != nil {
+			for _, o := range reserved {
+				bm.Unset(o)
+			}
+			if err == bitseq.ErrBitAllocated {
+				return types.ForbiddenErrorf("address %s reserved for %s is already allocated", ip, name)
+			}
+			return types.InternalErrorf("failed to reserve address %s for %s: %v", ip, name, err)
+		}
+		reserved = append(reserved, o)
+	}
+	return nil
+}
+
+// updatePool applies fn to the data of the pool k and writes it to the
+// store, retrying when the address space was modified concurrently.
+// fn reports whether it modified the data, which is otherwise not written.
+func (a *Allocator) updatePool(k SubnetKey, fn func(p *PoolData) (bool, error)) error {
+retry:
+	if err := a.refresh(k.AddressSpace); err != nil {
+		return err
+	}
+
+	aSpace, err := a.getAddrSpace(k.AddressSpace)
+	if err != nil {
+		return err
+	}
+
+	aSpace.Lock()
+	p, ok := aSpace.subnets[k]
+	if !ok {
+		aSpace.Unlock()
+		return types.NotFoundErrorf("cannot find address pool for poolID:%s", k.String())
+	}
+	modified, err := fn(p)
+	aSpace.Unlock()
+	if err != nil || !modified {
+		return err
+	}
+
+	if err := a.writeToStore(aSpace); err != nil {
+		if _, ok := err.(types.RetryError); !ok {
+			return types.InternalErrorf("pool (%s) update failed because of %v", k.String(), err)
+		}
+		goto retry
+	}
+	return nil
 }
 
 // ReleasePool releases the address pool identified by the passed id
@@ -474,6 +642,10 @@ func (a *Allocator) RequestAddress(poolID string, prefAddress net.IP, opts map[s
 		return nil, nil, ipamapi.ErrIPOutOfRange
 	}
 
+	name := opts[ipamapi.AllocReservedFor]
+	reserved, strategy := p.Reservations[name], p.Strategy
+
+	poolKey := k
 	c := p
 	for c.Range != nil {
 		k = c.ParentKey
@@ -481,6 +653,15 @@ func (a *Allocator) RequestAddress(poolID string, prefAddress net.IP, opts map[s
 	}
 	aSpace.Unlock()
 
+	// The reserved address remains allocated, it is handed out for its name
+	// as many times as requested.
+	if reserved != nil {
+		if prefAddress != nil && !prefAddress.Equal(reserved) {
+			return nil, nil, types.ForbiddenErrorf("requested address %s differs from the address %s reserved for %s", prefAddress, reserved, name)
+		}
+		return &net.IPNet{IP: types.GetIPCopy(reserved), Mask: p.Pool.Mask}, nil, nil
+	}
+
 	bm, err := a.retrieveBitmask(k, c.Pool)
 	if err != nil {
 		return nil, nil, types.InternalErrorf("could not find bitmask in datastore for %s on address %v request from pool %s: %v",
@@ -488,13 +669,17 @@ func (a *Allocator) RequestAddress(poolID string, prefAddress net.IP, opts map[s
 	}
 	// In order to request for a serial ip address allocation, callers can pass in the option to request
 	// IP allocation serially or first available IP in the subnet
-	var serial bool
 	if opts != nil {
-		if val, ok := opts[ipamapi.AllocSerialPrefix]; ok {
-			serial = (val == "true")
+		if val, ok := opts[ipamapi.AllocSerialPrefix]; ok && val == "true" && strategy == "" {
+			strategy = ipamapi.StrategySequential
 		}
 	}
-	ip, err := a.getAddress(p.Pool, bm, prefAddress, p.Range, serial)
+	var ip net.IP
+	if strategy == ipamapi.StrategyLowestFree {
+		ip, err = a.getLowestFree(poolKey, bm, prefAddress)
+	} else {
+		ip, err = a.getAddress(p.Pool, bm, prefAddress, p.Range, strategy)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -502,6 +687,80 @@ func (a *Allocator) RequestAddress(poolID string, prefAddress net.IP, opts map[s
 	return &net.IPNet{IP: ip, Mask: p.Pool.Mask}, nil, nil
 }
 
+// getLowestFree allocates an address of the pool k, with the lowest-free
+// strategy. The addresses released by the pool are held back for its
+// release delay, unless requested or when no other address is available.
+func (a *Allocator) getLowestFree(k SubnetKey, bm *bitseq.Handle, prefAddress net.IP) (net.IP, error) {
+	var (
+		nw      *net.IPNet
+		ipr     *AddressRange
+		expired []uint64
+		pref    uint64
+		held    bool
+		now     = time.Now()
+	)
+
+	if err := a.updatePool(k, func(p *PoolData) (bool, error) {
+		nw, ipr, expired, held = p.Pool, p.Range, nil, false
+		for o, t := range p.Released {
+			if now.Sub(t) >= p.ReleaseDelay {
+				expired = append(expired, o)
+				delete(p.Released, o)
+			}
+		}
+		if prefAddress != nil {
+			var err error
+			if pref, err = getOrdinal(prefAddress, p.Pool); err != nil {
+				return false, types.InternalErrorf("failed to allocate requested address %s: %v", prefAddress, err)
+			}
+			if _, held = p.Released[pref]; held {
+				delete(p.Released, pref)
+			}
+		}
+		return len(expired) > 0 || held, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// The addresses are freed once they are no longer recorded as held back,
+	// so that a failure leaks them rather than handing them out twice.
+	for _, o := range expired {
+		if err := bm.Unset(o); err != nil {
+			logrus.Warnf("Failed to free address %s held back by pool %s: %v", generateAddress(o, nw), k.String(), err)
+		}
+	}
+
+	if held {
+		return generateAddress(pref, nw), nil
+	}
+
+	ip, err := a.getAddress(nw, bm, prefAddress, ipr, "")
+	if err != ipamapi.ErrNoAvailableIPs {
+		return ip, err
+	}
+
+	// Hand out the address held back the longest.
+	if err := a.updatePool(k, func(p *PoolData) (bool, error) {
+		held = false
+		var oldest time.Time
+		for o, t := range p.Released {
+			if !held || t.Before(oldest) {
+				pref, oldest, held = o, t, true
+			}
+		}
+		if held {
+			delete(p.Released, pref)
+		}
+		return held, nil
+	}); err != nil {
+		return nil, err
+	}
+	if !held {
+		return nil, ipamapi.ErrNoAvailableIPs
+	}
+	return generateAddress(pref, nw), nil
+}
+
 // ReleaseAddress releases the address from the specified pool ID
 func (a *Allocator) ReleaseAddress(poolID string, address net.IP) error {
 	logrus.Debugf("ReleaseAddress(%s, %v)", poolID, address)
@@ -536,6 +795,16 @@ func (a *Allocator) ReleaseAddress(poolID string, address net.IP) error {
 		return ipamapi.ErrIPOutOfRange
 	}
 
+	for _, ip := range p.Reservations {
+		if ip.Equal(address) {
+			// The address remains allocated for the name it is reserved for.
+			aSpace.Unlock()
+			return nil
+		}
+	}
+	holdBack := p.Strategy == ipamapi.StrategyLowestFree && p.ReleaseDelay > 0
+
+	poolKey := k
 	c := p
 	for c.Range != nil {
 		k = c.ParentKey
@@ -557,23 +826,38 @@ func (a *Allocator) ReleaseAddress(poolID string, address net.IP) error {
 	}
 	defer logrus.Debugf("Released address PoolID:%s, Address:%v Sequence:%s", poolID, address, bm.String())
 
-	return bm.Unset(ipToUint64(h))
+	ordinal := ipToUint64(h)
+	if holdBack && bm.IsSet(ordinal) {
+		// Keep the address allocated until the release delay elapses.
+		return a.updatePool(poolKey, func(p *PoolData) (bool, error) {
+			if p.Released == nil {
+				p.Released = make(map[uint64]time.Time)
+			}
+			p.Released[ordinal] = time.Now()
+			return true, nil
+		})
+	}
+
+	return bm.Unset(ordinal)
 }
 
-func (a *Allocator) getAddress(nw *net.IPNet, bitmask *bitseq.Handle, prefAddress net.IP, ipr *AddressRange, serial bool) (net.IP, error) {
+func (a *Allocator) getAddress(nw *net.IPNet, bitmask *bitseq.Handle, prefAddress net.IP, ipr *AddressRange, strategy string) (net.IP, error) {
 	var (
 		ordinal uint64
 		err     error
 		base    *net.IPNet
 	)
 
-	logrus.Debugf("Request address PoolID:%v %s Serial:%v PrefAddress:%v ", nw, bitmask.String(), serial, prefAddress)
+	serial := strategy == ipamapi.StrategySequential
+	logrus.Debugf("Request address PoolID:%v %s Strategy:%v PrefAddress:%v ", nw, bitmask.String(), strategy, prefAddress)
 	base = types.GetIPNetCopy(nw)
 
 	if bitmask.Unselected() == 0 {
 		return nil, ipamapi.ErrNoAvailableIPs
 	}
-	if ipr == nil && prefAddress == nil {
+	if ipr == nil && prefAddress == nil && strategy == ipamapi.StrategyRandom {
+		ordinal, err = setRandom(bitmask, 0, bitmask.Bits()-1)
+	} else if ipr == nil && prefAddress == nil {
 		ordinal, err = bitmask.SetAny(serial)
 	} else if prefAddress != nil {
 		hostPart, e := types.GetHostPartIP(prefAddress, base.Mask)
@@ -582,6 +866,8 @@ func (a *Allocator) getAddress(nw *net.IPNet, bitmask *bitseq.Handle, prefAddres
 		}
 		ordinal = ipToUint64(types.GetMinimalIP(hostPart))
 		err = bitmask.Set(ordinal)
+	} else if strategy == ipamapi.StrategyRandom {
+		ordinal, err = setRandom(bitmask, ipr.Start, ipr.End)
 	} else {
 		ordinal, err = bitmask.SetAnyInRange(ipr.Start, ipr.End, serial)
 	}
@@ -599,6 +885,20 @@ func (a *Allocator) getAddress(nw *net.IPNet, bitmask *bitseq.Handle, prefAddres
 	}
 }
 
+// setRandom sets the first unset bit of the bitmask from a random ordinal
+// in the range [start, end], wrapping around to start.
+func setRandom(bitmask *bitseq.Handle, start, end uint64) (uint64, error) {
+	from := rand.Uint64()
+	if n := end - start + 1; n != 0 {
+		from = start + from%n
+	}
+	ordinal, err := bitmask.SetAnyInRange(from, end, false)
+	if err == bitseq.ErrNoBitAvailable && from > start {
+		ordinal, err = bitmask.SetAnyInRange(start, from-1, false)
+	}
+	return ordinal, err
+}
+
 // DumpDatabase dumps the internal info
 func (a *Allocator) DumpDatabase() string {
 	a.Lock()
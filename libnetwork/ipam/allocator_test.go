@@ -1205,7 +1205,7 @@ func assertGetAddress(t *testing.T, subnet string) {
 	start := time.Now()
 	run := 0
 	for err != ipamapi.ErrNoAvailableIPs {
-		_, err = a.getAddress(sub, bm, nil, nil, false)
+		_, err = a.getAddress(sub, bm, nil, nil, "")
 		run++
 	}
 	if printTime {
@@ -1595,6 +1595,184 @@ func TestRequestReleaseAddressDuplicate(t *testing.T) {
 	}
 }
 
+func TestAllocationStrategies(t *testing.T) {
+	for _, store := range []bool{false, true} {
+		a, err := getAllocator(store)
+		assert.NilError(t, err)
+
+		// The sequential strategy does not reuse the released address.
+		pid, _, _, err := a.RequestPool(localAddressSpace, "172.30.1.0/24", "", map[string]string{
+			ipamapi.AllocStrategy: ipamapi.StrategySequential,
+		}, false)
+		assert.NilError(t, err)
+		ip, _, err := a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.1.1/24"))
+		assert.NilError(t, a.ReleaseAddress(pid, ip.IP))
+		ip, _, err = a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.1.2/24"))
+
+		// The random strategy allocates every address of the range once.
+		pid, _, _, err = a.RequestPool(localAddressSpace, "172.30.2.0/24", "172.30.2.16/28", map[string]string{
+			ipamapi.AllocStrategy: ipamapi.StrategyRandom,
+		}, false)
+		assert.NilError(t, err)
+		seen := map[string]bool{}
+		for i := 0; i < 16; i++ {
+			ip, _, err := a.RequestAddress(pid, nil, nil)
+			assert.NilError(t, err)
+			_, sub, _ := net.ParseCIDR("172.30.2.16/28")
+			assert.Check(t, sub.Contains(ip.IP), ip)
+			assert.Check(t, !seen[ip.String()], ip)
+			seen[ip.String()] = true
+		}
+		_, _, err = a.RequestAddress(pid, nil, nil)
+		assert.Check(t, is.Equal(err, ipamapi.ErrNoAvailableIPs))
+
+		// The lowest-free strategy holds back the released addresses.
+		pid, _, _, err = a.RequestPool(localAddressSpace, "172.30.3.0/29", "", map[string]string{
+			ipamapi.AllocStrategy:     ipamapi.StrategyLowestFree,
+			ipamapi.AllocReleaseDelay: "1h",
+		}, false)
+		assert.NilError(t, err)
+		for i := 1; i <= 6; i++ {
+			ip, _, err := a.RequestAddress(pid, nil, nil)
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(ip.String(), fmt.Sprintf("172.30.3.%d/29", i)))
+		}
+		assert.NilError(t, a.ReleaseAddress(pid, net.ParseIP("172.30.3.2")))
+		assert.NilError(t, a.ReleaseAddress(pid, net.ParseIP("172.30.3.4")))
+		assert.NilError(t, a.ReleaseAddress(pid, net.ParseIP("172.30.3.6")))
+		// The held back address is given when requested.
+		ip, _, err = a.RequestAddress(pid, net.ParseIP("172.30.3.6"), nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.3.6/29"))
+		// Or when no other address is available, the oldest first.
+		ip, _, err = a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.3.2/29"))
+		ip, _, err = a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.3.4/29"))
+		_, _, err = a.RequestAddress(pid, nil, nil)
+		assert.Check(t, is.Equal(err, ipamapi.ErrNoAvailableIPs))
+
+		// The released addresses are freed once the delay elapsed.
+		pid, _, _, err = a.RequestPool(localAddressSpace, "172.30.4.0/24", "", map[string]string{
+			ipamapi.AllocStrategy:     ipamapi.StrategyLowestFree,
+			ipamapi.AllocReleaseDelay: "1ms",
+		}, false)
+		assert.NilError(t, err)
+		ip, _, err = a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.NilError(t, a.ReleaseAddress(pid, ip.IP))
+		ip, _, err = a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.4.2/24"))
+		time.Sleep(10 * time.Millisecond)
+		ip, _, err = a.RequestAddress(pid, nil, nil)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.30.4.1/24"))
+
+		_, _, _, err = a.RequestPool(localAddressSpace, "172.30.5.0/24", "", map[string]string{
+			ipamapi.AllocStrategy: "fastest",
+		}, false)
+		assert.Check(t, is.ErrorContains(err, "invalid allocation strategy"))
+		_, _, _, err = a.RequestPool(localAddressSpace, "172.30.5.0/24", "", map[string]string{
+			ipamapi.AllocReleaseDelay: "1m",
+		}, false)
+		assert.Check(t, is.ErrorContains(err, "requires the lowest-free allocation strategy"))
+	}
+}
+
+func TestAddressReservations(t *testing.T) {
+	for _, store := range []bool{false, true} {
+		a, err := getAllocator(store)
+		assert.NilError(t, err)
+
+		opts := map[string]string{
+			ipamapi.ReservationPrefix + "web": "172.31.0.3",
+		}
+		pid, _, _, err := a.RequestPool(localAddressSpace, "172.31.0.0/29", "", opts, false)
+		assert.NilError(t, err)
+
+		// The reserved address is only given for its name.
+		for i, exp := range []string{"172.31.0.1/29", "172.31.0.2/29", "172.31.0.4/29"} {
+			ip, _, err := a.RequestAddress(pid, nil, nil)
+			assert.NilError(t, err, i)
+			assert.Check(t, is.Equal(ip.String(), exp))
+		}
+		_, _, err = a.RequestAddress(pid, net.ParseIP("172.31.0.3"), nil)
+		assert.Check(t, is.Equal(err, ipamapi.ErrIPAlreadyAllocated))
+
+		web := map[string]string{ipamapi.AllocReservedFor: "web"}
+		ip, _, err := a.RequestAddress(pid, nil, web)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.31.0.3/29"))
+		_, _, err = a.RequestAddress(pid, net.ParseIP("172.31.0.5"), web)
+		assert.Check(t, is.ErrorContains(err, "reserved for web"))
+
+		// It remains reserved once released.
+		assert.NilError(t, a.ReleaseAddress(pid, ip.IP))
+		_, _, err = a.RequestAddress(pid, net.ParseIP("172.31.0.3"), nil)
+		assert.Check(t, is.Equal(err, ipamapi.ErrIPAlreadyAllocated))
+		ip, _, err = a.RequestAddress(pid, net.ParseIP("172.31.0.3"), web)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.31.0.3/29"))
+
+		// A pool given an IP range shares the reservations of the pool it
+		// replaces.
+		sid, _, _, err := a.RequestPool(localAddressSpace, "172.31.0.0/29", "172.31.0.4/30", opts, false)
+		assert.NilError(t, err)
+		assert.NilError(t, a.ReleasePool(pid))
+		ip, _, err = a.RequestAddress(sid, nil, web)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(ip.String(), "172.31.0.3/29"))
+		_, _, err = a.RequestAddress(sid, net.ParseIP("172.31.0.3"), nil)
+		assert.Check(t, is.Equal(err, ipamapi.ErrIPAlreadyAllocated))
+		assert.NilError(t, a.ReleasePool(sid))
+
+		// The reserved addresses must be in the pool and available.
+		_, _, _, err = a.RequestPool(localAddressSpace, "172.31.1.0/24", "", map[string]string{
+			ipamapi.ReservationPrefix + "web": "172.31.2.3",
+		}, false)
+		assert.Check(t, is.ErrorContains(err, "is not in pool"))
+		_, _, _, err = a.RequestPool(localAddressSpace, "172.31.1.0/24", "", map[string]string{
+			ipamapi.ReservationPrefix + "web": "172.31.1.0",
+		}, false)
+		assert.Check(t, is.ErrorContains(err, "is already allocated"))
+		// The pool was released on failure.
+		_, _, _, err = a.RequestPool(localAddressSpace, "172.31.1.0/24", "", nil, false)
+		assert.NilError(t, err)
+	}
+}
+
+func TestPoolDataMarshalAllocation(t *testing.T) {
+	_, nw, err := net.ParseCIDR("172.28.30.1/24")
+	assert.NilError(t, err)
+
+	released := time.Now().UTC().Round(time.Second)
+	p := &PoolData{
+		Pool:         nw,
+		RefCount:     1,
+		Strategy:     ipamapi.StrategyLowestFree,
+		ReleaseDelay: time.Minute,
+		Reservations: map[string]net.IP{"web": net.IPv4(172, 28, 30, 10).To4()},
+		Released:     map[uint64]time.Time{5: released},
+	}
+
+	ba, err := json.Marshal(p)
+	assert.NilError(t, err)
+	var q PoolData
+	assert.NilError(t, json.Unmarshal(ba, &q))
+	assert.Check(t, is.DeepEqual(p, &q))
+
+	var c PoolData
+	assert.NilError(t, p.CopyTo(&c))
+	assert.Check(t, is.DeepEqual(p, &c))
+}
+
 func TestParallelPredefinedRequest1(t *testing.T) {
 	runParallelTests(t, 0)
 }
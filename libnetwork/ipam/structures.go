@@ -6,6 +6,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/docker/libnetwork/datastore"
 	"github.com/docker/docker/libnetwork/ipamapi"
@@ -25,6 +26,25 @@ type PoolData struct {
 	Pool      *net.IPNet
 	Range     *AddressRange `json:",omitempty"`
 	RefCount  int
+	// Strategy is how the addresses of the pool are allocated, one of the
+	// ipamapi strategies. It defaults to the first available address.
+	Strategy string
+	// ReleaseDelay is how long the lowest-free strategy holds back the
+	// released addresses.
+	ReleaseDelay time.Duration
+	// Reservations maps names to the address reserved for them.
+	Reservations map[string]net.IP
+	// Released maps the ordinals of the addresses held back by the
+	// lowest-free strategy to the time they were released.
+	Released map[uint64]time.Time
+}
+
+// poolOptions are the allocation settings of a pool, parsed from the
+// options of its request.
+type poolOptions struct {
+	strategy     string
+	releaseDelay time.Duration
+	reservations map[string]net.IP
 }
 
 // addrSpace contains the pool configurations for the address space
@@ -122,6 +142,22 @@ func (p *PoolData) MarshalJSON() ([]byte, error) {
 	if p.Range != nil {
 		m["Range"] = p.Range
 	}
+	if p.Strategy != "" {
+		m["Strategy"] = p.Strategy
+	}
+	if p.ReleaseDelay != 0 {
+		m["ReleaseDelay"] = p.ReleaseDelay
+	}
+	if len(p.Reservations) > 0 {
+		r := make(map[string]string, len(p.Reservations))
+		for name, ip := range p.Reservations {
+			r[name] = ip.String()
+		}
+		m["Reservations"] = r
+	}
+	if len(p.Released) > 0 {
+		m["Released"] = p.Released
+	}
 	return json.Marshal(m)
 }
 
@@ -130,10 +166,14 @@ func (p *PoolData) UnmarshalJSON(data []byte) error {
 	var (
 		err error
 		t   struct {
-			ParentKey SubnetKey
-			Pool      string
-			Range     *AddressRange `json:",omitempty"`
-			RefCount  int
+			ParentKey    SubnetKey
+			Pool         string
+			Range        *AddressRange `json:",omitempty"`
+			RefCount     int
+			Strategy     string
+			ReleaseDelay time.Duration
+			Reservations map[string]string
+			Released     map[uint64]time.Time
 		}
 	)
 
@@ -149,6 +189,23 @@ func (p *PoolData) UnmarshalJSON(data []byte) error {
 			return err
 		}
 	}
+	p.Strategy = t.Strategy
+	p.ReleaseDelay = t.ReleaseDelay
+	p.Reservations = nil
+	if len(t.Reservations) > 0 {
+		p.Reservations = make(map[string]net.IP, len(t.Reservations))
+		for name, s := range t.Reservations {
+			ip := net.ParseIP(s)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			if ip == nil {
+				return fmt.Errorf("invalid address %q reserved for %s", s, name)
+			}
+			p.Reservations[name] = ip
+		}
+	}
+	p.Released = t.Released
 
 	return nil
 }
@@ -220,9 +277,43 @@ func (p *PoolData) CopyTo(dstP *PoolData) error {
 	}
 
 	dstP.RefCount = p.RefCount
+	dstP.Strategy = p.Strategy
+	dstP.ReleaseDelay = p.ReleaseDelay
+
+	dstP.Reservations = nil
+	if p.Reservations != nil {
+		dstP.Reservations = make(map[string]net.IP, len(p.Reservations))
+		for name, ip := range p.Reservations {
+			dstP.Reservations[name] = types.GetIPCopy(ip)
+		}
+	}
+
+	dstP.Released = nil
+	if p.Released != nil {
+		dstP.Released = make(map[uint64]time.Time, len(p.Released))
+		for o, t := range p.Released {
+			dstP.Released[o] = t
+		}
+	}
 	return nil
 }
 
+// heldOrdinals returns the ordinals of the addresses of the parent pool
+// held by the pool: the reserved addresses, and the released addresses
+// held back by the lowest-free strategy.
+func (p *PoolData) heldOrdinals() []uint64 {
+	var ordinals []uint64
+	for _, ip := range p.Reservations {
+		if o, err := getOrdinal(ip, p.Pool); err == nil {
+			ordinals = append(ordinals, o)
+		}
+	}
+	for o := range p.Released {
+		ordinals = append(ordinals, o)
+	}
+	return ordinals
+}
+
 func (aSpace *addrSpace) CopyTo(o datastore.KVObject) error {
 	aSpace.Lock()
 	defer aSpace.Unlock()
@@ -258,7 +349,7 @@ func (aSpace *addrSpace) New() datastore.KVObject {
 }
 
 // updatePoolDBOnAdd returns a closure which will add the subnet k to the address space when executed.
-func (aSpace *addrSpace) updatePoolDBOnAdd(k SubnetKey, nw *net.IPNet, ipr *AddressRange, pdf bool) (func() error, error) {
+func (aSpace *addrSpace) updatePoolDBOnAdd(k SubnetKey, nw *net.IPNet, ipr *AddressRange, pdf bool, opts poolOptions) (func() error, error) {
 	aSpace.Lock()
 	defer aSpace.Unlock()
 
@@ -278,7 +369,13 @@ func (aSpace *addrSpace) updatePoolDBOnAdd(k SubnetKey, nw *net.IPNet, ipr *Addr
 			return nil, ipamapi.ErrPoolOverlap
 		}
 		// This is a new master pool, add it along with corresponding bitmask
-		aSpace.subnets[k] = &PoolData{Pool: nw, RefCount: 1}
+		aSpace.subnets[k] = &PoolData{
+			Pool:         nw,
+			RefCount:     1,
+			Strategy:     opts.strategy,
+			ReleaseDelay: opts.releaseDelay,
+			Reservations: opts.reservations,
+		}
 		return func() error { return aSpace.alloc.insertBitMask(k, nw) }, nil
 	}
 
@@ -288,6 +385,10 @@ func (aSpace *addrSpace) updatePoolDBOnAdd(k SubnetKey, nw *net.IPNet, ipr *Addr
 		Pool:      nw,
 		Range:     ipr,
 		RefCount:  1,
+
+		Strategy:     opts.strategy,
+		ReleaseDelay: opts.releaseDelay,
+		Reservations: opts.reservations,
 	}
 	aSpace.subnets[k] = p
 
@@ -332,9 +433,66 @@ func (aSpace *addrSpace) updatePoolDBOnRemoval(k SubnetKey) (func() error, error
 		c, ok = aSpace.subnets[k]
 	}
 
+	// The parent pool remains, free the addresses the subpool held in it,
+	// unless they are reserved by another of its pools.
+	if p.RefCount == 0 && p.Range != nil {
+		pk, pp := p.ParentKey, aSpace.subnets[p.ParentKey]
+		var held []uint64
+		for _, o := range p.heldOrdinals() {
+			if !aSpace.reservesOrdinal(pk, o) {
+				held = append(held, o)
+			}
+		}
+		if len(held) == 0 {
+			return func() error { return nil }, nil
+		}
+		return func() error {
+			bm, err := aSpace.alloc.retrieveBitmask(pk, pp.Pool)
+			if err != nil {
+				return types.InternalErrorf("could not find bitmask in datastore for pool %s removal: %v", pk.String(), err)
+			}
+			for _, o := range held {
+				if err := bm.Unset(o); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+
 	return func() error { return nil }, nil
 }
 
+// sharesReservation returns whether a pool of the master pool mk other than
+// the pool k reserves the address ip for the name.
+func (aSpace *addrSpace) sharesReservation(mk, k SubnetKey, name string, ip net.IP) bool {
+	for sk, p := range aSpace.subnets {
+		if sk == k || (sk != mk && p.ParentKey != mk) {
+			continue
+		}
+		if rip, ok := p.Reservations[name]; ok && rip.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// reservesOrdinal returns whether a pool of the master pool mk reserves the
+// address with the ordinal.
+func (aSpace *addrSpace) reservesOrdinal(mk SubnetKey, ordinal uint64) bool {
+	for sk, p := range aSpace.subnets {
+		if sk != mk && p.ParentKey != mk {
+			continue
+		}
+		for _, ip := range p.Reservations {
+			if o, err := getOrdinal(ip, p.Pool); err == nil && o == ordinal {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (aSpace *addrSpace) incRefCount(p *PoolData, delta int) {
 	c := p
 	ok := true
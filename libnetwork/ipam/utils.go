@@ -36,6 +36,15 @@ func getAddressRange(pool string, masterNw *net.IPNet) (*AddressRange, error) {
 	return &AddressRange{nw, ipToUint64(types.GetMinimalIP(lIP)), ipToUint64(types.GetMinimalIP(hIP))}, nil
 }
 
+// getOrdinal returns the ordinal of the address ip in the network.
+func getOrdinal(ip net.IP, network *net.IPNet) (uint64, error) {
+	h, err := types.GetHostPartIP(ip, network.Mask)
+	if err != nil {
+		return 0, err
+	}
+	return ipToUint64(h), nil
+}
+
 // It generates the ip address in the passed subnet specified by
 // the passed host address ordinal
 func generateAddress(ordinal uint64, network *net.IPNet) net.IP {
@@ -7,4 +7,34 @@ const (
 	// AllocSerialPrefix constant marks the reserved label space for libnetwork ipam
 	// allocation ordering.(serial/first available)
 	AllocSerialPrefix = Prefix + ".ipam.serial"
+
+	// AllocStrategy constant marks the option selecting how the addresses of
+	// a pool are allocated, one of the strategies below. By default, the
+	// first available address is allocated. It is given with the request of
+	// each pool, so the pools of a network can use different strategies.
+	AllocStrategy = Prefix + ".ipam.strategy"
+
+	// AllocReleaseDelay constant marks the option setting how long the
+	// lowest-free strategy holds back the released addresses, as a duration.
+	AllocReleaseDelay = Prefix + ".ipam.release_delay"
+
+	// ReservationPrefix constant marks the options of a pool request reserving
+	// an address of the pool, the value, for the name following the prefix.
+	ReservationPrefix = Prefix + ".ipam.reservation."
+
+	// AllocReservedFor constant marks the option of an address request for
+	// the address reserved for the name, if any.
+	AllocReservedFor = Prefix + ".ipam.reserved_for"
+)
+
+// Allocation strategies of the AllocStrategy option
+const (
+	// StrategySequential allocates the addresses of the pool in sequence,
+	// wrapping around to its first address.
+	StrategySequential = "sequential"
+	// StrategyRandom allocates the addresses of the pool randomly.
+	StrategyRandom = "random"
+	// StrategyLowestFree allocates the lowest available address of the pool
+	// which was not released within the release delay.
+	StrategyLowestFree = "lowest-free"
 )
@@ -2,11 +2,23 @@
 package ipamutils
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"net"
 	"sync"
 )
 
+const (
+	// ulaPrefixSize is the size of the unique local IPv6 prefix of a host.
+	ulaPrefixSize = 48
+	// ulaSubnetSize is the size of the subnets the unique local IPv6 prefix
+	// of a host is split in.
+	ulaSubnetSize = 64
+	// maxSplitBits limits the number of subnets a pool is split in to
+	// 2^maxSplitBits, as they are all held in memory.
+	maxSplitBits = 24
+)
+
 var (
 	// PredefinedLocalScopeDefaultNetworks contains a list of 31 IPv4 private networks with host size 16 and 12
 	// (172.17-31.x.x/16, 192.168.x.x/20) which do not overlap with the networks in `PredefinedGlobalScopeDefaultNetworks`
@@ -86,6 +98,29 @@ func ConfigLocalScopeDefaultNetworks(defaultAddressPool []*NetworkToSplit) error
 	return configDefaultNetworks(defaultAddressPool, &PredefinedLocalScopeDefaultNetworks)
 }
 
+// LocalScopeDefaultPools returns the pools the local scope default networks
+// are split from, unless configured otherwise.
+func LocalScopeDefaultPools() []*NetworkToSplit {
+	pools := make([]*NetworkToSplit, 0, len(localScopeDefaultNetworks))
+	for _, p := range localScopeDefaultNetworks {
+		pools = append(pools, &NetworkToSplit{Base: p.Base, Size: p.Size})
+	}
+	return pools
+}
+
+// ULAPool returns the unique local IPv6 prefix (RFC 4193) of the host
+// identified by id, split in /64 subnets. The global ID of the /48 prefix
+// is derived from id, so that it is stable for the host while unlikely to be
+// used by other hosts.
+func ULAPool(id string) *NetworkToSplit {
+	sum := sha256.Sum256([]byte(id))
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfd
+	copy(ip[1:6], sum[:5])
+	base := &net.IPNet{IP: ip, Mask: net.CIDRMask(ulaPrefixSize, 8*net.IPv6len)}
+	return &NetworkToSplit{Base: base.String(), Size: ulaSubnetSize}
+}
+
 // splitNetworks takes a slice of networks, split them accordingly and returns them
 func splitNetworks(list []*NetworkToSplit) ([]*net.IPNet, error) {
 	localPools := make([]*net.IPNet, 0, len(list))
@@ -95,10 +130,13 @@ func splitNetworks(list []*NetworkToSplit) ([]*net.IPNet, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid base pool %q: %v", p.Base, err)
 		}
-		ones, _ := b.Mask.Size()
-		if p.Size <= 0 || p.Size < ones {
+		ones, bits := b.Mask.Size()
+		if p.Size <= 0 || p.Size < ones || p.Size > bits {
 			return nil, fmt.Errorf("invalid pools size: %d", p.Size)
 		}
+		if p.Size-ones > maxSplitBits {
+			return nil, fmt.Errorf("base pool %q cannot be split in more than %d subnets of size %d", p.Base, 1<<maxSplitBits, p.Size)
+		}
 		localPools = append(localPools, splitNetwork(p.Size, b)...)
 	}
 	return localPools, nil
@@ -113,7 +151,7 @@ func splitNetwork(size int, base *net.IPNet) []*net.IPNet {
 
 	for i := 0; i < n; i++ {
 		ip := copyIP(base.IP)
-		addIntToIP(ip, uint(i<<s))
+		addIntToIP(ip, uint(i), s)
 		list = append(list, &net.IPNet{IP: ip, Mask: mask})
 	}
 	return list
@@ -125,8 +163,11 @@ func copyIP(from net.IP) net.IP {
 	return ip
 }
 
-func addIntToIP(array net.IP, ordinal uint) {
-	for i := len(array) - 1; i >= 0; i-- {
+// addIntToIP adds ordinal, shifted left by shift bits, to the address. The
+// shift may exceed the width of ordinal, to split IPv6 prefixes.
+func addIntToIP(array net.IP, ordinal uint, shift uint) {
+	ordinal <<= shift % 8
+	for i := len(array) - 1 - int(shift/8); i >= 0; i-- {
 		array[i] |= (byte)(ordinal & 0xff)
 		ordinal >>= 8
 	}
@@ -115,3 +115,38 @@ func TestInitAddressPools(t *testing.T) {
 	assert.Check(t, is.Equal(PredefinedLocalScopeDefaultNetworks[383].String(), "172.90.127.0/24"))
 	assert.Check(t, is.Equal(PredefinedLocalScopeDefaultNetworks[511].String(), "172.90.255.0/24"))
 }
+
+func TestULAPool(t *testing.T) {
+	p := ULAPool("host1")
+	assert.Check(t, is.DeepEqual(p, ULAPool("host1")))
+	assert.Check(t, p.Base != ULAPool("host2").Base)
+	assert.Check(t, is.Equal(p.Size, 64))
+
+	_, base, err := net.ParseCIDR(p.Base)
+	assert.NilError(t, err)
+	_, ula, _ := net.ParseCIDR("fd00::/8")
+	assert.Check(t, ula.Contains(base.IP))
+	ones, _ := base.Mask.Size()
+	assert.Check(t, is.Equal(ones, 48))
+
+	nws, err := splitNetworks([]*NetworkToSplit{p})
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(nws, 1<<16))
+	assert.Check(t, is.Equal(nws[0].String(), base.IP.String()+"/64"))
+	for _, nw := range nws {
+		assert.Assert(t, base.Contains(nw.IP))
+	}
+}
+
+func TestSplitIPv6Networks(t *testing.T) {
+	nws, err := splitNetworks([]*NetworkToSplit{{"2001:db8:1:100::/56", 64}})
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(nws, 256))
+	assert.Check(t, is.Equal(nws[1].String(), "2001:db8:1:101::/64"))
+	assert.Check(t, is.Equal(nws[255].String(), "2001:db8:1:1ff::/64"))
+
+	_, err = splitNetworks([]*NetworkToSplit{{"2001:db8::/32", 64}})
+	assert.Check(t, is.ErrorContains(err, "cannot be split in more than"))
+	_, err = splitNetworks([]*NetworkToSplit{{"2001:db8::/56", 129}})
+	assert.Check(t, is.ErrorContains(err, "invalid pools size"))
+}
@@ -24,10 +24,11 @@ const (
 )
 
 const (
-	dbusInterface  = "org.fedoraproject.FirewallD1"
-	dbusPath       = "/org/fedoraproject/FirewallD1"
-	dbusConfigPath = "/org/fedoraproject/FirewallD1/config"
-	dockerZone     = "docker"
+	dbusInterface   = "org.fedoraproject.FirewallD1"
+	dbusPath        = "/org/fedoraproject/FirewallD1"
+	dbusConfigPath  = "/org/fedoraproject/FirewallD1/config"
+	dockerZone      = "docker"
+	dockerFwdPolicy = "docker-forwarding"
 )
 
 // Conn is a connection to firewalld dbus endpoint.
@@ -176,6 +177,14 @@ func OnReloaded(callback func()) {
 	onReloaded = append(onReloaded, &callback)
 }
 
+// UsingFirewalld returns whether firewalld is running, initializing the
+// firewalld management code if needed. Unlike the functions programming
+// iptables, it does not require iptables.
+func UsingFirewalld() bool {
+	firewalldOnce.Do(initFirewalld)
+	return firewalldRunning
+}
+
 // Call some remote method to see whether the service is actually running.
 func checkRunning() bool {
 	var zone string
@@ -253,6 +262,37 @@ func setupDockerZone() error {
 	return nil
 }
 
+// SetupDockerForwardingPolicy creates a policy in firewalld accepting the
+// connections forwarded to the interfaces of the docker zone, if missing.
+// Firewalld would otherwise reject the connections to the containers when
+// the rules of docker are not in the iptables chains it runs, as is the case
+// with the nftables firewall backend. Policies require firewalld 0.9.0.
+func SetupDockerForwardingPolicy() error {
+	var policies []string
+	if err := connection.sysObj.Call(dbusInterface+".policy.getPolicies", 0).Store(&policies); err != nil {
+		return err
+	}
+	if contains(policies, dockerFwdPolicy) {
+		logrus.Infof("Firewalld: %s policy already exists, returning", dockerFwdPolicy)
+		return nil
+	}
+	logrus.Debugf("Firewalld: creating %s policy", dockerFwdPolicy)
+
+	settings := map[string]dbus.Variant{
+		"short":         dbus.MakeVariant("Docker forwarding policy"),
+		"description":   dbus.MakeVariant("allow forwarding to the docker zone"),
+		"ingress_zones": dbus.MakeVariant([]string{"ANY"}),
+		"egress_zones":  dbus.MakeVariant([]string{dockerZone}),
+		"target":        dbus.MakeVariant("ACCEPT"),
+	}
+	// Permanent
+	if err := connection.sysConfObj.Call(dbusInterface+".config.addPolicy", 0, dockerFwdPolicy, settings).Err; err != nil {
+		return err
+	}
+	// Reload for change to take effect
+	return connection.sysObj.Call(dbusInterface+".reload", 0).Err
+}
+
 // AddInterfaceFirewalld adds the interface to the trusted zone
 func AddInterfaceFirewalld(intf string) error {
 	var intfs []string
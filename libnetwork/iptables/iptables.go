@@ -64,6 +64,7 @@ var (
 	// ErrIptablesNotFound is returned when the rule is not found.
 	ErrIptablesNotFound = errors.New("Iptables not found")
 	initOnce            sync.Once
+	firewalldOnce       sync.Once
 )
 
 // IPTable defines struct with IPVersion
@@ -139,7 +140,7 @@ func detectIptables() {
 
 func initDependencies() {
 	probe()
-	initFirewalld()
+	firewalldOnce.Do(initFirewalld)
 	detectIptables()
 }
 
@@ -226,7 +227,9 @@ func (iptable IPTable) ProgramChain(c *ChainInfo, bridgeName string, hairpinMode
 			"-m", "addrtype",
 			"--dst-type", "LOCAL",
 			"-j", c.Name}
-		if !hairpinMode {
+		// There is no equivalent of route_localnet for IPv6: connections to
+		// the loopback address are left to the listener of the port mapper.
+		if !hairpinMode || iptable.Version == IPv6 {
 			output = append(output, "!", "--dst", iptable.LoopbackByVersion())
 		}
 		if !iptable.Exists(Nat, "OUTPUT", output...) && enable {
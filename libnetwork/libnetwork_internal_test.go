@@ -8,15 +8,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/libnetwork/config"
 	"github.com/docker/docker/libnetwork/datastore"
 	"github.com/docker/docker/libnetwork/discoverapi"
 	"github.com/docker/docker/libnetwork/driverapi"
 	"github.com/docker/docker/libnetwork/internal/setmatrix"
 	"github.com/docker/docker/libnetwork/ipamapi"
+	"github.com/docker/docker/libnetwork/ipamutils"
 	"github.com/docker/docker/libnetwork/netlabel"
 	"github.com/docker/docker/libnetwork/netutils"
 	"github.com/docker/docker/libnetwork/testutils"
 	"github.com/docker/docker/libnetwork/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/skip"
 )
 
@@ -206,6 +210,9 @@ func TestEndpointMarshalling(t *testing.T) {
 			v6PoolID:  "poolv6",
 			llAddrs:   lla,
 		},
+		generic: map[string]interface{}{
+			netlabel.Bandwidth: &types.Bandwidth{IngressRate: 1 << 20, EgressRate: 1 << 19, EgressBurst: 1 << 16, Priority: 2},
+		},
 	}
 
 	b, err := json.Marshal(e)
@@ -222,6 +229,9 @@ func TestEndpointMarshalling(t *testing.T) {
 	if e.name != ee.name || e.id != ee.id || e.sandboxID != ee.sandboxID || !compareEndpointInterface(e.iface, ee.iface) || e.anonymous != ee.anonymous {
 		t.Fatalf("JSON marsh/unmarsh failed.\nOriginal:\n%#v\nDecoded:\n%#v\nOriginal iface: %#v\nDecodediface:\n%#v", e, ee, e.iface, ee.iface)
 	}
+	if bw := ee.Bandwidth(); bw == nil || *bw != *e.Bandwidth() {
+		t.Fatalf("JSON marsh/unmarsh failed.\nOriginal bandwidth: %#v\nDecoded bandwidth: %#v", e.Bandwidth(), bw)
+	}
 }
 
 func compareEndpointInterface(a, b *endpointInterface) bool {
@@ -346,6 +356,36 @@ func TestAuxAddresses(t *testing.T) {
 	}
 }
 
+func TestDefaultIPv6Pools(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	ula := ipamutils.ULAPool("host")
+	c, err := New(config.OptionDefaultAddressPoolConfig(append(ipamutils.LocalScopeDefaultPools(), ula)))
+	assert.NilError(t, err)
+	defer c.Stop()
+
+	_, prefix, err := net.ParseCIDR(ula.Base)
+	assert.NilError(t, err)
+
+	// The networks with IPv6 enabled are given distinct subnets of the
+	// unique local prefix.
+	var subnets []string
+	for i := 0; i < 2; i++ {
+		n := &network{ipamType: ipamapi.DefaultIPAM, networkType: "bridge", ctrlr: c.(*controller), enableIPv6: true}
+		assert.NilError(t, n.ipamAllocate())
+		defer n.ipamRelease()
+
+		assert.Assert(t, is.Len(n.ipamV6Info, 1))
+		pool := n.ipamV6Info[0].Pool
+		assert.Check(t, prefix.Contains(pool.IP), pool)
+		ones, _ := pool.Mask.Size()
+		assert.Check(t, is.Equal(ones, 64))
+		assert.Check(t, n.ipamV4Info[0].Pool.IP.To4() != nil)
+		subnets = append(subnets, pool.String())
+	}
+	assert.Check(t, subnets[0] != subnets[1])
+}
+
 func TestSRVServiceQuery(t *testing.T) {
 	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
 
@@ -559,6 +599,65 @@ func TestServiceVIPReuse(t *testing.T) {
 	}
 }
 
+func TestResolveNameUnreadyEndpoint(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	n, err := c.NewNetwork("bridge", "net1", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := n.Delete(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Two tasks of a service sharing the "web" alias, whose records are
+	// those of the service.
+	nw := n.(*network)
+	nw.addSvcRecords("ep1", "Web1", "ep1", net.ParseIP("192.168.0.1"), net.IP{}, true, "test")
+	nw.addSvcRecords("ep1", "web", "svc1", net.ParseIP("192.168.0.1"), net.IP{}, false, "test")
+	nw.addSvcRecords("ep2", "web2", "ep2", net.ParseIP("192.168.0.2"), net.IP{}, true, "test")
+	nw.addSvcRecords("ep2", "web", "svc1", net.ParseIP("192.168.0.2"), net.IP{}, false, "test")
+
+	ipList, _ := nw.ResolveName("web", types.IPv4)
+	if len(ipList) != 2 {
+		t.Fatalf("Expected 2 addresses for the alias, got %v", ipList)
+	}
+
+	// Unready containers drop out of their aliases, but not their name.
+	c.(*controller).setEndpointReady("ep1", false)
+	ipList, _ = nw.ResolveName("web", types.IPv4)
+	if len(ipList) != 1 || ipList[0].String() != "192.168.0.2" {
+		t.Fatalf("Expected only the ready container's address, got %v", ipList)
+	}
+	ipList, _ = nw.ResolveName("web1", types.IPv4)
+	if len(ipList) != 1 || ipList[0].String() != "192.168.0.1" {
+		t.Fatalf("Expected the unready container to be resolvable by name, got %v", ipList)
+	}
+
+	// If no container is ready, the alias resolves to nothing, and the query
+	// is not forwarded to external resolvers.
+	c.(*controller).setEndpointReady("ep2", false)
+	ipList, ok := nw.ResolveName("web", types.IPv4)
+	if len(ipList) != 0 || !ok {
+		t.Fatalf("Expected an empty answer, got %v, %v", ipList, ok)
+	}
+
+	c.(*controller).setEndpointReady("ep1", true)
+	c.(*controller).setEndpointReady("ep2", true)
+	ipList, _ = nw.ResolveName("web", types.IPv4)
+	if len(ipList) != 2 {
+		t.Fatalf("Expected 2 addresses for the alias, got %v", ipList)
+	}
+}
+
 func TestIpamReleaseOnNetDriverFailures(t *testing.T) {
 	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
 
@@ -906,6 +906,12 @@ func (f *fakeSandbox) DisableService() error {
 	return nil
 }
 
+func (f *fakeSandbox) ExecFunc(func()) error {
+	return nil
+}
+
+func (f *fakeSandbox) SetReady(ready bool) {}
+
 func TestEndpointDeleteWithActiveContainer(t *testing.T) {
 	if !testutils.IsRunningInContainer() {
 		defer testutils.SetupTestOSContext(t)()
@@ -30,6 +30,13 @@ const (
 	// DNSServers A list of DNS servers associated with the endpoint
 	DNSServers = Prefix + ".endpoint.dnsservers"
 
+	// Bandwidth constant represents the bandwidth limits of an endpoint
+	Bandwidth = Prefix + ".endpoint.bandwidth"
+
+	// EndpointLabels constant represents the labels of the container of an
+	// endpoint, which network policies select endpoints by
+	EndpointLabels = Prefix + ".endpoint.labels"
+
 	//EnableIPv6 constant represents enabling IPV6 at network level
 	EnableIPv6 = Prefix + ".enable_ipv6"
 
@@ -45,12 +52,19 @@ const (
 	// OverlayVxlanIDList constant represents a list of VXLAN Ids as csv
 	OverlayVxlanIDList = DriverPrefix + ".overlay.vxlanid_list"
 
+	// OverlayFirewallBackend constant represents the firewall backend
+	// programming the host rules of the overlay driver
+	OverlayFirewallBackend = DriverPrefix + ".overlay.firewall_backend"
+
 	// Gateway represents the gateway for the network
 	Gateway = Prefix + ".gateway"
 
 	// Internal constant represents that the network is internal which disables default gateway service
 	Internal = Prefix + ".internal"
 
+	// Policy constant represents the policy of the communication between the containers of a network
+	Policy = Prefix + ".policy"
+
 	// ContainerIfacePrefix can be used to override the interface prefix used inside the container
 	ContainerIfacePrefix = Prefix + ".container_iface_prefix"
 
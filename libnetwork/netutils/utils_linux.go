@@ -28,12 +28,21 @@ func CheckRouteOverlaps(toCheck *net.IPNet) error {
 	if networkGetRoutesFct == nil {
 		networkGetRoutesFct = ns.NlHandle().RouteList
 	}
-	networks, err := networkGetRoutesFct(nil, netlink.FAMILY_V4)
+	family, v6 := netlink.FAMILY_V4, toCheck.IP.To4() == nil
+	if v6 {
+		family = netlink.FAMILY_V6
+	}
+	networks, err := networkGetRoutesFct(nil, family)
 	if err != nil {
 		return err
 	}
 	for _, network := range networks {
-		if network.Dst != nil && network.Scope == netlink.SCOPE_LINK && NetworkOverlaps(toCheck, network.Dst) {
+		if network.Dst == nil || !NetworkOverlaps(toCheck, network.Dst) {
+			continue
+		}
+		// The IPv6 routes to the networks of the links have the universe
+		// scope, and no gateway.
+		if network.Scope == netlink.SCOPE_LINK || (v6 && network.Gw == nil) {
 			return ErrNetworkOverlaps
 		}
 	}
@@ -95,10 +104,15 @@ func ElectInterfaceAddresses(name string) ([]*net.IPNet, []*net.IPNet, error) {
 
 	if link == nil || len(v4Nets) == 0 {
 		// Choose from predefined local scope networks
-		v4Net, err := FindAvailableNetwork(ipamutils.PredefinedLocalScopeDefaultNetworks)
+		var predefined []*net.IPNet
+		for _, nw := range ipamutils.GetLocalScopeDefaultNetworks() {
+			if nw.IP.To4() != nil {
+				predefined = append(predefined, nw)
+			}
+		}
+		v4Net, err := FindAvailableNetwork(predefined)
 		if err != nil {
-			return nil, nil, errors.Wrapf(err, "PredefinedLocalScopeDefaultNetworks List: %+v",
-				ipamutils.PredefinedLocalScopeDefaultNetworks)
+			return nil, nil, errors.Wrapf(err, "PredefinedLocalScopeDefaultNetworks List: %+v", predefined)
 		}
 		v4Nets = append(v4Nets, v4Net)
 	}
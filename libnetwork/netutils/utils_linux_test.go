@@ -71,6 +71,36 @@ func TestCheckRouteOverlaps(t *testing.T) {
 	}
 }
 
+func TestCheckRouteOverlapsIPv6(t *testing.T) {
+	networkGetRoutesFct = func(_ netlink.Link, family int) ([]netlink.Route, error) {
+		if family != netlink.FAMILY_V6 {
+			return nil, nil
+		}
+		_, onLink, _ := net.ParseCIDR("fd12:3456:789a:1::/64")
+		_, viaGw, _ := net.ParseCIDR("fd12:3456:789a:2::/64")
+		return []netlink.Route{
+			{Dst: onLink, Scope: netlink.SCOPE_UNIVERSE},
+			{Dst: viaGw, Scope: netlink.SCOPE_UNIVERSE, Gw: net.ParseIP("fd12:3456:789a:1::1")},
+		}, nil
+	}
+	defer func() { networkGetRoutesFct = nil }()
+
+	_, netX, _ := net.ParseCIDR("fd12:3456:789a:1::/64")
+	if err := CheckRouteOverlaps(netX); err == nil {
+		t.Fatal("fd12:3456:789a:1::/64 should overlap the route to the link but it doesn't")
+	}
+
+	_, netX, _ = net.ParseCIDR("fd12:3456:789a:2::/64")
+	if err := CheckRouteOverlaps(netX); err != nil {
+		t.Fatal("fd12:3456:789a:2::/64 should not overlap the route via a gateway but it does")
+	}
+
+	_, netX, _ = net.ParseCIDR("fd12:3456:789a:3::/64")
+	if err := CheckRouteOverlaps(netX); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCheckNameserverOverlaps(t *testing.T) {
 	nameservers := []string{"10.0.2.3/32", "192.168.102.1/32"}
 
@@ -57,6 +57,11 @@ type Network interface {
 
 	// Info returns certain operational data belonging to this network.
 	Info() NetworkInfo
+
+	// Update applies the passed options to the configuration of the network.
+	// Only the labels, the attachable and internal flags, the DNS
+	// configuration, the driver options and the IPAM pools can be updated.
+	Update(options ...NetworkOption) error
 }
 
 // NetworkInfo returns some configuration and operational information about the network
@@ -82,6 +87,15 @@ type NetworkInfo interface {
 	// Services returns a map of services keyed by the service name with the details
 	// of all the tasks that belong to the service. Applicable only in swarm mode.
 	Services() map[string]ServiceInfo
+	// DNSRecords returns the static records served by the embedded DNS server
+	// to the containers on this network.
+	DNSRecords() []DNSRecord
+	// DNSForwarders returns the conditional forwarding rules of the embedded
+	// DNS server for the containers on this network.
+	DNSForwarders() []DNSForwarder
+	// Policy returns the policy of the communication between the containers
+	// on this network, or nil if it has none.
+	Policy() *types.NetworkPolicy
 }
 
 // EndpointWalker is a client provided function which will be used to walk the Endpoints.
@@ -99,9 +113,12 @@ type ipInfo struct {
 
 // svcMapEntry is the body of the element into the svcMap
 // The ip is a string because the SetMatrix does not accept non hashable values
+// The endpointID is empty for the VIP of a service, which is not the address
+// of an endpoint
 type svcMapEntry struct {
-	ip        string
-	serviceID string
+	ip         string
+	serviceID  string
+	endpointID string
 }
 
 type svcInfo struct {
@@ -141,6 +158,12 @@ type IpamConf struct {
 	// AuxAddresses contains auxiliary addresses for network driver. Must be within the master pool.
 	// libnetwork will reserve them if they fall into the container pool.
 	AuxAddresses map[string]string
+	// Reservations maps endpoint names to the address of the master pool
+	// reserved for them, which no other endpoint is given.
+	Reservations map[string]string
+	// Options are the IPAM driver options of the pool, which override the
+	// IPAM options of the network.
+	Options map[string]string
 }
 
 // Validate checks whether the configuration is valid
@@ -148,6 +171,11 @@ func (c *IpamConf) Validate() error {
 	if c.Gateway != "" && nil == net.ParseIP(c.Gateway) {
 		return types.BadRequestErrorf("invalid gateway address %s in Ipam configuration", c.Gateway)
 	}
+	for name, ip := range c.Reservations {
+		if name == "" || net.ParseIP(ip) == nil {
+			return types.BadRequestErrorf("invalid address reservation %s=%s in Ipam configuration", name, ip)
+		}
+	}
 	return nil
 }
 
@@ -234,6 +262,8 @@ type network struct {
 	configFrom       string
 	loadBalancerIP   net.IP
 	loadBalancerMode string
+	dnsRecords       []DNSRecord
+	dnsForwarders    []DNSForwarder
 	sync.Mutex
 }
 
@@ -342,6 +372,18 @@ func (c *IpamConf) CopyTo(dstC *IpamConf) error {
 			dstC.AuxAddresses[k] = v
 		}
 	}
+	if c.Reservations != nil {
+		dstC.Reservations = make(map[string]string, len(c.Reservations))
+		for k, v := range c.Reservations {
+			dstC.Reservations[k] = v
+		}
+	}
+	if c.Options != nil {
+		dstC.Options = make(map[string]string, len(c.Options))
+		for k, v := range c.Options {
+			dstC.Options[k] = v
+		}
+	}
 	return nil
 }
 
@@ -413,6 +455,12 @@ func (n *network) validateConfiguration() error {
 			}
 		}
 	}
+	if _, err := parseNetworkDNS(n.dnsRecords, n.dnsForwarders); err != nil {
+		return err
+	}
+	if p := n.policy(); p != nil {
+		return p.Validate()
+	}
 	return nil
 }
 
@@ -467,6 +515,7 @@ func (n *network) CopyTo(o datastore.KVObject) error {
 	dstN.scope = n.scope
 	dstN.dynamic = n.dynamic
 	dstN.ipamType = n.ipamType
+	dstN.addrSpace = n.addrSpace
 	dstN.enableIPv6 = n.enableIPv6
 	dstN.persist = n.persist
 	dstN.postIPv6 = n.postIPv6
@@ -481,6 +530,8 @@ func (n *network) CopyTo(o datastore.KVObject) error {
 	dstN.configFrom = n.configFrom
 	dstN.loadBalancerIP = n.loadBalancerIP
 	dstN.loadBalancerMode = n.loadBalancerMode
+	dstN.dnsRecords = append([]DNSRecord(nil), n.dnsRecords...)
+	dstN.dnsForwarders = append([]DNSForwarder(nil), n.dnsForwarders...)
 
 	// copy labels
 	if dstN.labels == nil {
@@ -607,6 +658,20 @@ func (n *network) MarshalJSON() ([]byte, error) {
 	netMap["configFrom"] = n.configFrom
 	netMap["loadBalancerIP"] = n.loadBalancerIP
 	netMap["loadBalancerMode"] = n.loadBalancerMode
+	if len(n.dnsRecords) > 0 {
+		drs, err := json.Marshal(n.dnsRecords)
+		if err != nil {
+			return nil, err
+		}
+		netMap["dnsRecords"] = string(drs)
+	}
+	if len(n.dnsForwarders) > 0 {
+		dfs, err := json.Marshal(n.dnsForwarders)
+		if err != nil {
+			return nil, err
+		}
+		netMap["dnsForwarders"] = string(dfs)
+	}
 	return json.Marshal(netMap)
 }
 
@@ -661,6 +726,18 @@ func (n *network) UnmarshalJSON(b []byte) (err error) {
 			}
 			n.generic[netlabel.GenericData] = lmap
 		}
+		// Restore the policy in its *types.NetworkPolicy form
+		if v, ok := n.generic[netlabel.Policy]; ok {
+			p := &types.NetworkPolicy{}
+			ba, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(ba, p); err != nil {
+				return err
+			}
+			n.generic[netlabel.Policy] = p
+		}
 	}
 	if v, ok := netMap["persist"]; ok {
 		n.persist = v.(bool)
@@ -724,6 +801,16 @@ func (n *network) UnmarshalJSON(b []byte) (err error) {
 	if v, ok := netMap["loadBalancerMode"]; ok {
 		n.loadBalancerMode = v.(string)
 	}
+	if v, ok := netMap["dnsRecords"]; ok {
+		if err := json.Unmarshal([]byte(v.(string)), &n.dnsRecords); err != nil {
+			return err
+		}
+	}
+	if v, ok := netMap["dnsForwarders"]; ok {
+		if err := json.Unmarshal([]byte(v.(string)), &n.dnsForwarders); err != nil {
+			return err
+		}
+	}
 	// Reconcile old networks with the recently added `--ipv6` flag
 	if !n.enableIPv6 {
 		n.enableIPv6 = len(n.ipamV6Info) > 0
@@ -793,6 +880,22 @@ func NetworkOptionInternalNetwork() NetworkOption {
 	}
 }
 
+// NetworkOptionInternal returns an option setter to set, or unset, the
+// internal flag of a network.
+func NetworkOptionInternal(internal bool) NetworkOption {
+	return func(n *network) {
+		if n.generic == nil {
+			n.generic = make(map[string]interface{})
+		}
+		n.internal = internal
+		if internal {
+			n.generic[netlabel.Internal] = true
+		} else {
+			delete(n.generic, netlabel.Internal)
+		}
+	}
+}
+
 // NetworkOptionAttachable returns an option setter to set attachable for a network
 func NetworkOptionAttachable(attachable bool) NetworkOption {
 	return func(n *network) {
@@ -845,6 +948,22 @@ func NetworkOptionDriverOpts(opts map[string]string) NetworkOption {
 	}
 }
 
+// NetworkOptionPolicy returns an option setter for the policy of the
+// communication between the containers of the network, which is enforced by
+// the driver. A nil policy removes it.
+func NetworkOptionPolicy(p *types.NetworkPolicy) NetworkOption {
+	return func(n *network) {
+		if n.generic == nil {
+			n.generic = make(map[string]interface{})
+		}
+		if p != nil {
+			n.generic[netlabel.Policy] = p.GetCopy()
+		} else {
+			delete(n.generic, netlabel.Policy)
+		}
+	}
+}
+
 // NetworkOptionLabels function returns an option setter for labels specific to a network
 func NetworkOptionLabels(labels map[string]string) NetworkOption {
 	return func(n *network) {
@@ -1109,6 +1228,10 @@ removeFromStore:
 		return fmt.Errorf("error deleting network from store: %v", err)
 	}
 
+	c.Lock()
+	delete(c.networkDNS, id)
+	c.Unlock()
+
 	return nil
 }
 
@@ -1385,21 +1508,23 @@ func delIPToName(ipMap setmatrix.SetMatrix, name, serviceID string, ip net.IP) {
 	})
 }
 
-func addNameToIP(svcMap setmatrix.SetMatrix, name, serviceID string, epIP net.IP) {
+func addNameToIP(svcMap setmatrix.SetMatrix, name, serviceID, eID string, epIP net.IP) {
 	// Since DNS name resolution is case-insensitive, Use the lower-case form
 	// of the name as the key into svcMap
 	lowerCaseName := strings.ToLower(name)
 	svcMap.Insert(lowerCaseName, svcMapEntry{
-		ip:        epIP.String(),
-		serviceID: serviceID,
+		ip:         epIP.String(),
+		serviceID:  serviceID,
+		endpointID: eID,
 	})
 }
 
-func delNameToIP(svcMap setmatrix.SetMatrix, name, serviceID string, epIP net.IP) {
+func delNameToIP(svcMap setmatrix.SetMatrix, name, serviceID, eID string, epIP net.IP) {
 	lowerCaseName := strings.ToLower(name)
 	svcMap.Remove(lowerCaseName, svcMapEntry{
-		ip:        epIP.String(),
-		serviceID: serviceID,
+		ip:         epIP.String(),
+		serviceID:  serviceID,
+		endpointID: eID,
 	})
 }
 
@@ -1433,9 +1558,9 @@ func (n *network) addSvcRecords(eID, name, serviceID string, epIP, epIPv6 net.IP
 		}
 	}
 
-	addNameToIP(sr.svcMap, name, serviceID, epIP)
+	addNameToIP(sr.svcMap, name, serviceID, eID, epIP)
 	if epIPv6 != nil {
-		addNameToIP(sr.svcIPv6Map, name, serviceID, epIPv6)
+		addNameToIP(sr.svcIPv6Map, name, serviceID, eID, epIPv6)
 	}
 }
 
@@ -1465,10 +1590,10 @@ func (n *network) deleteSvcRecords(eID, name, serviceID string, epIP net.IP, epI
 		}
 	}
 
-	delNameToIP(sr.svcMap, name, serviceID, epIP)
+	delNameToIP(sr.svcMap, name, serviceID, eID, epIP)
 
 	if epIPv6 != nil {
-		delNameToIP(sr.svcIPv6Map, name, serviceID, epIPv6)
+		delNameToIP(sr.svcIPv6Map, name, serviceID, eID, epIPv6)
 	}
 }
 
@@ -1621,22 +1746,16 @@ func (n *network) ipamAllocateVersion(ipVer int, ipam ipamapi.Ipam) error {
 		*cfgList = []*IpamConf{{}}
 	}
 
-	*infoList = make([]*IpamInfo, len(*cfgList))
+	*infoList = make([]*IpamInfo, 0, len(*cfgList))
 
 	logrus.Debugf("Allocating IPv%d pools for network %s (%s)", ipVer, n.Name(), n.ID())
 
-	for i, cfg := range *cfgList {
-		if err = cfg.Validate(); err != nil {
-			return err
-		}
-		d := &IpamInfo{}
-		(*infoList)[i] = d
-
-		d.AddressSpace = n.addrSpace
-		d.PoolID, d.Pool, d.Meta, err = n.requestPoolHelper(ipam, n.addrSpace, cfg.PreferredPool, cfg.SubPool, n.ipamOptions, ipVer == 6)
-		if err != nil {
+	for _, cfg := range *cfgList {
+		var d *IpamInfo
+		if d, err = n.ipamAllocatePool(ipVer, ipam, cfg); err != nil {
 			return err
 		}
+		*infoList = append(*infoList, d)
 
 		defer func() {
 			if err != nil {
@@ -1645,45 +1764,113 @@ func (n *network) ipamAllocateVersion(ipVer int, ipam ipamapi.Ipam) error {
 				}
 			}
 		}()
+	}
 
-		if gws, ok := d.Meta[netlabel.Gateway]; ok {
-			if d.Gateway, err = types.ParseCIDR(gws); err != nil {
-				return types.BadRequestErrorf("failed to parse gateway address (%v) returned by ipam driver: %v", gws, err)
+	return nil
+}
+
+// ipamAllocatePool requests the pool described by cfg, and its gateway and
+// auxiliary addresses.
+func (n *network) ipamAllocatePool(ipVer int, ipam ipamapi.Ipam, cfg *IpamConf) (_ *IpamInfo, err error) {
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+	d := &IpamInfo{}
+	d.AddressSpace = n.addrSpace
+	options, err := n.ipamPoolOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d.PoolID, d.Pool, d.Meta, err = n.requestPoolHelper(ipam, n.addrSpace, cfg.PreferredPool, cfg.SubPool, options, ipVer == 6)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err := ipam.ReleasePool(d.PoolID); err != nil {
+				logrus.Warnf("Failed to release address pool %s after failure to allocate it for network %s (%s)", d.PoolID, n.Name(), n.ID())
 			}
 		}
+	}()
 
-		// If user requested a specific gateway, libnetwork will allocate it
-		// irrespective of whether ipam driver returned a gateway already.
-		// If none of the above is true, libnetwork will allocate one.
-		if cfg.Gateway != "" || d.Gateway == nil {
-			var gatewayOpts = map[string]string{
-				ipamapi.RequestAddressType: netlabel.Gateway,
-			}
-			if d.Gateway, _, err = ipam.RequestAddress(d.PoolID, net.ParseIP(cfg.Gateway), gatewayOpts); err != nil {
-				return types.InternalErrorf("failed to allocate gateway (%v): %v", cfg.Gateway, err)
-			}
+	if gws, ok := d.Meta[netlabel.Gateway]; ok {
+		if d.Gateway, err = types.ParseCIDR(gws); err != nil {
+			return nil, types.BadRequestErrorf("failed to parse gateway address (%v) returned by ipam driver: %v", gws, err)
 		}
+	}
 
-		// Auxiliary addresses must be part of the master address pool
-		// If they fall into the container addressable pool, libnetwork will reserve them
-		if cfg.AuxAddresses != nil {
-			var ip net.IP
-			d.IPAMData.AuxAddresses = make(map[string]*net.IPNet, len(cfg.AuxAddresses))
-			for k, v := range cfg.AuxAddresses {
-				if ip = net.ParseIP(v); ip == nil {
-					return types.BadRequestErrorf("non parsable secondary ip address (%s:%s) passed for network %s", k, v, n.Name())
-				}
-				if !d.Pool.Contains(ip) {
-					return types.ForbiddenErrorf("auxiliary address: (%s:%s) must belong to the master pool: %s", k, v, d.Pool)
-				}
-				// Attempt reservation in the container addressable pool, silent the error if address does not belong to that pool
-				if d.IPAMData.AuxAddresses[k], _, err = ipam.RequestAddress(d.PoolID, ip, nil); err != nil && err != ipamapi.ErrIPOutOfRange {
-					return types.InternalErrorf("failed to allocate secondary ip address (%s:%s): %v", k, v, err)
-				}
+	// If user requested a specific gateway, libnetwork will allocate it
+	// irrespective of whether ipam driver returned a gateway already.
+	// If none of the above is true, libnetwork will allocate one.
+	if cfg.Gateway != "" || d.Gateway == nil {
+		var gatewayOpts = map[string]string{
+			ipamapi.RequestAddressType: netlabel.Gateway,
+		}
+		if d.Gateway, _, err = ipam.RequestAddress(d.PoolID, net.ParseIP(cfg.Gateway), gatewayOpts); err != nil {
+			return nil, types.InternalErrorf("failed to allocate gateway (%v): %v", cfg.Gateway, err)
+		}
+	}
+
+	// Auxiliary addresses must be part of the master address pool
+	// If they fall into the container addressable pool, libnetwork will reserve them
+	if cfg.AuxAddresses != nil {
+		var ip net.IP
+		d.IPAMData.AuxAddresses = make(map[string]*net.IPNet, len(cfg.AuxAddresses))
+		for k, v := range cfg.AuxAddresses {
+			if ip = net.ParseIP(v); ip == nil {
+				return nil, types.BadRequestErrorf("non parsable secondary ip address (%s:%s) passed for network %s", k, v, n.Name())
+			}
+			if !d.Pool.Contains(ip) {
+				return nil, types.ForbiddenErrorf("auxiliary address: (%s:%s) must belong to the master pool: %s", k, v, d.Pool)
+			}
+			// Attempt reservation in the container addressable pool, silent the error if address does not belong to that pool
+			if d.IPAMData.AuxAddresses[k], _, err = ipam.RequestAddress(d.PoolID, ip, nil); err != nil && err != ipamapi.ErrIPOutOfRange {
+				return nil, types.InternalErrorf("failed to allocate secondary ip address (%s:%s): %v", k, v, err)
 			}
 		}
 	}
 
+	return d, nil
+}
+
+// ipamPoolOptions returns the options of the request of the pool described
+// by cfg: the IPAM options of the network, overridden by the options of the
+// pool, and the address reservations.
+func (n *network) ipamPoolOptions(cfg *IpamConf) (map[string]string, error) {
+	if len(cfg.Reservations) == 0 && len(cfg.Options) == 0 {
+		return n.ipamOptions, nil
+	}
+	if len(cfg.Reservations) > 0 && n.ipamType != ipamapi.DefaultIPAM {
+		return nil, types.NotImplementedErrorf("address reservations are not supported by IPAM driver %s", n.ipamType)
+	}
+	options := make(map[string]string, len(n.ipamOptions)+len(cfg.Options)+len(cfg.Reservations))
+	for k, v := range n.ipamOptions {
+		options[k] = v
+	}
+	for k, v := range cfg.Options {
+		options[k] = v
+	}
+	for name, ip := range cfg.Reservations {
+		options[ipamapi.ReservationPrefix+name] = ip
+	}
+	return options, nil
+}
+
+// reservedAddress returns the IPv4 or IPv6 address reserved for the endpoint
+// name, if any.
+func (n *network) reservedAddress(ipVer int, name string) net.IP {
+	n.Lock()
+	defer n.Unlock()
+	cfgList := n.ipamV4Config
+	if ipVer == 6 {
+		cfgList = n.ipamV6Config
+	}
+	for _, cfg := range cfgList {
+		if ip, ok := cfg.Reservations[name]; ok {
+			return net.ParseIP(ip)
+		}
+	}
 	return nil
 }
 
@@ -1936,6 +2123,20 @@ func (n *network) Labels() map[string]string {
 	return lbls
 }
 
+func (n *network) Policy() *types.NetworkPolicy {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.policy().GetCopy()
+}
+
+// policy returns the policy of the network. It must be called with the lock
+// held, or on a network which is not shared yet.
+func (n *network) policy() *types.NetworkPolicy {
+	p, _ := n.generic[netlabel.Policy].(*types.NetworkPolicy)
+	return p
+}
+
 func (n *network) TableEventRegister(tableName string, objType driverapi.ObjectType) error {
 	if !driverapi.IsValidType(objType) {
 		return fmt.Errorf("invalid object type %v in registering table, %s", objType, tableName)
@@ -2008,17 +2209,40 @@ func (n *network) ResolveName(req string, ipType int) ([]net.IP, bool) {
 		noDup := make(map[string]bool)
 		var ipLocal []net.IP
 		for _, ip := range ipSet {
-			if _, dup := noDup[ip.(svcMapEntry).ip]; !dup {
-				noDup[ip.(svcMapEntry).ip] = true
-				ipLocal = append(ipLocal, net.ParseIP(ip.(svcMapEntry).ip))
+			entry := ip.(svcMapEntry)
+			if _, unready := c.unreadyEndpoints[entry.endpointID]; unready && !isEndpointName(sr.ipMap, req, entry) {
+				// containers which are not ready are only resolvable by name, not by alias
+				continue
+			}
+			if _, dup := noDup[entry.ip]; !dup {
+				noDup[entry.ip] = true
+				ipLocal = append(ipLocal, net.ParseIP(entry.ip))
 			}
 		}
+		if len(ipLocal) == 0 {
+			// The name exists in the network, but none of its backends are
+			// ready; don't let the query go to the external resolvers.
+			return nil, true
+		}
 		return ipLocal, ok
 	}
 
 	return nil, ipv6Miss
 }
 
+// isEndpointName returns whether name is the name of the endpoint the svcMap
+// entry belongs to, as opposed to one of its aliases. Only endpoint names have
+// a reverse mapping in ipMap.
+func isEndpointName(ipMap setmatrix.SetMatrix, name string, entry svcMapEntry) bool {
+	infos, _ := ipMap.Get(netutils.ReverseIP(entry.ip))
+	for _, info := range infos {
+		if info.(ipInfo).serviceID == entry.serviceID && strings.EqualFold(info.(ipInfo).name, name) {
+			return true
+		}
+	}
+	return false
+}
+
 func (n *network) HandleQueryResp(name string, ip net.IP) {
 	networkID := n.ID()
 	c := n.getController()
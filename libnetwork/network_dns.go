@@ -0,0 +1,229 @@
+package libnetwork
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/miekg/dns"
+)
+
+// maxCNAMEChain is the number of CNAME records followed when answering a
+// query from the static records of a network.
+const maxCNAMEChain = 8
+
+// DNSRecord is a static resource record served by the embedded DNS server
+// to the containers connected to a network.
+type DNSRecord struct {
+	// Name is the domain name of the record, e.g. "db.corp.internal".
+	Name string
+	// Type is one of "A", "AAAA", "CNAME", "SRV" or "TXT".
+	Type string
+	// Value is the data of the record in zone-file format, e.g.
+	// "10 5 5432 db1.corp.internal." for a SRV record.
+	Value string
+	// TTL is the time to live of the record in seconds. Zero means
+	// the default TTL of the embedded DNS server.
+	TTL uint32
+}
+
+// DNSForwarder is a conditional forwarding rule: the embedded DNS server
+// forwards queries for names in Domain to Servers instead of the
+// sandbox's external nameservers.
+type DNSForwarder struct {
+	Domain  string
+	Servers []string
+}
+
+// networkDNS is the parsed form of the static records and forwarding rules
+// of a network.
+type networkDNS struct {
+	records    map[string][]dns.RR // keyed by lower-case FQDN
+	forwarders []dnsForwarder
+}
+
+type dnsForwarder struct {
+	domain  string // lower-case FQDN
+	servers []extDNSEntry
+}
+
+var staticRRTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"CNAME": dns.TypeCNAME,
+	"SRV":   dns.TypeSRV,
+	"TXT":   dns.TypeTXT,
+}
+
+// parseNetworkDNS validates the static records and forwarding rules of a
+// network.
+func parseNetworkDNS(records []DNSRecord, forwarders []DNSForwarder) (*networkDNS, error) {
+	nd := &networkDNS{records: make(map[string][]dns.RR)}
+	for _, r := range records {
+		if _, ok := dns.IsDomainName(r.Name); !ok || r.Name == "" {
+			return nil, types.BadRequestErrorf("invalid DNS record name %q", r.Name)
+		}
+		typ := strings.ToUpper(r.Type)
+		if _, ok := staticRRTypes[typ]; !ok {
+			return nil, types.BadRequestErrorf("unsupported type %q for DNS record %s: supported types are A, AAAA, CNAME, SRV and TXT", r.Type, r.Name)
+		}
+		ttl := r.TTL
+		if ttl == 0 {
+			ttl = respTTL
+		}
+		name := strings.ToLower(dns.Fqdn(r.Name))
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, ttl, typ, r.Value))
+		if err != nil || rr == nil {
+			return nil, types.BadRequestErrorf("invalid value %q for DNS record %s %s", r.Value, r.Name, typ)
+		}
+		nd.records[name] = append(nd.records[name], rr)
+	}
+	for name, rrs := range nd.records {
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == dns.TypeCNAME && len(rrs) > 1 {
+				return nil, types.BadRequestErrorf("DNS record name %s has a CNAME record and other records", name)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range forwarders {
+		if _, ok := dns.IsDomainName(f.Domain); !ok || f.Domain == "" {
+			return nil, types.BadRequestErrorf("invalid DNS forwarding domain %q", f.Domain)
+		}
+		domain := strings.ToLower(dns.Fqdn(f.Domain))
+		if seen[domain] {
+			return nil, types.BadRequestErrorf("duplicate DNS forwarding rule for domain %s", f.Domain)
+		}
+		seen[domain] = true
+		if len(f.Servers) == 0 || len(f.Servers) > maxExtDNS {
+			return nil, types.BadRequestErrorf("DNS forwarding rule for domain %s must have between 1 and %d servers", f.Domain, maxExtDNS)
+		}
+		fwd := dnsForwarder{domain: domain}
+		for _, s := range f.Servers {
+			if net.ParseIP(s) == nil {
+				return nil, types.BadRequestErrorf("invalid server address %q in DNS forwarding rule for domain %s", s, f.Domain)
+			}
+			fwd.servers = append(fwd.servers, extDNSEntry{IPStr: s})
+		}
+		nd.forwarders = append(nd.forwarders, fwd)
+	}
+	return nd, nil
+}
+
+// lookup returns copies of the static records of name matching qtype, and
+// true if name has static records of any type. A CNAME record is returned
+// for any query type.
+func (nd *networkDNS) lookup(name string, qtype uint16) ([]dns.RR, bool) {
+	rrs, ok := nd.records[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	var res []dns.RR
+	for _, rr := range rrs {
+		t := rr.Header().Rrtype
+		if t == qtype || t == dns.TypeCNAME || qtype == dns.TypeANY {
+			res = append(res, dns.Copy(rr))
+		}
+	}
+	return res, true
+}
+
+// extServersFor returns the servers of the most specific forwarding rule
+// matching name, or nil if there is none.
+func (nd *networkDNS) extServersFor(name string) []extDNSEntry {
+	name = strings.ToLower(dns.Fqdn(name))
+	var (
+		servers []extDNSEntry
+		longest int
+	)
+	for _, f := range nd.forwarders {
+		if dns.IsSubDomain(f.domain, name) && len(f.domain) > longest {
+			servers = f.servers
+			longest = len(f.domain)
+		}
+	}
+	return servers
+}
+
+// NetworkOptionDNS function returns an option setter for the static
+// records and conditional forwarding rules served by the embedded DNS
+// server to the containers connected to the network.
+func NetworkOptionDNS(records []DNSRecord, forwarders []DNSForwarder) NetworkOption {
+	return func(n *network) {
+		n.dnsRecords = records
+		n.dnsForwarders = forwarders
+	}
+}
+
+func (n *network) DNSRecords() []DNSRecord {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.dnsRecords
+}
+
+func (n *network) DNSForwarders() []DNSForwarder {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.dnsForwarders
+}
+
+// getNetworkDNS returns the parsed static records and forwarding rules of
+// the network, loading them from the store on first use.
+func (c *controller) getNetworkDNS(nid string) *networkDNS {
+	c.Lock()
+	nd, ok := c.networkDNS[nid]
+	c.Unlock()
+	if ok {
+		return nd
+	}
+
+	nd = &networkDNS{}
+	if n, err := c.getNetworkFromStore(nid); err == nil {
+		if parsed, err := parseNetworkDNS(n.DNSRecords(), n.DNSForwarders()); err == nil {
+			nd = parsed
+		}
+	}
+
+	c.Lock()
+	c.networkDNS[nid] = nd
+	c.Unlock()
+	return nd
+}
+
+func (n *network) ResolveStatic(name string, qtype uint16) ([]dns.RR, bool) {
+	return n.getController().getNetworkDNS(n.ID()).lookup(name, qtype)
+}
+
+func (n *network) ExtServersFor(name string) []extDNSEntry {
+	return n.getController().getNetworkDNS(n.ID()).extServersFor(name)
+}
+
+func (sb *sandbox) ResolveStatic(name string, qtype uint16) ([]dns.RR, bool) {
+	for _, ep := range sb.getConnectedEndpoints() {
+		n := ep.getNetwork()
+		if n == nil {
+			continue
+		}
+		if rrs, ok := n.ResolveStatic(name, qtype); ok {
+			return rrs, true
+		}
+	}
+	return nil, false
+}
+
+func (sb *sandbox) ExtServersFor(name string) []extDNSEntry {
+	for _, ep := range sb.getConnectedEndpoints() {
+		n := ep.getNetwork()
+		if n == nil {
+			continue
+		}
+		if servers := n.ExtServersFor(name); servers != nil {
+			return servers
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package libnetwork
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/miekg/dns"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestParseNetworkDNS(t *testing.T) {
+	testcases := []struct {
+		name       string
+		records    []DNSRecord
+		forwarders []DNSForwarder
+		expErr     string
+	}{
+		{
+			name: "valid",
+			records: []DNSRecord{
+				{Name: "db.corp.internal", Type: "A", Value: "10.0.0.5"},
+				{Name: "db.corp.internal", Type: "aaaa", Value: "fd00::5", TTL: 30},
+				{Name: "www.corp.internal.", Type: "CNAME", Value: "db.corp.internal."},
+				{Name: "_pg._tcp.corp.internal", Type: "SRV", Value: "10 5 5432 db.corp.internal."},
+				{Name: "corp.internal", Type: "TXT", Value: `"v=spf1 -all"`},
+			},
+			forwarders: []DNSForwarder{{Domain: "ad.corp.internal", Servers: []string{"10.0.0.53", "fd00::53"}}},
+		},
+		{
+			name:    "unsupported type",
+			records: []DNSRecord{{Name: "mail.corp.internal", Type: "MX", Value: "10 mx.corp.internal."}},
+			expErr:  `unsupported type "MX" for DNS record mail.corp.internal: supported types are A, AAAA, CNAME, SRV and TXT`,
+		},
+		{
+			name:    "invalid value",
+			records: []DNSRecord{{Name: "db.corp.internal", Type: "A", Value: "fd00::5"}},
+			expErr:  `invalid value "fd00::5" for DNS record db.corp.internal A`,
+		},
+		{
+			name:    "empty name",
+			records: []DNSRecord{{Type: "A", Value: "10.0.0.5"}},
+			expErr:  `invalid DNS record name ""`,
+		},
+		{
+			name: "CNAME and other records",
+			records: []DNSRecord{
+				{Name: "db.corp.internal", Type: "A", Value: "10.0.0.5"},
+				{Name: "DB.corp.internal", Type: "CNAME", Value: "db1.corp.internal."},
+			},
+			expErr: "DNS record name db.corp.internal. has a CNAME record and other records",
+		},
+		{
+			name:       "no servers",
+			forwarders: []DNSForwarder{{Domain: "corp.internal"}},
+			expErr:     "DNS forwarding rule for domain corp.internal must have between 1 and 3 servers",
+		},
+		{
+			name:       "invalid server",
+			forwarders: []DNSForwarder{{Domain: "corp.internal", Servers: []string{"ns.corp.internal"}}},
+			expErr:     `invalid server address "ns.corp.internal" in DNS forwarding rule for domain corp.internal`,
+		},
+		{
+			name: "duplicate domain",
+			forwarders: []DNSForwarder{
+				{Domain: "corp.internal", Servers: []string{"10.0.0.53"}},
+				{Domain: "corp.internal.", Servers: []string{"10.0.0.54"}},
+			},
+			expErr: "duplicate DNS forwarding rule for domain corp.internal.",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseNetworkDNS(tc.records, tc.forwarders)
+			if tc.expErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Check(t, is.Error(err, tc.expErr))
+			_, ok := err.(types.BadRequestError)
+			assert.Check(t, ok, "expected a BadRequestError, got %T", err)
+		})
+	}
+}
+
+func TestNetworkDNSLookup(t *testing.T) {
+	nd, err := parseNetworkDNS([]DNSRecord{
+		{Name: "db.corp.internal", Type: "A", Value: "10.0.0.5"},
+		{Name: "db.corp.internal", Type: "TXT", Value: "primary"},
+		{Name: "www.corp.internal", Type: "CNAME", Value: "db.corp.internal."},
+	}, []DNSForwarder{
+		{Domain: "corp.internal", Servers: []string{"10.0.0.53"}},
+		{Domain: "ad.corp.internal", Servers: []string{"10.0.1.53"}},
+	})
+	assert.NilError(t, err)
+
+	rrs, ok := nd.lookup("DB.corp.internal.", dns.TypeA)
+	assert.Check(t, ok)
+	assert.Assert(t, is.Len(rrs, 1))
+	assert.Check(t, is.Equal(rrs[0].(*dns.A).A.String(), "10.0.0.5"))
+
+	// The name exists, but has no AAAA records.
+	rrs, ok = nd.lookup("db.corp.internal.", dns.TypeAAAA)
+	assert.Check(t, ok)
+	assert.Check(t, is.Len(rrs, 0))
+
+	// A CNAME is returned whatever the query type.
+	rrs, ok = nd.lookup("www.corp.internal.", dns.TypeA)
+	assert.Check(t, ok)
+	assert.Assert(t, is.Len(rrs, 1))
+	assert.Check(t, is.Equal(rrs[0].Header().Rrtype, dns.TypeCNAME))
+
+	_, ok = nd.lookup("other.corp.internal.", dns.TypeA)
+	assert.Check(t, !ok)
+
+	// The most specific forwarding rule wins.
+	assert.Check(t, is.DeepEqual(nd.extServersFor("dc1.AD.corp.internal."), []extDNSEntry{{IPStr: "10.0.1.53"}}))
+	assert.Check(t, is.DeepEqual(nd.extServersFor("corp.internal."), []extDNSEntry{{IPStr: "10.0.0.53"}}))
+	assert.Check(t, is.Nil(nd.extServersFor("example.com.")))
+	assert.Check(t, is.Nil(nd.extServersFor("notcorp.internal.")))
+}
+
+// a DNSBackend serving static records, for tests of the resolver
+type tststaticbackend struct {
+	tstbackend
+	nd *networkDNS
+}
+
+func (b *tststaticbackend) ResolveStatic(name string, qtype uint16) ([]dns.RR, bool) {
+	return b.nd.lookup(name, qtype)
+}
+
+func TestDNSStaticQuery(t *testing.T) {
+	nd, err := parseNetworkDNS([]DNSRecord{
+		{Name: "db.corp.internal", Type: "A", Value: "10.0.0.5", TTL: 60},
+		{Name: "www.corp.internal", Type: "CNAME", Value: "web.corp.internal."},
+		{Name: "web.corp.internal", Type: "CNAME", Value: "db.corp.internal."},
+		{Name: "_pg._tcp.corp.internal", Type: "SRV", Value: "10 5 5432 db.corp.internal."},
+	}, nil)
+	assert.NilError(t, err)
+	r := NewResolver(resolverIPSandbox, false, "", &tststaticbackend{nd: nd})
+
+	query := func(name string, qtype uint16) *dns.Msg {
+		w := new(tstwriter)
+		q := new(dns.Msg)
+		q.SetQuestion(name, qtype)
+		r.(*resolver).ServeDNS(w, q)
+		resp := w.GetResponse()
+		checkNonNullResponse(t, resp)
+		return resp
+	}
+
+	resp := query("db.corp.internal.", dns.TypeA)
+	checkDNSResponseCode(t, resp, dns.RcodeSuccess)
+	checkDNSAnswersCount(t, resp, 1)
+	assert.Check(t, is.Equal(resp.Answer[0].Header().Ttl, uint32(60)))
+
+	resp = query("www.corp.internal.", dns.TypeA)
+	checkDNSResponseCode(t, resp, dns.RcodeSuccess)
+	checkDNSAnswersCount(t, resp, 3)
+	checkDNSRRType(t, resp.Answer[0].Header().Rrtype, dns.TypeCNAME)
+	checkDNSRRType(t, resp.Answer[1].Header().Rrtype, dns.TypeCNAME)
+	checkDNSRRType(t, resp.Answer[2].Header().Rrtype, dns.TypeA)
+
+	resp = query("_pg._tcp.corp.internal.", dns.TypeSRV)
+	checkDNSResponseCode(t, resp, dns.RcodeSuccess)
+	checkDNSAnswersCount(t, resp, 1)
+	assert.Check(t, is.Equal(resp.Answer[0].(*dns.SRV).Port, uint16(5432)))
+
+	// NODATA for an existing name without records of the query type.
+	resp = query("db.corp.internal.", dns.TypeAAAA)
+	checkDNSResponseCode(t, resp, dns.RcodeSuccess)
+	checkDNSAnswersCount(t, resp, 0)
+
+	// Other names are not answered locally; without DNS proxying the
+	// query fails.
+	resp = query("other.corp.internal.", dns.TypeA)
+	checkDNSResponseCode(t, resp, dns.RcodeServerFailure)
+}
+
+func TestNetworkUpdateDNS(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	c, err := New()
+	assert.NilError(t, err)
+	defer c.Stop()
+
+	_, err = c.NewNetwork("bridge", "dnsnet0", "",
+		NetworkOptionDNS([]DNSRecord{{Name: "db.corp.internal", Type: "A", Value: "not-an-ip"}}, nil))
+	assert.Check(t, is.Error(err, `invalid value "not-an-ip" for DNS record db.corp.internal A`))
+
+	records := []DNSRecord{{Name: "db.corp.internal", Type: "A", Value: "10.0.0.5"}}
+	n, err := c.NewNetwork("bridge", "dnsnet1", "", NetworkOptionDNS(records, nil))
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, n.Delete())
+	}()
+	assert.Check(t, is.DeepEqual(n.Info().DNSRecords(), records))
+
+	ep, err := n.CreateEndpoint("testep")
+	assert.NilError(t, err)
+	sb, err := c.NewSandbox("c1")
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, sb.Delete())
+	}()
+	assert.NilError(t, ep.Join(sb))
+
+	rrs, ok := sb.(*sandbox).ResolveStatic("db.corp.internal.", dns.TypeA)
+	assert.Check(t, ok)
+	assert.Check(t, is.Len(rrs, 1))
+
+	records = []DNSRecord{{Name: "cache.corp.internal", Type: "A", Value: "10.0.0.6"}}
+	forwarders := []DNSForwarder{{Domain: "ad.corp.internal", Servers: []string{"10.0.1.53"}}}
+	assert.NilError(t, n.Update(NetworkOptionDNS(records, forwarders)))
+
+	// The update is persisted.
+	n2, err := c.NetworkByID(n.ID())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(n2.Info().DNSRecords(), records))
+	assert.Check(t, is.DeepEqual(n2.Info().DNSForwarders(), forwarders))
+
+	_, ok = sb.(*sandbox).ResolveStatic("db.corp.internal.", dns.TypeA)
+	assert.Check(t, !ok)
+	_, ok = sb.(*sandbox).ResolveStatic("cache.corp.internal.", dns.TypeA)
+	assert.Check(t, ok)
+	assert.Check(t, is.DeepEqual(sb.(*sandbox).ExtServersFor("dc1.ad.corp.internal."), []extDNSEntry{{IPStr: "10.0.1.53"}}))
+
+	err = n.Update(NetworkOptionDNS([]DNSRecord{{Name: "db.corp.internal", Type: "MX", Value: "10 mx.corp.internal."}}, nil))
+	assert.Check(t, is.ErrorContains(err, `unsupported type "MX"`))
+}
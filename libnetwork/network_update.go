@@ -0,0 +1,335 @@
+package libnetwork
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/docker/docker/libnetwork/driverapi"
+	"github.com/docker/docker/libnetwork/ipamapi"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Update applies options to the configuration of the network, which is
+// persisted once the changes are in effect.
+//
+// The labels, the attachable flag and the DNS configuration are handled by
+// libnetwork. The driver options, the internal flag, the policy and the pools
+// added to the IPAM configuration are applied by the driver, which must
+// implement driverapi.NetworkUpdater. The existing pools can only be given an
+// IP range, and the internal flag can only be changed while the network has
+// no endpoints.
+func (n *network) Update(options ...NetworkOption) (err error) {
+	c := n.getController()
+	id := n.ID()
+	c.networkLocker.Lock(id)
+	defer c.networkLocker.Unlock(id) //nolint:errcheck
+
+	cur, err := c.getNetworkFromStore(id)
+	if err != nil {
+		return err
+	}
+	if cur.configOnly || cur.ingress || cur.dynamic || cur.hasSpecialDriver() {
+		return types.ForbiddenErrorf("network %s cannot be updated", cur.Name())
+	}
+
+	upd := &network{ctrlr: c}
+	if err := cur.CopyTo(upd); err != nil {
+		return err
+	}
+	upd.epCnt = cur.epCnt
+	upd.processOptions(options...)
+
+	if err := cur.validateUpdate(upd); err != nil {
+		return err
+	}
+	if _, err := parseNetworkDNS(upd.dnsRecords, upd.dnsForwarders); err != nil {
+		return err
+	}
+	if p := upd.policy(); p != nil {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+
+	optionsChanged := !reflect.DeepEqual(cur.generic, upd.generic)
+	if cur.configFrom != "" && (optionsChanged || ipamConfigChanged(cur, upd)) {
+		return types.ForbiddenErrorf("the driver options and IPAM configuration of network %s are those of the configuration network %s", cur.Name(), cur.configFrom)
+	}
+	if upd.internal != cur.internal && cur.getEpCnt().EndpointCnt() > 0 {
+		return types.ForbiddenErrorf("the internal flag of network %s cannot be changed while it has active endpoints", cur.Name())
+	}
+
+	ipam, _, err := c.getIPAMDriver(upd.ipamType)
+	if err != nil {
+		return err
+	}
+	var acquired, replaced []string
+	defer func() {
+		// Release the pools replaced by an IP range on success, or the
+		// pools acquired for the update on failure.
+		release := replaced
+		if err != nil {
+			release = acquired
+		}
+		for _, poolID := range release {
+			if err := ipam.ReleasePool(poolID); err != nil {
+				logrus.Warnf("Failed to release address pool %s on update of network %s (%s): %v", poolID, upd.Name(), id, err)
+			}
+		}
+	}()
+	for _, ipVer := range []int{4, 6} {
+		var a, r []string
+		a, r, err = upd.ipamUpdateVersion(ipVer, ipam, cur)
+		acquired = append(acquired, a...)
+		replaced = append(replaced, r...)
+		if err != nil {
+			return err
+		}
+	}
+
+	var nu driverapi.NetworkUpdater
+	if optionsChanged || len(upd.ipamV4Info) != len(cur.ipamV4Info) || len(upd.ipamV6Info) != len(cur.ipamV6Info) {
+		var d driverapi.Driver
+		if d, err = upd.driver(true); err != nil {
+			return err
+		}
+		var ok bool
+		if nu, ok = d.(driverapi.NetworkUpdater); !ok {
+			err = types.ForbiddenErrorf("the %s driver does not support updating the driver options, internal flag, policy or IPAM pools of a network", upd.Type())
+			return err
+		}
+		if err = nu.UpdateNetwork(id, upd.generic, upd.getIPData(4), upd.getIPData(6)); err != nil {
+			return err
+		}
+	}
+
+	if err = c.updateToStore(upd); err != nil {
+		if nu != nil {
+			if err := nu.UpdateNetwork(id, cur.generic, cur.getIPData(4), cur.getIPData(6)); err != nil {
+				logrus.WithError(err).Warnf("Failed to restore the configuration of network %s (%s) in the %s driver", upd.Name(), id, upd.Type())
+			}
+		}
+		return fmt.Errorf("failed to update network %s: %v", id, err)
+	}
+
+	// cur may be n itself when the store is cached, so compare them before
+	// the update.
+	mtu := driverMTU(upd.generic)
+	mtuChanged := mtu != driverMTU(cur.generic)
+	dnsChanged := !reflect.DeepEqual(cur.dnsRecords, upd.dnsRecords) || !reflect.DeepEqual(cur.dnsForwarders, upd.dnsForwarders)
+
+	n.Lock()
+	n.labels = upd.labels
+	n.generic = upd.generic
+	n.internal = upd.internal
+	n.attachable = upd.attachable
+	n.ipamV4Config, n.ipamV6Config = upd.ipamV4Config, upd.ipamV6Config
+	n.ipamV4Info, n.ipamV6Info = upd.ipamV4Info, upd.ipamV6Info
+	n.dnsRecords, n.dnsForwarders = upd.dnsRecords, upd.dnsForwarders
+	n.dbIndex = upd.dbIndex
+	n.Unlock()
+
+	if mtuChanged && mtu > 0 {
+		c.setNetworkMTU(id, mtu)
+	}
+
+	if dnsChanged {
+		c.Lock()
+		delete(c.networkDNS, id)
+		c.Unlock()
+
+		// Drop the responses cached for the old rules.
+		for _, sb := range c.resolversOnNetwork(id) {
+			sb.resolver.FlushCache()
+		}
+	}
+	return nil
+}
+
+// validateUpdate returns an error if upd changes settings of n which cannot
+// be updated.
+func (n *network) validateUpdate(upd *network) error {
+	// The IPAM driver and address space are left unchanged when not set.
+	if upd.ipamType == "" {
+		upd.ipamType = n.ipamType
+	}
+	if upd.addrSpace == "" {
+		upd.addrSpace = n.addrSpace
+	}
+
+	for _, s := range []struct {
+		setting string
+		changed bool
+	}{
+		{"IPv6 flag", upd.enableIPv6 != n.enableIPv6},
+		{"scope", upd.scope != n.scope},
+		{"IPAM driver", upd.ipamType != n.ipamType},
+		{"address space", upd.addrSpace != n.addrSpace},
+		{"IPAM options", !equalStringMaps(upd.ipamOptions, n.ipamOptions)},
+		{"ingress flag", upd.ingress != n.ingress},
+		{"configuration network", upd.configOnly != n.configOnly || upd.configFrom != n.configFrom},
+		{"dynamic flag", upd.dynamic != n.dynamic},
+		{"persistence policy", upd.persist != n.persist},
+		{"load balancer IP", !upd.loadBalancerIP.Equal(n.loadBalancerIP)},
+	} {
+		if s.changed {
+			return types.ForbiddenErrorf("the %s of network %s cannot be updated", s.setting, n.Name())
+		}
+	}
+
+	if !upd.enableIPv6 && len(upd.ipamV6Config) > len(n.ipamV6Config) {
+		return types.ForbiddenErrorf("IPv6 pools cannot be added to network %s, which does not have IPv6 enabled", n.Name())
+	}
+	return nil
+}
+
+// ipamUpdateVersion requests the pools added to the IPAM configuration of
+// the network for the IP version, and the IP ranges set on its existing
+// pools, whose configuration in cur cannot be changed otherwise. Adding a
+// second IP range to an existing pool is rejected. It returns the IDs of the
+// pools it acquired, and of the existing pools they replace.
+func (n *network) ipamUpdateVersion(ipVer int, ipam ipamapi.Ipam, cur *network) (acquired, replaced []string, err error) {
+	var (
+		cfgList  *[]*IpamConf
+		infoList *[]*IpamInfo
+		curList  []*IpamConf
+	)
+	switch ipVer {
+	case 4:
+		cfgList, infoList, curList = &n.ipamV4Config, &n.ipamV4Info, cur.ipamV4Config
+	case 6:
+		cfgList, infoList, curList = &n.ipamV6Config, &n.ipamV6Info, cur.ipamV6Config
+	default:
+		return nil, nil, types.InternalErrorf("incorrect ip version passed to ipam update: %d", ipVer)
+	}
+
+	if len(*cfgList) < len(curList) {
+		return nil, nil, types.ForbiddenErrorf("IPv%d pools cannot be removed from network %s", ipVer, n.Name())
+	}
+
+	for i, cfg := range (*cfgList)[:len(curList)] {
+		c, info := curList[i], (*infoList)[i]
+
+		// The configuration of a pool allocated by the IPAM driver does
+		// not hold its subnet and gateway, which can be passed instead.
+		pool, gw := c.PreferredPool, c.Gateway
+		if pool == "" {
+			pool = info.Pool.String()
+		}
+		if gw == "" && info.Gateway != nil {
+			gw = info.Gateway.IP.String()
+		}
+		switch {
+		case cfg.PreferredPool != c.PreferredPool && cfg.PreferredPool != pool:
+			return acquired, replaced, types.ForbiddenErrorf("the IPv%d pools of network %s cannot be changed or reordered, only given an IP range", ipVer, n.Name())
+		case cfg.Gateway != c.Gateway && cfg.Gateway != gw:
+			return acquired, replaced, types.ForbiddenErrorf("the gateway of pool %s of network %s cannot be changed", pool, n.Name())
+		case !equalStringMaps(cfg.AuxAddresses, c.AuxAddresses):
+			return acquired, replaced, types.ForbiddenErrorf("the auxiliary addresses of pool %s of network %s cannot be changed", pool, n.Name())
+		case !equalStringMaps(cfg.Reservations, c.Reservations):
+			return acquired, replaced, types.ForbiddenErrorf("the address reservations of pool %s of network %s cannot be changed", pool, n.Name())
+		case !equalStringMaps(cfg.Options, c.Options):
+			return acquired, replaced, types.ForbiddenErrorf("the IPAM options of pool %s of network %s cannot be changed", pool, n.Name())
+		case cfg.SubPool == c.SubPool:
+			continue
+		case cfg.SubPool == "":
+			return acquired, replaced, types.ForbiddenErrorf("the IP range of pool %s of network %s cannot be removed", pool, n.Name())
+		}
+
+		cfg.PreferredPool = pool
+		options, err := n.ipamPoolOptions(cfg)
+		if err != nil {
+			return acquired, replaced, err
+		}
+		poolID, _, _, err := ipam.RequestPool(n.addrSpace, pool, cfg.SubPool, options, ipVer == 6)
+		if err != nil {
+			return acquired, replaced, err
+		}
+		acquired = append(acquired, poolID)
+		replaced = append(replaced, info.PoolID)
+		info.PoolID = poolID
+	}
+
+	for _, cfg := range (*cfgList)[len(curList):] {
+		// A pool only has one IP range, which is set on the existing pool
+		// with the same subnet. A second range of the subnet would be
+		// another pool, which drivers are not given.
+		for i, c := range curList {
+			pool := c.PreferredPool
+			if pool == "" {
+				pool = (*infoList)[i].Pool.String()
+			}
+			if cfg.PreferredPool == pool {
+				return acquired, replaced, types.ForbiddenErrorf("pool %s of network %s already exists: only one IP range can be set on a pool", pool, n.Name())
+			}
+		}
+		d, err := n.ipamAllocatePool(ipVer, ipam, cfg)
+		if err != nil {
+			return acquired, replaced, err
+		}
+		acquired = append(acquired, d.PoolID)
+		*infoList = append(*infoList, d)
+	}
+	return acquired, replaced, nil
+}
+
+// ipamConfigChanged returns whether the IPAM configurations of n and upd
+// differ.
+func ipamConfigChanged(n, upd *network) bool {
+	return !reflect.DeepEqual(n.ipamV4Config, upd.ipamV4Config) || !reflect.DeepEqual(n.ipamV6Config, upd.ipamV6Config)
+}
+
+// setNetworkMTU sets the MTU of the interfaces of the containers connected
+// to the network. The driver sets the MTU of the interfaces it manages.
+func (c *controller) setNetworkMTU(nid string, mtu int) {
+	c.Lock()
+	sandboxes := make([]*sandbox, 0, len(c.sandboxes))
+	for _, sb := range c.sandboxes {
+		sandboxes = append(sandboxes, sb)
+	}
+	c.Unlock()
+
+	for _, sb := range sandboxes {
+		sb.Lock()
+		osSbox := sb.osSbox
+		sb.Unlock()
+		if osSbox == nil {
+			continue
+		}
+		for _, ep := range sb.getConnectedEndpoints() {
+			if n := ep.getNetwork(); n == nil || n.ID() != nid {
+				continue
+			}
+			for _, i := range osSbox.Info().Interfaces() {
+				if !ep.hasInterface(i.SrcName()) {
+					continue
+				}
+				if err := i.SetMTU(mtu); err != nil {
+					logrus.WithError(err).Warnf("Failed to set the MTU of interface %s of sandbox %s", i.DstName(), sb.ID())
+				}
+			}
+		}
+	}
+}
+
+// driverMTU returns the MTU set in the driver options, or zero.
+func driverMTU(generic map[string]interface{}) int {
+	opts, _ := generic[netlabel.GenericData].(map[string]string)
+	mtu, _ := strconv.Atoi(opts[netlabel.DriverMTU])
+	return mtu
+}
+
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package libnetwork
+
+import (
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/docker/docker/libnetwork/ipamapi"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestNetworkUpdate(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	c, err := New()
+	assert.NilError(t, err)
+	defer c.Stop()
+
+	n, err := c.NewNetwork("bridge", "updnet", "",
+		NetworkOptionLabels(map[string]string{"foo": "bar"}),
+		NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16"}}, nil, nil))
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, n.Delete())
+	}()
+
+	labels := map[string]string{"foo": "baz"}
+	assert.NilError(t, n.Update(NetworkOptionLabels(labels), NetworkOptionAttachable(true), NetworkOptionInternal(true)))
+
+	// The update is persisted.
+	n2, err := c.NetworkByID(n.ID())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(n2.Info().Labels(), labels))
+	assert.Check(t, n2.Info().Attachable())
+	assert.Check(t, n2.Info().Internal())
+
+	assert.NilError(t, n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16", SubPool: "172.28.10.0/24"}}, nil, nil)))
+	ep, err := n.CreateEndpoint("testep")
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, ep.Delete(false))
+	}()
+	_, ipRange, _ := net.ParseCIDR("172.28.10.0/24")
+	assert.Check(t, ipRange.Contains(ep.Info().Iface().Address().IP))
+
+	err = n.Update(NetworkOptionInternal(false))
+	assert.Check(t, is.ErrorContains(err, "cannot be changed while it has active endpoints"))
+	_, ok := err.(types.ForbiddenError)
+	assert.Check(t, ok)
+
+	err = n.Update(NetworkOptionEnableIPv6(true))
+	assert.Check(t, is.Error(err, "the IPv6 flag of network updnet cannot be updated"))
+
+	err = n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.29.0.0/16"}}, nil, nil))
+	assert.Check(t, is.ErrorContains(err, "cannot be changed or reordered"))
+
+	err = n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16", SubPool: "172.28.10.0/24"}, {PreferredPool: "172.28.0.0/16", SubPool: "172.28.20.0/24"}}, nil, nil))
+	assert.Check(t, is.Error(err, "pool 172.28.0.0/16 of network updnet already exists: only one IP range can be set on a pool"))
+	_, ok = err.(types.ForbiddenError)
+	assert.Check(t, ok)
+
+	// The bridge driver does not support multiple IPv4 pools, the pool
+	// acquired for the update is released.
+	err = n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16", SubPool: "172.28.10.0/24"}, {PreferredPool: "172.30.0.0/16"}}, nil, nil))
+	assert.Check(t, err != nil)
+	n3, err := c.NewNetwork("bridge", "updnet2", "", NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.30.0.0/16"}}, nil, nil))
+	assert.NilError(t, err)
+	assert.Check(t, n3.Delete())
+
+	_, _, v4Conf, _ := n.Info().IpamConfig()
+	assert.Check(t, is.Len(v4Conf, 1))
+
+	assert.NilError(t, n.Update(NetworkOptionDriverOpts(map[string]string{netlabel.DriverMTU: "1400"})))
+	n2, err = c.NetworkByID(n.ID())
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(n2.Info().DriverOptions()[netlabel.DriverMTU], "1400"))
+}
+
+func TestNetworkPolicy(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	c, err := New()
+	assert.NilError(t, err)
+	defer c.Stop()
+
+	policy := &types.NetworkPolicy{
+		Default: types.PolicyDeny,
+		Rules: []types.PolicyRule{{
+			Action:   types.PolicyAllow,
+			From:     map[string]string{"role": "web"},
+			To:       map[string]string{"role": "db"},
+			Protocol: "tcp",
+			Ports:    []types.PortRange{{Start: 5432, End: 5432}},
+		}},
+	}
+	_, err = c.NewNetwork("bridge", "polnet", "", NetworkOptionPolicy(&types.NetworkPolicy{Default: "reject"}))
+	assert.Check(t, is.ErrorContains(err, "invalid default action"))
+
+	n, err := c.NewNetwork("bridge", "polnet", "",
+		NetworkOptionPolicy(policy),
+		NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16"}}, nil, nil))
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, n.Delete())
+	}()
+
+	ep, err := n.CreateEndpoint("testep", CreateOptionLabels(map[string]string{"role": "web"}))
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, ep.Delete(false))
+	}()
+
+	// The policy is persisted.
+	n2, err := c.NetworkByID(n.ID())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(n2.Info().Policy(), policy))
+
+	err = n.Update(NetworkOptionPolicy(&types.NetworkPolicy{Rules: []types.PolicyRule{{Action: types.PolicyAllow, Protocol: "icmp"}}}))
+	assert.Check(t, is.ErrorContains(err, "invalid protocol"))
+
+	assert.NilError(t, n.Update(NetworkOptionPolicy(nil)))
+	n2, err = c.NetworkByID(n.ID())
+	assert.NilError(t, err)
+	assert.Check(t, is.Nil(n2.Info().Policy()))
+}
+
+func TestNetworkReservations(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	c, err := New()
+	assert.NilError(t, err)
+	defer c.Stop()
+
+	reservations := map[string]string{"web": "172.28.0.10"}
+	n, err := c.NewNetwork("bridge", "resnet", "",
+		NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16", Reservations: reservations}}, nil, nil))
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, n.Delete())
+	}()
+
+	ep, err := n.CreateEndpoint("web")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(ep.Info().Iface().Address().String(), "172.28.0.10/16"))
+	assert.NilError(t, ep.Delete(false))
+
+	// The reserved address is not given to other endpoints.
+	_, err = n.CreateEndpoint("db", CreateOptionIpam(net.ParseIP("172.28.0.10"), nil, nil, nil))
+	assert.Check(t, is.Error(err, ipamapi.ErrIPAlreadyAllocated.Error()))
+
+	// It remains reserved when the pool is given an IP range.
+	assert.NilError(t, n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16", SubPool: "172.28.10.0/24", Reservations: reservations}}, nil, nil)))
+	ep, err = n.CreateEndpoint("web")
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, ep.Delete(false))
+	}()
+	assert.Check(t, is.Equal(ep.Info().Iface().Address().String(), "172.28.0.10/16"))
+
+	err = n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "", []*IpamConf{{PreferredPool: "172.28.0.0/16", SubPool: "172.28.10.0/24"}}, nil, nil))
+	assert.Check(t, is.ErrorContains(err, "address reservations of pool 172.28.0.0/16 of network resnet cannot be changed"))
+
+	_, _, v4Conf, _ := n.Info().IpamConfig()
+	assert.Check(t, is.Len(v4Conf, 1))
+	assert.Check(t, is.DeepEqual(v4Conf[0].Reservations, reservations))
+}
+
+func TestNetworkPoolOptions(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	c, err := New()
+	assert.NilError(t, err)
+	defer c.Stop()
+
+	// The IPv4 pool uses the strategy of the network, the IPv6 pool its own.
+	v6Options := map[string]string{
+		ipamapi.AllocStrategy:     ipamapi.StrategyLowestFree,
+		ipamapi.AllocReleaseDelay: "0s",
+	}
+	n, err := c.NewNetwork("bridge", "poolnet", "",
+		NetworkOptionEnableIPv6(true),
+		NetworkOptionIpam(ipamapi.DefaultIPAM, "",
+			[]*IpamConf{{PreferredPool: "172.28.0.0/16"}},
+			[]*IpamConf{{PreferredPool: "fd28::/64", Options: v6Options}},
+			map[string]string{ipamapi.AllocStrategy: ipamapi.StrategySequential}))
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, n.Delete())
+	}()
+
+	ep, err := n.CreateEndpoint("ep1")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(ep.Info().Iface().Address().String(), "172.28.0.2/16"))
+	assert.Check(t, is.Equal(ep.Info().Iface().AddressIPv6().String(), "fd28::2/64"))
+	assert.NilError(t, ep.Delete(false))
+
+	// The sequential strategy does not reuse the released address, the
+	// lowest-free strategy does once the release delay elapsed.
+	ep, err = n.CreateEndpoint("ep2")
+	assert.NilError(t, err)
+	defer func() {
+		assert.Check(t, ep.Delete(false))
+	}()
+	assert.Check(t, is.Equal(ep.Info().Iface().Address().String(), "172.28.0.3/16"))
+	assert.Check(t, is.Equal(ep.Info().Iface().AddressIPv6().String(), "fd28::2/64"))
+
+	_, _, v4Conf, v6Conf := n.Info().IpamConfig()
+	assert.Check(t, is.Len(v4Conf, 1))
+	assert.Check(t, is.Len(v4Conf[0].Options, 0))
+	assert.Check(t, is.Len(v6Conf, 1))
+	assert.Check(t, is.DeepEqual(v6Conf[0].Options, v6Options))
+
+	err = n.Update(NetworkOptionIpam(ipamapi.DefaultIPAM, "",
+		[]*IpamConf{{PreferredPool: "172.28.0.0/16"}},
+		[]*IpamConf{{PreferredPool: "fd28::/64"}},
+		map[string]string{ipamapi.AllocStrategy: ipamapi.StrategySequential}))
+	assert.Check(t, is.Error(err, "the IPAM options of pool fd28::/64 of network poolnet cannot be changed"))
+}
@@ -0,0 +1,110 @@
+//go:build linux
+// +build linux
+
+// Package nftables programs nftables rulesets with the nft command.
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Family refers to the address family of a table.
+type Family string
+
+const (
+	// IPv4 is the family of tables for IPv4 packets.
+	IPv4 Family = "ip"
+	// IPv6 is the family of tables for IPv6 packets.
+	IPv6 Family = "ip6"
+	// Bridge is the family of tables for the frames forwarded by bridges.
+	Bridge Family = "bridge"
+)
+
+// Names shared by the rulesets programmed by libnetwork.
+const (
+	// DockerTable is the table of the nftables backend of the bridge driver,
+	// in the ip and ip6 families.
+	DockerTable = "docker"
+	// IngressPorts is the set of DockerTable of the ip family holding the
+	// protocols and ports published by the ingress network of swarm mode,
+	// whose forwarded connections are accepted whatever their interfaces.
+	IngressPorts = "ingress-ports"
+)
+
+var (
+	nftPath  string
+	initOnce sync.Once
+	// ErrNftNotFound is returned when the nft command is not found.
+	ErrNftNotFound = errors.New("nft not found")
+)
+
+func detectNft() {
+	path, err := exec.LookPath("nft")
+	if err != nil {
+		logrus.Warnf("Failed to find nft: %v", err)
+		return
+	}
+	nftPath = path
+}
+
+// Supported returns whether the nft command is available.
+func Supported() bool {
+	initOnce.Do(detectNft)
+	return nftPath != ""
+}
+
+// Batch is a list of nft commands, which are applied in a single
+// transaction: either all of them take effect, or none does.
+type Batch struct {
+	cmds []string
+}
+
+// Add appends a command to the batch.
+func (b *Batch) Add(format string, args ...interface{}) {
+	b.cmds = append(b.cmds, fmt.Sprintf(format, args...))
+}
+
+// Len returns the number of commands in the batch.
+func (b *Batch) Len() int {
+	return len(b.cmds)
+}
+
+// String returns the nft script of the batch.
+func (b *Batch) String() string {
+	var sb strings.Builder
+	for _, cmd := range b.cmds {
+		sb.WriteString(cmd)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Apply runs the commands of the batch atomically.
+func (b *Batch) Apply() error {
+	if len(b.cmds) == 0 {
+		return nil
+	}
+	if !Supported() {
+		return ErrNftNotFound
+	}
+	script := b.String()
+	logrus.Debugf("nft -f -:\n%s", script)
+	cmd := exec.Command(nftPath, "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("nft failed: %s (%v)", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// Quote quotes the name of a chain, set or interface, so that it can be
+// used in a command whatever characters it contains.
+func Quote(name string) string {
+	return `"` + name + `"`
+}
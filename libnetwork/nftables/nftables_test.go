@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package nftables
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestBatch(t *testing.T) {
+	b := &Batch{}
+	assert.NilError(t, b.Apply())
+
+	b.Add("add table %s %s", IPv4, "docker")
+	b.Add("add set %s %s bridges { type ifname; }", IPv4, "docker")
+	assert.Check(t, is.Equal(b.Len(), 2))
+	assert.Check(t, is.Equal(b.String(), "add table ip docker\nadd set ip docker bridges { type ifname; }\n"))
+}
+
+func TestQuote(t *testing.T) {
+	assert.Check(t, is.Equal(Quote("br-0123456789ab"), `"br-0123456789ab"`))
+}
@@ -0,0 +1,157 @@
+package osl
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/docker/docker/libnetwork/ns"
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// tbfLatency is how long a packet can wait in the queue of the token
+	// bucket filter shaping the traffic sent to a container.
+	tbfLatency = 25 * time.Millisecond
+
+	// policeMTU is the size of the largest packet let through by the
+	// policer of the traffic sent by a container, which must allow for
+	// the GSO packets sent over veth devices.
+	policeMTU = 65535
+
+	// minBurst is the smallest burst used when none is set, large enough
+	// for a GSO packet.
+	minBurst = 65536
+)
+
+var ingressHandle = netlink.MakeHandle(0xffff, 0)
+
+// SetBandwidth limits the traffic of the host interface ifName of a veth
+// pair connecting a container. The traffic the interface sends, which the
+// container receives, is shaped with a token bucket filter to the ingress
+// rate. The traffic the interface receives, sent by the container, is
+// given the priority and policed to the egress rate. Limits with a zero
+// rate are removed, and a nil bw removes all of them.
+func SetBandwidth(ifName string, bw *types.Bandwidth) error {
+	if bw == nil {
+		bw = &types.Bandwidth{}
+	}
+	if bw.EgressRate > math.MaxUint32 {
+		return fmt.Errorf("egress rate %d of interface %s exceeds the maximum of %d", bw.EgressRate, ifName, uint64(math.MaxUint32))
+	}
+	nlh := ns.NlHandle()
+	link, err := nlh.LinkByName(ifName)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %s: %v", ifName, err)
+	}
+	attrs := link.Attrs()
+
+	qdiscs, err := nlh.QdiscList(link)
+	if err != nil {
+		return fmt.Errorf("failed to list the queueing disciplines of interface %s: %v", ifName, err)
+	}
+	var tbf, ingress netlink.Qdisc
+	for _, q := range qdiscs {
+		switch q.(type) {
+		case *netlink.Tbf:
+			if q.Attrs().Parent == netlink.HANDLE_ROOT {
+				tbf = q
+			}
+		case *netlink.Ingress:
+			ingress = q
+		}
+	}
+
+	if bw.IngressRate > 0 {
+		burst := bandwidthBurst(bw.IngressRate, bw.IngressBurst)
+		q := &netlink.Tbf{
+			QdiscAttrs: netlink.QdiscAttrs{
+				LinkIndex: attrs.Index,
+				Handle:    netlink.MakeHandle(1, 0),
+				Parent:    netlink.HANDLE_ROOT,
+			},
+			Rate:   bw.IngressRate,
+			Limit:  uint32(bw.IngressRate/uint64(time.Second/tbfLatency)) + burst,
+			Buffer: netlink.Xmittime(bw.IngressRate, burst),
+		}
+		if err := nlh.QdiscReplace(q); err != nil {
+			return fmt.Errorf("failed to shape the traffic sent by interface %s: %v", ifName, err)
+		}
+	} else if tbf != nil {
+		if err := nlh.QdiscDel(tbf); err != nil {
+			return fmt.Errorf("failed to remove the shaping of the traffic sent by interface %s: %v", ifName, err)
+		}
+	}
+
+	// Replace the filter of the traffic received by the interface.
+	if ingress != nil {
+		if err := nlh.QdiscDel(ingress); err != nil {
+			return fmt.Errorf("failed to remove the policing of the traffic received by interface %s: %v", ifName, err)
+		}
+	}
+	if bw.EgressRate == 0 && bw.Priority == 0 {
+		return nil
+	}
+	var actions []netlink.Action
+	if bw.Priority > 0 {
+		prio := bw.Priority
+		a := netlink.NewSkbEditAction()
+		a.Priority = &prio
+		if bw.EgressRate == 0 {
+			a.Action = netlink.TC_ACT_OK
+		}
+		actions = append(actions, a)
+	}
+	if bw.EgressRate > 0 {
+		a := netlink.NewPoliceAction()
+		a.Rate = uint32(bw.EgressRate)
+		a.Burst = bandwidthBurst(bw.EgressRate, bw.EgressBurst)
+		a.Mtu = policeMTU
+		a.ExceedAction = netlink.TC_POLICE_SHOT
+		a.NotExceedAction = netlink.TC_POLICE_OK
+		actions = append(actions, a)
+	}
+	ingress = &netlink.Ingress{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: attrs.Index,
+			Handle:    ingressHandle,
+			Parent:    netlink.HANDLE_INGRESS,
+		},
+	}
+	if err := nlh.QdiscAdd(ingress); err != nil {
+		return fmt.Errorf("failed to add ingress queueing discipline to interface %s: %v", ifName, err)
+	}
+	if err := nlh.FilterAdd(&netlink.MatchAll{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: attrs.Index,
+			Parent:    ingressHandle,
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: actions,
+	}); err != nil {
+		if err := nlh.QdiscDel(ingress); err != nil {
+			logrus.WithError(err).Warnf("Failed to remove ingress queueing discipline of interface %s", ifName)
+		}
+		return fmt.Errorf("failed to police the traffic received by interface %s: %v", ifName, err)
+	}
+	return nil
+}
+
+// bandwidthBurst returns burst, or when it is not set the traffic of
+// 100ms at rate, and at least minBurst.
+func bandwidthBurst(rate, burst uint64) uint32 {
+	if burst == 0 {
+		burst = rate / 10
+		if burst < minBurst {
+			burst = minBurst
+		}
+	}
+	if burst > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(burst)
+}
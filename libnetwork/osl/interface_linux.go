@@ -202,6 +202,18 @@ func (i *nwIface) Statistics() (*types.InterfaceStatistics, error) {
 	}, nil
 }
 
+func (i *nwIface) SetMTU(mtu int) error {
+	i.Lock()
+	n := i.ns
+	i.Unlock()
+
+	l, err := n.nlHandle.LinkByName(i.DstName())
+	if err != nil {
+		return fmt.Errorf("failed to find interface %s in netns %s: %v", i.DstName(), n.path, err)
+	}
+	return n.nlHandle.LinkSetMTU(l, mtu)
+}
+
 func (n *networkNamespace) findDst(srcName string, isBridge bool) string {
 	n.Lock()
 	defer n.Unlock()
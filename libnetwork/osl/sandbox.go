@@ -190,4 +190,7 @@ type Interface interface {
 
 	// Statistics returns the statistics for this interface
 	Statistics() (*types.InterfaceStatistics, error)
+
+	// SetMTU sets the MTU of the interface.
+	SetMTU(mtu int) error
 }
@@ -580,3 +580,67 @@ func TestAddRemoveInterface(t *testing.T) {
 	GC()
 	verifyCleanup(t, s, false)
 }
+
+func TestSetBandwidth(t *testing.T) {
+	defer testutils.SetupTestOSContext(t)()
+
+	nlh := ns.NlHandle()
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: vethName1}, PeerName: vethName2}
+	if err := nlh.LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	link, err := nlh.LinkByName(vethName1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qdiscTypes := func() map[string]bool {
+		qdiscs, err := nlh.QdiscList(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]bool)
+		for _, q := range qdiscs {
+			m[q.Type()] = true
+		}
+		return m
+	}
+
+	if err := SetBandwidth(vethName1, &types.Bandwidth{IngressRate: 1 << 20}); err != nil {
+		t.Fatal(err)
+	}
+	if q := qdiscTypes(); !q["tbf"] || q["ingress"] {
+		t.Fatalf("expected only the tbf queueing discipline, got %v", q)
+	}
+	if err := SetBandwidth(vethName1, &types.Bandwidth{}); err != nil {
+		t.Fatal(err)
+	}
+	if q := qdiscTypes(); q["tbf"] {
+		t.Fatalf("expected the tbf queueing discipline to be removed, got %v", q)
+	}
+
+	err = SetBandwidth(vethName1, &types.Bandwidth{IngressRate: 1 << 21, EgressRate: 1 << 19, Priority: 4})
+	if err != nil && strings.Contains(err.Error(), syscall.ENOENT.Error()) {
+		t.Skipf("traffic policing is not supported by the kernel: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := qdiscTypes(); !q["tbf"] || !q["ingress"] {
+		t.Fatalf("expected tbf and ingress queueing disciplines, got %v", q)
+	}
+	filters, err := nlh.FilterList(link, netlink.MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 || filters[0].Type() != "matchall" {
+		t.Fatalf("expected a matchall filter, got %v", filters)
+	}
+
+	if err := SetBandwidth(vethName1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if q := qdiscTypes(); q["tbf"] || q["ingress"] {
+		t.Fatalf("expected no limits, got %v", q)
+	}
+}
@@ -80,7 +80,7 @@ func (pm *PortMapper) MapRange(container net.Addr, hostIP net.IP, hostPortStart,
 				return nil, err
 			}
 		} else {
-			m.userlandProxy, err = newDummyProxy(proto, hostIP, allocatedHostPort)
+			m.userlandProxy, err = newForwardingProxy(hostIP, allocatedHostPort, t)
 			if err != nil {
 				return nil, err
 			}
@@ -20,6 +20,13 @@ type PortMapper struct {
 
 	Allocator *portallocator.PortAllocator
 	chain     *iptables.ChainInfo
+	forwarder Forwarder
+}
+
+// Forwarder programs the forwarding of published ports to containers. It is
+// implemented by iptables chains, and by the other firewall backends.
+type Forwarder interface {
+	Forward(action iptables.Action, ip net.IP, port int, proto, destAddr string, destPort int, bridgeName string) error
 }
 
 // SetIptablesChain sets the specified chain into portmapper
@@ -28,6 +35,13 @@ func (pm *PortMapper) SetIptablesChain(c *iptables.ChainInfo, bridgeName string)
 	pm.bridgeName = bridgeName
 }
 
+// SetForwarder sets the Forwarder programming the port mappings, in place of
+// an iptables chain.
+func (pm *PortMapper) SetForwarder(f Forwarder, bridgeName string) {
+	pm.forwarder = f
+	pm.bridgeName = bridgeName
+}
+
 // AppendForwardingTableEntry adds a port mapping to the forwarding table
 func (pm *PortMapper) AppendForwardingTableEntry(proto string, sourceIP net.IP, sourcePort int, containerIP string, containerPort int) error {
 	return pm.forward(iptables.Append, proto, sourceIP, sourcePort, containerIP, containerPort)
@@ -39,6 +53,9 @@ func (pm *PortMapper) DeleteForwardingTableEntry(proto string, sourceIP net.IP,
 }
 
 func (pm *PortMapper) forward(action iptables.Action, proto string, sourceIP net.IP, sourcePort int, containerIP string, containerPort int) error {
+	if pm.forwarder != nil {
+		return pm.forwarder.Forward(action, sourceIP, sourcePort, proto, containerIP, containerPort, pm.bridgeName)
+	}
 	if pm.chain == nil {
 		return nil
 	}
@@ -1,6 +1,7 @@
 package portmapper
 
 import (
+	"io"
 	"net"
 	"strings"
 	"testing"
@@ -267,3 +268,58 @@ func TestMapUDPDummyListen(t *testing.T) {
 		}
 	}
 }
+
+func TestMapTCPPortWithoutProxy(t *testing.T) {
+	backend, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	go func() {
+		for {
+			conn, err := backend.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	pm := New("")
+	host, err := pm.Map(backend.Addr(), net.ParseIP("127.0.0.1"), 0, false)
+	if err != nil {
+		t.Fatalf("Failed to allocate port: %s", err)
+	}
+
+	// the connection is not forwarded by the kernel, but by the listener
+	// holding the host port.
+	conn, err := net.Dial("tcp", host.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "hello" {
+		t.Fatalf("expected hello, got %q", buf)
+	}
+
+	if err := pm.Unmap(host); err != nil {
+		t.Fatalf("Failed to release port: %s", err)
+	}
+	// unmapping closes the forwarded connections.
+	if _, err := conn.Read(buf); err == nil {
+		t.Fatal("expected the connection to be closed")
+	}
+	if _, err := net.Dial("tcp", host.String()); err == nil {
+		t.Fatal("expected the port to be released")
+	}
+}
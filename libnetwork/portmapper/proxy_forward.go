@@ -0,0 +1,99 @@
+package portmapper
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// forwardingProxy is used for TCP mappings instead of dummyProxy when the
+// userland proxy is disabled. It holds the host port the same way, and the
+// daemon forwards the connections it accepts to the container. Those are
+// the connections which are not forwarded by the kernel, such as the IPv6
+// connections from the loopback interface, which cannot be routed to
+// containers. Unlike the userland proxy, it does not run any process.
+type forwardingProxy struct {
+	dummyProxy
+	container *net.TCPAddr
+	done      chan struct{}
+}
+
+func newForwardingProxy(hostIP net.IP, hostPort int, container *net.TCPAddr) (userlandProxy, error) {
+	p, err := newDummyProxy("tcp", hostIP, hostPort)
+	if err != nil {
+		return nil, err
+	}
+	return &forwardingProxy{dummyProxy: *p.(*dummyProxy), container: container}, nil
+}
+
+func (p *forwardingProxy) Start() error {
+	if err := p.dummyProxy.Start(); err != nil {
+		return err
+	}
+	p.done = make(chan struct{})
+	go p.run(p.listener.(*net.TCPListener))
+	return nil
+}
+
+func (p *forwardingProxy) Stop() error {
+	err := p.dummyProxy.Stop()
+	if p.done != nil {
+		<-p.done
+	}
+	return err
+}
+
+func (p *forwardingProxy) run(l *net.TCPListener) {
+	defer close(p.done)
+
+	// quit closes the forwarded connections once the listener is closed.
+	quit := make(chan struct{})
+	defer close(quit)
+	for {
+		client, err := l.AcceptTCP()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logrus.WithError(err).Errorf("Stopping forwarding from %s to %s", p.addr, p.container)
+			}
+			return
+		}
+		go p.forward(client, quit)
+	}
+}
+
+func (p *forwardingProxy) forward(client *net.TCPConn, quit chan struct{}) {
+	backend, err := net.DialTCP("tcp", nil, p.container)
+	if err != nil {
+		logrus.WithError(err).Debugf("Can't forward connection from %s to %s", p.addr, p.container)
+		client.Close()
+		return
+	}
+
+	var wg sync.WaitGroup
+	broker := func(to, from *net.TCPConn) {
+		io.Copy(to, from)
+		from.CloseRead()
+		to.CloseWrite()
+		wg.Done()
+	}
+	wg.Add(2)
+	go broker(client, backend)
+	go broker(backend, client)
+
+	finish := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finish)
+	}()
+
+	select {
+	case <-quit:
+	case <-finish:
+	}
+	client.Close()
+	backend.Close()
+	<-finish
+}
@@ -32,6 +32,11 @@ type Resolver interface {
 	SetExtServers([]extDNSEntry)
 	// ResolverOptions returns resolv.conf options that should be set
 	ResolverOptions() []string
+	// CacheStats returns the counters of the cache of responses from
+	// the external nameservers
+	CacheStats() DNSCacheStats
+	// FlushCache drops all cached responses from the external nameservers
+	FlushCache()
 }
 
 // DNSBackend represents a backend DNS resolver used for DNS name
@@ -57,6 +62,14 @@ type DNSBackend interface {
 	// HandleQueryResp passes the name & IP from a response to the backend. backend
 	// can use it to maintain any required state about the resolution
 	HandleQueryResp(name string, ip net.IP)
+	// ResolveStatic returns the static records of the passed type for the passed
+	// name, configured on the networks the sandbox is connected to. The second
+	// return value is true if the name has static records of any type.
+	ResolveStatic(name string, qtype uint16) ([]dns.RR, bool)
+	// ExtServersFor returns the nameservers of the conditional forwarding rule
+	// matching the passed name, or nil if queries for the name should go to the
+	// default external nameservers.
+	ExtServersFor(name string) []extDNSEntry
 }
 
 const (
@@ -67,6 +80,7 @@ const (
 	maxExtDNS       = 3 // max number of external servers to try
 	extIOTimeout    = 4 * time.Second
 	defaultRespSize = 512
+	extUDPSize      = 1232 // EDNS0 buffer size advertised to external servers
 	maxConcurrent   = 1024
 	logInterval     = 2 * time.Second
 )
@@ -92,6 +106,10 @@ type resolver struct {
 	proxyDNS      bool
 	resolverKey   string
 	startCh       chan struct{}
+	cache         *dnsCache
+	// firewallBackend programs the rules redirecting the DNS queries of
+	// the sandbox to the resolver. It is iptables if empty.
+	firewallBackend string
 }
 
 func init() {
@@ -107,6 +125,7 @@ func NewResolver(address string, proxyDNS bool, resolverKey string, backend DNSB
 		resolverKey:   resolverKey,
 		err:           fmt.Errorf("setup not done yet"),
 		startCh:       make(chan struct{}, 1),
+		cache:         newDNSCache(dnsCacheSize),
 	}
 }
 
@@ -198,6 +217,7 @@ func (r *resolver) SetExtServers(extDNS []extDNSEntry) {
 	for i := 0; i < l; i++ {
 		r.extDNSList[i] = extDNS[i]
 	}
+	r.cache.flush()
 }
 
 func (r *resolver) NameServer() string {
@@ -208,6 +228,14 @@ func (r *resolver) ResolverOptions() []string {
 	return []string{"ndots:0"}
 }
 
+func (r *resolver) CacheStats() DNSCacheStats {
+	return r.cache.stats()
+}
+
+func (r *resolver) FlushCache() {
+	r.cache.flush()
+}
+
 func setCommonFlags(msg *dns.Msg) {
 	msg.RecursionAvailable = true
 }
@@ -255,7 +283,7 @@ func (r *resolver) handleIPQuery(query *dns.Msg, ipType int) (*dns.Msg, error) {
 
 	if addr == nil && ipv6Miss {
 		// Send a reply without any Answer sections
-		logrus.Debugf("[resolver] lookup name %s present without IPv6 address", name)
+		logrus.Debugf("[resolver] lookup name %s present without IPv6 address or ready backend", name)
 		resp := createRespMsg(query)
 		return resp, nil
 	}
@@ -349,6 +377,33 @@ func (r *resolver) handleSRVQuery(query *dns.Msg) (*dns.Msg, error) {
 	return resp, nil
 }
 
+// handleStaticQuery answers the query from the static records of the
+// networks, following CNAME records to the records they point to.
+func (r *resolver) handleStaticQuery(query *dns.Msg) *dns.Msg {
+	name := query.Question[0].Name
+	qtype := query.Question[0].Qtype
+
+	rrs, ok := r.backend.ResolveStatic(name, qtype)
+	if !ok {
+		return nil
+	}
+	logrus.Debugf("[resolver] lookup for %s (%s): static records found", name, dns.TypeToString[qtype])
+
+	resp := createRespMsg(query)
+	resp.Answer = rrs
+	for i := 0; i < maxCNAMEChain && qtype != dns.TypeCNAME && len(rrs) == 1; i++ {
+		cname, ok := rrs[0].(*dns.CNAME)
+		if !ok {
+			break
+		}
+		if rrs, ok = r.backend.ResolveStatic(cname.Target, qtype); !ok {
+			break
+		}
+		resp.Answer = append(resp.Answer, rrs...)
+	}
+	return resp
+}
+
 func truncateResp(resp *dns.Msg, maxSize int, isTCP bool) {
 	if !isTCP {
 		resp.Truncated = true
@@ -368,9 +423,8 @@ func truncateResp(resp *dns.Msg, maxSize int, isTCP bool) {
 
 func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 	var (
-		extConn net.Conn
-		resp    *dns.Msg
-		err     error
+		resp *dns.Msg
+		err  error
 	)
 
 	if query == nil || len(query.Question) == 0 {
@@ -396,10 +450,14 @@ func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 	}
 
 	if err != nil {
-		logrus.WithError(err).Errorf("[resolver] failed to handle query: %s (%s) from %s", queryName, dns.TypeToString[queryType], extConn.LocalAddr().String())
+		logrus.WithError(err).Errorf("[resolver] failed to handle query: %s (%s) from %s", queryName, dns.TypeToString[queryType], w.RemoteAddr().String())
 		return
 	}
 
+	if resp == nil {
+		resp = r.handleStaticQuery(query)
+	}
+
 	if resp == nil {
 		// If the backend doesn't support proxying dns request
 		// fail the response
@@ -443,122 +501,193 @@ func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 			truncateResp(resp, maxSize, proto == "tcp")
 		}
 	} else {
-		for i := 0; i < maxExtDNS; i++ {
-			extDNS := &r.extDNSList[i]
-			if extDNS.IPStr == "" {
-				break
+		resp = r.cache.get(query)
+		if resp == nil {
+			servers := r.backend.ExtServersFor(queryName)
+			if servers == nil {
+				servers = r.extDNSList[:]
 			}
-			extConnect := func() {
-				addr := fmt.Sprintf("%s:%d", extDNS.IPStr, 53)
-				extConn, err = net.DialTimeout(proto, addr, extIOTimeout)
+			resp = r.forwardExtDNS(proto, servers, query)
+			if resp == nil {
+				return
 			}
+			r.cache.put(query, resp)
+		}
+		r.handleExtResp(resp)
+		fitExtResp(query, resp, proto, maxSize)
+	}
 
-			if extDNS.HostLoopback {
-				extConnect()
-			} else {
-				execErr := r.backend.ExecFunc(extConnect)
-				if execErr != nil {
-					logrus.Warn(execErr)
-					continue
-				}
-			}
-			if err != nil {
-				logrus.WithField("retries", i).Warnf("[resolver] connect failed: %s", err)
-				continue
-			}
-			logrus.Debugf("[resolver] query %s (%s) from %s, forwarding to %s:%s", queryName, dns.TypeToString[queryType],
-				extConn.LocalAddr().String(), proto, extDNS.IPStr)
+	if err = w.WriteMsg(resp); err != nil {
+		logrus.WithError(err).Errorf("[resolver] failed to write response")
+	}
+}
 
-			// Timeout has to be set for every IO operation.
-			if err := extConn.SetDeadline(time.Now().Add(extIOTimeout)); err != nil {
-				logrus.WithError(err).Error("[resolver] error setting conn deadline")
-			}
-			co := &dns.Conn{
-				Conn:    extConn,
-				UDPSize: uint16(maxSize),
-			}
-			defer co.Close()
-
-			// limits the number of outstanding concurrent queries.
-			if !r.forwardQueryStart() {
-				old := r.tStamp
-				r.tStamp = time.Now()
-				if r.tStamp.Sub(old) > logInterval {
-					logrus.Errorf("[resolver] more than %v concurrent queries from %s", maxConcurrent, extConn.LocalAddr().String())
-				}
-				continue
-			}
+// forwardExtDNS sends the query to the passed external DNS servers in turn
+// until one of them gives a usable answer. Queries are sent with an EDNS0 buffer
+// size of extUDPSize, and retried over TCP when the UDP response is
+// truncated.
+func (r *resolver) forwardExtDNS(proto string, servers []extDNSEntry, query *dns.Msg) *dns.Msg {
+	var resp *dns.Msg
 
-			err = co.WriteMsg(query)
-			if err != nil {
-				r.forwardQueryEnd()
-				logrus.Debugf("[resolver] send to DNS server failed, %s", err)
-				continue
-			}
+	queryName := query.Question[0].Name
+	queryType := query.Question[0].Qtype
+	extQuery := extQueryMsg(query)
 
-			resp, err = co.ReadMsg()
-			// Truncated DNS replies should be sent to the client so that the
-			// client can retry over TCP
-			if err != nil && (resp == nil || !resp.Truncated) {
-				r.forwardQueryEnd()
-				logrus.WithError(err).Debugf("[resolver] failed to read from DNS server")
-				continue
+	for i := range servers {
+		extDNS := &servers[i]
+		if extDNS.IPStr == "" {
+			break
+		}
+
+		// limits the number of outstanding concurrent queries.
+		if !r.forwardQueryStart() {
+			old := r.tStamp
+			r.tStamp = time.Now()
+			if r.tStamp.Sub(old) > logInterval {
+				logrus.Errorf("[resolver] more than %v concurrent queries", maxConcurrent)
 			}
+			continue
+		}
+
+		var err error
+		resp, err = r.exchange(proto, extDNS, extQuery)
+		// Truncated DNS replies should be sent to the client so that the
+		// client can retry over TCP
+		if err != nil && (resp == nil || !resp.Truncated) {
 			r.forwardQueryEnd()
+			logrus.WithError(err).WithField("retries", i).Debugf("[resolver] failed to query DNS server %s:%s", proto, extDNS.IPStr)
+			resp = nil
+			continue
+		}
+		if resp != nil && resp.Truncated && proto == "udp" {
+			logrus.Debugf("[resolver] external DNS udp:%s returned truncated response for %q, retrying over tcp", extDNS.IPStr, queryName)
+			tcpResp, err := r.exchange("tcp", extDNS, extQuery)
+			if err != nil {
+				logrus.WithError(err).Debugf("[resolver] failed to query DNS server tcp:%s", extDNS.IPStr)
+			} else if tcpResp != nil {
+				resp = tcpResp
+			}
+		}
+		r.forwardQueryEnd()
 
-			if resp == nil {
-				logrus.Debugf("[resolver] external DNS %s:%s returned empty response for %q", proto, extDNS.IPStr, queryName)
+		if resp == nil {
+			logrus.Debugf("[resolver] external DNS %s:%s returned empty response for %q", proto, extDNS.IPStr, queryName)
+			break
+		}
+		switch resp.Rcode {
+		case dns.RcodeServerFailure, dns.RcodeRefused:
+			// Server returned FAILURE: continue with the next external DNS server
+			// Server returned REFUSED: this can be a transitional status, so continue with the next external DNS server
+			logrus.Debugf("[resolver] external DNS %s:%s responded with %s for %q", proto, extDNS.IPStr, statusString(resp.Rcode), queryName)
+			continue
+		case dns.RcodeNameError:
+			// Server returned NXDOMAIN. Stop resolution if it's an authoritative answer (see RFC 8020: https://tools.ietf.org/html/rfc8020#section-2)
+			logrus.Debugf("[resolver] external DNS %s:%s responded with %s for %q", proto, extDNS.IPStr, statusString(resp.Rcode), queryName)
+			if resp.Authoritative {
 				break
 			}
-			switch resp.Rcode {
-			case dns.RcodeServerFailure, dns.RcodeRefused:
-				// Server returned FAILURE: continue with the next external DNS server
-				// Server returned REFUSED: this can be a transitional status, so continue with the next external DNS server
-				logrus.Debugf("[resolver] external DNS %s:%s responded with %s for %q", proto, extDNS.IPStr, statusString(resp.Rcode), queryName)
-				continue
-			case dns.RcodeNameError:
-				// Server returned NXDOMAIN. Stop resolution if it's an authoritative answer (see RFC 8020: https://tools.ietf.org/html/rfc8020#section-2)
-				logrus.Debugf("[resolver] external DNS %s:%s responded with %s for %q", proto, extDNS.IPStr, statusString(resp.Rcode), queryName)
-				if resp.Authoritative {
-					break
-				}
-				continue
-			case dns.RcodeSuccess:
-				// All is well
-			default:
-				// Server gave some error. Log the error, and continue with the next external DNS server
-				logrus.Debugf("[resolver] external DNS %s:%s responded with %s (code %d) for %q", proto, extDNS.IPStr, statusString(resp.Rcode), resp.Rcode, queryName)
-				continue
-			}
-			answers := 0
-			for _, rr := range resp.Answer {
-				h := rr.Header()
-				switch h.Rrtype {
-				case dns.TypeA:
-					answers++
-					ip := rr.(*dns.A).A
-					logrus.Debugf("[resolver] received A record %q for %q from %s:%s", ip, h.Name, proto, extDNS.IPStr)
-					r.backend.HandleQueryResp(h.Name, ip)
-				case dns.TypeAAAA:
-					answers++
-					ip := rr.(*dns.AAAA).AAAA
-					logrus.Debugf("[resolver] received AAAA record %q for %q from %s:%s", ip, h.Name, proto, extDNS.IPStr)
-					r.backend.HandleQueryResp(h.Name, ip)
-				}
-			}
-			if resp.Answer == nil || answers == 0 {
-				logrus.Debugf("[resolver] external DNS %s:%s did not return any %s records for %q", proto, extDNS.IPStr, dns.TypeToString[queryType], queryName)
-			}
-			resp.Compress = true
-			break
+			continue
+		case dns.RcodeSuccess:
+			// All is well
+		default:
+			// Server gave some error. Log the error, and continue with the next external DNS server
+			logrus.Debugf("[resolver] external DNS %s:%s responded with %s (code %d) for %q", proto, extDNS.IPStr, statusString(resp.Rcode), resp.Rcode, queryName)
+			continue
 		}
-		if resp == nil {
-			return
+		if len(resp.Answer) == 0 {
+			logrus.Debugf("[resolver] external DNS %s:%s did not return any %s records for %q", proto, extDNS.IPStr, dns.TypeToString[queryType], queryName)
 		}
+		break
 	}
+	return resp
+}
 
-	if err = w.WriteMsg(resp); err != nil {
-		logrus.WithError(err).Errorf("[resolver] failed to write response")
+// exchange sends query to an external DNS server and reads its response.
+func (r *resolver) exchange(proto string, extDNS *extDNSEntry, query *dns.Msg) (*dns.Msg, error) {
+	var (
+		extConn net.Conn
+		err     error
+	)
+	extConnect := func() {
+		addr := net.JoinHostPort(extDNS.IPStr, dnsPort)
+		extConn, err = net.DialTimeout(proto, addr, extIOTimeout)
+	}
+
+	if extDNS.HostLoopback {
+		extConnect()
+	} else if execErr := r.backend.ExecFunc(extConnect); execErr != nil {
+		return nil, execErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect failed: %w", err)
+	}
+	defer extConn.Close()
+
+	logrus.Debugf("[resolver] query %s (%s) from %s, forwarding to %s:%s", query.Question[0].Name, dns.TypeToString[query.Question[0].Qtype],
+		extConn.LocalAddr().String(), proto, extDNS.IPStr)
+
+	// Timeout has to be set for every IO operation.
+	if err := extConn.SetDeadline(time.Now().Add(extIOTimeout)); err != nil {
+		logrus.WithError(err).Error("[resolver] error setting conn deadline")
+	}
+	co := &dns.Conn{
+		Conn:    extConn,
+		UDPSize: extUDPSize,
+	}
+	if err := co.WriteMsg(query); err != nil {
+		return nil, fmt.Errorf("send to DNS server failed: %w", err)
+	}
+	r.cache.forwarded()
+
+	return co.ReadMsg()
+}
+
+// handleExtResp passes the addresses in a response from an external server
+// to the backend.
+func (r *resolver) handleExtResp(resp *dns.Msg) {
+	for _, rr := range resp.Answer {
+		h := rr.Header()
+		switch h.Rrtype {
+		case dns.TypeA:
+			ip := rr.(*dns.A).A
+			logrus.Debugf("[resolver] received A record %q for %q", ip, h.Name)
+			r.backend.HandleQueryResp(h.Name, ip)
+		case dns.TypeAAAA:
+			ip := rr.(*dns.AAAA).AAAA
+			logrus.Debugf("[resolver] received AAAA record %q for %q", ip, h.Name)
+			r.backend.HandleQueryResp(h.Name, ip)
+		}
+	}
+}
+
+// extQueryMsg returns a copy of the client's query to be sent to external
+// servers, advertising an EDNS0 buffer size of extUDPSize.
+func extQueryMsg(query *dns.Msg) *dns.Msg {
+	extQuery := query.Copy()
+	if opt := extQuery.IsEdns0(); opt != nil {
+		opt.SetUDPSize(extUDPSize)
+	} else {
+		extQuery.SetEdns0(extUDPSize, false)
+	}
+	return extQuery
+}
+
+// fitExtResp adapts a response from an external server to the client's
+// query. The OPT record is only returned to clients that sent one, and UDP
+// responses are truncated to the client's buffer size.
+func fitExtResp(query, resp *dns.Msg, proto string, maxSize int) {
+	opt := removeEdns0(resp)
+	if query.IsEdns0() != nil {
+		if opt == nil {
+			opt = &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}
+		}
+		opt.SetUDPSize(extUDPSize)
+		opt.SetDo(query.IsEdns0().Do())
+		resp.Extra = append(resp.Extra, opt)
+	}
+	resp.Compress = true
+	if proto == "udp" {
+		resp.Truncate(maxSize)
 	}
 }
 
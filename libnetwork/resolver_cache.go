@@ -0,0 +1,251 @@
+package libnetwork
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	dnsCacheSize   = 1024             // max number of responses cached per resolver
+	maxCacheTTL    = time.Hour        // upper bound for caching positive responses
+	maxNegCacheTTL = 15 * time.Minute // upper bound for caching NXDOMAIN and NODATA responses
+)
+
+// DNSCacheStats holds the counters of the embedded DNS server's cache.
+type DNSCacheStats struct {
+	// Entries is the number of responses currently cached.
+	Entries int
+	// Hits is the number of queries answered from the cache.
+	Hits uint64
+	// Misses is the number of queries that were not found in the cache.
+	Misses uint64
+	// Forwards is the number of queries sent to external servers.
+	Forwards uint64
+}
+
+type dnsCacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+	do     bool
+}
+
+type dnsCacheEntry struct {
+	key     dnsCacheKey
+	msg     *dns.Msg
+	stored  time.Time
+	expires time.Time
+}
+
+// dnsCache is a bounded cache of the responses received from external DNS
+// servers. Entries expire with the smallest TTL of the answer records, or
+// for negative responses (NXDOMAIN and NODATA) with the TTL derived from the
+// SOA record in the authority section as described in RFC 2308. When the
+// cache is full the least recently used entry is evicted.
+type dnsCache struct {
+	hits     uint64
+	misses   uint64
+	forwards uint64
+
+	mu      sync.Mutex
+	size    int
+	entries map[dnsCacheKey]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+func newDNSCache(size int) *dnsCache {
+	return &dnsCache{
+		size:    size,
+		entries: make(map[dnsCacheKey]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+func cacheKey(query *dns.Msg) (dnsCacheKey, bool) {
+	if len(query.Question) != 1 {
+		return dnsCacheKey{}, false
+	}
+	q := query.Question[0]
+	key := dnsCacheKey{
+		name:   strings.ToLower(q.Name),
+		qtype:  q.Qtype,
+		qclass: q.Qclass,
+	}
+	if opt := query.IsEdns0(); opt != nil {
+		key.do = opt.Do()
+	}
+	return key, true
+}
+
+// cacheTTL returns how long resp may be cached. It returns false if the
+// response must not be cached.
+func cacheTTL(resp *dns.Msg) (time.Duration, bool) {
+	if resp.Truncated {
+		return 0, false
+	}
+	var ttl time.Duration
+	switch {
+	case resp.Rcode == dns.RcodeSuccess && len(resp.Answer) > 0:
+		ttl = time.Duration(minTTL(resp.Answer)) * time.Second
+		if ttl > maxCacheTTL {
+			ttl = maxCacheTTL
+		}
+	case resp.Rcode == dns.RcodeSuccess, resp.Rcode == dns.RcodeNameError:
+		// Negative responses can only be cached if the authority section
+		// carries a SOA record (RFC 2308, section 5).
+		var soa *dns.SOA
+		for _, rr := range resp.Ns {
+			if s, ok := rr.(*dns.SOA); ok {
+				soa = s
+				break
+			}
+		}
+		if soa == nil {
+			return 0, false
+		}
+		ttl = time.Duration(soa.Hdr.Ttl) * time.Second
+		if m := time.Duration(soa.Minttl) * time.Second; m < ttl {
+			ttl = m
+		}
+		if ttl > maxNegCacheTTL {
+			ttl = maxNegCacheTTL
+		}
+	default:
+		return 0, false
+	}
+	return ttl, ttl > 0
+}
+
+func minTTL(rrs []dns.RR) uint32 {
+	var ttl uint32
+	for i, rr := range rrs {
+		if t := rr.Header().Ttl; i == 0 || t < ttl {
+			ttl = t
+		}
+	}
+	return ttl
+}
+
+// get returns a copy of the cached response to query, with the TTLs
+// decremented by the time spent in the cache, or nil if there is no
+// valid entry.
+func (c *dnsCache) get(query *dns.Msg) *dns.Msg {
+	key, ok := cacheKey(query)
+	if !ok {
+		return nil
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil
+	}
+	e := el.Value.(*dnsCacheEntry)
+	if !now.Before(e.expires) {
+		c.remove(el)
+		atomic.AddUint64(&c.misses, 1)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	atomic.AddUint64(&c.hits, 1)
+
+	resp := e.msg.Copy()
+	resp.Id = query.Id
+	resp.Question = []dns.Question{query.Question[0]}
+	elapsed := uint32(now.Sub(e.stored) / time.Second)
+	for _, rrs := range [][]dns.RR{resp.Answer, resp.Ns, resp.Extra} {
+		for _, rr := range rrs {
+			h := rr.Header()
+			if h.Ttl > elapsed {
+				h.Ttl -= elapsed
+			} else {
+				h.Ttl = 0
+			}
+		}
+	}
+	return resp
+}
+
+// put stores resp as the response to query if it is cacheable.
+func (c *dnsCache) put(query, resp *dns.Msg) {
+	key, ok := cacheKey(query)
+	if !ok {
+		return
+	}
+	ttl, ok := cacheTTL(resp)
+	if !ok {
+		return
+	}
+
+	msg := resp.Copy()
+	removeEdns0(msg)
+	now := c.now()
+	e := &dnsCacheEntry{key: key, msg: msg, stored: now, expires: now.Add(ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(e)
+}
+
+func (c *dnsCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*dnsCacheEntry).key)
+}
+
+// forwarded records that a query was sent to an external server.
+func (c *dnsCache) forwarded() {
+	atomic.AddUint64(&c.forwards, 1)
+}
+
+// flush drops all cached responses.
+func (c *dnsCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[dnsCacheKey]*list.Element)
+	c.lru.Init()
+}
+
+func (c *dnsCache) stats() DNSCacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	return DNSCacheStats{
+		Entries:  entries,
+		Hits:     atomic.LoadUint64(&c.hits),
+		Misses:   atomic.LoadUint64(&c.misses),
+		Forwards: atomic.LoadUint64(&c.forwards),
+	}
+}
+
+// removeEdns0 removes the OPT pseudo-record from msg and returns it.
+func removeEdns0(msg *dns.Msg) *dns.OPT {
+	for i, rr := range msg.Extra {
+		if opt, ok := rr.(*dns.OPT); ok {
+			msg.Extra = append(msg.Extra[:i], msg.Extra[i+1:]...)
+			return opt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package libnetwork
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func newTestDNSCache(size int) (*dnsCache, *time.Time) {
+	now := time.Now()
+	c := newDNSCache(size)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func newTestAResp(query *dns.Msg, ttl uint32) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetReply(query)
+	resp.Answer = append(resp.Answer, &dns.A{
+		Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+		A:   net.ParseIP("192.0.2.1"),
+	})
+	return resp
+}
+
+func newTestNegResp(query *dns.Msg, rcode int, soaTTL, minTTL uint32) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetRcode(query, rcode)
+	resp.Ns = append(resp.Ns, &dns.SOA{
+		Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: soaTTL},
+		Ns:     "ns.example.com.",
+		Mbox:   "hostmaster.example.com.",
+		Minttl: minTTL,
+	})
+	return resp
+}
+
+func TestDNSCacheTTL(t *testing.T) {
+	c, now := newTestDNSCache(dnsCacheSize)
+
+	q := new(dns.Msg)
+	q.SetQuestion("www.example.com.", dns.TypeA)
+	assert.Check(t, is.Nil(c.get(q)))
+
+	c.put(q, newTestAResp(q, 60))
+
+	// Names are case insensitive, and the response carries the client's ID
+	// and question.
+	q2 := new(dns.Msg)
+	q2.SetQuestion("WWW.example.com.", dns.TypeA)
+	*now = now.Add(20 * time.Second)
+	resp := c.get(q2)
+	assert.Assert(t, resp != nil)
+	assert.Check(t, is.Equal(resp.Id, q2.Id))
+	assert.Check(t, is.Equal(resp.Question[0].Name, "WWW.example.com."))
+	assert.Assert(t, is.Len(resp.Answer, 1))
+	assert.Check(t, is.Equal(resp.Answer[0].Header().Ttl, uint32(40)))
+
+	// A different type is a different entry.
+	q3 := new(dns.Msg)
+	q3.SetQuestion("www.example.com.", dns.TypeAAAA)
+	assert.Check(t, is.Nil(c.get(q3)))
+
+	*now = now.Add(40 * time.Second)
+	assert.Check(t, is.Nil(c.get(q)))
+
+	assert.Check(t, is.DeepEqual(c.stats(), DNSCacheStats{Hits: 1, Misses: 3}))
+}
+
+func TestDNSCacheNegative(t *testing.T) {
+	c, now := newTestDNSCache(dnsCacheSize)
+
+	q := new(dns.Msg)
+	q.SetQuestion("missing.example.com.", dns.TypeA)
+
+	// Without a SOA record a negative response isn't cached.
+	resp := new(dns.Msg)
+	resp.SetRcode(q, dns.RcodeNameError)
+	c.put(q, resp)
+	assert.Check(t, is.Nil(c.get(q)))
+
+	// The negative TTL is the smaller of the SOA's TTL and MINIMUM field.
+	c.put(q, newTestNegResp(q, dns.RcodeNameError, 300, 30))
+	*now = now.Add(29 * time.Second)
+	resp = c.get(q)
+	assert.Assert(t, resp != nil)
+	assert.Check(t, is.Equal(resp.Rcode, dns.RcodeNameError))
+	*now = now.Add(time.Second)
+	assert.Check(t, is.Nil(c.get(q)))
+
+	// NODATA responses are cached the same way.
+	q.SetQuestion("v4only.example.com.", dns.TypeAAAA)
+	c.put(q, newTestNegResp(q, dns.RcodeSuccess, 60, 60))
+	resp = c.get(q)
+	assert.Assert(t, resp != nil)
+	assert.Check(t, is.Len(resp.Answer, 0))
+
+	// Failures and truncated responses are never cached.
+	q.SetQuestion("fail.example.com.", dns.TypeA)
+	resp = new(dns.Msg)
+	resp.SetRcode(q, dns.RcodeServerFailure)
+	c.put(q, resp)
+	resp = newTestAResp(q, 60)
+	resp.Truncated = true
+	c.put(q, resp)
+	assert.Check(t, is.Nil(c.get(q)))
+}
+
+func TestDNSCacheEviction(t *testing.T) {
+	c, _ := newTestDNSCache(2)
+
+	q1 := new(dns.Msg)
+	q1.SetQuestion("one.example.com.", dns.TypeA)
+	q2 := new(dns.Msg)
+	q2.SetQuestion("two.example.com.", dns.TypeA)
+	q3 := new(dns.Msg)
+	q3.SetQuestion("three.example.com.", dns.TypeA)
+
+	c.put(q1, newTestAResp(q1, 60))
+	c.put(q2, newTestAResp(q2, 60))
+	// Use q1 so that q2 is the least recently used entry.
+	assert.Check(t, c.get(q1) != nil)
+	c.put(q3, newTestAResp(q3, 60))
+
+	assert.Check(t, c.get(q1) != nil)
+	assert.Check(t, is.Nil(c.get(q2)))
+	assert.Check(t, c.get(q3) != nil)
+	assert.Check(t, is.Equal(c.stats().Entries, 2))
+
+	c.flush()
+	assert.Check(t, is.Equal(c.stats().Entries, 0))
+	assert.Check(t, is.Nil(c.get(q1)))
+}
+
+func TestFitExtResp(t *testing.T) {
+	q := new(dns.Msg)
+	q.SetQuestion("www.example.com.", dns.TypeA)
+
+	extQuery := extQueryMsg(q)
+	opt := extQuery.IsEdns0()
+	assert.Assert(t, opt != nil)
+	assert.Check(t, is.Equal(opt.UDPSize(), uint16(extUDPSize)))
+	assert.Check(t, is.Nil(q.IsEdns0()))
+
+	newResp := func() *dns.Msg {
+		resp := new(dns.Msg)
+		resp.SetReply(extQuery)
+		for i := 0; i < 100; i++ {
+			resp.Answer = append(resp.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.IPv4(192, 0, 2, byte(i)),
+			})
+		}
+		resp.SetEdns0(4096, false)
+		return resp
+	}
+
+	// A client without EDNS0 gets no OPT record, and at most 512 bytes
+	// over UDP.
+	resp := newResp()
+	fitExtResp(q, resp, "udp", defaultRespSize)
+	assert.Check(t, is.Nil(resp.IsEdns0()))
+	assert.Check(t, resp.Truncated)
+	assert.Check(t, resp.Len() <= defaultRespSize)
+
+	// Over TCP the response is returned whole.
+	resp = newResp()
+	fitExtResp(q, resp, "tcp", dns.MaxMsgSize-1)
+	assert.Check(t, !resp.Truncated)
+	assert.Check(t, is.Len(resp.Answer, 100))
+
+	// An EDNS0 client gets an OPT record with its DO bit.
+	q.SetEdns0(4096, true)
+	resp = newResp()
+	fitExtResp(q, resp, "udp", 4096)
+	opt = resp.IsEdns0()
+	assert.Assert(t, opt != nil)
+	assert.Check(t, opt.Do())
+	assert.Check(t, !resp.Truncated)
+	assert.Check(t, is.Len(resp.Answer, 100))
+}
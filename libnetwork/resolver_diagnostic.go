@@ -0,0 +1,112 @@
+package libnetwork
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/docker/docker/libnetwork/diagnostic"
+	"github.com/docker/docker/libnetwork/internal/caller"
+	"github.com/sirupsen/logrus"
+)
+
+// resolverPaths2Func are the diagnostic handlers of the embedded DNS servers
+var resolverPaths2Func = map[string]diagnostic.HTTPHandlerFunc{
+	"/dnscachestats": dnsCacheStats,
+	"/dnscacheflush": dnsCacheFlush,
+}
+
+// resolversOnNetwork returns the sandboxes with a running resolver. If nid
+// is not empty, only the sandboxes connected to that network are returned.
+func (c *controller) resolversOnNetwork(nid string) []*sandbox {
+	c.Lock()
+	sandboxes := make([]*sandbox, 0, len(c.sandboxes))
+	for _, sb := range c.sandboxes {
+		sandboxes = append(sandboxes, sb)
+	}
+	c.Unlock()
+
+	var res []*sandbox
+	for _, sb := range sandboxes {
+		if sb.resolver == nil {
+			continue
+		}
+		if nid == "" {
+			res = append(res, sb)
+			continue
+		}
+		for _, ep := range sb.getConnectedEndpoints() {
+			if n := ep.getNetwork(); n != nil && n.ID() == nid {
+				res = append(res, sb)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func dnsCacheStats(ctx interface{}, w http.ResponseWriter, r *http.Request) {
+	r.ParseForm() //nolint:errcheck
+	diagnostic.DebugHTTPForm(r)
+	_, json := diagnostic.ParseHTTPFormOptions(r)
+
+	// audit logs
+	log := logrus.WithFields(logrus.Fields{"component": "diagnostic", "remoteIP": r.RemoteAddr, "method": caller.Name(0), "url": r.URL.String()})
+	log.Info("dns cache stats")
+
+	c, ok := ctx.(*controller)
+	if !ok {
+		diagnostic.HTTPReply(w, diagnostic.FailCommand(fmt.Errorf("network controller not available")), json) //nolint:errcheck
+		return
+	}
+
+	var nid string
+	if len(r.Form["nid"]) > 0 {
+		nid = r.Form["nid"][0]
+	}
+	sandboxes := c.resolversOnNetwork(nid)
+	rsp := &diagnostic.TableObj{Length: len(sandboxes)}
+	for i, sb := range sandboxes {
+		stats := sb.resolver.CacheStats()
+		rsp.Elements = append(rsp.Elements, &diagnostic.DNSCacheStatsObj{
+			Index:     i,
+			Sandbox:   sb.ID(),
+			Container: sb.ContainerID(),
+			Entries:   stats.Entries,
+			Hits:      stats.Hits,
+			Misses:    stats.Misses,
+			Forwards:  stats.Forwards,
+		})
+	}
+	log.WithField("response", fmt.Sprintf("%+v", rsp)).Info("dns cache stats done")
+	diagnostic.HTTPReply(w, diagnostic.CommandSucceed(rsp), json) //nolint:errcheck
+}
+
+func dnsCacheFlush(ctx interface{}, w http.ResponseWriter, r *http.Request) {
+	r.ParseForm() //nolint:errcheck
+	diagnostic.DebugHTTPForm(r)
+	_, json := diagnostic.ParseHTTPFormOptions(r)
+
+	// audit logs
+	log := logrus.WithFields(logrus.Fields{"component": "diagnostic", "remoteIP": r.RemoteAddr, "method": caller.Name(0), "url": r.URL.String()})
+	log.Info("dns cache flush")
+
+	if len(r.Form["nid"]) < 1 {
+		rsp := diagnostic.WrongCommand("missing parameter", fmt.Sprintf("%s?nid=network_id", r.URL.Path))
+		log.Error("dns cache flush failed, wrong input")
+		diagnostic.HTTPReply(w, rsp, json) //nolint:errcheck
+		return
+	}
+
+	c, ok := ctx.(*controller)
+	if !ok {
+		diagnostic.HTTPReply(w, diagnostic.FailCommand(fmt.Errorf("network controller not available")), json) //nolint:errcheck
+		return
+	}
+
+	sandboxes := c.resolversOnNetwork(r.Form["nid"][0])
+	for _, sb := range sandboxes {
+		sb.resolver.FlushCache()
+	}
+	log.WithField("sandboxes", len(sandboxes)).Info("dns cache flush done")
+	diagnostic.HTTPReply(w, diagnostic.CommandSucceed(&diagnostic.StringCmd{Info: fmt.Sprintf("flushed %d resolver caches", len(sandboxes))}), json) //nolint:errcheck
+}
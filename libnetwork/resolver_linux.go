@@ -0,0 +1,61 @@
+package libnetwork
+
+import (
+	"net"
+	"os"
+	"runtime"
+
+	"github.com/docker/docker/libnetwork/nftables"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/sirupsen/logrus"
+)
+
+// resolverTable is the nftables table of a sandbox holding the rules which
+// redirect the DNS queries to the embedded resolver.
+const resolverTable = "docker-dns"
+
+func init() {
+	reexec.Register("setup-resolver-nftables", reexecSetupResolverNftables)
+}
+
+// reexecSetupResolverNftables programs the rules of the embedded resolver with
+// nftables, instead of iptables as reexecSetupResolver does.
+func reexecSetupResolverNftables() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if len(os.Args) < 4 {
+		logrus.Error("invalid number of arguments..")
+		os.Exit(1)
+	}
+
+	f := setResolverNetns(os.Args[1])
+	defer f.Close() //nolint:gosec
+
+	if err := resolverNftRules(os.Args[2], os.Args[3]).Apply(); err != nil {
+		logrus.Errorf("set up resolver rules failed: %v", err)
+		os.Exit(4)
+	}
+}
+
+// resolverNftRules returns the batch replacing the resolver table of a sandbox,
+// so that the DNS queries sent to the resolver IP address are redirected to
+// the UDP and TCP addresses the resolver listens on.
+func resolverNftRules(udpAddr, tcpAddr string) *nftables.Batch {
+	resolverIP, udpPort, _ := net.SplitHostPort(udpAddr)
+	_, tcpPort, _ := net.SplitHostPort(tcpAddr)
+	t := "ip " + resolverTable
+
+	// TODO IPv6 support
+	b := &nftables.Batch{}
+	b.Add("add table %s", t)
+	b.Add("delete table %s", t)
+	b.Add("add table %s", t)
+	b.Add("add chain %s output { type nat hook output priority -100; policy accept; }", t)
+	b.Add("add chain %s postrouting { type nat hook postrouting priority 100; policy accept; }", t)
+	b.Add("add rule %s output ip daddr %s udp dport %s dnat to %s", t, resolverIP, dnsPort, udpAddr)
+	b.Add("add rule %s output ip daddr %s tcp dport %s dnat to %s", t, resolverIP, dnsPort, tcpAddr)
+	b.Add("add rule %s postrouting ip saddr %s udp sport %s snat to %s", t, resolverIP, udpPort, net.JoinHostPort(resolverIP, dnsPort))
+	b.Add("add rule %s postrouting ip saddr %s tcp sport %s snat to %s", t, resolverIP, tcpPort, net.JoinHostPort(resolverIP, dnsPort))
+	return b
+}
@@ -0,0 +1,22 @@
+package libnetwork
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestResolverNftRules(t *testing.T) {
+	script := resolverNftRules("127.0.0.11:41234", "127.0.0.11:40321").String()
+	for _, cmd := range []string{
+		"delete table ip docker-dns\n",
+		"add chain ip docker-dns output { type nat hook output priority -100; policy accept; }\n",
+		"add rule ip docker-dns output ip daddr 127.0.0.11 udp dport 53 dnat to 127.0.0.11:41234\n",
+		"add rule ip docker-dns output ip daddr 127.0.0.11 tcp dport 53 dnat to 127.0.0.11:40321\n",
+		"add rule ip docker-dns postrouting ip saddr 127.0.0.11 udp sport 41234 snat to 127.0.0.11:53\n",
+		"add rule ip docker-dns postrouting ip saddr 127.0.0.11 tcp sport 40321 snat to 127.0.0.11:53\n",
+	} {
+		assert.Check(t, is.Contains(script, cmd))
+	}
+}
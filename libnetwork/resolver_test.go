@@ -280,3 +280,118 @@ func TestDNSProxyServFail(t *testing.T) {
 	}
 	t.Logf("Expected number of DNS requests generated")
 }
+
+// a DNSBackend that doesn't know any names, for tests of the forwarding path
+type tstbackend struct{}
+
+func (b *tstbackend) ResolveName(name string, iplen int) ([]net.IP, bool) { return nil, false }
+
+func (b *tstbackend) ResolveIP(name string) string { return "" }
+
+func (b *tstbackend) ResolveService(name string) ([]*net.SRV, []net.IP) { return nil, nil }
+
+func (b *tstbackend) ExecFunc(f func()) error { f(); return nil }
+
+func (b *tstbackend) NdotsSet() bool { return false }
+
+func (b *tstbackend) HandleQueryResp(name string, ip net.IP) {}
+
+func (b *tstbackend) ResolveStatic(name string, qtype uint16) ([]dns.RR, bool) { return nil, false }
+
+func (b *tstbackend) ExtServersFor(name string) []extDNSEntry { return nil }
+
+type tstudpaddr struct {
+	tstaddr
+}
+
+func (a *tstudpaddr) Network() string { return "udp" }
+
+type tstudpwriter struct {
+	tstwriter
+}
+
+func (w *tstudpwriter) LocalAddr() net.Addr { return new(tstudpaddr) }
+
+func startTestDNSServer(t *testing.T, network string, handler dns.HandlerFunc) {
+	started := make(chan struct{})
+	server := &dns.Server{Addr: "127.0.0.1:53", Net: network, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	srvErrCh := make(chan error, 1)
+	go func() {
+		srvErrCh <- server.ListenAndServe()
+	}()
+	select {
+	case <-started:
+	case err := <-srvErrCh:
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		server.Shutdown() //nolint:errcheck
+		if err := <-srvErrCh; err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDNSProxyTCPFallbackAndCache(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "test only works on linux")
+
+	var udpRequests, tcpRequests int
+	newResp := func(r *dns.Msg) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		for i := 0; i < 2; i++ {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.IPv4(192, 0, 2, byte(i+1)),
+			})
+		}
+		return m
+	}
+	// The UDP server always claims the response doesn't fit.
+	startTestDNSServer(t, "udp", func(w dns.ResponseWriter, r *dns.Msg) {
+		udpRequests++
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Truncated = true
+		w.WriteMsg(m) //nolint:errcheck
+	})
+	startTestDNSServer(t, "tcp", func(w dns.ResponseWriter, r *dns.Msg) {
+		tcpRequests++
+		w.WriteMsg(newResp(r)) //nolint:errcheck
+	})
+
+	r := NewResolver(resolverIPSandbox, true, "", &tstbackend{})
+	r.SetExtServers([]extDNSEntry{{IPStr: "127.0.0.1", HostLoopback: true}})
+
+	for i := 0; i < 2; i++ {
+		w := new(tstudpwriter)
+		q := new(dns.Msg)
+		q.SetQuestion("name1.example.", dns.TypeA)
+		r.(*resolver).ServeDNS(w, q)
+
+		resp := w.GetResponse()
+		checkNonNullResponse(t, resp)
+		checkDNSResponseCode(t, resp, dns.RcodeSuccess)
+		checkDNSAnswersCount(t, resp, 2)
+		if resp.Truncated {
+			t.Fatal("Expected a complete response")
+		}
+		if resp.IsEdns0() != nil {
+			t.Fatal("Unexpected OPT record in the response to a query without EDNS0")
+		}
+	}
+
+	// The second query is answered from the cache.
+	if udpRequests != 1 || tcpRequests != 1 {
+		t.Fatalf("Expected 1 UDP and 1 TCP query. Found: %d and %d", udpRequests, tcpRequests)
+	}
+	stats := r.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Forwards != 2 || stats.Entries != 1 {
+		t.Fatalf("Unexpected cache stats: %+v", stats)
+	}
+
+	r.FlushCache()
+	if stats := r.CacheStats(); stats.Entries != 0 {
+		t.Fatalf("Expected an empty cache after flush. Found: %d entries", stats.Entries)
+	}
+}
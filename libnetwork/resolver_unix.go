@@ -45,24 +45,14 @@ func reexecSetupResolver() {
 		{"-t", "nat", "-I", postroutingchain, "-s", resolverIP, "-p", "tcp", "--sport", tcpPort, "-j", "SNAT", "--to-source", ":" + dnsPort},
 	}
 
-	f, err := os.OpenFile(os.Args[1], os.O_RDONLY, 0)
-	if err != nil {
-		logrus.Errorf("failed get network namespace %q: %v", os.Args[1], err)
-		os.Exit(2)
-	}
+	f := setResolverNetns(os.Args[1])
 	defer f.Close() //nolint:gosec
 
-	nsFD := f.Fd()
-	if err = netns.Set(netns.NsHandle(nsFD)); err != nil {
-		logrus.Errorf("setting into container net ns %v failed, %v", os.Args[1], err)
-		os.Exit(3)
-	}
-
 	// TODO IPv6 support
 	iptable := iptables.GetIptable(iptables.IPv4)
 
 	// insert outputChain and postroutingchain
-	err = iptable.RawCombinedOutputNative("-t", "nat", "-C", "OUTPUT", "-d", resolverIP, "-j", outputChain)
+	err := iptable.RawCombinedOutputNative("-t", "nat", "-C", "OUTPUT", "-d", resolverIP, "-j", outputChain)
 	if err == nil {
 		iptable.RawCombinedOutputNative("-t", "nat", "-F", outputChain)
 	} else {
@@ -85,6 +75,24 @@ func reexecSetupResolver() {
 	}
 }
 
+// setResolverNetns moves the current thread into the network namespace at
+// path, exiting if it fails. The returned file must be kept open as long as
+// the thread is in the namespace.
+func setResolverNetns(path string) *os.File {
+	f, err := os.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		logrus.Errorf("failed get network namespace %q: %v", path, err)
+		os.Exit(2)
+	}
+
+	nsFD := f.Fd()
+	if err = netns.Set(netns.NsHandle(nsFD)); err != nil {
+		logrus.Errorf("setting into container net ns %v failed, %v", path, err)
+		os.Exit(3)
+	}
+	return f
+}
+
 func (r *resolver) setupIPTable() error {
 	if r.err != nil {
 		return r.err
@@ -92,9 +100,13 @@ func (r *resolver) setupIPTable() error {
 	laddr := r.conn.LocalAddr().String()
 	ltcpaddr := r.tcpListen.Addr().String()
 
+	setup := "setup-resolver"
+	if r.firewallBackend == "nftables" {
+		setup = "setup-resolver-nftables"
+	}
 	cmd := &exec.Cmd{
 		Path:   reexec.Self(),
-		Args:   append([]string{"setup-resolver"}, r.resolverKey, laddr, ltcpaddr),
+		Args:   append([]string{setup}, r.resolverKey, laddr, ltcpaddr),
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
 	}
@@ -48,6 +48,12 @@ type Sandbox interface {
 	// DisableService removes a managed container's endpoints from the load balancer
 	// and service discovery
 	DisableService() error
+	// ExecFunc runs the given function in the sandbox's network namespace
+	ExecFunc(f func()) error
+	// SetReady sets whether the container is ready to receive traffic. The
+	// embedded DNS server does not resolve the aliases of containers which
+	// are not ready; they remain resolvable by their name.
+	SetReady(ready bool)
 }
 
 // SandboxOption is an option setter function type used to pass various options to
@@ -82,6 +88,7 @@ type sandbox struct {
 	inDelete           bool
 	ingress            bool
 	ndotsSet           bool
+	notReady           bool
 	oslTypes           []osl.SandboxType // slice of properties of this sandbox
 	loadBalancerNID    string            // NID that this SB is a load balancer for
 	sync.Mutex
@@ -733,6 +740,21 @@ func (sb *sandbox) DisableService() (err error) {
 	return nil
 }
 
+func (sb *sandbox) SetReady(ready bool) {
+	sb.Lock()
+	sb.notReady = !ready
+	sb.Unlock()
+	for _, ep := range sb.getConnectedEndpoints() {
+		sb.controller.setEndpointReady(ep.ID(), ready)
+	}
+}
+
+func (sb *sandbox) isReady() bool {
+	sb.Lock()
+	defer sb.Unlock()
+	return !sb.notReady
+}
+
 func releaseOSSboxResources(osSbox osl.Sandbox, ep *endpoint) {
 	for _, i := range osSbox.Info().Interfaces() {
 		// Only remove the interfaces owned by this endpoint from the sandbox.
@@ -1193,6 +1215,15 @@ func OptionLoadBalancer(nid string) SandboxOption {
 	}
 }
 
+// OptionNotReady function returns an option setter for marking the
+// container of a sandbox as not ready from the start, so that its endpoints
+// are joined unready until SetReady is called.
+func OptionNotReady() SandboxOption {
+	return func(sb *sandbox) {
+		sb.notReady = true
+	}
+}
+
 // <=> Returns true if a < b, false if a > b and advances to next level if a == b
 // epi.prio <=> epj.prio           # 2 < 1
 // epi.gw <=> epj.gw               # non-gw < gw
@@ -27,7 +27,9 @@ const (
 func (sb *sandbox) startResolver(restore bool) {
 	sb.resolverOnce.Do(func() {
 		var err error
-		sb.resolver = NewResolver(resolverIPSandbox, true, sb.Key(), sb)
+		r := NewResolver(resolverIPSandbox, true, sb.Key(), sb).(*resolver)
+		r.firewallBackend = sb.controller.firewallBackend()
+		sb.resolver = r
 		defer func() {
 			if err != nil {
 				sb.resolver = nil
@@ -80,6 +80,44 @@ func TestSandboxAddEmpty(t *testing.T) {
 	osl.GC()
 }
 
+func TestSandboxJoinNotReady(t *testing.T) {
+	if !testutils.IsRunningInContainer() {
+		defer testutils.SetupTestOSContext(t)()
+	}
+
+	c, nws := getTestEnv(t, []NetworkOption{})
+	ctrlr := c.(*controller)
+
+	sbx, err := ctrlr.NewSandbox("sandbox0", OptionNotReady())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep, err := nws[0].CreateEndpoint("ep1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The endpoints of a container which is not ready yet are unready as
+	// soon as they are joined.
+	if err := ep.Join(sbx); err != nil {
+		t.Fatal(err)
+	}
+	if _, unready := ctrlr.unreadyEndpoints[ep.ID()]; !unready {
+		t.Fatal("Expected the endpoint joined to a sandbox which is not ready to be unready")
+	}
+
+	sbx.SetReady(true)
+	if _, unready := ctrlr.unreadyEndpoints[ep.ID()]; unready {
+		t.Fatal("Expected the endpoint to be ready with its sandbox")
+	}
+
+	if err := sbx.Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	osl.GC()
+}
+
 // // If different priorities are specified, internal option and ipv6 addresses mustn't influence endpoint order
 func TestSandboxAddMultiPrio(t *testing.T) {
 	if !testutils.IsRunningInContainer() {
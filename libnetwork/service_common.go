@@ -45,10 +45,12 @@ func (c *controller) addEndpointNameResolution(svcName, svcID, nID, eID, contain
 		}
 	}
 
+	// The VIP record is shared by the backends, and is removed along with
+	// the last one: it is not recorded as the address of the endpoint.
 	if addService && len(vip) != 0 {
-		n.(*network).addSvcRecords(eID, svcName, serviceID, vip, nil, false, method)
+		n.(*network).addSvcRecords("", svcName, serviceID, vip, nil, false, method)
 		for _, alias := range serviceAliases {
-			n.(*network).addSvcRecords(eID, alias, serviceID, vip, nil, false, method)
+			n.(*network).addSvcRecords("", alias, serviceID, vip, nil, false, method)
 		}
 	}
 
@@ -110,9 +112,9 @@ func (c *controller) deleteEndpointNameResolution(svcName, svcID, nID, eID, cont
 
 	// Remove the DNS record for VIP only if we are removing the service
 	if rmService && len(vip) != 0 && !multipleEntries {
-		n.(*network).deleteSvcRecords(eID, svcName, serviceID, vip, nil, false, method)
+		n.(*network).deleteSvcRecords("", svcName, serviceID, vip, nil, false, method)
 		for _, alias := range serviceAliases {
-			n.(*network).deleteSvcRecords(eID, alias, serviceID, vip, nil, false, method)
+			n.(*network).deleteSvcRecords("", alias, serviceID, vip, nil, false, method)
 		}
 	}
 
@@ -0,0 +1,326 @@
+package libnetwork
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/libnetwork/nftables"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netns"
+)
+
+const (
+	// ingressTable is the nftables table of the host translating the
+	// connections to the ports published by the ingress network, and the
+	// table of the ingress sandboxes of the containers redirecting them to
+	// their target ports.
+	ingressTable = "docker-ingress"
+	// lbTable is the nftables table of a load balancer sandbox marking the
+	// connections to the services, so that they are balanced by IPVS.
+	lbTable = "docker-lb"
+)
+
+func init() {
+	reexec.Register("fwmarker-nftables", fwMarkerNftables)
+	reexec.Register("redirector-nftables", redirectorNftables)
+}
+
+func portProtocol(iPort *PortConfig) string {
+	return strings.ToLower(PortConfig_Protocol_name[int32(iPort.Protocol)])
+}
+
+// ingressNftBatch returns the commands replacing the chains of the ingress
+// table of the host, so that the connections to the published ports of the
+// host are translated to the ingress sandbox, reached through oifName.
+func ingressNftBatch(oifName string) *nftables.Batch {
+	t := "ip " + ingressTable
+
+	// TODO IPv6 support
+	b := &nftables.Batch{}
+	b.Add("add table %s", t)
+	b.Add("add map %s ports { type inet_proto . inet_service : ipv4_addr . inet_service; }", t)
+	b.Add("add set %s masquerade { type ifname; }", t)
+	for _, chain := range []string{"prerouting", "output"} {
+		b.Add("add chain %s %s { type nat hook %s priority -101; policy accept; }", t, chain, chain)
+		b.Add("flush chain %s %s", t, chain)
+		b.Add("add rule %s %s fib daddr type local dnat ip addr . port to meta l4proto . th dport map @ports", t, chain)
+	}
+	b.Add("add chain %s postrouting { type nat hook postrouting priority 99; policy accept; }", t)
+	b.Add("flush chain %s postrouting", t)
+	b.Add("add rule %s postrouting fib saddr type local oifname @masquerade masquerade", t)
+	b.Add("add element %s masquerade { %s }", t, nftables.Quote(oifName))
+	return b
+}
+
+// ingressNftPortsBatch returns the commands adding, or deleting, the
+// translation of published ports to the ingress sandbox at gwIP, and the
+// acceptance of their forwarded connections by the bridge networks.
+func ingressNftPortsBatch(gwIP net.IP, ingressPorts []*PortConfig, isDelete bool) *nftables.Batch {
+	op := "add"
+	if isDelete {
+		op = "delete"
+	}
+	b := &nftables.Batch{}
+	for _, iPort := range ingressPorts {
+		key := fmt.Sprintf("%s . %d", portProtocol(iPort), iPort.PublishedPort)
+		if isDelete {
+			b.Add("%s element ip %s ports { %s }", op, ingressTable, key)
+		} else {
+			b.Add("%s element ip %s ports { %s : %s . %d }", op, ingressTable, key, gwIP, iPort.PublishedPort)
+		}
+		b.Add("%s element ip %s %s { %s }", op, nftables.DockerTable, nftables.IngressPorts, key)
+	}
+	return b
+}
+
+// programIngressNft programs the rules of the ingress network of the host
+// with nftables, instead of iptables as programIngress does.
+func programIngressNft(gwIP net.IP, ingressPorts []*PortConfig, isDelete bool) error {
+	ingressMu.Lock()
+	defer ingressMu.Unlock()
+
+	ingressOnce.Do(func() {
+		// Remove the table during init if it exists. It might contain stale
+		// rules from previous life.
+		b := &nftables.Batch{}
+		b.Add("add table ip %s", ingressTable)
+		b.Add("delete table ip %s", ingressTable)
+		if err := b.Apply(); err != nil {
+			logrus.Errorf("Could not remove the ingress table during init: %v", err)
+		}
+	})
+
+	if !isDelete {
+		oifName, err := findOIFName(gwIP)
+		if err != nil {
+			return fmt.Errorf("failed to find gateway bridge interface name for %s: %v", gwIP, err)
+		}
+
+		path := filepath.Join("/proc/sys/net/ipv4/conf", oifName, "route_localnet")
+		if err := os.WriteFile(path, []byte{'1', '\n'}, 0644); err != nil { //nolint:gosec // gosec complains about perms here, which must be 0644 in this case
+			return fmt.Errorf("could not write to %s: %v", path, err)
+		}
+
+		if err := ingressNftBatch(oifName).Apply(); err != nil {
+			return fmt.Errorf("failed to set up the ingress table: %v", err)
+		}
+	}
+
+	// Filter the ingress ports until port rules start to be added/deleted
+	filteredPorts := filterPortConfigs(ingressPorts, isDelete)
+	if isDelete {
+		// The ports are deleted one at a time, so that a port which is
+		// already gone does not prevent the deletion of the others.
+		for _, iPort := range filteredPorts {
+			if err := ingressNftPortsBatch(gwIP, []*PortConfig{iPort}, true).Apply(); err != nil {
+				logrus.Warnf("failed to delete the ingress rules of port %d/%s: %v", iPort.PublishedPort, portProtocol(iPort), err)
+			}
+			if err := plumbProxy(iPort, true); err != nil {
+				logrus.Warnf("failed to delete proxy for port %d: %v", iPort.PublishedPort, err)
+			}
+		}
+		return nil
+	}
+
+	// The rules of all the ports are added atomically, so that there is
+	// nothing to roll back on failure but the port configs.
+	if err := ingressNftPortsBatch(gwIP, filteredPorts, false).Apply(); err != nil {
+		filterPortConfigs(filteredPorts, true)
+		return fmt.Errorf("set up ingress rules failed: %v", err)
+	}
+	for _, iPort := range filteredPorts {
+		if err := plumbProxy(iPort, false); err != nil {
+			logrus.Warnf("failed to create proxy for port %d: %v", iPort.PublishedPort, err)
+		}
+	}
+	return nil
+}
+
+// fwMarkerNftBatch returns the commands adding, or deleting, the marks of the
+// connections to a service, through its vip or its ingressPorts. In NAT
+// mode, the marked connections are also translated to the address eIP of the
+// load balancer in subnet.
+func fwMarkerNftBatch(vip string, fwMark uint32, ingressPorts []*PortConfig, eIP net.IP, subnet *net.IPNet, isDelete bool) *nftables.Batch {
+	t := "ip " + lbTable
+
+	// TODO IPv6 support
+	b := &nftables.Batch{}
+	if isDelete {
+		for _, iPort := range ingressPorts {
+			b.Add("delete element %s ingress { %s . %d }", t, portProtocol(iPort), iPort.PublishedPort)
+		}
+		b.Add("delete element %s vips { %s }", t, vip)
+		return b
+	}
+
+	b.Add("add table %s", t)
+	b.Add("add map %s ingress { type inet_proto . inet_service : mark; }", t)
+	b.Add("add map %s vips { type ipv4_addr : mark; }", t)
+	b.Add("add chain %s prerouting { type filter hook prerouting priority -150; policy accept; }", t)
+	b.Add("flush chain %s prerouting", t)
+	b.Add("add rule %s prerouting meta mark set meta l4proto . th dport map @ingress", t)
+	b.Add("add chain %s input { type filter hook input priority -150; policy accept; }", t)
+	b.Add("flush chain %s input", t)
+	b.Add("add rule %s input meta mark set ip daddr map @vips", t)
+	if subnet != nil {
+		// There is no match of the connections handled by IPVS, but the
+		// mark of their packets is preserved.
+		b.Add("add chain %s postrouting { type nat hook postrouting priority 100; policy accept; }", t)
+		b.Add("flush chain %s postrouting", t)
+		b.Add("add rule %s postrouting meta mark != 0 ip daddr %s snat to %s", t, subnet, eIP)
+	}
+	for _, iPort := range ingressPorts {
+		b.Add("add element %s ingress { %s . %d : %d }", t, portProtocol(iPort), iPort.PublishedPort, fwMark)
+	}
+	b.Add("add element %s vips { %s : %d }", t, vip, fwMark)
+	return b
+}
+
+// fwMarkerNftables marks the connections to a service with nftables,
+// instead of iptables as fwMarker does.
+func fwMarkerNftables() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if len(os.Args) < 8 {
+		logrus.Error("invalid number of arguments..")
+		os.Exit(1)
+	}
+
+	var ingressPorts []*PortConfig
+	if os.Args[5] != "" {
+		var err error
+		ingressPorts, err = readPortsFromFile(os.Args[5])
+		if err != nil {
+			logrus.Errorf("Failed reading ingress ports file: %v", err)
+			os.Exit(2)
+		}
+	}
+
+	vip := os.Args[2]
+	fwMark, err := strconv.ParseUint(os.Args[3], 10, 32)
+	if err != nil {
+		logrus.Errorf("bad fwmark value(%s) passed: %v", os.Args[3], err)
+		os.Exit(3)
+	}
+	isDelete := os.Args[4] == "-D"
+
+	ns, err := netns.GetFromPath(os.Args[1])
+	if err != nil {
+		logrus.Errorf("failed get network namespace %q: %v", os.Args[1], err)
+		os.Exit(4)
+	}
+	defer ns.Close()
+
+	if err := netns.Set(ns); err != nil {
+		logrus.Errorf("setting into container net ns %v failed, %v", os.Args[1], err)
+		os.Exit(5)
+	}
+
+	var (
+		eIP    net.IP
+		subnet *net.IPNet
+	)
+	if !isDelete && os.Args[7] == loadBalancerModeNAT {
+		eIP, subnet, err = net.ParseCIDR(os.Args[6])
+		if err != nil {
+			logrus.Errorf("Failed to parse endpoint IP %s: %v", os.Args[6], err)
+			os.Exit(6)
+		}
+
+		if err := os.WriteFile("/proc/sys/net/ipv4/vs/conntrack", []byte{'1', '\n'}, 0644); err != nil { //nolint:gosec
+			logrus.Errorf("Failed to write to /proc/sys/net/ipv4/vs/conntrack: %v", err)
+			os.Exit(7)
+		}
+	}
+
+	if err := fwMarkerNftBatch(vip, uint32(fwMark), ingressPorts, eIP, subnet, isDelete).Apply(); err != nil {
+		logrus.Errorf("set up rules failed: %v", err)
+		os.Exit(8)
+	}
+}
+
+// redirectorNftBatch returns the commands replacing the ingress table of the
+// sandbox of a container at ipAddr, so that the connections to the published
+// ingressPorts are redirected to their target ports, and only those.
+func redirectorNftBatch(ipAddr string, ingressPorts []*PortConfig) *nftables.Batch {
+	t := "ip " + ingressTable
+
+	// TODO IPv6 support
+	b := &nftables.Batch{}
+	b.Add("add table %s", t)
+	b.Add("delete table %s", t)
+	b.Add("add table %s", t)
+	b.Add("add chain %s prerouting { type nat hook prerouting priority -100; policy accept; }", t)
+	b.Add("add chain %s input { type filter hook input priority 0; policy accept; }", t)
+	b.Add("add chain %s output { type filter hook output priority 0; policy accept; }", t)
+	for _, iPort := range ingressPorts {
+		protocol := portProtocol(iPort)
+		b.Add("add rule %s prerouting ip daddr %s %s dport %d redirect to :%d", t, ipAddr, protocol, iPort.PublishedPort, iPort.TargetPort)
+
+		// Allow only incoming connections to exposed ports
+		b.Add("add rule %s input ip daddr %s %s dport %d ct state new,established accept", t, ipAddr, protocol, iPort.TargetPort)
+
+		// Allow only outgoing connections from exposed ports
+		b.Add("add rule %s output ip saddr %s %s sport %d ct state established accept", t, ipAddr, protocol, iPort.TargetPort)
+	}
+	if len(ingressPorts) == 0 {
+		return b
+	}
+
+	// Ensure blocking rules for anything else in/to ingress network
+	b.Add("add rule %s input ip daddr %s meta l4proto { sctp, udp, tcp } drop", t, ipAddr)
+	b.Add("add rule %s output ip saddr %s meta l4proto { sctp, udp, tcp } drop", t, ipAddr)
+	return b
+}
+
+// redirectorNftables programs the redirection of the ingress ports of a
+// container with nftables, instead of iptables as redirector does.
+func redirectorNftables() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if len(os.Args) < 4 {
+		logrus.Error("invalid number of arguments..")
+		os.Exit(1)
+	}
+
+	var ingressPorts []*PortConfig
+	if os.Args[3] != "" {
+		var err error
+		ingressPorts, err = readPortsFromFile(os.Args[3])
+		if err != nil {
+			logrus.Errorf("Failed reading ingress ports file: %v", err)
+			os.Exit(2)
+		}
+	}
+
+	eIP, _, err := net.ParseCIDR(os.Args[2])
+	if err != nil {
+		logrus.Errorf("Failed to parse endpoint IP %s: %v", os.Args[2], err)
+		os.Exit(3)
+	}
+
+	ns, err := netns.GetFromPath(os.Args[1])
+	if err != nil {
+		logrus.Errorf("failed get network namespace %q: %v", os.Args[1], err)
+		os.Exit(4)
+	}
+	defer ns.Close()
+
+	if err := netns.Set(ns); err != nil {
+		logrus.Errorf("setting into container net ns %v failed, %v", os.Args[1], err)
+		os.Exit(5)
+	}
+
+	if err := redirectorNftBatch(eIP.String(), ingressPorts).Apply(); err != nil {
+		logrus.Errorf("set up rules failed: %v", err)
+		os.Exit(6)
+	}
+}
@@ -0,0 +1,80 @@
+package libnetwork
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+var testIngressPorts = []*PortConfig{
+	{Protocol: ProtocolTCP, PublishedPort: 8080, TargetPort: 80},
+	{Protocol: ProtocolUDP, PublishedPort: 5353, TargetPort: 53},
+}
+
+func TestIngressNftRules(t *testing.T) {
+	script := ingressNftBatch("docker_gwbridge").String()
+	for _, cmd := range []string{
+		"add rule ip docker-ingress prerouting fib daddr type local dnat ip addr . port to meta l4proto . th dport map @ports\n",
+		"add rule ip docker-ingress output fib daddr type local dnat ip addr . port to meta l4proto . th dport map @ports\n",
+		"add rule ip docker-ingress postrouting fib saddr type local oifname @masquerade masquerade\n",
+		`add element ip docker-ingress masquerade { "docker_gwbridge" }` + "\n",
+	} {
+		assert.Check(t, is.Contains(script, cmd))
+	}
+
+	gwIP := net.ParseIP("172.18.0.2")
+	assert.Check(t, is.Equal(ingressNftPortsBatch(gwIP, testIngressPorts, false).String(),
+		`add element ip docker-ingress ports { tcp . 8080 : 172.18.0.2 . 8080 }
+add element ip docker ingress-ports { tcp . 8080 }
+add element ip docker-ingress ports { udp . 5353 : 172.18.0.2 . 5353 }
+add element ip docker ingress-ports { udp . 5353 }
+`))
+	assert.Check(t, is.Equal(ingressNftPortsBatch(gwIP, testIngressPorts[:1], true).String(),
+		`delete element ip docker-ingress ports { tcp . 8080 }
+delete element ip docker ingress-ports { tcp . 8080 }
+`))
+}
+
+func TestFWMarkerNftRules(t *testing.T) {
+	eIP, subnet, err := net.ParseCIDR("10.0.0.3/24")
+	assert.NilError(t, err)
+
+	script := fwMarkerNftBatch("10.0.0.2", 257, testIngressPorts, eIP, subnet, false).String()
+	for _, cmd := range []string{
+		"add rule ip docker-lb prerouting meta mark set meta l4proto . th dport map @ingress\n",
+		"add rule ip docker-lb input meta mark set ip daddr map @vips\n",
+		"add rule ip docker-lb postrouting meta mark != 0 ip daddr 10.0.0.0/24 snat to 10.0.0.3\n",
+		"add element ip docker-lb ingress { tcp . 8080 : 257 }\n",
+		"add element ip docker-lb ingress { udp . 5353 : 257 }\n",
+		"add element ip docker-lb vips { 10.0.0.2 : 257 }\n",
+	} {
+		assert.Check(t, is.Contains(script, cmd))
+	}
+
+	script = fwMarkerNftBatch("10.0.0.2", 257, nil, nil, nil, false).String()
+	assert.Check(t, !strings.Contains(script, "postrouting"))
+
+	assert.Check(t, is.Equal(fwMarkerNftBatch("10.0.0.2", 257, testIngressPorts[:1], nil, nil, true).String(),
+		`delete element ip docker-lb ingress { tcp . 8080 }
+delete element ip docker-lb vips { 10.0.0.2 }
+`))
+}
+
+func TestRedirectorNftRules(t *testing.T) {
+	script := redirectorNftBatch("10.255.0.5", testIngressPorts).String()
+	for _, cmd := range []string{
+		"delete table ip docker-ingress\n",
+		"add rule ip docker-ingress prerouting ip daddr 10.255.0.5 tcp dport 8080 redirect to :80\n",
+		"add rule ip docker-ingress input ip daddr 10.255.0.5 udp dport 53 ct state new,established accept\n",
+		"add rule ip docker-ingress output ip saddr 10.255.0.5 tcp sport 80 ct state established accept\n",
+		"add rule ip docker-ingress input ip daddr 10.255.0.5 meta l4proto { sctp, udp, tcp } drop\n",
+	} {
+		assert.Check(t, is.Contains(script, cmd))
+	}
+
+	script = redirectorNftBatch("10.255.0.5", nil).String()
+	assert.Check(t, !strings.Contains(script, "drop"))
+}
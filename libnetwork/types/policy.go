@@ -0,0 +1,198 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// Actions of the rules of a network policy.
+const (
+	PolicyAllow = "allow"
+	PolicyDeny  = "deny"
+)
+
+// NetworkPolicy controls the communication between the containers of a
+// network. The first rule matching a connection decides whether it is
+// allowed, and the connections matching no rule are subject to the default
+// action, PolicyAllow when not set.
+type NetworkPolicy struct {
+	Default string       `json:",omitempty"`
+	Rules   []PolicyRule `json:",omitempty"`
+}
+
+// PolicyRule is a rule of a network policy. It matches the connections from
+// the containers having all the From labels to the containers having all the
+// To labels, for the protocol and to one of the ports. Empty selectors match
+// all the containers of the network, an empty protocol all the protocols and
+// no ports all the ports.
+type PolicyRule struct {
+	Action   string
+	From     map[string]string `json:",omitempty"`
+	To       map[string]string `json:",omitempty"`
+	Protocol string            `json:",omitempty"`
+	Ports    []PortRange       `json:",omitempty"`
+}
+
+// PortRange is a range of ports, from Start to End included.
+type PortRange struct {
+	Start uint16
+	End   uint16
+}
+
+// String returns the range as start-end, or as the port if it has only one.
+func (r PortRange) String() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("%d", r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// Validate returns a BadRequestError if the policy is not valid.
+func (p *NetworkPolicy) Validate() error {
+	switch p.Default {
+	case "", PolicyAllow, PolicyDeny:
+	default:
+		return BadRequestErrorf("invalid default action %q of network policy: must be %q or %q", p.Default, PolicyAllow, PolicyDeny)
+	}
+	for i, r := range p.Rules {
+		if r.Action != PolicyAllow && r.Action != PolicyDeny {
+			return BadRequestErrorf("invalid action %q of rule %d of network policy: must be %q or %q", r.Action, i, PolicyAllow, PolicyDeny)
+		}
+		for _, selector := range []map[string]string{r.From, r.To} {
+			if _, ok := selector[""]; ok {
+				return BadRequestErrorf("invalid selector of rule %d of network policy: empty label name", i)
+			}
+		}
+		switch r.Protocol {
+		case "":
+			if len(r.Ports) > 0 {
+				return BadRequestErrorf("invalid rule %d of network policy: ports require a protocol", i)
+			}
+		case "tcp", "udp", "sctp":
+		default:
+			return BadRequestErrorf("invalid protocol %q of rule %d of network policy: must be tcp, udp or sctp", r.Protocol, i)
+		}
+		for _, pr := range r.Ports {
+			if pr.Start == 0 || pr.End < pr.Start {
+				return BadRequestErrorf("invalid port range %d-%d of rule %d of network policy", pr.Start, pr.End, i)
+			}
+		}
+	}
+	return nil
+}
+
+// GetCopy returns a copy of this NetworkPolicy structure instance
+func (p *NetworkPolicy) GetCopy() *NetworkPolicy {
+	if p == nil {
+		return nil
+	}
+	c := &NetworkPolicy{Default: p.Default}
+	if p.Rules != nil {
+		c.Rules = make([]PolicyRule, 0, len(p.Rules))
+		for _, r := range p.Rules {
+			cr := PolicyRule{
+				Action:   r.Action,
+				From:     copyLabels(r.From),
+				To:       copyLabels(r.To),
+				Protocol: r.Protocol,
+			}
+			if r.Ports != nil {
+				cr.Ports = append([]PortRange{}, r.Ports...)
+			}
+			c.Rules = append(c.Rules, cr)
+		}
+	}
+	return c
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	c := make(map[string]string, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
+
+// PolicyEndpoint is an endpoint of a network subject to its policy.
+type PolicyEndpoint struct {
+	Addrs  []net.IP
+	Labels map[string]string
+}
+
+// PolicyFilter is a rule of a network policy applied to the addresses of the
+// endpoints it selects. Nil sources or destinations match all the addresses
+// of the network.
+type PolicyFilter struct {
+	Accept       bool
+	Sources      []net.IP
+	Destinations []net.IP
+	Protocol     string
+	Ports        []PortRange
+}
+
+// Filters returns the filters enforcing the policy between the IPv4, or IPv6,
+// addresses of endpoints, in the order of the rules. The rules selecting no
+// endpoint are left out, and the last filter applies the default action.
+func (p *NetworkPolicy) Filters(endpoints []PolicyEndpoint, ipv6 bool) []PolicyFilter {
+	filters := make([]PolicyFilter, 0, len(p.Rules)+1)
+	for _, r := range p.Rules {
+		srcs, ok := selectAddrs(endpoints, r.From, ipv6)
+		if !ok {
+			continue
+		}
+		dsts, ok := selectAddrs(endpoints, r.To, ipv6)
+		if !ok {
+			continue
+		}
+		filters = append(filters, PolicyFilter{
+			Accept:       r.Action == PolicyAllow,
+			Sources:      srcs,
+			Destinations: dsts,
+			Protocol:     r.Protocol,
+			Ports:        r.Ports,
+		})
+	}
+	return append(filters, PolicyFilter{Accept: p.Default != PolicyDeny})
+}
+
+// selectAddrs returns the sorted addresses of the endpoints having the labels
+// of selector, or nil if the selector is empty. It returns false if the
+// selector matches no address.
+func selectAddrs(endpoints []PolicyEndpoint, selector map[string]string, ipv6 bool) ([]net.IP, bool) {
+	if len(selector) == 0 {
+		return nil, true
+	}
+	var addrs []net.IP
+	for _, ep := range endpoints {
+		if !hasLabels(ep.Labels, selector) {
+			continue
+		}
+		for _, ip := range ep.Addrs {
+			if v4 := ip.To4(); v4 != nil {
+				if !ipv6 {
+					addrs = append(addrs, v4)
+				}
+			} else if ipv6 {
+				addrs = append(addrs, ip)
+			}
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i], addrs[j]) < 0
+	})
+	return addrs, len(addrs) > 0
+}
+
+func hasLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestNetworkPolicyValidate(t *testing.T) {
+	valid := []NetworkPolicy{
+		{},
+		{Default: PolicyDeny},
+		{Rules: []PolicyRule{{Action: PolicyAllow, From: map[string]string{"role": "web"}}}},
+		{Rules: []PolicyRule{{Action: PolicyDeny, Protocol: "udp"}}},
+		{Rules: []PolicyRule{{Action: PolicyAllow, Protocol: "tcp", Ports: []PortRange{{5432, 5432}, {8000, 8080}}}}},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("expected policy %+v to be valid, got %v", p, err)
+		}
+	}
+
+	invalid := []NetworkPolicy{
+		{Default: "reject"},
+		{Rules: []PolicyRule{{}}},
+		{Rules: []PolicyRule{{Action: PolicyAllow, To: map[string]string{"": "db"}}}},
+		{Rules: []PolicyRule{{Action: PolicyAllow, Protocol: "icmp"}}},
+		{Rules: []PolicyRule{{Action: PolicyAllow, Ports: []PortRange{{80, 80}}}}},
+		{Rules: []PolicyRule{{Action: PolicyAllow, Protocol: "tcp", Ports: []PortRange{{0, 80}}}}},
+		{Rules: []PolicyRule{{Action: PolicyAllow, Protocol: "tcp", Ports: []PortRange{{81, 80}}}}},
+	}
+	for _, p := range invalid {
+		err := p.Validate()
+		if err == nil {
+			t.Errorf("expected policy %+v to be invalid", p)
+			continue
+		}
+		if _, ok := err.(BadRequestError); !ok {
+			t.Errorf("expected a BadRequestError for policy %+v, got %T", p, err)
+		}
+	}
+}
+
+func TestNetworkPolicyFilters(t *testing.T) {
+	p := &NetworkPolicy{
+		Default: PolicyDeny,
+		Rules: []PolicyRule{
+			{Action: PolicyAllow, From: map[string]string{"role": "web"}, To: map[string]string{"role": "db"}, Protocol: "tcp", Ports: []PortRange{{5432, 5432}}},
+			{Action: PolicyDeny, From: map[string]string{"role": "batch"}},
+			{Action: PolicyAllow, To: map[string]string{"role": "web"}},
+		},
+	}
+	endpoints := []PolicyEndpoint{
+		{Addrs: []net.IP{net.ParseIP("172.20.0.3"), net.ParseIP("fd00::3")}, Labels: map[string]string{"role": "web", "tier": "front"}},
+		{Addrs: []net.IP{net.ParseIP("172.20.0.4")}, Labels: map[string]string{"role": "db"}},
+		{Addrs: []net.IP{net.ParseIP("172.20.0.2").To4()}, Labels: map[string]string{"role": "web"}},
+		{Addrs: []net.IP{net.ParseIP("172.20.0.5")}},
+	}
+
+	expected := []PolicyFilter{
+		{
+			Accept:       true,
+			Sources:      []net.IP{net.ParseIP("172.20.0.2").To4(), net.ParseIP("172.20.0.3").To4()},
+			Destinations: []net.IP{net.ParseIP("172.20.0.4").To4()},
+			Protocol:     "tcp",
+			Ports:        []PortRange{{5432, 5432}},
+		},
+		{
+			Accept:       true,
+			Destinations: []net.IP{net.ParseIP("172.20.0.2").To4(), net.ParseIP("172.20.0.3").To4()},
+		},
+		{},
+	}
+	if filters := p.Filters(endpoints, false); !reflect.DeepEqual(filters, expected) {
+		t.Fatalf("unexpected IPv4 filters:\n%+v\nexpected:\n%+v", filters, expected)
+	}
+
+	// The database has no IPv6 address, so only the last rule applies.
+	expected = []PolicyFilter{
+		{Accept: true, Destinations: []net.IP{net.ParseIP("fd00::3")}},
+		{},
+	}
+	if filters := p.Filters(endpoints, true); !reflect.DeepEqual(filters, expected) {
+		t.Fatalf("unexpected IPv6 filters:\n%+v\nexpected:\n%+v", filters, expected)
+	}
+
+	p.Default = ""
+	filters := p.Filters(nil, false)
+	if len(filters) != 1 || !filters[0].Accept {
+		t.Fatalf("expected only the default filter, accepting connections, got %+v", filters)
+	}
+}
@@ -35,6 +35,27 @@ type QosPolicy struct {
 	MaxEgressBandwidth uint64
 }
 
+// Bandwidth represents the bandwidth limits of an endpoint. The rates are in
+// bytes per second and the bursts in bytes; a zero rate means no limit, and
+// a zero burst a default burst for the rate. Priority is the priority given
+// to the packets sent by the endpoint.
+type Bandwidth struct {
+	IngressRate  uint64 `json:",omitempty"`
+	IngressBurst uint64 `json:",omitempty"`
+	EgressRate   uint64 `json:",omitempty"`
+	EgressBurst  uint64 `json:",omitempty"`
+	Priority     uint32 `json:",omitempty"`
+}
+
+// GetCopy returns a copy of this Bandwidth structure instance
+func (b *Bandwidth) GetCopy() *Bandwidth {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
+
 // TransportPort represents a local Layer 4 endpoint
 type TransportPort struct {
 	Proto Protocol
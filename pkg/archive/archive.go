@@ -70,6 +70,10 @@ type (
 		// replaced with the matching name from this map.
 		RebaseNames map[string]string
 		InUserNS    bool
+		// When packing, specifies whether all the extended attributes of
+		// the files are included in the archive, rather than only their
+		// security.capability.
+		Xattrs bool
 	}
 )
 
@@ -293,9 +297,17 @@ func CompressStream(dest io.Writer, compression Compression) (io.WriteCloser, er
 		gzWriter := gzip.NewWriter(dest)
 		writeBufWrapper := p.NewWriteCloserWrapper(buf, gzWriter)
 		return writeBufWrapper, nil
+	case Zstd:
+		zstdWriter, err := zstd.NewWriter(dest)
+		if err != nil {
+			return nil, err
+		}
+		writeBufWrapper := p.NewWriteCloserWrapper(buf, zstdWriter)
+		return writeBufWrapper, nil
 	case Bzip2, Xz:
 		// archive/bzip2 does not support writing, and there is no xz support at all
-		// However, this is not a problem as docker only currently generates gzipped tars
+		// However, this is not a problem as docker only currently generates gzipped
+		// or zstd-compressed tars
 		return nil, fmt.Errorf("Unsupported compression format %s", (&compression).Extension())
 	default:
 		return nil, fmt.Errorf("Unsupported compression format %s", (&compression).Extension())
@@ -500,6 +512,39 @@ func ReadSecurityXattrToTarHeader(path string, hdr *tar.Header) error {
 	return nil
 }
 
+// ReadXattrsToTarHeader reads all the xattrs of path from filesystem to a tar
+// header. The security.capability xattr is converted as by
+// ReadSecurityXattrToTarHeader.
+func ReadXattrsToTarHeader(path string, hdr *tar.Header) error {
+	if err := ReadSecurityXattrToTarHeader(path, hdr); err != nil {
+		return err
+	}
+	attrs, err := system.Llistxattr(path)
+	if err != nil {
+		if errors.Is(err, syscall.ENOTSUP) || errors.Is(err, system.ErrNotSupportedPlatform) {
+			return nil
+		}
+		return err
+	}
+	for _, attr := range attrs {
+		if attr == "security.capability" {
+			continue
+		}
+		value, err := system.Lgetxattr(path, attr)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if hdr.Xattrs == nil {
+			hdr.Xattrs = make(map[string]string)
+		}
+		hdr.Xattrs[attr] = string(value)
+	}
+	return nil
+}
+
 type tarWhiteoutConverter interface {
 	ConvertWrite(*tar.Header, string, os.FileInfo) (*tar.Header, error)
 	ConvertRead(*tar.Header, string) (bool, error)
@@ -513,6 +558,9 @@ type tarAppender struct {
 	SeenFiles       map[uint64]string
 	IdentityMapping idtools.IdentityMapping
 	ChownOpts       *idtools.Identity
+	// Xattrs specifies whether all the xattrs of the files are added to
+	// their header, rather than only security.capability.
+	Xattrs bool
 
 	// For packing and unpacking whiteout files in the
 	// non standard format. The whiteout files defined
@@ -570,7 +618,12 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 	if err != nil {
 		return err
 	}
-	if err := ReadSecurityXattrToTarHeader(path, hdr); err != nil {
+	if ta.Xattrs {
+		err = ReadXattrsToTarHeader(path, hdr)
+	} else {
+		err = ReadSecurityXattrToTarHeader(path, hdr)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -849,6 +902,7 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 			options.ChownOpts,
 		)
 		ta.WhiteoutConverter = whiteoutConverter
+		ta.Xattrs = options.Xattrs
 
 		defer func() {
 			// Make sure to check the error on Close.
@@ -277,6 +277,40 @@ func TestTarUntarWithXattr(t *testing.T) {
 	}
 }
 
+func TestTarWithXattrs(t *testing.T) {
+	origin := t.TempDir()
+	err := os.WriteFile(filepath.Join(origin, "1"), []byte("hello world"), 0600)
+	assert.NilError(t, err)
+	if err := system.Lsetxattr(filepath.Join(origin, "1"), "user.test", []byte("value"), 0); err != nil {
+		t.Skipf("user xattrs not supported: %v", err)
+	}
+
+	for _, all := range []bool{false, true} {
+		rdr, err := TarWithOptions(origin, &TarOptions{Xattrs: all})
+		assert.NilError(t, err)
+
+		var found bool
+		tr := tar.NewReader(rdr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NilError(t, err)
+			if hdr.Name == "1" {
+				found = true
+				if all {
+					assert.Check(t, is.Equal(hdr.Xattrs["user.test"], "value"))
+				} else {
+					assert.Check(t, is.Len(hdr.Xattrs, 0))
+				}
+			}
+		}
+		assert.Check(t, found)
+		assert.NilError(t, rdr.Close())
+	}
+}
+
 func TestCopyInfoDestinationPathSymlink(t *testing.T) {
 	tmpDir, _ := getTestTempDirs(t)
 	defer removeAllPaths(tmpDir)
@@ -0,0 +1,345 @@
+package archive // import "github.com/docker/docker/pkg/archive"
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// ZstdChunkedManifestChecksumAnnotation is the layer descriptor annotation
+	// holding the digest of the compressed table of contents of a
+	// zstd:chunked layer.
+	ZstdChunkedManifestChecksumAnnotation = "io.github.containers.zstd-chunked.manifest-checksum"
+	// ZstdChunkedManifestPositionAnnotation is the layer descriptor
+	// annotation holding the position of the table of contents of a
+	// zstd:chunked layer, as "offset:length:uncompressedLength:type".
+	ZstdChunkedManifestPositionAnnotation = "io.github.containers.zstd-chunked.manifest-position"
+
+	zstdChunkedManifestTypeCRFS = 1
+	zstdChunkedFrameMagic       = "GNUlInUx"
+	zstdSkippableFrameMagic     = 0x184D2A50
+
+	// zstdChunkedMaxManifestSize is the maximum size of the uncompressed
+	// table of contents read by OpenZstdChunked.
+	zstdChunkedMaxManifestSize = 50 << 20
+)
+
+// zstdChunkedTOC is the table of contents of a zstd:chunked layer. It lists
+// the entries of the tar stream, and the zstd frame holding the content of
+// each regular file, so that files can be fetched individually.
+type zstdChunkedTOC struct {
+	Version int                `json:"version"`
+	Entries []ZstdChunkedEntry `json:"entries"`
+}
+
+// ZstdChunkedEntry is an entry of the table of contents of a zstd:chunked
+// layer. The content of a regular file is in the zstd frame starting at
+// Offset and ending at EndOffset in the layer.
+type ZstdChunkedEntry struct {
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	LinkName  string    `json:"linkName,omitempty"`
+	Mode      int64     `json:"mode,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	UID       int       `json:"uid,omitempty"`
+	GID       int       `json:"gid,omitempty"`
+	ModTime   time.Time `json:"modtime"`
+	Offset    int64     `json:"offset,omitempty"`
+	EndOffset int64     `json:"endOffset,omitempty"`
+	Digest    string    `json:"digest,omitempty"`
+}
+
+// ZstdChunkedWriter compresses a tar stream in the zstd:chunked format. The
+// content of each regular file is compressed in its own zstd frame, and a
+// table of contents locating these frames is appended to the stream in a
+// skippable frame. The result is a valid zstd stream, which decompresses to
+// the original tar stream.
+type ZstdChunkedWriter struct {
+	pw          *io.PipeWriter
+	done        chan error
+	annotations map[string]string
+}
+
+// NewZstdChunkedWriter returns a writer which compresses the tar stream
+// written to it in the zstd:chunked format, and writes the result to dest.
+// The caller must close the writer to flush the table of contents.
+func NewZstdChunkedWriter(dest io.Writer) (*ZstdChunkedWriter, error) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	w := &ZstdChunkedWriter{
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := w.compress(pr, &countingWriter{w: dest}, enc)
+		// unblock the writer if compression failed early.
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+// Write writes a part of the tar stream.
+func (w *ZstdChunkedWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close flushes the compressed stream and its table of contents.
+func (w *ZstdChunkedWriter) Close() error {
+	w.pw.Close()
+	return <-w.done
+}
+
+// Annotations returns the annotations to add to the descriptor of the layer
+// so that the table of contents can be found. It must only be called after
+// Close returned successfully.
+func (w *ZstdChunkedWriter) Annotations() map[string]string {
+	return w.annotations
+}
+
+func (w *ZstdChunkedWriter) compress(src io.Reader, dest *countingWriter, enc *zstd.Encoder) error {
+	enc.Reset(dest)
+	// Everything read by the tar reader is compressed, so that the
+	// decompressed stream is identical to the original one.
+	tr := tar.NewReader(io.TeeReader(src, writerFunc(func(p []byte) (int, error) {
+		return enc.Write(p)
+	})))
+
+	toc := zstdChunkedTOC{Version: 1}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		entry := ZstdChunkedEntry{
+			Type:     zstdChunkedEntryType(hdr.Typeflag),
+			Name:     hdr.Name,
+			LinkName: hdr.Linkname,
+			Mode:     hdr.Mode,
+			UID:      hdr.Uid,
+			GID:      hdr.Gid,
+			ModTime:  hdr.ModTime,
+		}
+		if (hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA) && hdr.Size > 0 {
+			// Start a new frame for the content of the file.
+			if err := enc.Close(); err != nil {
+				return err
+			}
+			entry.Offset = dest.n
+			entry.Size = hdr.Size
+			enc.Reset(dest)
+
+			digester := digest.Canonical.Digester()
+			if _, err := io.Copy(digester.Hash(), tr); err != nil {
+				return err
+			}
+			if err := enc.Close(); err != nil {
+				return err
+			}
+			entry.EndOffset = dest.n
+			entry.Digest = digester.Digest().String()
+			enc.Reset(dest)
+		}
+		toc.Entries = append(toc.Entries, entry)
+	}
+	// Keep the padding following the end of the archive, if any.
+	if _, err := io.Copy(enc, src); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(toc)
+	if err != nil {
+		return err
+	}
+	compressedManifest := enc.EncodeAll(manifest, nil)
+	manifestOffset := dest.n + 8 // skip the header of the skippable frame
+	if err := writeZstdSkippableFrame(dest, compressedManifest); err != nil {
+		return err
+	}
+
+	footer := make([]byte, 8*4+len(zstdChunkedFrameMagic))
+	binary.LittleEndian.PutUint64(footer, uint64(manifestOffset))
+	binary.LittleEndian.PutUint64(footer[8:], uint64(len(compressedManifest)))
+	binary.LittleEndian.PutUint64(footer[16:], uint64(len(manifest)))
+	binary.LittleEndian.PutUint64(footer[24:], zstdChunkedManifestTypeCRFS)
+	copy(footer[32:], zstdChunkedFrameMagic)
+	if err := writeZstdSkippableFrame(dest, footer); err != nil {
+		return err
+	}
+
+	w.annotations = map[string]string{
+		ZstdChunkedManifestChecksumAnnotation: digest.FromBytes(compressedManifest).String(),
+		ZstdChunkedManifestPositionAnnotation: fmt.Sprintf("%d:%d:%d:%d", manifestOffset, len(compressedManifest), len(manifest), zstdChunkedManifestTypeCRFS),
+	}
+	return nil
+}
+
+// ZstdChunkedReader reads the files of a zstd:chunked layer individually,
+// locating them with the table of contents of the layer.
+type ZstdChunkedReader struct {
+	ra      io.ReaderAt
+	entries []ZstdChunkedEntry
+}
+
+// OpenZstdChunked reads the table of contents of the zstd:chunked layer read
+// from ra. The table of contents is located with the annotations of the layer
+// descriptor, and verified against the checksum they hold.
+func OpenZstdChunked(ra io.ReaderAt, annotations map[string]string) (*ZstdChunkedReader, error) {
+	checksum, err := digest.Parse(annotations[ZstdChunkedManifestChecksumAnnotation])
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd:chunked manifest checksum: %w", err)
+	}
+	var offset, length, uncompressedLength, manifestType int64
+	position := annotations[ZstdChunkedManifestPositionAnnotation]
+	if _, err := fmt.Sscanf(position, "%d:%d:%d:%d", &offset, &length, &uncompressedLength, &manifestType); err != nil {
+		return nil, fmt.Errorf("invalid zstd:chunked manifest position %q: %w", position, err)
+	}
+	if manifestType != zstdChunkedManifestTypeCRFS {
+		return nil, fmt.Errorf("unsupported zstd:chunked manifest type %d", manifestType)
+	}
+	if offset < 0 || length <= 0 || uncompressedLength <= 0 || uncompressedLength > zstdChunkedMaxManifestSize {
+		return nil, fmt.Errorf("invalid zstd:chunked manifest position %q", position)
+	}
+
+	compressed := make([]byte, length)
+	if n, err := ra.ReadAt(compressed, offset); n != len(compressed) {
+		return nil, fmt.Errorf("failed to read zstd:chunked manifest: %w", err)
+	}
+	if digest.FromBytes(compressed) != checksum {
+		return nil, fmt.Errorf("zstd:chunked manifest does not match checksum %s", checksum)
+	}
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	manifest, err := dec.DecodeAll(compressed, make([]byte, 0, uncompressedLength))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress zstd:chunked manifest: %w", err)
+	}
+	var toc zstdChunkedTOC
+	if err := json.Unmarshal(manifest, &toc); err != nil {
+		return nil, fmt.Errorf("invalid zstd:chunked manifest: %w", err)
+	}
+	return &ZstdChunkedReader{ra: ra, entries: toc.Entries}, nil
+}
+
+// Entries returns the entries of the table of contents, in the order of the
+// tar stream of the layer.
+func (r *ZstdChunkedReader) Entries() []ZstdChunkedEntry {
+	return r.entries
+}
+
+// OpenFile returns the content of the regular file of the given entry, which
+// is decompressed from its own frame. The content is verified against the
+// digest of the entry when the end of the file is read.
+func (r *ZstdChunkedReader) OpenFile(e ZstdChunkedEntry) (io.ReadCloser, error) {
+	if e.Size == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if e.Offset <= 0 || e.EndOffset <= e.Offset {
+		return nil, fmt.Errorf("invalid zstd:chunked entry %s: no content", e.Name)
+	}
+	dgst, err := digest.Parse(e.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd:chunked entry %s: %w", e.Name, err)
+	}
+	dec, err := zstd.NewReader(io.NewSectionReader(r.ra, e.Offset, e.EndOffset-e.Offset))
+	if err != nil {
+		return nil, err
+	}
+	rc := dec.IOReadCloser()
+	vr := &verifiedReader{r: io.LimitReader(rc, e.Size), verifier: dgst.Verifier(), name: e.Name}
+	return ioutils.NewReadCloserWrapper(vr, func() error {
+		rc.Close()
+		return nil
+	}), nil
+}
+
+// verifiedReader returns an error at the end of the content it reads if the
+// content does not match the expected digest.
+type verifiedReader struct {
+	r        io.Reader
+	verifier digest.Verifier
+	name     string
+}
+
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.verifier.Write(p[:n])
+	if err == io.EOF && !v.verifier.Verified() {
+		return n, fmt.Errorf("content of %s does not match its digest", v.name)
+	}
+	return n, err
+}
+
+func zstdChunkedEntryType(typeflag byte) string {
+	switch typeflag {
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "hardlink"
+	case tar.TypeChar:
+		return "char"
+	case tar.TypeBlock:
+		return "block"
+	case tar.TypeFifo:
+		return "fifo"
+	default:
+		return "reg"
+	}
+}
+
+// writeZstdSkippableFrame writes data in a skippable frame, which zstd
+// decoders ignore.
+func writeZstdSkippableFrame(dest io.Writer, data []byte) error {
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header, zstdSkippableFrameMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	if _, err := dest.Write(header); err != nil {
+		return err
+	}
+	_, err := dest.Write(data)
+	return err
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
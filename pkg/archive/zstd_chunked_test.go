@@ -0,0 +1,134 @@
+package archive // import "github.com/docker/docker/pkg/archive"
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestZstdChunkedWriter(t *testing.T) {
+	var src bytes.Buffer
+	tw := tar.NewWriter(&src)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, f := range []struct{ name, content string }{
+		{"dir/a", "hello"},
+		{"dir/empty", ""},
+		{"dir/b", strings.Repeat("world", 1000)},
+	} {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.content))}))
+		_, err := tw.Write([]byte(f.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "dir/a"}))
+	assert.NilError(t, tw.Close())
+
+	var compressed bytes.Buffer
+	w, err := NewZstdChunkedWriter(&compressed)
+	assert.NilError(t, err)
+	_, err = io.Copy(w, bytes.NewReader(src.Bytes()))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+
+	// The result decompresses to the original tar stream.
+	assert.Check(t, is.Equal(DetectCompression(compressed.Bytes()), Zstd))
+	rc, err := DecompressStream(bytes.NewReader(compressed.Bytes()))
+	assert.NilError(t, err)
+	decompressed, err := io.ReadAll(rc)
+	assert.NilError(t, err)
+	rc.Close()
+	assert.Check(t, bytes.Equal(decompressed, src.Bytes()))
+
+	// The table of contents can be found from the annotations.
+	annotations := w.Annotations()
+	position := strings.Split(annotations[ZstdChunkedManifestPositionAnnotation], ":")
+	assert.Assert(t, is.Len(position, 4))
+	offset, _ := strconv.Atoi(position[0])
+	length, _ := strconv.Atoi(position[1])
+	manifest := compressed.Bytes()[offset : offset+length]
+	assert.Check(t, is.Equal(annotations[ZstdChunkedManifestChecksumAnnotation], digest.FromBytes(manifest).String()))
+
+	dec, err := zstd.NewReader(nil)
+	assert.NilError(t, err)
+	defer dec.Close()
+	b, err := dec.DecodeAll(manifest, nil)
+	assert.NilError(t, err)
+	var toc zstdChunkedTOC
+	assert.NilError(t, json.Unmarshal(b, &toc))
+	assert.Assert(t, is.Len(toc.Entries, 5))
+	assert.Check(t, is.Equal(toc.Entries[4].Type, "symlink"))
+
+	// Each file can be decompressed on its own.
+	entry := toc.Entries[3]
+	assert.Check(t, is.Equal(entry.Name, "dir/b"))
+	content, err := dec.DecodeAll(compressed.Bytes()[entry.Offset:entry.EndOffset], nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), strings.Repeat("world", 1000)))
+	assert.Check(t, is.Equal(entry.Digest, digest.FromBytes(content).String()))
+}
+
+func TestOpenZstdChunked(t *testing.T) {
+	var src bytes.Buffer
+	tw := tar.NewWriter(&src)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "a", Typeflag: tar.TypeReg, Mode: 0o644, Size: 5}))
+	_, err := tw.Write([]byte("hello"))
+	assert.NilError(t, err)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "empty", Typeflag: tar.TypeReg, Mode: 0o644}))
+	assert.NilError(t, tw.Close())
+
+	var compressed bytes.Buffer
+	w, err := NewZstdChunkedWriter(&compressed)
+	assert.NilError(t, err)
+	_, err = io.Copy(w, &src)
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+
+	r, err := OpenZstdChunked(bytes.NewReader(compressed.Bytes()), w.Annotations())
+	assert.NilError(t, err)
+	entries := r.Entries()
+	assert.Assert(t, is.Len(entries, 2))
+	assert.Check(t, is.Equal(entries[0].Name, "a"))
+
+	for _, tc := range []struct {
+		entry    ZstdChunkedEntry
+		expected string
+	}{
+		{entry: entries[0], expected: "hello"},
+		{entry: entries[1], expected: ""},
+	} {
+		rc, err := r.OpenFile(tc.entry)
+		assert.NilError(t, err)
+		content, err := io.ReadAll(rc)
+		assert.NilError(t, err)
+		assert.Check(t, rc.Close())
+		assert.Check(t, is.Equal(string(content), tc.expected))
+	}
+
+	// The content of a file is verified.
+	corrupted := entries[0]
+	corrupted.Digest = digest.FromString("other").String()
+	rc, err := r.OpenFile(corrupted)
+	assert.NilError(t, err)
+	_, err = io.ReadAll(rc)
+	assert.Check(t, is.ErrorContains(err, "does not match its digest"))
+	rc.Close()
+
+	// So is the table of contents.
+	annotations := map[string]string{
+		ZstdChunkedManifestChecksumAnnotation: digest.FromString("other").String(),
+		ZstdChunkedManifestPositionAnnotation: w.Annotations()[ZstdChunkedManifestPositionAnnotation],
+	}
+	_, err = OpenZstdChunked(bytes.NewReader(compressed.Bytes()), annotations)
+	assert.Check(t, is.ErrorContains(err, "does not match checksum"))
+
+	_, err = OpenZstdChunked(bytes.NewReader(compressed.Bytes()), nil)
+	assert.Check(t, is.ErrorContains(err, "invalid zstd:chunked manifest checksum"))
+}
@@ -244,7 +244,8 @@ func setProjectQuota(backingFsBlockDev string, projectID uint32, quota Quota) er
 	return nil
 }
 
-// GetQuota - get the quota limits of a directory that was configured with SetQuota
+// GetQuota - get the quota limits and usage of a directory that was configured
+// with SetQuota
 func (q *Control) GetQuota(targetPath string, quota *Quota) error {
 	q.RLock()
 	projectID, ok := q.quotas[targetPath]
@@ -269,6 +270,7 @@ func (q *Control) GetQuota(targetPath string, quota *Quota) error {
 			projectID, q.backingFsBlockDev)
 	}
 	quota.Size = uint64(d.d_blk_hardlimit) * 512
+	quota.Used = uint64(d.d_bcount) * 512
 
 	return nil
 }
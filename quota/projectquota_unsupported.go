@@ -13,7 +13,8 @@ func (q *Control) SetQuota(targetPath string, quota Quota) error {
 	return ErrQuotaNotSupported
 }
 
-// GetQuota - get the quota limits of a directory that was configured with SetQuota
+// GetQuota - get the quota limits and usage of a directory that was configured
+// with SetQuota
 func (q *Control) GetQuota(targetPath string, quota *Quota) error {
 	return ErrQuotaNotSupported
 }
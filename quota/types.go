@@ -5,6 +5,9 @@ import "sync"
 // Quota limit params - currently we only control blocks hard limit
 type Quota struct {
 	Size uint64
+	// Used is the number of bytes used by the directory, as reported by
+	// GetQuota. It is ignored by SetQuota.
+	Used uint64
 }
 
 // Control - Context to be used by storage driver (e.g. overlay)
@@ -12,9 +12,13 @@ import (
 const (
 	backoffMultiplier = 2
 	defaultTimeout    = 100 * time.Millisecond
-	maxRestartTimeout = 1 * time.Minute
+	resetTimeoutAfter = 10 * time.Second
 )
 
+// DefaultMaxDelay is the upper bound of the delay between restarts of a
+// restart policy without a MaxDelay.
+const DefaultMaxDelay = 1 * time.Minute
+
 // ErrRestartCanceled is returned when the restart manager has been
 // canceled and will no longer restart the container.
 var ErrRestartCanceled = errors.New("restart canceled")
@@ -34,6 +38,12 @@ type restartManager struct {
 	active       bool
 	cancel       chan struct{}
 	canceled     bool
+	// forceRestart makes the next exit restart the container regardless
+	// of its policy. See ForceRestart.
+	forceRestart bool
+	// restarts holds the time of each restart within the policy's
+	// RestartWindow; it is only maintained when a restart budget is set.
+	restarts []time.Time
 }
 
 // New returns a new restartManager based on a policy.
@@ -47,10 +57,16 @@ func (rm *restartManager) SetPolicy(policy container.RestartPolicy) {
 	rm.Unlock()
 }
 
+// ForceRestart makes the restart manager restart the container on its next
+// exit, even if its restart policy would not, unless it was stopped manually.
+// The restart is still subject to the policy's backoff and restart budget.
+func (rm *restartManager) ForceRestart() {
+	rm.Lock()
+	rm.forceRestart = true
+	rm.Unlock()
+}
+
 func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped bool, executionDuration time.Duration) (bool, chan error, error) {
-	if rm.policy.IsNone() {
-		return false, nil, nil
-	}
 	rm.Lock()
 	unlockOnExit := true
 	defer func() {
@@ -59,6 +75,12 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 		}
 	}()
 
+	forceRestart := rm.forceRestart
+	rm.forceRestart = false
+	if rm.policy.IsNone() && !forceRestart {
+		return false, nil, nil
+	}
+
 	if rm.canceled {
 		return false, nil, ErrRestartCanceled
 	}
@@ -66,23 +88,26 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 	if rm.active {
 		return false, nil, fmt.Errorf("invalid call on an active restart manager")
 	}
-	// if the container ran for more than 10s, regardless of status and policy reset the
-	// the timeout back to the default.
-	if executionDuration.Seconds() >= 10 {
+	// if the container ran for longer than the reset period (10s by default),
+	// regardless of status and policy reset the timeout back to the initial delay.
+	if executionDuration >= rm.resetAfter() {
 		rm.timeout = 0
 	}
+	maxTimeout := rm.maxTimeout()
 	switch {
 	case rm.timeout == 0:
-		rm.timeout = defaultTimeout
-	case rm.timeout < maxRestartTimeout:
-		rm.timeout *= backoffMultiplier
+		rm.timeout = rm.initialTimeout()
+	case rm.timeout < maxTimeout:
+		rm.timeout = time.Duration(float64(rm.timeout) * rm.multiplier())
 	}
-	if rm.timeout > maxRestartTimeout {
-		rm.timeout = maxRestartTimeout
+	if rm.timeout > maxTimeout {
+		rm.timeout = maxTimeout
 	}
 
 	var restart bool
 	switch {
+	case forceRestart:
+		restart = !hasBeenManuallyStopped
 	case rm.policy.IsAlways():
 		restart = true
 	case rm.policy.IsUnlessStopped() && !hasBeenManuallyStopped:
@@ -94,6 +119,10 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 		}
 	}
 
+	if restart && !rm.withinBudget(time.Now()) {
+		restart = false
+	}
+
 	if !restart {
 		rm.active = false
 		return false, nil, nil
@@ -125,6 +154,56 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 	return true, ch, nil
 }
 
+// withinBudget reports whether another restart at now fits in the policy's
+// restart budget, and records it if so. It must be called with rm locked.
+func (rm *restartManager) withinBudget(now time.Time) bool {
+	if rm.policy.MaximumRestarts <= 0 || rm.policy.RestartWindow <= 0 {
+		rm.restarts = nil
+		return true
+	}
+	cutoff := now.Add(-rm.policy.RestartWindow)
+	recent := rm.restarts[:0]
+	for _, t := range rm.restarts {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	rm.restarts = recent
+	if len(rm.restarts) >= rm.policy.MaximumRestarts {
+		return false
+	}
+	rm.restarts = append(rm.restarts, now)
+	return true
+}
+
+func (rm *restartManager) initialTimeout() time.Duration {
+	if rm.policy.InitialDelay > 0 {
+		return rm.policy.InitialDelay
+	}
+	return defaultTimeout
+}
+
+func (rm *restartManager) maxTimeout() time.Duration {
+	if rm.policy.MaxDelay > 0 {
+		return rm.policy.MaxDelay
+	}
+	return DefaultMaxDelay
+}
+
+func (rm *restartManager) multiplier() float64 {
+	if rm.policy.Multiplier > 0 {
+		return rm.policy.Multiplier
+	}
+	return backoffMultiplier
+}
+
+func (rm *restartManager) resetAfter() time.Duration {
+	if rm.policy.ResetAfter > 0 {
+		return rm.policy.ResetAfter
+	}
+	return resetTimeoutAfter
+}
+
 func (rm *restartManager) Cancel() error {
 	rm.Do(func() {
 		rm.Lock()
@@ -34,3 +34,100 @@ func TestRestartManagerTimeoutReset(t *testing.T) {
 		t.Fatalf("restart manager should have a timeout of 100 ms but has %s", rm.timeout)
 	}
 }
+
+func TestRestartManagerCustomBackoff(t *testing.T) {
+	rm := New(container.RestartPolicy{
+		Name:         "always",
+		InitialDelay: time.Second,
+		MaxDelay:     5 * time.Second,
+		Multiplier:   3,
+		ResetAfter:   time.Minute,
+	}, 0).(*restartManager)
+
+	for _, expected := range []time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second} {
+		rm.active = false
+		if _, _, err := rm.ShouldRestart(0, false, 30*time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if rm.timeout != expected {
+			t.Fatalf("restart manager should have a timeout of %s but has %s", expected, rm.timeout)
+		}
+	}
+
+	rm.active = false
+	if _, _, err := rm.ShouldRestart(0, false, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if rm.timeout != time.Second {
+		t.Fatalf("restart manager should have a timeout of 1s but has %s", rm.timeout)
+	}
+}
+
+func TestRestartManagerRestartBudget(t *testing.T) {
+	rm := New(container.RestartPolicy{
+		Name:            "always",
+		MaximumRestarts: 2,
+		RestartWindow:   time.Hour,
+	}, 0).(*restartManager)
+
+	for i := 0; i < 2; i++ {
+		rm.active = false
+		should, _, err := rm.ShouldRestart(0, false, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !should {
+			t.Fatalf("container should be restarted (restart %d)", i+1)
+		}
+	}
+
+	rm.active = false
+	should, _, err := rm.ShouldRestart(0, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if should {
+		t.Fatal("container should not be restarted once the restart budget is exhausted")
+	}
+
+	// restarts that fell out of the window no longer count against the budget
+	rm.restarts[0] = rm.restarts[0].Add(-2 * time.Hour)
+	should, _, err = rm.ShouldRestart(0, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !should {
+		t.Fatal("container should be restarted once older restarts leave the window")
+	}
+}
+
+func TestRestartManagerForceRestart(t *testing.T) {
+	rm := New(container.RestartPolicy{Name: "no"}, 0).(*restartManager)
+	rm.ForceRestart()
+	should, _, err := rm.ShouldRestart(137, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !should {
+		t.Fatal("container should be restarted")
+	}
+
+	// the forced restart only applies to the next exit
+	rm.active = false
+	should, _, err = rm.ShouldRestart(137, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if should {
+		t.Fatal("container should not be restarted")
+	}
+
+	rm.ForceRestart()
+	should, _, err = rm.ShouldRestart(137, true, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if should {
+		t.Fatal("manually stopped container should not be restarted")
+	}
+}
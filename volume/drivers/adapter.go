@@ -2,9 +2,11 @@ package drivers // import "github.com/docker/docker/volume/drivers"
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/volume"
 	"github.com/sirupsen/logrus"
 )
@@ -113,6 +115,75 @@ func (a *volumeDriverAdapter) getCapabilities() volume.Capability {
 	return cap
 }
 
+// supportsSnapshots returns an error if the driver does not implement the
+// snapshot endpoints.
+func (a *volumeDriverAdapter) supportsSnapshots() error {
+	if !a.getCapabilities().Snapshots {
+		return errdefs.NotImplemented(fmt.Errorf("volume driver %s does not support snapshots", a.name))
+	}
+	return nil
+}
+
+func (a *volumeDriverAdapter) CreateSnapshot(v volume.Volume, name string) (volume.Snapshot, error) {
+	if err := a.supportsSnapshots(); err != nil {
+		return volume.Snapshot{}, err
+	}
+	s, err := a.proxy.CreateSnapshot(v.Name(), name)
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	snapshot := volume.Snapshot{Name: name, Volume: v.Name()}
+	if s != nil {
+		snapshot.CreatedAt = s.CreatedAt
+	}
+	return snapshot, nil
+}
+
+func (a *volumeDriverAdapter) ListSnapshots(v volume.Volume) ([]volume.Snapshot, error) {
+	if err := a.supportsSnapshots(); err != nil {
+		return nil, err
+	}
+	ls, err := a.proxy.ListSnapshots(v.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]volume.Snapshot, 0, len(ls))
+	for _, s := range ls {
+		out = append(out, volume.Snapshot{Name: s.Name, Volume: v.Name(), CreatedAt: s.CreatedAt})
+	}
+	return out, nil
+}
+
+func (a *volumeDriverAdapter) RemoveSnapshot(v volume.Volume, name string) error {
+	if err := a.supportsSnapshots(); err != nil {
+		return err
+	}
+	return a.proxy.RemoveSnapshot(v.Name(), name)
+}
+
+func (a *volumeDriverAdapter) RestoreSnapshot(v volume.Volume, name string) error {
+	if err := a.supportsSnapshots(); err != nil {
+		return err
+	}
+	return a.proxy.RestoreSnapshot(v.Name(), name)
+}
+
+func (a *volumeDriverAdapter) CreateFromSnapshot(name string, v volume.Volume, snapshot string, opts map[string]string) (volume.Volume, error) {
+	if err := a.supportsSnapshots(); err != nil {
+		return nil, err
+	}
+	if err := a.proxy.CreateFromSnapshot(name, v.Name(), snapshot, opts); err != nil {
+		return nil, err
+	}
+	return &volumeAdapter{
+		proxy:      a.proxy,
+		name:       name,
+		driverName: a.name,
+		scopePath:  a.scopePath,
+	}, nil
+}
+
 type volumeAdapter struct {
 	proxy      volumeDriver
 	name       string
@@ -130,6 +201,11 @@ type proxyVolume struct {
 	Status     map[string]interface{}
 }
 
+type proxySnapshot struct {
+	Name      string
+	CreatedAt time.Time
+}
+
 func (a *volumeAdapter) Name() string {
 	return a.name
 }
@@ -40,6 +40,16 @@ type volumeDriver interface {
 	Get(name string) (volume *proxyVolume, err error)
 	// Capabilities gets the list of capabilities of the driver
 	Capabilities() (capabilities volume.Capability, err error)
+	// CreateSnapshot takes a snapshot of the given volume
+	CreateSnapshot(name, snapshot string) (created *proxySnapshot, err error)
+	// ListSnapshots lists the snapshots of the given volume
+	ListSnapshots(name string) (snapshots []*proxySnapshot, err error)
+	// RemoveSnapshot removes a snapshot of the given volume
+	RemoveSnapshot(name, snapshot string) (err error)
+	// RestoreSnapshot replaces the data of the given volume with a snapshot
+	RestoreSnapshot(name, snapshot string) (err error)
+	// CreateFromSnapshot creates a volume holding a snapshot of the source volume
+	CreateFromSnapshot(name, source, snapshot string, opts map[string]string) (err error)
 }
 
 // Store is an in-memory store for volume drivers
@@ -253,3 +253,156 @@ func (pp *volumeDriverProxy) Capabilities() (capabilities volume.Capability, err
 
 	return
 }
+
+type volumeDriverProxyCreateSnapshotRequest struct {
+	Name     string
+	Snapshot string
+}
+
+type volumeDriverProxyCreateSnapshotResponse struct {
+	Created *proxySnapshot
+	Err     string
+}
+
+func (pp *volumeDriverProxy) CreateSnapshot(name string, snapshot string) (created *proxySnapshot, err error) {
+	var (
+		req volumeDriverProxyCreateSnapshotRequest
+		ret volumeDriverProxyCreateSnapshotResponse
+	)
+
+	req.Name = name
+	req.Snapshot = snapshot
+
+	if err = pp.CallWithOptions("VolumeDriver.CreateSnapshot", req, &ret, plugins.WithRequestTimeout(longTimeout)); err != nil {
+		return
+	}
+
+	created = ret.Created
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyListSnapshotsRequest struct {
+	Name string
+}
+
+type volumeDriverProxyListSnapshotsResponse struct {
+	Snapshots []*proxySnapshot
+	Err       string
+}
+
+func (pp *volumeDriverProxy) ListSnapshots(name string) (snapshots []*proxySnapshot, err error) {
+	var (
+		req volumeDriverProxyListSnapshotsRequest
+		ret volumeDriverProxyListSnapshotsResponse
+	)
+
+	req.Name = name
+
+	if err = pp.CallWithOptions("VolumeDriver.ListSnapshots", req, &ret, plugins.WithRequestTimeout(shortTimeout)); err != nil {
+		return
+	}
+
+	snapshots = ret.Snapshots
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyRemoveSnapshotRequest struct {
+	Name     string
+	Snapshot string
+}
+
+type volumeDriverProxyRemoveSnapshotResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) RemoveSnapshot(name string, snapshot string) (err error) {
+	var (
+		req volumeDriverProxyRemoveSnapshotRequest
+		ret volumeDriverProxyRemoveSnapshotResponse
+	)
+
+	req.Name = name
+	req.Snapshot = snapshot
+
+	if err = pp.CallWithOptions("VolumeDriver.RemoveSnapshot", req, &ret, plugins.WithRequestTimeout(shortTimeout)); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyRestoreSnapshotRequest struct {
+	Name     string
+	Snapshot string
+}
+
+type volumeDriverProxyRestoreSnapshotResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) RestoreSnapshot(name string, snapshot string) (err error) {
+	var (
+		req volumeDriverProxyRestoreSnapshotRequest
+		ret volumeDriverProxyRestoreSnapshotResponse
+	)
+
+	req.Name = name
+	req.Snapshot = snapshot
+
+	if err = pp.CallWithOptions("VolumeDriver.RestoreSnapshot", req, &ret, plugins.WithRequestTimeout(longTimeout)); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyCreateFromSnapshotRequest struct {
+	Name     string
+	Source   string
+	Snapshot string
+	Opts     map[string]string
+}
+
+type volumeDriverProxyCreateFromSnapshotResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) CreateFromSnapshot(name string, source string, snapshot string, opts map[string]string) (err error) {
+	var (
+		req volumeDriverProxyCreateFromSnapshotRequest
+		ret volumeDriverProxyCreateFromSnapshotResponse
+	)
+
+	req.Name = name
+	req.Source = source
+	req.Snapshot = snapshot
+	req.Opts = opts
+
+	if err = pp.CallWithOptions("VolumeDriver.CreateFromSnapshot", req, &ret, plugins.WithRequestTimeout(longTimeout)); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
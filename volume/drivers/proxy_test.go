@@ -57,6 +57,31 @@ func TestVolumeRequestError(t *testing.T) {
 		http.Error(w, "error", 500)
 	})
 
+	mux.HandleFunc("/VolumeDriver.CreateSnapshot", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Err": "Cannot create snapshot"}`)
+	})
+
+	mux.HandleFunc("/VolumeDriver.ListSnapshots", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Err": "Cannot list snapshots"}`)
+	})
+
+	mux.HandleFunc("/VolumeDriver.RemoveSnapshot", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Err": "Cannot remove snapshot"}`)
+	})
+
+	mux.HandleFunc("/VolumeDriver.RestoreSnapshot", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Err": "Cannot restore snapshot"}`)
+	})
+
+	mux.HandleFunc("/VolumeDriver.CreateFromSnapshot", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Err": "Cannot create volume from snapshot"}`)
+	})
+
 	u, _ := url.Parse(server.URL)
 	client, err := plugins.NewClient("tcp://"+u.Host, &tlsconfig.Options{InsecureSkipVerify: true})
 	if err != nil {
@@ -129,4 +154,44 @@ func TestVolumeRequestError(t *testing.T) {
 	if err == nil {
 		t.Fatal(err)
 	}
+
+	_, err = driver.CreateSnapshot("volume", "snapshot")
+	if err == nil {
+		t.Fatal("Expected error, was nil")
+	}
+	if !strings.Contains(err.Error(), "Cannot create snapshot") {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	_, err = driver.ListSnapshots("volume")
+	if err == nil {
+		t.Fatal("Expected error, was nil")
+	}
+	if !strings.Contains(err.Error(), "Cannot list snapshots") {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	err = driver.RemoveSnapshot("volume", "snapshot")
+	if err == nil {
+		t.Fatal("Expected error, was nil")
+	}
+	if !strings.Contains(err.Error(), "Cannot remove snapshot") {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	err = driver.RestoreSnapshot("volume", "snapshot")
+	if err == nil {
+		t.Fatal("Expected error, was nil")
+	}
+	if !strings.Contains(err.Error(), "Cannot restore snapshot") {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	err = driver.CreateFromSnapshot("clone", "volume", "snapshot", nil)
+	if err == nil {
+		t.Fatal("Expected error, was nil")
+	}
+	if !strings.Contains(err.Error(), "Cannot create volume from snapshot") {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
 }
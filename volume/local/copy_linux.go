@@ -0,0 +1,9 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import "github.com/docker/docker/daemon/graphdriver/copy"
+
+// copyData copies the directory src to dst, sharing the extents of the
+// files when the filesystem supports it.
+func copyData(src, dst string) error {
+	return copy.DirCopy(src, dst, copy.Content, false)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -151,6 +152,15 @@ func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error
 		return nil, errors.Wrapf(errdefs.System(err), "error while creating volume root path '%s'", v.rootPath)
 	}
 
+	// The data path is created as a btrfs subvolume if requested, so that
+	// the volume can be snapshotted natively. It is copied otherwise.
+	if subvolume, _ := strconv.ParseBool(opts["subvolume"]); subvolume {
+		if err := createSubvolume(v.path); err != nil {
+			os.RemoveAll(v.rootPath)
+			return nil, errors.Wrapf(err, "error while creating volume data path '%s'", v.path)
+		}
+	}
+
 	// Remapped root does need access to the data path
 	if err := idtools.MkdirAllAndChown(v.path, 0755, r.rootIdentity); err != nil {
 		return nil, errors.Wrapf(errdefs.System(err), "error while creating volume data path '%s'", v.path)
@@ -171,6 +181,20 @@ func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error
 	return v, nil
 }
 
+// Update updates the options of the given volume. Only the quota size limit
+// ("size") can be updated. The new limit applies immediately if the data of
+// the volume is available, and on its next mount otherwise.
+func (r *Root) Update(v volume.Volume, opts map[string]string) error {
+	lv, err := localVolumeOf(v)
+	if err != nil {
+		return err
+	}
+
+	lv.m.Lock()
+	defer lv.m.Unlock()
+	return lv.updateOpts(opts)
+}
+
 // Remove removes the specified volume and all underlying data. If the
 // given volume does not belong to this driver and an error is
 // returned. The volume is reference counted, if all references are
@@ -192,6 +216,13 @@ func (r *Root) Remove(v volume.Volume) error {
 		return err
 	}
 
+	lv.m.Lock()
+	err := lv.removeSnapshots()
+	lv.m.Unlock()
+	if err != nil {
+		return err
+	}
+
 	// TODO(thaJeztah) is there a reason we're evaluating the data-path here, and not the volume's rootPath?
 	realPath, err := filepath.EvalSymlinks(lv.path)
 	if err != nil {
@@ -296,15 +327,15 @@ func (v *localVolume) CachedPath() string {
 func (v *localVolume) Mount(id string) (string, error) {
 	v.m.Lock()
 	defer v.m.Unlock()
-	if v.needsMount() {
-		if !v.active.mounted {
-			if err := v.mount(); err != nil {
-				return "", errdefs.System(err)
-			}
-			v.active.mounted = true
+	if v.needsMount() && !v.active.mounted {
+		if err := v.mount(); err != nil {
+			return "", errdefs.System(err)
 		}
-		v.active.count++
+		v.active.mounted = true
 	}
+	// Mounts are counted for all volumes, as their snapshots cannot be
+	// restored while they are in use.
+	v.active.count++
 	if err := v.postMount(); err != nil {
 		return "", err
 	}
@@ -321,7 +352,7 @@ func (v *localVolume) Unmount(id string) error {
 	// Essentially docker doesn't care if this fails, it will send an error, but
 	// ultimately there's nothing that can be done. If we don't decrement the count
 	// this volume can never be removed until a daemon restart occurs.
-	if v.needsMount() {
+	if v.active.count > 0 {
 		v.active.count--
 	}
 
@@ -332,6 +363,20 @@ func (v *localVolume) Unmount(id string) error {
 	return v.unmount()
 }
 
+// Usage returns the number of bytes used by the volume, as accounted by its
+// project quota. An error is returned if no project quota was set for the
+// volume.
+func (v *localVolume) Usage() (int64, error) {
+	if v.quotaCtl == nil {
+		return 0, quota.ErrQuotaNotSupported
+	}
+	var q quota.Quota
+	if err := v.quotaCtl.GetQuota(v.path, &q); err != nil {
+		return 0, err
+	}
+	return int64(q.Used), nil
+}
+
 func (v *localVolume) Status() map[string]interface{} {
 	return nil
 }
@@ -12,6 +12,8 @@ import (
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/quota"
+	"github.com/docker/docker/volume"
+	"golang.org/x/sys/unix"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -69,6 +71,22 @@ func testVolWithQuota(t *testing.T, mountPoint, backingFsDev, testDir string) {
 	if _, err := os.Stat(testfile); err == nil {
 		assert.NilError(t, os.Remove(testfile))
 	}
+
+	// test raising the quota of the mounted volume
+	assert.NilError(t, r.Update(vol, map[string]string{"size": "2M"}))
+	assert.NilError(t, os.WriteFile(testfile, make([]byte, quotaSize+1), 0644))
+	unix.Sync()
+
+	usage, err := vol.(volume.UsageVolume).Usage()
+	assert.NilError(t, err)
+	assert.Check(t, usage >= quotaSize+1, "usage: %d", usage)
+
+	// test that the updated quota is persisted
+	r, err = New(testDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	v, err := r.Get("testing")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(v.(*localVolume).opts.Quota.Size, uint64(2*quotaSize)))
 }
 
 func testVolQuotaUnsupported(t *testing.T, mountPoint, backingFsDev, testDir string) {
@@ -96,6 +114,25 @@ func testVolQuotaUnsupported(t *testing.T, mountPoint, backingFsDev, testDir str
 
 	_, err = vol.Mount("1234")
 	assert.ErrorContains(t, err, "no quota support")
+
+	err = r.Update(vol, map[string]string{"size": quotaSizeLiteral})
+	assert.ErrorContains(t, err, "no quota support")
+}
+
+func TestUpdate(t *testing.T) {
+	r, err := New(t.TempDir(), idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+
+	vol, err := r.Create("testing", nil)
+	assert.NilError(t, err)
+
+	err = r.Update(vol, map[string]string{"type": "tmpfs"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "got: %v", err)
+	err = r.Update(vol, map[string]string{"size": "invalid"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "got: %v", err)
+
+	assert.NilError(t, r.Update(vol, map[string]string{"size": "0"}))
+	assert.NilError(t, r.Update(vol, nil))
 }
 
 func TestVolCreateValidation(t *testing.T) {
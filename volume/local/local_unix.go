@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -28,6 +29,13 @@ var (
 		"o":      {}, // generic mount options
 		"device": {}, // device to mount from
 		"size":   {}, // quota size limit
+		// create the data path as a btrfs subvolume, which can be
+		// snapshotted natively
+		"subvolume": {},
+	}
+	// updatableOpts are the options which can be updated on existing volumes.
+	updatableOpts = map[string]struct{}{
+		"size": {},
 	}
 	mandatoryOpts = map[string][]string{
 		"device": {"type"},
@@ -65,6 +73,14 @@ func (r *Root) validateOpts(opts map[string]string) error {
 			return errdefs.InvalidParameter(errors.New("quota size requested but no quota support"))
 		}
 	}
+	if val, ok := opts["subvolume"]; ok {
+		if _, err := strconv.ParseBool(val); err != nil {
+			return errdefs.InvalidParameter(errors.Errorf("invalid value for subvolume: %q", val))
+		}
+		if _, ok := opts["device"]; ok {
+			return errdefs.InvalidParameter(errors.New("subvolume cannot be set on volumes with a device to mount"))
+		}
+	}
 	for opt, reqopts := range mandatoryOpts {
 		if _, ok := opts[opt]; ok {
 			for _, reqopt := range reqopts {
@@ -99,6 +115,43 @@ func (v *localVolume) setOpts(opts map[string]string) error {
 	return v.saveOpts()
 }
 
+// updateOpts updates the options of the volume. Callers of this function are
+// expected to hold the volume lock.
+func (v *localVolume) updateOpts(opts map[string]string) error {
+	for opt := range opts {
+		if _, ok := updatableOpts[opt]; !ok {
+			return errdefs.InvalidParameter(errors.Errorf("option %q cannot be updated", opt))
+		}
+	}
+	val, ok := opts["size"]
+	if !ok {
+		return nil
+	}
+	size, err := units.RAMInBytes(val)
+	if err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+	if size > 0 && v.quotaCtl == nil {
+		return errdefs.InvalidParameter(errors.New("quota size requested but no quota support"))
+	}
+
+	var cfg optsConfig
+	if v.opts != nil {
+		cfg = *v.opts
+	}
+	cfg.Quota.Size = uint64(size)
+
+	// The data of volumes with a device to mount is only available while
+	// they are mounted; their limit is set by postMount otherwise.
+	if v.quotaCtl != nil && (!v.needsMount() || v.active.mounted) {
+		if err := v.quotaCtl.SetQuota(v.path, cfg.Quota); err != nil {
+			return errdefs.System(errors.Wrap(err, "error while setting the quota size limit of the volume"))
+		}
+	}
+	v.opts = &cfg
+	return v.saveOpts()
+}
+
 func unmount(path string) {
 	_ = mount.Unmount(path)
 }
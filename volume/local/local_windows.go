@@ -26,6 +26,13 @@ func (v *localVolume) setOpts(opts map[string]string) error {
 	return nil
 }
 
+func (v *localVolume) updateOpts(opts map[string]string) error {
+	if len(opts) == 0 {
+		return nil
+	}
+	return errdefs.InvalidParameter(errors.New("options are not supported on this platform"))
+}
+
 func (v *localVolume) needsMount() bool {
 	return false
 }
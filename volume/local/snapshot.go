@@ -0,0 +1,296 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/daemon/names"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/volume"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// snapshotsPathName is the name of the directory of a volume where its
+	// snapshots are stored.
+	snapshotsPathName = "snapshots"
+	// snapshotMetaName is the name of the file holding the metadata of a
+	// snapshot, next to its data.
+	snapshotMetaName = "snapshot.json"
+)
+
+// Types of the snapshots of local volumes.
+const (
+	// snapshotTypeBtrfs is a read-only snapshot of the btrfs subvolume
+	// holding the data of the volume.
+	snapshotTypeBtrfs = "btrfs"
+	// snapshotTypeZFS is a snapshot of the ZFS dataset mounted by the volume.
+	snapshotTypeZFS = "zfs"
+	// snapshotTypeCopy is a copy of the data of the volume, sharing its
+	// extents if the filesystem supports reflinks.
+	snapshotTypeCopy = "copy"
+)
+
+// snapshotMeta is the metadata of a snapshot of a local volume.
+type snapshotMeta struct {
+	Type string
+	// Dataset is the ZFS dataset of a snapshot of type zfs.
+	Dataset   string `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+// CreateSnapshot takes a snapshot of the data of the volume. The snapshot
+// is native if the data of the volume is a btrfs subvolume or a ZFS dataset,
+// and a copy of the data otherwise.
+func (r *Root) CreateSnapshot(v volume.Volume, name string) (volume.Snapshot, error) {
+	lv, err := localVolumeOf(v)
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	if err := validateSnapshotName(name); err != nil {
+		return volume.Snapshot{}, err
+	}
+
+	lv.m.Lock()
+	defer lv.m.Unlock()
+
+	dir := lv.snapshotPath(name)
+	if _, err := os.Stat(dir); err == nil {
+		return volume.Snapshot{}, errdefs.Conflict(errors.Errorf("snapshot %s of volume %s already exists", name, lv.name))
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return volume.Snapshot{}, errdefs.System(errors.Wrapf(err, "error while creating snapshot path '%s'", dir))
+	}
+
+	meta, err := lv.snapshot(dir, name)
+	if err == nil {
+		meta.CreatedAt = time.Now().UTC()
+		err = saveSnapshotMeta(dir, meta)
+		if err != nil {
+			if rmErr := removeSnapshot(dir, name, meta); rmErr != nil {
+				logrus.WithError(rmErr).WithField("volume", lv.name).WithField("snapshot", name).Warn("Error cleaning up snapshot")
+			}
+		}
+	}
+	if err != nil {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			logrus.WithError(rmErr).WithField("volume", lv.name).WithField("snapshot", name).Warn("Error cleaning up snapshot path")
+		}
+		return volume.Snapshot{}, err
+	}
+	return volume.Snapshot{Name: name, Volume: lv.name, CreatedAt: meta.CreatedAt}, nil
+}
+
+// ListSnapshots lists the snapshots of the volume, oldest first.
+func (r *Root) ListSnapshots(v volume.Volume) ([]volume.Snapshot, error) {
+	lv, err := localVolumeOf(v)
+	if err != nil {
+		return nil, err
+	}
+
+	lv.m.Lock()
+	defer lv.m.Unlock()
+	return lv.listSnapshots()
+}
+
+// RemoveSnapshot removes the snapshot of the volume.
+func (r *Root) RemoveSnapshot(v volume.Volume, name string) error {
+	lv, err := localVolumeOf(v)
+	if err != nil {
+		return err
+	}
+
+	lv.m.Lock()
+	defer lv.m.Unlock()
+
+	meta, err := lv.getSnapshot(name)
+	if err != nil {
+		return err
+	}
+	return removeSnapshot(lv.snapshotPath(name), name, meta)
+}
+
+// RestoreSnapshot replaces the data of the volume with the data of its
+// snapshot. The volume must not be mounted.
+func (r *Root) RestoreSnapshot(v volume.Volume, name string) error {
+	lv, err := localVolumeOf(v)
+	if err != nil {
+		return err
+	}
+
+	lv.m.Lock()
+	defer lv.m.Unlock()
+
+	if lv.active.count > 0 {
+		return errdefs.Conflict(errors.Errorf("volume %s has active mounts", lv.name))
+	}
+	meta, err := lv.getSnapshot(name)
+	if err != nil {
+		return err
+	}
+	if meta.Type == snapshotTypeZFS {
+		// Rolling a dataset back to a snapshot destroys the snapshots
+		// taken after it, so only the most recent one can be restored.
+		ls, err := lv.listSnapshots()
+		if err != nil {
+			return err
+		}
+		if latest := ls[len(ls)-1]; latest.Name != name {
+			return errdefs.Conflict(errors.Errorf("snapshot %s of volume %s cannot be restored: only the most recent snapshot (%s) of a ZFS volume can be restored", name, lv.name, latest.Name))
+		}
+	}
+	return lv.restoreSnapshot(lv.snapshotPath(name), name, meta)
+}
+
+// CreateFromSnapshot creates a new volume with the given name and options,
+// holding the data of the snapshot of the volume.
+func (r *Root) CreateFromSnapshot(name string, v volume.Volume, snapshot string, opts map[string]string) (volume.Volume, error) {
+	lv, err := localVolumeOf(v)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Get(name); err == nil {
+		return nil, errdefs.Conflict(errors.Errorf("volume %s already exists", name))
+	}
+
+	lv.m.Lock()
+	_, err = lv.getSnapshot(snapshot)
+	lv.m.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	nv, err := r.Create(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	dst := nv.(*localVolume)
+
+	// The snapshot may have been removed while the volume was created.
+	lv.m.Lock()
+	meta, err := lv.getSnapshot(snapshot)
+	if err == nil {
+		err = lv.cloneSnapshot(lv.snapshotPath(snapshot), snapshot, meta, dst)
+	}
+	lv.m.Unlock()
+	if err != nil {
+		if rmErr := r.Remove(dst); rmErr != nil {
+			logrus.WithError(rmErr).WithField("volume", name).Warn("Error cleaning up volume created from snapshot")
+		}
+		return nil, err
+	}
+	return dst, nil
+}
+
+// removeSnapshots removes all the snapshots of the volume.
+// Callers of this function are expected to hold the volume lock.
+func (v *localVolume) removeSnapshots() error {
+	dirs, err := os.ReadDir(filepath.Join(v.rootPath, snapshotsPathName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errdefs.System(err)
+	}
+	for _, d := range dirs {
+		meta, err := loadSnapshotMeta(v.snapshotPath(d.Name()))
+		if err != nil {
+			// Remove the leftovers of a snapshot that failed.
+			meta = snapshotMeta{Type: snapshotTypeCopy}
+		}
+		if err := removeSnapshot(v.snapshotPath(d.Name()), d.Name(), meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listSnapshots lists the snapshots of the volume, oldest first.
+// Callers of this function are expected to hold the volume lock.
+func (v *localVolume) listSnapshots() ([]volume.Snapshot, error) {
+	dirs, err := os.ReadDir(filepath.Join(v.rootPath, snapshotsPathName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []volume.Snapshot{}, nil
+		}
+		return nil, errdefs.System(err)
+	}
+
+	ls := make([]volume.Snapshot, 0, len(dirs))
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		meta, err := loadSnapshotMeta(v.snapshotPath(d.Name()))
+		if err != nil {
+			logrus.WithError(err).WithField("volume", v.name).WithField("snapshot", d.Name()).Warn("Skipping snapshot with invalid metadata")
+			continue
+		}
+		ls = append(ls, volume.Snapshot{Name: d.Name(), Volume: v.name, CreatedAt: meta.CreatedAt})
+	}
+	sort.Slice(ls, func(i, j int) bool {
+		return ls[i].CreatedAt.Before(ls[j].CreatedAt)
+	})
+	return ls, nil
+}
+
+func (v *localVolume) snapshotPath(name string) string {
+	return filepath.Join(v.rootPath, snapshotsPathName, name)
+}
+
+// getSnapshot returns the metadata of the snapshot of the volume.
+func (v *localVolume) getSnapshot(name string) (snapshotMeta, error) {
+	if err := validateSnapshotName(name); err != nil {
+		return snapshotMeta{}, err
+	}
+	meta, err := loadSnapshotMeta(v.snapshotPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshotMeta{}, errdefs.NotFound(errors.Errorf("snapshot %s of volume %s not found", name, v.name))
+		}
+		return snapshotMeta{}, errdefs.System(err)
+	}
+	return meta, nil
+}
+
+func loadSnapshotMeta(dir string) (snapshotMeta, error) {
+	var meta snapshotMeta
+	b, err := os.ReadFile(filepath.Join(dir, snapshotMetaName))
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return meta, errors.Wrap(err, "error while unmarshaling snapshot metadata")
+	}
+	return meta, nil
+}
+
+func saveSnapshotMeta(dir string, meta snapshotMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotMetaName), b, 0600); err != nil {
+		return errdefs.System(errors.Wrap(err, "error while persisting snapshot metadata"))
+	}
+	return nil
+}
+
+func localVolumeOf(v volume.Volume) (*localVolume, error) {
+	lv, ok := v.(*localVolume)
+	if !ok {
+		return nil, errdefs.System(errors.Errorf("unknown volume type %T", v))
+	}
+	return lv, nil
+}
+
+func validateSnapshotName(name string) error {
+	if len(name) < 2 || !volumeNameRegex.MatchString(name) {
+		return errdefs.InvalidParameter(errors.Errorf("invalid snapshot name %q, names should be at least two characters, only %q are allowed", name, names.RestrictedNameChars))
+	}
+	return nil
+}
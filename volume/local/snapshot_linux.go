@@ -0,0 +1,336 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/docker/docker/errdefs"
+	zfs "github.com/mistifyio/go-zfs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// The btrfs ioctls used to manage subvolumes, see linux/btrfs.h.
+const (
+	btrfsIocSubvolCreate = 0x5000940e
+	btrfsIocSnapDestroy  = 0x5000940f
+	btrfsIocSnapCreateV2 = 0x50009417
+
+	// btrfsSubvolRdonly makes a snapshot read-only.
+	btrfsSubvolRdonly = 1 << 1
+	// btrfsFirstFreeObjectid is the inode number of the root directory of
+	// a subvolume.
+	btrfsFirstFreeObjectid = 256
+)
+
+// btrfsVolArgs is struct btrfs_ioctl_vol_args.
+type btrfsVolArgs struct {
+	fd   int64
+	name [4088]byte
+}
+
+// btrfsVolArgsV2 is struct btrfs_ioctl_vol_args_v2.
+type btrfsVolArgsV2 struct {
+	fd      int64
+	transid uint64
+	flags   uint64
+	unused  [4]uint64
+	name    [4040]byte
+}
+
+// snapshot takes a snapshot of the data of the volume into the snapshot
+// directory dir. Callers of this function are expected to hold the volume
+// lock.
+func (v *localVolume) snapshot(dir, name string) (snapshotMeta, error) {
+	if v.needsMount() && v.opts.MountType == "zfs" {
+		meta := snapshotMeta{Type: snapshotTypeZFS, Dataset: v.opts.MountDevice}
+		ds, err := zfs.GetDataset(meta.Dataset)
+		if err != nil {
+			return meta, errdefs.System(errors.Wrapf(err, "error while looking up the dataset of volume %s", v.name))
+		}
+		if _, err := ds.Snapshot(name, false); err != nil {
+			return meta, errdefs.System(errors.Wrapf(err, "error while taking a snapshot of volume %s", v.name))
+		}
+		return meta, nil
+	}
+
+	data := filepath.Join(dir, volumeDataPathName)
+	if !v.needsMount() && isSubvolume(v.path) {
+		if err := snapshotSubvolume(v.path, data, true); err != nil {
+			return snapshotMeta{}, errdefs.System(errors.Wrapf(err, "error while taking a snapshot of volume %s", v.name))
+		}
+		return snapshotMeta{Type: snapshotTypeBtrfs}, nil
+	}
+
+	err := v.withData(func() error {
+		return copyData(v.path, data)
+	})
+	if err != nil {
+		return snapshotMeta{}, errdefs.System(errors.Wrapf(err, "error while copying the data of volume %s", v.name))
+	}
+	return snapshotMeta{Type: snapshotTypeCopy}, nil
+}
+
+// restoreSnapshot replaces the data of the volume with the data of the
+// snapshot in dir. Callers of this function are expected to hold the volume
+// lock.
+func (v *localVolume) restoreSnapshot(dir, name string, meta snapshotMeta) error {
+	if meta.Type == snapshotTypeZFS {
+		// The snapshot is the most recent one of the dataset, which can be
+		// rolled back to without destroying other snapshots.
+		ds, err := zfs.GetDataset(meta.Dataset + "@" + name)
+		if err == nil {
+			err = ds.Rollback(false)
+		}
+		if err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+		}
+		return nil
+	}
+
+	data := filepath.Join(dir, volumeDataPathName)
+	if meta.Type == snapshotTypeBtrfs && !v.needsMount() && isSubvolume(v.path) {
+		// Swap the subvolume of the volume with a writable snapshot of the
+		// snapshot, so that the volume is left untouched on failure.
+		tmp := v.path + ".restore"
+		if err := snapshotSubvolume(data, tmp, false); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+		}
+		if err := destroySubvolume(v.path); err != nil {
+			_ = destroySubvolume(tmp)
+			return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+		}
+		if err := os.Rename(tmp, v.path); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+		}
+		return nil
+	}
+
+	err := v.withData(func() error {
+		return restoreData(data, v.path)
+	})
+	if err != nil {
+		return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+	}
+	return nil
+}
+
+// cloneSnapshot copies the data of the snapshot in dir to the volume dst.
+// Callers of this function are expected to hold the lock of the volume of
+// the snapshot.
+func (v *localVolume) cloneSnapshot(dir, name string, meta snapshotMeta, dst *localVolume) error {
+	dst.m.Lock()
+	defer dst.m.Unlock()
+
+	src := filepath.Join(dir, volumeDataPathName)
+	if meta.Type == snapshotTypeZFS {
+		// The data of the snapshot is only reachable through the snapshot
+		// directory of the mounted dataset.
+		err := v.withData(func() error {
+			return dst.withData(func() error {
+				return copyData(filepath.Join(v.path, ".zfs", "snapshot", name), dst.path)
+			})
+		})
+		if err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while copying snapshot %s of volume %s", name, v.name))
+		}
+		return nil
+	}
+
+	if meta.Type == snapshotTypeBtrfs && !dst.needsMount() && isBtrfs(dst.rootPath) {
+		// The data of the new volume is replaced with a writable snapshot of
+		// the snapshot, so that it can be snapshotted natively as well.
+		var err error
+		if isSubvolume(dst.path) {
+			err = destroySubvolume(dst.path)
+		} else {
+			err = os.Remove(dst.path)
+		}
+		if err != nil {
+			return errdefs.System(err)
+		}
+		if err := snapshotSubvolume(src, dst.path, false); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while copying snapshot %s of volume %s", name, v.name))
+		}
+		return nil
+	}
+
+	err := dst.withData(func() error {
+		return copyData(src, dst.path)
+	})
+	if err != nil {
+		return errdefs.System(errors.Wrapf(err, "error while copying snapshot %s of volume %s", name, v.name))
+	}
+	return nil
+}
+
+// removeSnapshot removes the snapshot in dir.
+func removeSnapshot(dir, name string, meta snapshotMeta) error {
+	if meta.Type == snapshotTypeZFS {
+		ds, err := zfs.GetDataset(meta.Dataset + "@" + name)
+		if err == nil {
+			err = ds.Destroy(zfs.DestroyDefault)
+		}
+		if err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while removing snapshot %s", name))
+		}
+	}
+
+	if data := filepath.Join(dir, volumeDataPathName); isSubvolume(data) {
+		if err := destroySubvolume(data); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while removing snapshot %s", name))
+		}
+	}
+	return removePath(dir)
+}
+
+// withData calls fn with the data of the volume available at its path,
+// mounting the volume for the duration of the call if needed. Callers of
+// this function are expected to hold the volume lock.
+func (v *localVolume) withData(fn func() error) error {
+	if v.needsMount() && !v.active.mounted {
+		if err := v.mount(); err != nil {
+			return err
+		}
+		defer unmount(v.path)
+	}
+	return fn()
+}
+
+// restoreData replaces the contents of the directory dst with a copy of the
+// contents of src. The copy is made in a temporary directory in dst, so that
+// it is on the same filesystem and under the same quota as the data, and
+// dst is left untouched if it fails. The contents of dst are then swapped
+// with the copy by renaming them.
+func restoreData(src, dst string) error {
+	tmp, err := os.MkdirTemp(dst, ".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := copyData(src, tmp); err != nil {
+		return err
+	}
+
+	old, err := os.MkdirTemp(dst, ".old-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(old)
+	skip := map[string]bool{filepath.Base(tmp): true, filepath.Base(old): true}
+	if err := moveEntries(dst, old, skip); err != nil {
+		if rbErr := moveEntries(old, dst, nil); rbErr != nil {
+			logrus.WithError(rbErr).WithField("path", dst).Warn("Error restoring the data of a volume after a failed restore")
+		}
+		return err
+	}
+	if err := moveEntries(tmp, dst, nil); err != nil {
+		rbErr := moveEntries(dst, tmp, skip)
+		if rbErr == nil {
+			rbErr = moveEntries(old, dst, nil)
+		}
+		if rbErr != nil {
+			logrus.WithError(rbErr).WithField("path", dst).Warn("Error restoring the data of a volume after a failed restore")
+		}
+		return err
+	}
+
+	// The copy holds the ownership and permissions of the data of the
+	// snapshot.
+	var st unix.Stat_t
+	if err := unix.Stat(tmp, &st); err != nil {
+		return err
+	}
+	if err := os.Chown(dst, int(st.Uid), int(st.Gid)); err != nil {
+		return err
+	}
+	return os.Chmod(dst, os.FileMode(st.Mode&07777))
+}
+
+// moveEntries renames the entries of the directory from into the directory
+// to, except for those in skip.
+func moveEntries(from, to string, skip map[string]bool) error {
+	entries, err := os.ReadDir(from)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if skip[e.Name()] {
+			continue
+		}
+		if err := os.Rename(filepath.Join(from, e.Name()), filepath.Join(to, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSubvolume creates the data path of a volume as a btrfs subvolume, so
+// that the volume can be snapshotted natively. Its parent directory must be
+// on btrfs.
+func createSubvolume(path string) error {
+	if !isBtrfs(filepath.Dir(path)) {
+		return errdefs.InvalidParameter(errors.New("btrfs subvolume requested but the volume is not on btrfs"))
+	}
+	var args btrfsVolArgs
+	copy(args.name[:len(args.name)-1], filepath.Base(path))
+	if err := btrfsIoctl(filepath.Dir(path), btrfsIocSubvolCreate, unsafe.Pointer(&args)); err != nil {
+		return errdefs.System(err)
+	}
+	return nil
+}
+
+// snapshotSubvolume creates the subvolume dst as a snapshot of the
+// subvolume src.
+func snapshotSubvolume(src, dst string, readonly bool) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var args btrfsVolArgsV2
+	args.fd = int64(f.Fd())
+	if readonly {
+		args.flags = btrfsSubvolRdonly
+	}
+	copy(args.name[:len(args.name)-1], filepath.Base(dst))
+	return btrfsIoctl(filepath.Dir(dst), btrfsIocSnapCreateV2, unsafe.Pointer(&args))
+}
+
+// destroySubvolume deletes the subvolume and its contents.
+func destroySubvolume(path string) error {
+	var args btrfsVolArgs
+	copy(args.name[:len(args.name)-1], filepath.Base(path))
+	return btrfsIoctl(filepath.Dir(path), btrfsIocSnapDestroy, unsafe.Pointer(&args))
+}
+
+func btrfsIoctl(dir string, req uintptr, args unsafe.Pointer) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), req, uintptr(args)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isBtrfs(path string) bool {
+	var buf unix.Statfs_t
+	return unix.Statfs(path, &buf) == nil && buf.Type == unix.BTRFS_SUPER_MAGIC
+}
+
+// isSubvolume returns whether path is the root directory of a btrfs
+// subvolume.
+func isSubvolume(path string) bool {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil || st.Ino != btrfsFirstFreeObjectid {
+		return false
+	}
+	return isBtrfs(path)
+}
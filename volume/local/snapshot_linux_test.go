@@ -0,0 +1,146 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestSnapshots(t *testing.T) {
+	r, err := New(t.TempDir(), idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+
+	v, err := r.Create("testing", nil)
+	assert.NilError(t, err)
+	file := filepath.Join(v.Path(), "data")
+	assert.NilError(t, os.WriteFile(file, []byte("first"), 0644))
+
+	s, err := r.CreateSnapshot(v, "first")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(s.Name, "first"))
+	assert.Check(t, is.Equal(s.Volume, "testing"))
+	assert.Check(t, !s.CreatedAt.IsZero())
+
+	_, err = r.CreateSnapshot(v, "first")
+	assert.Check(t, errdefs.IsConflict(err), "got: %v", err)
+	_, err = r.CreateSnapshot(v, "../first")
+	assert.Check(t, errdefs.IsInvalidParameter(err), "got: %v", err)
+
+	assert.NilError(t, os.WriteFile(file, []byte("second"), 0644))
+	_, err = r.CreateSnapshot(v, "second")
+	assert.NilError(t, err)
+
+	ls, err := r.ListSnapshots(v)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(ls, 2))
+	assert.Check(t, is.Equal(ls[0].Name, "first"))
+	assert.Check(t, is.Equal(ls[1].Name, "second"))
+
+	// The snapshots of a volume in use cannot be restored.
+	_, err = v.Mount("1234")
+	assert.NilError(t, err)
+	err = r.RestoreSnapshot(v, "first")
+	assert.Check(t, errdefs.IsConflict(err), "got: %v", err)
+	assert.NilError(t, v.Unmount("1234"))
+
+	assert.NilError(t, os.WriteFile(filepath.Join(v.Path(), "other"), []byte("other"), 0644))
+	assert.NilError(t, os.Chmod(v.Path(), 0700))
+	assert.NilError(t, r.RestoreSnapshot(v, "first"))
+	b, err := os.ReadFile(file)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "first"))
+	_, err = os.Stat(filepath.Join(v.Path(), "other"))
+	assert.Check(t, os.IsNotExist(err))
+	// The data is swapped in place, without leaving the temporary copy.
+	entries, err := os.ReadDir(v.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1))
+	fi, err := os.Stat(v.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fi.Mode().Perm(), os.FileMode(0755)))
+
+	err = r.RestoreSnapshot(v, "missing")
+	assert.Check(t, errdefs.IsNotFound(err), "got: %v", err)
+
+	clone, err := r.CreateFromSnapshot("clone", v, "second", nil)
+	assert.NilError(t, err)
+	b, err = os.ReadFile(filepath.Join(clone.Path(), "data"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "second"))
+	_, err = r.Get("clone")
+	assert.NilError(t, err)
+
+	_, err = r.CreateFromSnapshot("clone", v, "second", nil)
+	assert.Check(t, errdefs.IsConflict(err), "got: %v", err)
+	_, err = r.CreateFromSnapshot("other", v, "missing", nil)
+	assert.Check(t, errdefs.IsNotFound(err), "got: %v", err)
+	_, err = r.Get("other")
+	assert.Check(t, is.ErrorIs(err, ErrNotFound))
+
+	assert.NilError(t, r.RemoveSnapshot(v, "second"))
+	ls, err = r.ListSnapshots(v)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(ls, 1))
+	err = r.RemoveSnapshot(v, "second")
+	assert.Check(t, errdefs.IsNotFound(err), "got: %v", err)
+
+	// Removing a volume removes its snapshots.
+	assert.NilError(t, r.Remove(v))
+	_, err = os.Stat(filepath.Join(r.path, "testing"))
+	assert.Check(t, os.IsNotExist(err))
+}
+
+func TestRestoreZFSSnapshot(t *testing.T) {
+	r, err := New(t.TempDir(), idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	v, err := r.Create("testing", nil)
+	assert.NilError(t, err)
+	lv := v.(*localVolume)
+
+	// Only the most recent snapshot of a ZFS volume can be restored, which
+	// is checked before the dataset is looked up.
+	now := time.Now().UTC()
+	for i, name := range []string{"first", "second"} {
+		dir := lv.snapshotPath(name)
+		assert.NilError(t, os.MkdirAll(dir, 0700))
+		assert.NilError(t, saveSnapshotMeta(dir, snapshotMeta{
+			Type:      snapshotTypeZFS,
+			Dataset:   "pool/testing",
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+	err = r.RestoreSnapshot(v, "first")
+	assert.Check(t, errdefs.IsConflict(err), "got: %v", err)
+	assert.Check(t, is.ErrorContains(err, "only the most recent snapshot (second) of a ZFS volume can be restored"))
+}
+
+func TestCreateSubvolume(t *testing.T) {
+	r, err := New(t.TempDir(), idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+
+	_, err = r.Create("invalid", map[string]string{"subvolume": "maybe"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "got: %v", err)
+	_, err = r.Create("invalid", map[string]string{"subvolume": "true", "type": "tmpfs", "device": "tmpfs"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "got: %v", err)
+
+	// Volumes are only created as subvolumes when requested.
+	v, err := r.Create("plain", nil)
+	assert.NilError(t, err)
+	assert.Check(t, !isSubvolume(v.Path()))
+
+	v, err = r.Create("subvolume", map[string]string{"subvolume": "true"})
+	if !isBtrfs(r.path) {
+		assert.Check(t, errdefs.IsInvalidParameter(err), "got: %v", err)
+		_, err = os.Stat(filepath.Join(r.path, "subvolume"))
+		assert.Check(t, os.IsNotExist(err))
+		return
+	}
+	assert.NilError(t, err)
+	assert.Check(t, isSubvolume(v.Path()))
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+var errSnapshotsNotSupported = errdefs.NotImplemented(errors.New("volume snapshots are not supported on this platform"))
+
+func (v *localVolume) snapshot(dir, name string) (snapshotMeta, error) {
+	return snapshotMeta{}, errSnapshotsNotSupported
+}
+
+func (v *localVolume) restoreSnapshot(dir, name string, meta snapshotMeta) error {
+	return errSnapshotsNotSupported
+}
+
+func (v *localVolume) cloneSnapshot(dir, name string, meta snapshotMeta, dst *localVolume) error {
+	return errSnapshotsNotSupported
+}
+
+func removeSnapshot(dir, name string, meta snapshotMeta) error {
+	return removePath(dir)
+}
+
+func createSubvolume(path string) error {
+	return errdefs.InvalidParameter(errors.New("btrfs subvolumes are not supported on this platform"))
+}
@@ -0,0 +1,88 @@
+package mounts // import "github.com/docker/docker/volume/mounts"
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// MountIDMapped mounts source at target as an idmapped mount with the given
+// mappings. The target must exist, and be a directory if the source is one,
+// or a file otherwise. The mounts below source are included, unless
+// recursive is false.
+func MountIDMapped(source, target string, mapping idtools.IdentityMapping, recursive bool) error {
+	userns, err := usernsFile(mapping)
+	if err != nil {
+		return errors.Wrap(err, "error creating user namespace for idmapped mount")
+	}
+	defer userns.Close()
+
+	var recursiveFlag uint
+	if recursive {
+		recursiveFlag = unix.AT_RECURSIVE
+	}
+
+	treeFd, err := unix.OpenTree(unix.AT_FDCWD, source, unix.OPEN_TREE_CLONE|unix.OPEN_TREE_CLOEXEC|recursiveFlag)
+	if err != nil {
+		return &os.PathError{Op: "open_tree", Path: source, Err: err}
+	}
+	defer unix.Close(treeFd)
+
+	attr := unix.MountAttr{
+		Attr_set:  unix.MOUNT_ATTR_IDMAP,
+		Userns_fd: uint64(userns.Fd()),
+	}
+	if err := unix.MountSetattr(treeFd, "", unix.AT_EMPTY_PATH|recursiveFlag, &attr); err != nil {
+		return errors.Wrapf(err, "error setting idmapping on mount of %s, the filesystem may not support idmapped mounts", source)
+	}
+	if err := unix.MoveMount(treeFd, "", unix.AT_FDCWD, target, unix.MOVE_MOUNT_F_EMPTY_PATH); err != nil {
+		return &os.PathError{Op: "move_mount", Path: target, Err: err}
+	}
+	return nil
+}
+
+// usernsFile returns a file referring to a new user namespace with the given
+// mappings. The user namespace is created by a child process which is
+// stopped before it runs, and killed once its namespace is opened.
+func usernsFile(mapping idtools.IdentityMapping) (*os.File, error) {
+	// The child is traced by the thread which starts it, so that it stops
+	// when it executes, and must not change thread until it is killed.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cmd := exec.Command("/proc/self/exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  unix.CLONE_NEWUSER,
+		UidMappings: toSysProcIDMaps(mapping.UIDMaps),
+		GidMappings: toSysProcIDMaps(mapping.GIDMaps),
+		Ptrace:      true,
+		Pdeathsig:   syscall.SIGKILL,
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	return os.Open(fmt.Sprintf("/proc/%d/ns/user", cmd.Process.Pid))
+}
+
+func toSysProcIDMaps(idMaps []idtools.IDMap) []syscall.SysProcIDMap {
+	sysMaps := make([]syscall.SysProcIDMap, 0, len(idMaps))
+	for _, m := range idMaps {
+		sysMaps = append(sysMaps, syscall.SysProcIDMap{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+	return sysMaps
+}
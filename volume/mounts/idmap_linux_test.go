@@ -0,0 +1,50 @@
+package mounts // import "github.com/docker/docker/volume/mounts"
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/moby/sys/mount"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
+)
+
+func TestMountIDMapped(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "skipping test that requires root")
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	target := filepath.Join(dir, "target")
+	assert.NilError(t, os.Mkdir(source, 0755))
+	assert.NilError(t, os.Mkdir(target, 0755))
+
+	assert.NilError(t, mount.Mount("tmpfs", source, "tmpfs", ""))
+	defer mount.Unmount(source)
+	assert.NilError(t, os.WriteFile(filepath.Join(source, "file"), []byte("data"), 0644))
+	assert.NilError(t, os.Chown(filepath.Join(source, "file"), 1000, 1000))
+
+	mapping := idtools.IdentityMapping{
+		UIDMaps: []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GIDMaps: []idtools.IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}},
+	}
+	err := MountIDMapped(source, target, mapping, true)
+	skip.If(t, err != nil, "idmapped mounts are not supported: %v", err)
+	defer mount.Unmount(target)
+
+	fi, err := os.Stat(filepath.Join(target, "file"))
+	assert.NilError(t, err)
+	st := fi.Sys().(*syscall.Stat_t)
+	assert.Check(t, is.Equal(st.Uid, uint32(101000)))
+	assert.Check(t, is.Equal(st.Gid, uint32(201000)))
+
+	// the files of the source are not changed
+	fi, err = os.Stat(filepath.Join(source, "file"))
+	assert.NilError(t, err)
+	st = fi.Sys().(*syscall.Stat_t)
+	assert.Check(t, is.Equal(st.Uid, uint32(1000)))
+	assert.Check(t, is.Equal(st.Gid, uint32(1000)))
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package mounts // import "github.com/docker/docker/volume/mounts"
+
+import (
+	"errors"
+
+	"github.com/docker/docker/pkg/idtools"
+)
+
+// MountIDMapped is not supported on this platform.
+func MountIDMapped(source, target string, mapping idtools.IdentityMapping, recursive bool) error {
+	return errors.New("idmapped mounts are only supported on Linux")
+}
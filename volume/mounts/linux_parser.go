@@ -80,6 +80,9 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 					return &errMountConfig{mnt, fmt.Errorf("invalid propagation mode: %s", opts.Propagation)}
 				}
 			}
+			if err := linuxValidateIDMap(opts.IDMap); err != nil {
+				return &errMountConfig{mnt, err}
+			}
 		}
 		if mnt.VolumeOptions != nil {
 			return &errMountConfig{mnt, errExtraField("VolumeOptions")}
@@ -107,6 +110,11 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 		if len(mnt.Source) == 0 && mnt.ReadOnly {
 			return &errMountConfig{mnt, fmt.Errorf("must not set ReadOnly mode when using anonymous volumes")}
 		}
+		if opts := mnt.VolumeOptions; opts != nil {
+			if err := linuxValidateIDMap(opts.IDMap); err != nil {
+				return &errMountConfig{mnt, err}
+			}
+		}
 	case mount.TypeTmpfs:
 		if mnt.BindOptions != nil {
 			return &errMountConfig{mnt, errExtraField("BindOptions")}
@@ -123,6 +131,29 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 	return nil
 }
 
+// linuxValidateIDMap validates the options of an idmapped mount. The uid and
+// gid mappings must be specified together, or not at all to use the mappings
+// of the container.
+func linuxValidateIDMap(opts *mount.IDMapOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if (len(opts.UIDMappings) == 0) != (len(opts.GIDMappings) == 0) {
+		return errors.New("idmapped mounts must specify both UIDMappings and GIDMappings, or neither")
+	}
+	for _, mappings := range [][]mount.IDMapping{opts.UIDMappings, opts.GIDMappings} {
+		for _, m := range mappings {
+			if m.Size == 0 {
+				return fmt.Errorf("invalid idmapped mount mapping %d:%d:%d: size must not be zero", m.ContainerID, m.HostID, m.Size)
+			}
+			if uint64(m.ContainerID)+uint64(m.Size) > 1<<32 || uint64(m.HostID)+uint64(m.Size) > 1<<32 {
+				return fmt.Errorf("invalid idmapped mount mapping %d:%d:%d: range out of bounds", m.ContainerID, m.HostID, m.Size)
+			}
+		}
+	}
+	return nil
+}
+
 // label modes
 var linuxLabelModes = map[string]bool{
 	"Z": true,
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/pkg/idtools"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 )
 
 func TestLinuxParseMountRaw(t *testing.T) {
@@ -178,6 +180,67 @@ func TestLinuxParseMountSpecBindWithFileinfoError(t *testing.T) {
 	assert.ErrorContains(t, err, testErr.Error())
 }
 
+func TestLinuxParseMountSpecIDMap(t *testing.T) {
+	containerMapping := idtools.IdentityMapping{
+		UIDMaps: []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GIDMaps: []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+	}
+	mapping := []mount.IDMapping{{ContainerID: 1000, HostID: 2000, Size: 10}}
+
+	cases := []struct {
+		idmap    *mount.IDMapOptions
+		expected idtools.IdentityMapping
+		err      string
+	}{
+		{
+			idmap:    &mount.IDMapOptions{},
+			expected: containerMapping,
+		},
+		{
+			idmap: &mount.IDMapOptions{UIDMappings: mapping, GIDMappings: mapping},
+			expected: idtools.IdentityMapping{
+				UIDMaps: []idtools.IDMap{{ContainerID: 1000, HostID: 2000, Size: 10}},
+				GIDMaps: []idtools.IDMap{{ContainerID: 1000, HostID: 2000, Size: 10}},
+			},
+		},
+		{
+			idmap: &mount.IDMapOptions{UIDMappings: mapping},
+			err:   "must specify both UIDMappings and GIDMappings",
+		},
+		{
+			idmap: &mount.IDMapOptions{UIDMappings: []mount.IDMapping{{Size: 0}}, GIDMappings: mapping},
+			err:   "size must not be zero",
+		},
+		{
+			idmap: &mount.IDMapOptions{UIDMappings: mapping, GIDMappings: []mount.IDMapping{{HostID: 1 << 31, Size: 1 << 31}, {HostID: 1<<32 - 1, Size: 2}}},
+			err:   "range out of bounds",
+		},
+	}
+
+	parser := NewLinuxParser()
+	for _, tc := range cases {
+		for _, cfg := range []mount.Mount{
+			{Type: mount.TypeVolume, Target: "/data", VolumeOptions: &mount.VolumeOptions{IDMap: tc.idmap}},
+			{Type: mount.TypeBind, Source: "/", Target: "/data", BindOptions: &mount.BindOptions{IDMap: tc.idmap}},
+		} {
+			mp, err := parser.ParseMountSpec(cfg)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				continue
+			}
+			assert.NilError(t, err)
+			idMapping, ok := mp.IDMapping(containerMapping)
+			assert.Check(t, ok)
+			assert.Check(t, is.DeepEqual(idMapping, tc.expected))
+		}
+	}
+
+	mp, err := parser.ParseMountSpec(mount.Mount{Type: mount.TypeVolume, Target: "/data"})
+	assert.NilError(t, err)
+	_, ok := mp.IDMapping(containerMapping)
+	assert.Check(t, !ok)
+}
+
 func TestConvertTmpfsOptions(t *testing.T) {
 	type testCase struct {
 		opt                  mount.TmpfsOptions
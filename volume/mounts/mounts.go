@@ -172,6 +172,41 @@ func (m *MountPoint) Path() string {
 	return m.Source
 }
 
+// IDMapping returns the uid and gid mappings of the idmapped mount of the
+// mount point, and whether the mount point is idmapped. The mappings of the
+// container are returned if the mount spec does not specify any.
+func (m *MountPoint) IDMapping(containerMapping idtools.IdentityMapping) (idtools.IdentityMapping, bool) {
+	var opts *mounttypes.IDMapOptions
+	switch {
+	case m.Spec.BindOptions != nil:
+		opts = m.Spec.BindOptions.IDMap
+	case m.Spec.VolumeOptions != nil:
+		opts = m.Spec.VolumeOptions.IDMap
+	}
+	if opts == nil {
+		return idtools.IdentityMapping{}, false
+	}
+	if len(opts.UIDMappings) == 0 && len(opts.GIDMappings) == 0 {
+		return containerMapping, true
+	}
+	return idtools.IdentityMapping{
+		UIDMaps: toIDMaps(opts.UIDMappings),
+		GIDMaps: toIDMaps(opts.GIDMappings),
+	}, true
+}
+
+func toIDMaps(mappings []mounttypes.IDMapping) []idtools.IDMap {
+	idMaps := make([]idtools.IDMap, 0, len(mappings))
+	for _, m := range mappings {
+		idMaps = append(idMaps, idtools.IDMap{
+			ContainerID: int(m.ContainerID),
+			HostID:      int(m.HostID),
+			Size:        int(m.Size),
+		})
+	}
+	return idMaps
+}
+
 func errInvalidMode(mode string) error {
 	return errors.Errorf("invalid mode: %v", mode)
 }
@@ -233,6 +233,9 @@ func (p *windowsParser) validateMountConfigReg(mnt *mount.Mount, additionalValid
 			if len(opts.Propagation) > 0 {
 				return &errMountConfig{mnt, fmt.Errorf("invalid propagation mode: %s", opts.Propagation)}
 			}
+			if opts.IDMap != nil {
+				return &errMountConfig{mnt, errExtraField("BindOptions.IDMap")}
+			}
 		}
 		if mnt.VolumeOptions != nil {
 			return &errMountConfig{mnt, errExtraField("VolumeOptions")}
@@ -261,6 +264,9 @@ func (p *windowsParser) validateMountConfigReg(mnt *mount.Mount, additionalValid
 		if len(mnt.Source) == 0 && mnt.ReadOnly {
 			return &errMountConfig{mnt, fmt.Errorf("must not set ReadOnly mode when using anonymous volumes")}
 		}
+		if opts := mnt.VolumeOptions; opts != nil && opts.IDMap != nil {
+			return &errMountConfig{mnt, errExtraField("VolumeOptions.IDMap")}
+		}
 
 		if len(mnt.Source) != 0 {
 			if err := p.ValidateVolumeName(mnt.Source); err != nil {
@@ -0,0 +1,100 @@
+package service // import "github.com/docker/docker/volume/service"
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/service/opts"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Export writes a tar archive of the contents of the volume to out,
+// compressed with the given compression ("none", "gzip" or "zstd").
+// The volume is referenced while it is exported, so that it cannot be
+// removed, and mounted through its driver.
+func (s *VolumesService) Export(ctx context.Context, name, compression string, out io.Writer) error {
+	c, err := parseCompression(compression)
+	if err != nil {
+		return err
+	}
+
+	ref := "export-" + stringid.GenerateRandomID()
+	v, err := s.vs.Get(ctx, name, opts.WithGetReference(ref))
+	if err != nil {
+		if IsNotExist(err) {
+			err = errdefs.NotFound(err)
+		}
+		return err
+	}
+	defer func() {
+		if err := s.vs.Release(context.Background(), v.Name(), ref); err != nil {
+			logrus.WithError(err).WithField("volume", v.Name()).Warn("Error releasing volume after export")
+		}
+	}()
+
+	path, err := v.Mount(ref)
+	if err != nil {
+		return errors.Wrapf(err, "error mounting volume %s", v.Name())
+	}
+	defer unmountVolume(v, ref)
+
+	data, err := chrootarchive.Tar(path, &archive.TarOptions{
+		Compression: c,
+		IDMap:       s.idMapping,
+		Xattrs:      true,
+	}, path)
+	if err != nil {
+		return errors.Wrapf(err, "error exporting volume %s", v.Name())
+	}
+	defer data.Close()
+
+	if _, err := io.Copy(out, data); err != nil {
+		return errors.Wrapf(err, "error exporting volume %s", v.Name())
+	}
+	return nil
+}
+
+// Import extracts the tar archive read from in, which may be compressed,
+// into the volume. Files of the volume are replaced by the files of the
+// archive with the same path. The volume must not be referenced, and is
+// locked while the archive is extracted.
+func (s *VolumesService) Import(ctx context.Context, name string, in io.Reader) error {
+	return s.vs.exclusive(ctx, name, "import", func(v volume.Volume) error {
+		ref := "import-" + stringid.GenerateRandomID()
+		path, err := v.Mount(ref)
+		if err != nil {
+			return errors.Wrap(err, "error mounting volume")
+		}
+		defer unmountVolume(v, ref)
+
+		return chrootarchive.Untar(in, path, &archive.TarOptions{
+			IDMap: s.idMapping,
+		})
+	})
+}
+
+func unmountVolume(v volume.Volume, ref string) {
+	if err := v.Unmount(ref); err != nil {
+		logrus.WithError(err).WithField("volume", v.Name()).Warn("Error unmounting volume")
+	}
+}
+
+// parseCompression returns the compression of an exported volume.
+func parseCompression(compression string) (archive.Compression, error) {
+	switch compression {
+	case "", "none":
+		return archive.Uncompressed, nil
+	case "gzip":
+		return archive.Gzip, nil
+	case "zstd":
+		return archive.Zstd, nil
+	default:
+		return archive.Uncompressed, errdefs.InvalidParameter(errors.Errorf("invalid compression %q: must be one of none, gzip or zstd", compression))
+	}
+}
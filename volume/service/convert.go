@@ -31,6 +31,10 @@ type pathCacher interface {
 	CachedPath() string
 }
 
+type accessModer interface {
+	AccessMode() string
+}
+
 func (s *VolumesService) volumesToAPI(ctx context.Context, volumes []volume.Volume, opts ...convertOpt) []*volumetypes.Volume {
 	var (
 		out        = make([]*volumetypes.Volume, 0, len(volumes))
@@ -67,7 +71,7 @@ func (s *VolumesService) volumesToAPI(ctx context.Context, volumes []volume.Volu
 			if apiV.Mountpoint == "" {
 				apiV.Mountpoint = p
 			}
-			sz, err := directory.Size(ctx, p)
+			sz, err := volumeSize(ctx, v)
 			if err != nil {
 				logrus.WithError(err).WithField("volume", v.Name()).Warnf("Failed to determine size of volume")
 				sz = -1
@@ -80,6 +84,17 @@ func (s *VolumesService) volumesToAPI(ctx context.Context, volumes []volume.Volu
 	return out
 }
 
+// volumeSize returns the size of the volume, as reported by the volume if it
+// can, or by walking its path otherwise.
+func volumeSize(ctx context.Context, v volume.Volume) (int64, error) {
+	if uv, ok := unwrapVolume(v).(volume.UsageVolume); ok {
+		if sz, err := uv.Usage(); err == nil {
+			return sz, nil
+		}
+	}
+	return directory.Size(ctx, v.Path())
+}
+
 func volumeToAPIType(v volume.Volume) volumetypes.Volume {
 	createdAt, _ := v.CreatedAt()
 	tv := volumetypes.Volume{
@@ -95,9 +110,23 @@ func volumeToAPIType(v volume.Volume) volumetypes.Volume {
 	if cp, ok := v.(pathCacher); ok {
 		tv.Mountpoint = cp.CachedPath()
 	}
+	if am, ok := v.(accessModer); ok {
+		tv.AccessMode = am.AccessMode()
+	}
 	return tv
 }
 
+func snapshotToAPIType(s volume.Snapshot) volumetypes.Snapshot {
+	ts := volumetypes.Snapshot{
+		Name:   s.Name,
+		Volume: s.Volume,
+	}
+	if !s.CreatedAt.IsZero() {
+		ts.CreatedAt = s.CreatedAt.Format(time.RFC3339)
+	}
+	return ts
+}
+
 func filtersToBy(filter filters.Args, acceptedFilters map[string]bool) (By, error) {
 	if err := filter.Validate(acceptedFilters); err != nil {
 		return nil, err
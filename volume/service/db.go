@@ -12,10 +12,11 @@ import (
 var volumeBucketName = []byte("volumes")
 
 type volumeMetadata struct {
-	Name    string
-	Driver  string
-	Labels  map[string]string
-	Options map[string]string
+	Name       string
+	Driver     string
+	Labels     map[string]string
+	Options    map[string]string
+	AccessMode string `json:",omitempty"`
 }
 
 func (s *VolumeStore) setMeta(name string, meta volumeMetadata) error {
@@ -95,6 +95,26 @@ func isErr(err error, expected error) bool {
 	return err == expected
 }
 
+// snapshotsNotSupportedError is returned for the snapshot operations on the
+// volumes of the named driver, which does not support snapshots.
+type snapshotsNotSupportedError string
+
+func (e snapshotsNotSupportedError) Error() string {
+	return "volume driver " + string(e) + " does not support snapshots"
+}
+
+func (snapshotsNotSupportedError) NotImplemented() {}
+
+// updateNotSupportedError is returned when updating the volumes of the named
+// driver, which does not support updates.
+type updateNotSupportedError string
+
+func (e updateNotSupportedError) Error() string {
+	return "volume driver " + string(e) + " does not support updating volumes"
+}
+
+func (updateNotSupportedError) NotImplemented() {}
+
 type invalidFilter struct {
 	filter string
 	value  interface{}
@@ -0,0 +1,135 @@
+package service // import "github.com/docker/docker/volume/service"
+
+import (
+	"sort"
+
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/volume/service/opts"
+	"github.com/pkg/errors"
+)
+
+// volumeHolder is a container which has a volume mounted. The volume may be
+// mounted more than once with the same reference, which is counted.
+type volumeHolder struct {
+	container string
+	readWrite bool
+	count     int
+}
+
+// validateAccessMode returns an error if the access mode is unknown. An empty
+// access mode is valid, and does not restrict mounts of the volume.
+func validateAccessMode(mode string) error {
+	switch mode {
+	case "", volumetypes.AccessModeSingleWriter, volumetypes.AccessModeSingleContainer, volumetypes.AccessModeReadOnlyMany:
+		return nil
+	default:
+		return errdefs.InvalidParameter(errors.Errorf("invalid access mode %q: must be one of %s, %s or %s", mode,
+			volumetypes.AccessModeSingleWriter, volumetypes.AccessModeSingleContainer, volumetypes.AccessModeReadOnlyMany))
+	}
+}
+
+// checkAccessMode returns a conflict error if the access mode does not allow
+// the container to mount the volume, given its current holders.
+func checkAccessMode(mode string, holders map[string]*volumeHolder, container string, readWrite bool) error {
+	switch mode {
+	case volumetypes.AccessModeReadOnlyMany:
+		if readWrite {
+			return errdefs.Conflict(errors.Errorf("volume has access mode %s and cannot be mounted read-write", mode))
+		}
+	case volumetypes.AccessModeSingleWriter:
+		if !readWrite {
+			return nil
+		}
+		for _, h := range holders {
+			if h.readWrite && h.container != container {
+				return errdefs.Conflict(errors.Errorf("volume has access mode %s and is already mounted read-write by container %s", mode, h.container))
+			}
+		}
+	case volumetypes.AccessModeSingleContainer:
+		for _, h := range holders {
+			if h.container != container {
+				return errdefs.Conflict(errors.Errorf("volume has access mode %s and is already mounted by container %s", mode, h.container))
+			}
+		}
+	}
+	return nil
+}
+
+// acquire records that the volume with the given name is mounted with the
+// given reference, unless the access mode of the volume does not allow it.
+// The holder of the mount is the container in cfg, or the reference if no
+// container is set.
+func (s *VolumeStore) acquire(name, ref string, cfg opts.MountConfig) error {
+	name = normalizeVolumeName(name)
+	container := cfg.Holder
+	if container == "" {
+		container = ref
+	}
+	readWrite := !cfg.ReadOnly
+
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+
+	if h, exists := s.holders[name][ref]; exists {
+		h.count++
+		return nil
+	}
+	if err := checkAccessMode(s.accessModes[name], s.holders[name], container, readWrite); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "mount"}
+	}
+	if s.holders[name] == nil {
+		s.holders[name] = make(map[string]*volumeHolder)
+	}
+	s.holders[name][ref] = &volumeHolder{container: container, readWrite: readWrite, count: 1}
+	return nil
+}
+
+// releaseMount releases the mount of the volume with the given name and
+// reference recorded by acquire.
+func (s *VolumeStore) releaseMount(name, ref string) {
+	name = normalizeVolumeName(name)
+
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+
+	h, exists := s.holders[name][ref]
+	if !exists {
+		return
+	}
+	h.count--
+	if h.count <= 0 {
+		delete(s.holders[name], ref)
+	}
+}
+
+// getHolders returns the containers which have the volume with the given
+// name mounted, sorted by container. A container which has the volume
+// mounted more than once is only returned once, and is a writer if any of
+// its mounts is read-write.
+func (s *VolumeStore) getHolders(name string) []volumeHolder {
+	name = normalizeVolumeName(name)
+
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+
+	byContainer := make(map[string]*volumeHolder, len(s.holders[name]))
+	for _, h := range s.holders[name] {
+		if held, exists := byContainer[h.container]; exists {
+			held.readWrite = held.readWrite || h.readWrite
+			held.count += h.count
+			continue
+		}
+		held := *h
+		byContainer[h.container] = &held
+	}
+
+	holders := make([]volumeHolder, 0, len(byContainer))
+	for _, h := range byContainer {
+		holders = append(holders, *h)
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		return holders[i].container < holders[j].container
+	})
+	return holders
+}
@@ -9,6 +9,12 @@ type CreateConfig struct {
 	Options   map[string]string
 	Labels    map[string]string
 	Reference string
+	// Source and Snapshot are the volume and the name of its snapshot to
+	// create the volume from.
+	Source   string
+	Snapshot string
+	// AccessMode restricts how containers can mount the volume concurrently.
+	AccessMode string
 }
 
 // WithCreateLabel creates a CreateOption which adds a label with the given key/value pair
@@ -46,6 +52,24 @@ func WithCreateReference(ref string) CreateOption {
 	}
 }
 
+// WithCreateFromSnapshot creates a CreateOption which sets the snapshot of
+// the source volume to create the volume from. The volume is created with the
+// driver of the source volume.
+func WithCreateFromSnapshot(source, snapshot string) CreateOption {
+	return func(cfg *CreateConfig) {
+		cfg.Source = source
+		cfg.Snapshot = snapshot
+	}
+}
+
+// WithCreateAccessMode creates a CreateOption which sets the access mode of
+// the volume, which restricts how containers can mount it concurrently.
+func WithCreateAccessMode(mode string) CreateOption {
+	return func(cfg *CreateConfig) {
+		cfg.AccessMode = mode
+	}
+}
+
 // GetConfig is used with `GetOption` to set options for the volumes service's
 // `Get` implementation.
 type GetConfig struct {
@@ -81,6 +105,34 @@ func WithGetResolveStatus(cfg *GetConfig) {
 	cfg.ResolveStatus = true
 }
 
+// MountConfig is used with `MountOption` to set options for the volumes
+// service's `Mount` implementation.
+type MountConfig struct {
+	// Holder is the container which mounts the volume. The reference of the
+	// mount is used if it is not set.
+	Holder   string
+	ReadOnly bool
+}
+
+// MountOption is passed to the service `Mount` to describe who is mounting
+// the volume, and how. Access modes of volumes are enforced based on these.
+type MountOption func(*MountConfig)
+
+// WithMountHolder provides the container which mounts the volume.
+func WithMountHolder(container string) MountOption {
+	return func(o *MountConfig) {
+		o.Holder = container
+	}
+}
+
+// WithMountReadOnly indicates whether the volume is mounted read-only.
+// Volumes are mounted read-write by default.
+func WithMountReadOnly(readOnly bool) MountOption {
+	return func(o *MountConfig) {
+		o.ReadOnly = readOnly
+	}
+}
+
 // RemoveConfig is used by `RemoveOption` to store config options for remove
 type RemoveConfig struct {
 	PurgeOnError bool
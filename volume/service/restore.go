@@ -66,6 +66,9 @@ func (s *VolumeStore) restore() {
 			s.globalLock.Lock()
 			s.options[v.Name()] = meta.Options
 			s.labels[v.Name()] = meta.Labels
+			if meta.AccessMode != "" {
+				s.accessModes[v.Name()] = meta.AccessMode
+			}
 			s.names[v.Name()] = v
 			s.refs[v.Name()] = make(map[string]struct{})
 			s.globalLock.Unlock()
@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/service/opts"
@@ -56,3 +57,39 @@ func TestRestore(t *testing.T) {
 	assert.DeepEqual(t, testOpts, dv.Options())
 	assert.DeepEqual(t, testLabels, dv.Labels())
 }
+
+func TestRestoreAccessMode(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "test-restore-access-mode")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	drivers := volumedrivers.NewStore(nil)
+	driverName := "test-restore-access-mode"
+	drivers.Register(volumetestutils.NewFakeDriver(driverName), driverName)
+
+	s, err := NewStore(dir, drivers)
+	assert.NilError(t, err)
+	defer s.Shutdown()
+
+	ctx := context.Background()
+	_, err = s.Create(ctx, "test1", driverName)
+	assert.NilError(t, err)
+	_, err = s.Create(ctx, "test2", driverName, opts.WithCreateAccessMode(volumetypes.AccessModeSingleWriter))
+	assert.NilError(t, err)
+
+	s.Shutdown()
+
+	s, err = NewStore(dir, drivers)
+	assert.NilError(t, err)
+	defer s.Shutdown()
+
+	v, err := s.Get(ctx, "test1")
+	assert.NilError(t, err)
+	assert.Equal(t, "", v.(volumeWrapper).AccessMode())
+
+	v, err = s.Get(ctx, "test2")
+	assert.NilError(t, err)
+	assert.Equal(t, volumetypes.AccessModeSingleWriter, v.(volumeWrapper).AccessMode())
+}
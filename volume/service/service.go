@@ -39,12 +39,13 @@ type VolumesService struct {
 	pruneRunning int32
 	eventLogger  VolumeEventLogger
 	usage        singleflight.Group
+	idMapping    idtools.IdentityMapping
 }
 
 // NewVolumeService creates a new volume service
-func NewVolumeService(root string, pg plugingetter.PluginGetter, rootIDs idtools.Identity, logger VolumeEventLogger) (*VolumesService, error) {
+func NewVolumeService(root string, pg plugingetter.PluginGetter, idMapping idtools.IdentityMapping, logger VolumeEventLogger) (*VolumesService, error) {
 	ds := drivers.NewStore(pg)
-	if err := setupDefaultDriver(ds, root, rootIDs); err != nil {
+	if err := setupDefaultDriver(ds, root, idMapping.RootPair()); err != nil {
 		return nil, err
 	}
 
@@ -52,7 +53,7 @@ func NewVolumeService(root string, pg plugingetter.PluginGetter, rootIDs idtools
 	if err != nil {
 		return nil, err
 	}
-	return &VolumesService{vs: vs, ds: ds, eventLogger: logger}, nil
+	return &VolumesService{vs: vs, ds: ds, eventLogger: logger, idMapping: idMapping}, nil
 }
 
 // GetDriverList gets the list of registered volume drivers
@@ -101,19 +102,77 @@ func (s *VolumesService) Get(ctx context.Context, name string, getOpts ...opts.G
 	if cfg.ResolveStatus {
 		vol.Status = v.Status()
 	}
+	if uv, ok := unwrapVolume(v).(volume.UsageVolume); ok {
+		if size, err := uv.Usage(); err == nil {
+			vol.UsageData = &volumetypes.UsageData{Size: size, RefCount: int64(s.vs.CountReferences(v))}
+		}
+	}
+	for _, h := range s.vs.getHolders(v.Name()) {
+		vol.Holders = append(vol.Holders, &volumetypes.Holder{Container: h.container, ReadWrite: h.readWrite})
+	}
 	return &vol, nil
 }
 
+// Update updates the options of a volume
+// The options which can be updated depend on the driver of the volume.
+func (s *VolumesService) Update(ctx context.Context, name string, options map[string]string) error {
+	return s.vs.Update(ctx, name, options)
+}
+
+// CreateSnapshot takes a snapshot of a volume
+func (s *VolumesService) CreateSnapshot(ctx context.Context, name, snapshot string) (*volumetypes.Snapshot, error) {
+	snap, err := s.vs.CreateSnapshot(ctx, name, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	apiS := snapshotToAPIType(snap)
+	return &apiS, nil
+}
+
+// ListSnapshots lists the snapshots of a volume
+func (s *VolumesService) ListSnapshots(ctx context.Context, name string) ([]*volumetypes.Snapshot, error) {
+	ls, err := s.vs.ListSnapshots(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*volumetypes.Snapshot, 0, len(ls))
+	for _, snap := range ls {
+		apiS := snapshotToAPIType(snap)
+		out = append(out, &apiS)
+	}
+	return out, nil
+}
+
+// RemoveSnapshot removes a snapshot of a volume
+func (s *VolumesService) RemoveSnapshot(ctx context.Context, name, snapshot string) error {
+	return s.vs.RemoveSnapshot(ctx, name, snapshot)
+}
+
+// RestoreSnapshot replaces the data of a volume with the data of its snapshot
+// An error is returned if the volume is mounted.
+func (s *VolumesService) RestoreSnapshot(ctx context.Context, name, snapshot string) error {
+	return s.vs.RestoreSnapshot(ctx, name, snapshot)
+}
+
 // Mount mounts the volume
 // Callers should specify a uniqe reference for each Mount/Unmount pair.
 //
 // Example:
 // ```go
 // mountID := "randomString"
-// s.Mount(ctx, vol, mountID)
+// s.Mount(ctx, vol, mountID, opts.WithMountHolder(containerID))
 // s.Unmount(ctx, vol, mountID)
 // ```
-func (s *VolumesService) Mount(ctx context.Context, vol *volumetypes.Volume, ref string) (string, error) {
+//
+// The container mounting the volume, and whether it is mounted read-only,
+// are checked against the access mode of the volume. A conflict error is
+// returned if the access mode does not allow the mount.
+func (s *VolumesService) Mount(ctx context.Context, vol *volumetypes.Volume, ref string, mountOpts ...opts.MountOption) (string, error) {
+	var cfg opts.MountConfig
+	for _, o := range mountOpts {
+		o(&cfg)
+	}
+
 	v, err := s.vs.Get(ctx, vol.Name, opts.WithGetDriver(vol.Driver))
 	if err != nil {
 		if IsNotExist(err) {
@@ -121,7 +180,27 @@ func (s *VolumesService) Mount(ctx context.Context, vol *volumetypes.Volume, ref
 		}
 		return "", err
 	}
-	return v.Mount(ref)
+	if err := s.vs.acquire(v.Name(), ref, cfg); err != nil {
+		return "", err
+	}
+	path, err := v.Mount(ref)
+	if err != nil {
+		s.vs.releaseMount(v.Name(), ref)
+		return "", err
+	}
+	return path, nil
+}
+
+// RestoreMount records that the volume with the given name is mounted with
+// the given reference, as Mount does, without mounting the volume again. It
+// is used for the mounts of containers which kept running while the daemon
+// was restarted, so that they count against the access mode of the volume.
+func (s *VolumesService) RestoreMount(ctx context.Context, name, ref string, mountOpts ...opts.MountOption) error {
+	var cfg opts.MountConfig
+	for _, o := range mountOpts {
+		o(&cfg)
+	}
+	return s.vs.acquire(name, ref, cfg)
 }
 
 // Unmount unmounts the volume.
@@ -138,7 +217,11 @@ func (s *VolumesService) Unmount(ctx context.Context, vol *volumetypes.Volume, r
 		}
 		return err
 	}
-	return v.Unmount(ref)
+	if err := v.Unmount(ref); err != nil {
+		return err
+	}
+	s.vs.releaseMount(v.Name(), ref)
+	return nil
 }
 
 // Release releases a volume reference
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
@@ -14,8 +20,13 @@ import (
 	"github.com/docker/docker/volume/testutils"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/skip"
 )
 
+func init() {
+	reexec.Init()
+}
+
 func TestLocalVolumeSize(t *testing.T) {
 	t.Parallel()
 
@@ -63,3 +74,170 @@ func TestLocalVolumeSize(t *testing.T) {
 		}
 	}
 }
+
+func TestServiceSnapshots(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	l, err := local.New(t.TempDir(), idtools.Identity{UID: os.Getuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	assert.Assert(t, ds.Register(l, volume.DefaultDriverName))
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver("fake"), "fake"))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+
+	ctx := context.Background()
+	v, err := service.Create(ctx, "test1", volume.DefaultDriverName)
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(filepath.Join(v.Mountpoint, "data"), []byte("snapshot"), 0644))
+
+	s, err := service.CreateSnapshot(ctx, "test1", "snap")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(s.Name, "snap"))
+	assert.Check(t, is.Equal(s.Volume, "test1"))
+	assert.Check(t, s.CreatedAt != "")
+
+	ls, err := service.ListSnapshots(ctx, "test1")
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(ls, 1))
+	assert.Check(t, is.DeepEqual(ls[0], s))
+
+	_, err = service.CreateSnapshot(ctx, "notexist", "snap")
+	assert.Check(t, errdefs.IsNotFound(err), err)
+
+	_, err = service.Create(ctx, "test2", "fake")
+	assert.NilError(t, err)
+	_, err = service.CreateSnapshot(ctx, "test2", "snap")
+	assert.Check(t, errdefs.IsNotImplemented(err), err)
+
+	clone, err := service.Create(ctx, "clone", "", opts.WithCreateFromSnapshot("test1", "snap"), opts.WithCreateLabel("foo", "bar"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(clone.Driver, volume.DefaultDriverName))
+	assert.Check(t, is.DeepEqual(clone.Labels, map[string]string{"foo": "bar"}))
+	b, err := os.ReadFile(filepath.Join(clone.Mountpoint, "data"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "snapshot"))
+
+	_, err = service.Create(ctx, "clone", "", opts.WithCreateFromSnapshot("test1", "snap"))
+	assert.Check(t, IsNameConflict(err), err)
+	_, err = service.Create(ctx, "clone2", "fake", opts.WithCreateFromSnapshot("test1", "snap"))
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+
+	assert.NilError(t, os.WriteFile(filepath.Join(v.Mountpoint, "data"), []byte("changed"), 0644))
+
+	// The snapshots of a volume referenced by a container cannot be
+	// restored, even if it is not mounted.
+	_, err = service.Get(ctx, "test1", opts.WithGetReference("container"))
+	assert.NilError(t, err)
+	err = service.RestoreSnapshot(ctx, "test1", "snap")
+	assert.Check(t, IsInUse(err), err)
+	assert.NilError(t, service.Release(ctx, "test1", "container"))
+
+	assert.NilError(t, service.RestoreSnapshot(ctx, "test1", "snap"))
+	b, err = os.ReadFile(filepath.Join(v.Mountpoint, "data"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "snapshot"))
+
+	assert.NilError(t, service.RemoveSnapshot(ctx, "test1", "snap"))
+	err = service.RemoveSnapshot(ctx, "test1", "snap")
+	assert.Check(t, errdefs.IsNotFound(err), err)
+}
+
+func TestServiceExportImport(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "skipping test that requires root")
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	l, err := local.New(t.TempDir(), idtools.Identity{UID: os.Getuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	assert.Assert(t, ds.Register(l, volume.DefaultDriverName))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+
+	ctx := context.Background()
+	src, err := service.Create(ctx, "src", volume.DefaultDriverName)
+	assert.NilError(t, err)
+	file := filepath.Join(src.Mountpoint, "dir", "data")
+	assert.NilError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	assert.NilError(t, os.WriteFile(file, []byte("data"), 0600))
+	assert.NilError(t, os.Chown(file, 1234, 5678))
+	xattrs := system.Lsetxattr(file, "user.test", []byte("value"), 0) == nil
+
+	var buf bytes.Buffer
+	err = service.Export(ctx, "src", "lz4", &buf)
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+	err = service.Export(ctx, "notexist", "", &buf)
+	assert.Check(t, errdefs.IsNotFound(err), err)
+
+	assert.NilError(t, service.Export(ctx, "src", "gzip", &buf))
+	// The export released its reference to the volume.
+	assert.NilError(t, service.Import(ctx, "src", bytes.NewReader(buf.Bytes())))
+
+	dst, err := service.Create(ctx, "dst", volume.DefaultDriverName)
+	assert.NilError(t, err)
+	assert.NilError(t, service.Import(ctx, "dst", &buf))
+
+	file = filepath.Join(dst.Mountpoint, "dir", "data")
+	b, err := os.ReadFile(file)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "data"))
+	fi, err := os.Stat(file)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fi.Mode().Perm(), os.FileMode(0600)))
+	uid, gid, err := getOwner(fi)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(uid, 1234))
+	assert.Check(t, is.Equal(gid, 5678))
+	if xattrs {
+		value, err := system.Lgetxattr(file, "user.test")
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(value), "value"))
+	}
+
+	// Referenced volumes cannot be imported into.
+	_, err = service.Get(ctx, "dst", opts.WithGetReference("container"))
+	assert.NilError(t, err)
+	err = service.Import(ctx, "dst", bytes.NewReader(nil))
+	assert.Check(t, errdefs.IsConflict(err), err)
+}
+
+func getOwner(fi os.FileInfo) (int, int, error) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected stat type %T", fi.Sys())
+	}
+	return int(st.Uid), int(st.Gid), nil
+}
+
+func TestServiceUpdate(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	l, err := local.New(t.TempDir(), idtools.Identity{UID: os.Getuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	assert.Assert(t, ds.Register(l, volume.DefaultDriverName))
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver("fake"), "fake"))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err = service.Create(ctx, "test1", volume.DefaultDriverName)
+	assert.NilError(t, err)
+	_, err = service.Create(ctx, "test2", "fake")
+	assert.NilError(t, err)
+
+	assert.NilError(t, service.Update(ctx, "test1", map[string]string{"size": "0"}))
+	v, err := service.Get(ctx, "test1")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(v.Options, map[string]string{"size": "0"}))
+
+	err = service.Update(ctx, "test1", map[string]string{"type": "tmpfs"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+	err = service.Update(ctx, "test2", map[string]string{"size": "0"})
+	assert.Check(t, errdefs.IsNotImplemented(err), err)
+	err = service.Update(ctx, "notexist", map[string]string{"size": "0"})
+	assert.Check(t, errdefs.IsNotFound(err), err)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types/filters"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
@@ -156,6 +157,97 @@ func TestServiceGet(t *testing.T) {
 	assert.Assert(t, errdefs.IsConflict(err), err)
 }
 
+func TestServiceAccessModes(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver("d1"), "d1"))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := service.Create(ctx, "invalid", "d1", opts.WithCreateAccessMode("many-writers"))
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+
+	t.Run("single-writer", func(t *testing.T) {
+		v, err := service.Create(ctx, "single-writer", "d1", opts.WithCreateAccessMode(volumetypes.AccessModeSingleWriter))
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(v.AccessMode, volumetypes.AccessModeSingleWriter))
+
+		_, err = service.Mount(ctx, v, "m1", opts.WithMountHolder("c1"))
+		assert.NilError(t, err)
+		_, err = service.Mount(ctx, v, "m2", opts.WithMountHolder("c1"))
+		assert.NilError(t, err, "the writer can mount the volume more than once")
+		_, err = service.Mount(ctx, v, "m3", opts.WithMountHolder("c2"), opts.WithMountReadOnly(true))
+		assert.NilError(t, err)
+
+		_, err = service.Mount(ctx, v, "m4", opts.WithMountHolder("c3"))
+		assert.Check(t, errdefs.IsConflict(err), err)
+		assert.Check(t, is.ErrorContains(err, "c1"))
+
+		got, err := service.Get(ctx, v.Name)
+		assert.NilError(t, err)
+		assert.Check(t, is.DeepEqual(got.Holders, []*volumetypes.Holder{
+			{Container: "c1", ReadWrite: true},
+			{Container: "c2", ReadWrite: false},
+		}))
+
+		assert.NilError(t, service.Unmount(ctx, v, "m1"))
+		_, err = service.Mount(ctx, v, "m4", opts.WithMountHolder("c3"))
+		assert.Check(t, errdefs.IsConflict(err), err)
+
+		assert.NilError(t, service.Unmount(ctx, v, "m2"))
+		_, err = service.Mount(ctx, v, "m4", opts.WithMountHolder("c3"))
+		assert.NilError(t, err)
+	})
+
+	t.Run("single-container", func(t *testing.T) {
+		v, err := service.Create(ctx, "single-container", "d1", opts.WithCreateAccessMode(volumetypes.AccessModeSingleContainer))
+		assert.NilError(t, err)
+
+		_, err = service.Mount(ctx, v, "m1", opts.WithMountHolder("c1"), opts.WithMountReadOnly(true))
+		assert.NilError(t, err)
+		_, err = service.Mount(ctx, v, "m2", opts.WithMountHolder("c1"))
+		assert.NilError(t, err)
+		_, err = service.Mount(ctx, v, "m3", opts.WithMountHolder("c2"), opts.WithMountReadOnly(true))
+		assert.Check(t, errdefs.IsConflict(err), err)
+
+		// releasing the container drops its mounts, even if it did not
+		// unmount the volume
+		assert.NilError(t, service.Release(ctx, v.Name, "c1"))
+		got, err := service.Get(ctx, v.Name)
+		assert.NilError(t, err)
+		assert.Check(t, is.Len(got.Holders, 0))
+
+		_, err = service.Mount(ctx, v, "m3", opts.WithMountHolder("c2"), opts.WithMountReadOnly(true))
+		assert.NilError(t, err)
+	})
+
+	t.Run("read-only-many", func(t *testing.T) {
+		v, err := service.Create(ctx, "read-only-many", "d1", opts.WithCreateAccessMode(volumetypes.AccessModeReadOnlyMany))
+		assert.NilError(t, err)
+
+		_, err = service.Mount(ctx, v, "m1", opts.WithMountHolder("c1"))
+		assert.Check(t, errdefs.IsConflict(err), err)
+		_, err = service.Mount(ctx, v, "m1", opts.WithMountHolder("c1"), opts.WithMountReadOnly(true))
+		assert.NilError(t, err)
+		_, err = service.Mount(ctx, v, "m2", opts.WithMountHolder("c2"), opts.WithMountReadOnly(true))
+		assert.NilError(t, err)
+	})
+
+	t.Run("no access mode", func(t *testing.T) {
+		v, err := service.Create(ctx, "shared", "d1")
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(v.AccessMode, ""))
+
+		_, err = service.Mount(ctx, v, "m1", opts.WithMountHolder("c1"))
+		assert.NilError(t, err)
+		_, err = service.Mount(ctx, v, "m2", opts.WithMountHolder("c2"))
+		assert.NilError(t, err)
+	})
+}
+
 func TestServicePrune(t *testing.T) {
 	t.Parallel()
 
@@ -231,6 +323,50 @@ func TestServicePrune(t *testing.T) {
 	assert.Assert(t, is.Equal(pr.VolumesDeleted[0], "test"))
 }
 
+func TestServiceRestoreMount(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver("d1"), "d1"))
+
+	dir, err := os.MkdirTemp("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	store, err := NewStore(dir, ds)
+	assert.NilError(t, err)
+	service := &VolumesService{vs: store, eventLogger: dummyEventLogger{}}
+
+	v, err := service.Create(ctx, "single-container", "d1", opts.WithCreateAccessMode(volumetypes.AccessModeSingleContainer))
+	assert.NilError(t, err)
+	_, err = service.Mount(ctx, v, "m1", opts.WithMountHolder("c1"))
+	assert.NilError(t, err)
+
+	// the holders of the volume are lost when the daemon is restarted, and
+	// are restored for the containers which kept running
+	assert.NilError(t, service.Shutdown())
+	store, err = NewStore(dir, ds)
+	assert.NilError(t, err)
+	service = &VolumesService{vs: store, eventLogger: dummyEventLogger{}}
+	defer service.Shutdown()
+
+	assert.NilError(t, service.RestoreMount(ctx, v.Name, "m1", opts.WithMountHolder("c1")))
+
+	got, err := service.Get(ctx, v.Name)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(got.Holders, []*volumetypes.Holder{
+		{Container: "c1", ReadWrite: true},
+	}))
+
+	_, err = service.Mount(ctx, v, "m2", opts.WithMountHolder("c2"), opts.WithMountReadOnly(true))
+	assert.Check(t, errdefs.IsConflict(err), err)
+
+	assert.NilError(t, service.Unmount(ctx, v, "m1"))
+	_, err = service.Mount(ctx, v, "m2", opts.WithMountHolder("c2"), opts.WithMountReadOnly(true))
+	assert.NilError(t, err)
+}
+
 func newTestService(t *testing.T, ds *volumedrivers.Store) (*VolumesService, func()) {
 	t.Helper()
 
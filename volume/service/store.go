@@ -26,9 +26,10 @@ const (
 
 type volumeWrapper struct {
 	volume.Volume
-	labels  map[string]string
-	scope   string
-	options map[string]string
+	labels     map[string]string
+	scope      string
+	options    map[string]string
+	accessMode string
 }
 
 func (v volumeWrapper) Options() map[string]string {
@@ -58,6 +59,10 @@ func (v volumeWrapper) Scope() string {
 	return v.scope
 }
 
+func (v volumeWrapper) AccessMode() string {
+	return v.accessMode
+}
+
 func (v volumeWrapper) CachedPath() string {
 	if vv, ok := v.Volume.(interface {
 		CachedPath() string
@@ -73,12 +78,14 @@ type StoreOpt func(store *VolumeStore) error
 // NewStore creates a new volume store at the given path
 func NewStore(rootPath string, drivers *drivers.Store, opts ...StoreOpt) (*VolumeStore, error) {
 	vs := &VolumeStore{
-		locks:   &locker.Locker{},
-		names:   make(map[string]volume.Volume),
-		refs:    make(map[string]map[string]struct{}),
-		labels:  make(map[string]map[string]string),
-		options: make(map[string]map[string]string),
-		drivers: drivers,
+		locks:       &locker.Locker{},
+		names:       make(map[string]volume.Volume),
+		refs:        make(map[string]map[string]struct{}),
+		labels:      make(map[string]map[string]string),
+		options:     make(map[string]map[string]string),
+		drivers:     drivers,
+		accessModes: make(map[string]string),
+		holders:     make(map[string]map[string]*volumeHolder),
 	}
 
 	for _, o := range opts {
@@ -195,6 +202,8 @@ func (s *VolumeStore) purge(ctx context.Context, name string) error {
 	delete(s.refs, name)
 	delete(s.labels, name)
 	delete(s.options, name)
+	delete(s.accessModes, name)
+	delete(s.holders, name)
 	return nil
 }
 
@@ -215,6 +224,11 @@ type VolumeStore struct {
 	labels map[string]map[string]string
 	// options stores volume options for each volume
 	options map[string]map[string]string
+	// accessModes stores the access mode of each volume which has one
+	accessModes map[string]string
+	// holders stores the volume name and the containers which have it mounted,
+	// by the reference of the mount
+	holders map[string]map[string]*volumeHolder
 
 	db          *bolt.DB
 	eventLogger VolumeEventLogger
@@ -433,7 +447,7 @@ func (s *VolumeStore) list(ctx context.Context, driverNames ...string) ([]volume
 			}
 			for i, v := range vs {
 				s.globalLock.RLock()
-				vs[i] = volumeWrapper{v, s.labels[v.Name()], d.Scope(), s.options[v.Name()]}
+				vs[i] = volumeWrapper{v, s.labels[v.Name()], d.Scope(), s.options[v.Name()], s.accessModes[v.Name()]}
 				s.globalLock.RUnlock()
 			}
 
@@ -472,6 +486,30 @@ func (s *VolumeStore) Create(ctx context.Context, name, driverName string, creat
 	for _, o := range createOpts {
 		o(&cfg)
 	}
+	if err := validateAccessMode(cfg.AccessMode); err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+
+	// The source volume is looked up before the name is locked, so that the
+	// names of two volumes are never locked at once.
+	var from *snapshotSource
+	if cfg.Snapshot != "" {
+		src, err := s.Get(ctx, cfg.Source)
+		if err != nil {
+			return nil, err
+		}
+		if driverName != "" {
+			vd, err := s.drivers.GetDriver(driverName)
+			if err != nil {
+				return nil, &OpErr{Err: err, Name: name, Op: "create"}
+			}
+			if vd.Name() != src.DriverName() {
+				return nil, &OpErr{Err: errdefs.InvalidParameter(errors.Errorf("the volume must be created with the driver of the snapshot (%s)", src.DriverName())), Name: name, Op: "create"}
+			}
+		}
+		driverName = src.DriverName()
+		from = &snapshotSource{volume: unwrapVolume(src), name: cfg.Snapshot}
+	}
 
 	name = normalizeVolumeName(name)
 	s.locks.Lock(name)
@@ -483,7 +521,7 @@ func (s *VolumeStore) Create(ctx context.Context, name, driverName string, creat
 	default:
 	}
 
-	v, created, err := s.create(ctx, name, driverName, cfg.Options, cfg.Labels)
+	v, created, err := s.create(ctx, name, driverName, cfg.Options, cfg.Labels, cfg.AccessMode, from)
 	if err != nil {
 		if _, ok := err.(*OpErr); ok {
 			return nil, err
@@ -573,8 +611,11 @@ func volumeExists(ctx context.Context, store *drivers.Store, v volume.Volume) (b
 // If the passed in driver name does not match the driver name which is stored
 // for the given volume name, an error is returned after checking if the reference is stale.
 // If the reference is stale, it will be purged and this create can continue.
+// If from is set, the volume is created from the snapshot, and an error is
+// returned if the volume already exists.
+// The access mode is only set on volumes which are created.
 // It is expected that callers of this function hold any necessary locks.
-func (s *VolumeStore) create(ctx context.Context, name, driverName string, opts, labels map[string]string) (volume.Volume, bool, error) {
+func (s *VolumeStore) create(ctx context.Context, name, driverName string, opts, labels map[string]string, accessMode string, from *snapshotSource) (volume.Volume, bool, error) {
 	// Validate the name in a platform-specific manner
 
 	// volume name validation is specific to the host os and not on container image
@@ -589,6 +630,9 @@ func (s *VolumeStore) create(ctx context.Context, name, driverName string, opts,
 		return nil, false, err
 	}
 
+	if v != nil && from != nil {
+		return nil, false, errors.Wrapf(errNameConflict, "volume '%s' already exists", name)
+	}
 	if v != nil {
 		// there is an existing volume, if we already have this stored locally, return it.
 		// TODO: there could be some inconsistent details such as labels here
@@ -615,32 +659,63 @@ func (s *VolumeStore) create(ctx context.Context, name, driverName string, opts,
 
 	logrus.Debugf("Registering new volume reference: driver %q, name %q", vd.Name(), name)
 	if v, _ = vd.Get(name); v == nil {
-		v, err = vd.Create(name, opts)
+		if from != nil {
+			v, err = createFromSnapshot(vd, name, from, opts)
+		} else {
+			v, err = vd.Create(name, opts)
+		}
 		if err != nil {
 			if _, err := s.drivers.ReleaseDriver(driverName); err != nil {
 				logrus.WithError(err).WithField("driver", driverName).Error("Error releasing reference to volume driver")
 			}
 			return nil, false, err
 		}
+	} else if from != nil {
+		if _, err := s.drivers.ReleaseDriver(driverName); err != nil {
+			logrus.WithError(err).WithField("driver", driverName).Error("Error releasing reference to volume driver")
+		}
+		return nil, false, errors.Wrapf(errNameConflict, "volume '%s' already exists in driver '%s'", name, vd.Name())
 	}
 
 	s.globalLock.Lock()
 	s.labels[name] = labels
 	s.options[name] = opts
 	s.refs[name] = make(map[string]struct{})
+	if accessMode != "" {
+		s.accessModes[name] = accessMode
+	} else {
+		delete(s.accessModes, name)
+	}
 	s.globalLock.Unlock()
 
 	metadata := volumeMetadata{
-		Name:    name,
-		Driver:  vd.Name(),
-		Labels:  labels,
-		Options: opts,
+		Name:       name,
+		Driver:     vd.Name(),
+		Labels:     labels,
+		Options:    opts,
+		AccessMode: accessMode,
 	}
 
 	if err := s.setMeta(name, metadata); err != nil {
 		return nil, true, err
 	}
-	return volumeWrapper{v, labels, vd.Scope(), opts}, true, nil
+	return volumeWrapper{v, labels, vd.Scope(), opts, accessMode}, true, nil
+}
+
+// snapshotSource is the snapshot of a volume to create a volume from.
+type snapshotSource struct {
+	volume volume.Volume
+	name   string
+}
+
+// createFromSnapshot asks the driver to create the named volume from the
+// snapshot.
+func createFromSnapshot(vd volume.Driver, name string, from *snapshotSource, opts map[string]string) (volume.Volume, error) {
+	sd, ok := vd.(volume.SnapshotDriver)
+	if !ok {
+		return nil, snapshotsNotSupportedError(vd.Name())
+	}
+	return sd.CreateFromSnapshot(name, from.volume, from.name, opts)
 }
 
 // Get looks if a volume with the given name exists and returns it if so
@@ -719,7 +794,7 @@ func (s *VolumeStore) getVolume(ctx context.Context, name, driverName string) (v
 		if err == nil {
 			scope = vd.Scope()
 		}
-		return volumeWrapper{vol, meta.Labels, scope, meta.Options}, nil
+		return volumeWrapper{vol, meta.Labels, scope, meta.Options, meta.AccessMode}, nil
 	}
 
 	logrus.Debugf("Probing all drivers for volume with name: %s", name)
@@ -742,7 +817,7 @@ func (s *VolumeStore) getVolume(ctx context.Context, name, driverName string) (v
 		if err := s.setMeta(name, meta); err != nil {
 			return nil, err
 		}
-		return volumeWrapper{v, meta.Labels, d.Scope(), meta.Options}, nil
+		return volumeWrapper{v, meta.Labels, d.Scope(), meta.Options, meta.AccessMode}, nil
 	}
 	return nil, errNoSuchVolume
 }
@@ -829,6 +904,156 @@ func (s *VolumeStore) Remove(ctx context.Context, v volume.Volume, rmOpts ...opt
 	return err
 }
 
+// CreateSnapshot takes a snapshot of the volume with the given name.
+func (s *VolumeStore) CreateSnapshot(ctx context.Context, name, snapshot string) (volume.Snapshot, error) {
+	name = normalizeVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, sd, err := s.getSnapshotDriver(ctx, name)
+	if err != nil {
+		return volume.Snapshot{}, &OpErr{Err: err, Name: name, Op: "snapshot"}
+	}
+	snap, err := sd.CreateSnapshot(v, snapshot)
+	if err != nil {
+		return volume.Snapshot{}, &OpErr{Err: err, Name: name, Op: "snapshot"}
+	}
+	return snap, nil
+}
+
+// ListSnapshots lists the snapshots of the volume with the given name.
+func (s *VolumeStore) ListSnapshots(ctx context.Context, name string) ([]volume.Snapshot, error) {
+	name = normalizeVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, sd, err := s.getSnapshotDriver(ctx, name)
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "list snapshots"}
+	}
+	ls, err := sd.ListSnapshots(v)
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "list snapshots"}
+	}
+	return ls, nil
+}
+
+// RemoveSnapshot removes the snapshot of the volume with the given name.
+func (s *VolumeStore) RemoveSnapshot(ctx context.Context, name, snapshot string) error {
+	name = normalizeVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, sd, err := s.getSnapshotDriver(ctx, name)
+	if err == nil {
+		err = sd.RemoveSnapshot(v, snapshot)
+	}
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "remove snapshot"}
+	}
+	return nil
+}
+
+// RestoreSnapshot replaces the data of the volume with the given name with
+// the data of its snapshot. The volume must not be referenced.
+func (s *VolumeStore) RestoreSnapshot(ctx context.Context, name, snapshot string) error {
+	return s.exclusive(ctx, name, "restore snapshot", func(v volume.Volume) error {
+		vd, err := s.drivers.GetDriver(v.DriverName())
+		if err != nil {
+			return err
+		}
+		sd, ok := vd.(volume.SnapshotDriver)
+		if !ok {
+			return snapshotsNotSupportedError(vd.Name())
+		}
+		return sd.RestoreSnapshot(unwrapVolume(v), snapshot)
+	})
+}
+
+// getSnapshotDriver returns the volume with the given name, unwrapped, and
+// its driver if it supports snapshots.
+// It is expected that callers of this function hold the name lock.
+func (s *VolumeStore) getSnapshotDriver(ctx context.Context, name string) (volume.Volume, volume.SnapshotDriver, error) {
+	v, err := s.getVolume(ctx, name, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	vd, err := s.drivers.GetDriver(v.DriverName())
+	if err != nil {
+		return nil, nil, err
+	}
+	sd, ok := vd.(volume.SnapshotDriver)
+	if !ok {
+		return nil, nil, snapshotsNotSupportedError(vd.Name())
+	}
+	return unwrapVolume(v), sd, nil
+}
+
+// Update updates the options of the volume with the given name.
+func (s *VolumeStore) Update(ctx context.Context, name string, opts map[string]string) error {
+	name = normalizeVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, err := s.getVolume(ctx, name, "")
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "update"}
+	}
+	vd, err := s.drivers.GetDriver(v.DriverName())
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "update"}
+	}
+	ud, ok := vd.(volume.UpdateDriver)
+	if !ok {
+		return &OpErr{Err: updateNotSupportedError(vd.Name()), Name: name, Op: "update"}
+	}
+	if err := ud.Update(unwrapVolume(v), opts); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "update"}
+	}
+
+	meta, err := s.getMeta(name)
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "update"}
+	}
+	meta.Name = name
+	meta.Driver = v.DriverName()
+	if meta.Options == nil {
+		meta.Options = make(map[string]string, len(opts))
+	}
+	for k, v := range opts {
+		meta.Options[k] = v
+	}
+	if err := s.setMeta(name, meta); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "update"}
+	}
+
+	s.globalLock.Lock()
+	s.options[name] = meta.Options
+	s.globalLock.Unlock()
+	return nil
+}
+
+// exclusive calls fn with the volume with the given name, unless the volume
+// is referenced. The name lock is held until fn returns, so that the volume
+// cannot be referenced in the meantime.
+func (s *VolumeStore) exclusive(ctx context.Context, name, op string, fn func(volume.Volume) error) error {
+	name = normalizeVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, err := s.getVolume(ctx, name, "")
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: op}
+	}
+	if s.hasRef(name) {
+		return &OpErr{Err: errVolumeInUse, Name: name, Op: op, Refs: s.getRefs(name)}
+	}
+	if err := fn(v); err != nil {
+		return &OpErr{Err: err, Name: name, Op: op}
+	}
+	return nil
+}
+
 // Release releases the specified reference to the volume
 func (s *VolumeStore) Release(ctx context.Context, name string, ref string) error {
 	s.locks.Lock(name)
@@ -851,6 +1076,13 @@ func (s *VolumeStore) Release(ctx context.Context, name string, ref string) erro
 	if s.refs[name] != nil {
 		delete(s.refs[name], ref)
 	}
+	// the container may not have unmounted the volume, such as when the
+	// daemon was stopped while the container was running
+	for mountRef, h := range s.holders[name] {
+		if h.container == ref {
+			delete(s.holders[name], mountRef)
+		}
+	}
 	return nil
 }
 
@@ -39,6 +39,10 @@ type Capability struct {
 	// A `local` scope indicates that the driver only manages volumes resources local to the host
 	// Scope is declared by the driver
 	Scope string
+	// Snapshots indicates that the driver implements the snapshot
+	// endpoints, to take, list, remove and restore snapshots of its
+	// volumes and to create volumes from them.
+	Snapshots bool
 }
 
 // Volume is a place to store data. It is backed by a specific driver, and can be mounted.
@@ -67,3 +71,48 @@ type DetailedVolume interface {
 	Scope() string
 	Volume
 }
+
+// UsageVolume is implemented by the volumes that can report their disk usage
+// without walking their data.
+type UsageVolume interface {
+	// Usage returns the number of bytes used by the volume.
+	Usage() (int64, error)
+	Volume
+}
+
+// Snapshot is a point-in-time copy of the data of a volume.
+type Snapshot struct {
+	// Name is the name of the snapshot, unique for the volume.
+	Name string
+	// Volume is the name of the volume the snapshot was taken of.
+	Volume string
+	// CreatedAt is the time the snapshot was taken.
+	CreatedAt time.Time
+}
+
+// SnapshotDriver is implemented by the drivers that can take snapshots of
+// their volumes. It is an optional capability of a Driver.
+type SnapshotDriver interface {
+	Driver
+	// CreateSnapshot takes a snapshot of the volume with the given name.
+	CreateSnapshot(vol Volume, name string) (Snapshot, error)
+	// ListSnapshots lists the snapshots of the volume.
+	ListSnapshots(vol Volume) ([]Snapshot, error)
+	// RemoveSnapshot deletes the snapshot of the volume.
+	RemoveSnapshot(vol Volume, name string) error
+	// RestoreSnapshot replaces the data of the volume with the data of
+	// its snapshot.
+	RestoreSnapshot(vol Volume, name string) error
+	// CreateFromSnapshot makes a new volume with the given name, holding
+	// the data of the snapshot of vol.
+	CreateFromSnapshot(name string, vol Volume, snapshot string, opts map[string]string) (Volume, error)
+}
+
+// UpdateDriver is implemented by the drivers that can update the options of
+// their existing volumes. It is an optional capability of a Driver.
+type UpdateDriver interface {
+	Driver
+	// Update applies the options to the volume. Options which are not
+	// given are left unchanged.
+	Update(vol Volume, opts map[string]string) error
+}